@@ -0,0 +1,53 @@
+// Command export writes the data of a profile to a ZIP archive, the same archive a profile gets
+// by requesting an export through the API. It is meant for data requests handled by support.
+//
+//	go run ./cmd/export -profile <profile id> -out export.zip
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"time"
+
+	"github.com/VladimirKholomyanskyy/gym-api/internal/common"
+	exportrepos "github.com/VladimirKholomyanskyy/gym-api/internal/export/repository"
+	exportusecase "github.com/VladimirKholomyanskyy/gym-api/internal/export/usecase"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/server"
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	profileID := flag.String("profile", "", "ID of the profile to export")
+	out := flag.String("out", "export.zip", "path of the archive to write")
+	envFile := flag.String("env", "../../.env", "file to load the configuration from")
+	flag.Parse()
+
+	if !common.IsUUIDValid(*profileID) {
+		log.Fatal("-profile must be the UUID of a profile")
+	}
+	if err := godotenv.Load(*envFile); err != nil {
+		log.Printf("Not loading %s: %v", *envFile, err)
+	}
+	db, err := server.OpenDatabase()
+	if err != nil {
+		log.Fatal("Failed to connect to the database:", err)
+	}
+	fileStorage, urlSigner := server.NewFileStorage()
+	useCase := exportusecase.NewExportUseCase(exportrepos.NewExportRepository(db), fileStorage, urlSigner)
+
+	file, err := os.OpenFile(*out, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		log.Fatal("Failed to create the archive:", err)
+	}
+	if err := useCase.Write(context.Background(), *profileID, file, time.Now()); err != nil {
+		file.Close()
+		os.Remove(*out)
+		log.Fatal("Failed to export the profile:", err)
+	}
+	if err := file.Close(); err != nil {
+		log.Fatal("Failed to write the archive:", err)
+	}
+	log.Printf("Exported profile %s to %s", *profileID, *out)
+}
@@ -0,0 +1,208 @@
+package account
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	openapi "github.com/VladimirKholomyanskyy/gym-api/internal/api/go"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/common"
+	customerrors "github.com/VladimirKholomyanskyy/gym-api/internal/customErrors"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/imaging"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/storage"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/utils"
+)
+
+const (
+	// MaxAvatarBytes bounds the size of an uploaded avatar image
+	MaxAvatarBytes = 5 << 20
+	// AvatarSize is the side of the square avatars are stored as
+	AvatarSize = 256
+	// AvatarPrefix is where avatars are kept in the storage, files below it are public
+	AvatarPrefix = "avatars/"
+	// avatarFormField is the multipart field carrying the image
+	avatarFormField = "avatar"
+)
+
+// avatarHandler uploads and removes the avatar of the profile. Avatars are cropped to a square,
+// scaled down and re-encoded, so whatever was uploaded is never served as is. The avatar URL of
+// the profile is only ever set here.
+type avatarHandler struct {
+	profileRepo ProfileRepository
+	storage     storage.Storage
+	// baseURL is the public address avatars are served from
+	baseURL string
+}
+
+// NewAvatarHandler serves POST with a multipart "avatar" image and DELETE
+func NewAvatarHandler(profileRepo ProfileRepository, storage storage.Storage, publicURL string) http.Handler {
+	return &avatarHandler{
+		profileRepo: profileRepo,
+		storage:     storage,
+		baseURL:     strings.TrimSuffix(publicURL, "/") + "/api/v1/" + AvatarPrefix,
+	}
+}
+
+func (h *avatarHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var response openapi.ImplResponse
+	switch r.Method {
+	case http.MethodPost:
+		response, _ = h.upload(w, r)
+	case http.MethodDelete:
+		response, _ = h.delete(r.Context())
+	default:
+		response, _ = utils.ErrorResponse(http.StatusMethodNotAllowed, openapi.INVALID_REQUEST, "Method not allowed")
+	}
+	openapi.EncodeJSONResponse(response.Body, &response.Code, w)
+}
+
+// upload - Replace the avatar of the profile with an uploaded JPEG or PNG image
+func (h *avatarHandler) upload(w http.ResponseWriter, r *http.Request) (openapi.ImplResponse, error) {
+	ctx := r.Context()
+	profileID, err := common.ExtractProfileID(ctx)
+	if err != nil {
+		return utils.ErrorResponse(http.StatusUnauthorized, openapi.UNAUTHORIZED, err.Error())
+	}
+	// Leave room for the multipart framing around the image
+	r.Body = http.MaxBytesReader(w, r.Body, MaxAvatarBytes+64<<10)
+	content, err := readAvatarPart(r)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) || errors.Is(err, errAvatarTooLarge) {
+			return utils.ErrorResponse(http.StatusRequestEntityTooLarge, openapi.INVALID_REQUEST, "avatar must not exceed 5 MB")
+		}
+		return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_REQUEST, err.Error())
+	}
+	avatar, err := resizeAvatar(content)
+	if err != nil {
+		return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_REQUEST, customerrors.ErrInvalidImage.Error())
+	}
+
+	profile, err := h.profileRepo.GetByID(ctx, profileID)
+	if err != nil {
+		if errors.Is(err, customerrors.ErrEntityNotFound) {
+			return utils.ErrorResponse(http.StatusNotFound, openapi.RESOURCE_NOT_FOUND, "User profile not found")
+		}
+		return utils.ErrorResponse(http.StatusInternalServerError, openapi.INTERNAL_SERVER_ERROR, "Failed to fetch user profile")
+	}
+	name := make([]byte, 16)
+	if _, err := rand.Read(name); err != nil {
+		return utils.ErrorResponse(http.StatusInternalServerError, openapi.INTERNAL_SERVER_ERROR, "Failed to store avatar")
+	}
+	// A new name for every upload, cached copies of the previous avatar never show up again
+	key := fmt.Sprintf("%s%s/%s.jpg", AvatarPrefix, profile.ID, hex.EncodeToString(name))
+	if _, err := h.storage.Put(ctx, key, avatar); err != nil {
+		log.Printf("Failed to store avatar: %v", err)
+		return utils.ErrorResponse(http.StatusInternalServerError, openapi.INTERNAL_SERVER_ERROR, "Failed to store avatar")
+	}
+	avatarURL := h.baseURL + strings.TrimPrefix(key, AvatarPrefix)
+	if err := h.profileRepo.UpdatePartial(ctx, profile.ID, map[string]any{"avatar_url": avatarURL}); err != nil {
+		h.deleteFile(key)
+		return utils.ErrorResponse(http.StatusInternalServerError, openapi.INTERNAL_SERVER_ERROR, "Failed to update user profile")
+	}
+	h.deleteAvatar(profile.AvatarURL)
+
+	profile.AvatarURL = &avatarURL
+	return openapi.Response(http.StatusOK, ConvertProfileToOpenAPI(profile, common.ExtractUnits(ctx))), nil
+}
+
+// delete - Remove the avatar of the profile
+func (h *avatarHandler) delete(ctx context.Context) (openapi.ImplResponse, error) {
+	profileID, err := common.ExtractProfileID(ctx)
+	if err != nil {
+		return utils.ErrorResponse(http.StatusUnauthorized, openapi.UNAUTHORIZED, err.Error())
+	}
+	profile, err := h.profileRepo.GetByID(ctx, profileID)
+	if err != nil {
+		if errors.Is(err, customerrors.ErrEntityNotFound) {
+			return utils.ErrorResponse(http.StatusNotFound, openapi.RESOURCE_NOT_FOUND, "User profile not found")
+		}
+		return utils.ErrorResponse(http.StatusInternalServerError, openapi.INTERNAL_SERVER_ERROR, "Failed to fetch user profile")
+	}
+	if profile.AvatarURL == nil {
+		return openapi.Response(http.StatusNoContent, nil), nil
+	}
+	if err := h.profileRepo.UpdatePartial(ctx, profile.ID, map[string]any{"avatar_url": nil}); err != nil {
+		return utils.ErrorResponse(http.StatusInternalServerError, openapi.INTERNAL_SERVER_ERROR, "Failed to update user profile")
+	}
+	h.deleteAvatar(profile.AvatarURL)
+	return openapi.Response(http.StatusNoContent, nil), nil
+}
+
+var errAvatarTooLarge = errors.New("avatar is too large")
+
+// readAvatarPart reads the image out of the multipart body, without buffering the other parts
+func readAvatarPart(r *http.Request) ([]byte, error) {
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return nil, errors.New("request must be multipart/form-data")
+	}
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return nil, fmt.Errorf("%s is required", avatarFormField)
+		}
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				return nil, err
+			}
+			return nil, errors.New("malformed multipart body")
+		}
+		if part.FormName() != avatarFormField {
+			continue
+		}
+		content, err := io.ReadAll(io.LimitReader(part, MaxAvatarBytes+1))
+		if err != nil {
+			return nil, err
+		}
+		if len(content) > MaxAvatarBytes {
+			return nil, errAvatarTooLarge
+		}
+		return content, nil
+	}
+}
+
+// resizeAvatar checks the content is a JPEG or PNG image, by its bytes rather than the declared
+// type, and turns it into the square JPEG that is stored
+func resizeAvatar(content []byte) (io.Reader, error) {
+	switch http.DetectContentType(content) {
+	case "image/jpeg", "image/png":
+	default:
+		return nil, customerrors.ErrInvalidImage
+	}
+	if _, _, err := imaging.DecodeConfig(bytes.NewReader(content)); err != nil {
+		return nil, err
+	}
+	img, err := imaging.Decode(bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+	var avatar bytes.Buffer
+	if err := imaging.EncodeJPEG(&avatar, imaging.Square(img, AvatarSize)); err != nil {
+		return nil, err
+	}
+	return &avatar, nil
+}
+
+// deleteAvatar removes the stored file of an avatar URL. URLs set before avatars were uploaded
+// point elsewhere and are left alone. Failures are logged, the file is no longer referenced.
+func (h *avatarHandler) deleteAvatar(avatarURL *string) {
+	if avatarURL == nil || !strings.HasPrefix(*avatarURL, h.baseURL) {
+		return
+	}
+	h.deleteFile(AvatarPrefix + strings.TrimPrefix(*avatarURL, h.baseURL))
+}
+
+func (h *avatarHandler) deleteFile(key string) {
+	if err := h.storage.Delete(context.Background(), key); err != nil && !errors.Is(err, storage.ErrNotFound) {
+		log.Printf("Failed to delete stored file %s: %v", key, err)
+	}
+}
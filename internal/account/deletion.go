@@ -0,0 +1,124 @@
+package account
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/VladimirKholomyanskyy/gym-api/internal/storage"
+)
+
+const (
+	// DeletionGracePeriod is how long a deleted profile can be restored before it is purged
+	DeletionGracePeriod = 30 * 24 * time.Hour
+	// RestorePath is the only endpoint a profile pending deletion can reach
+	RestorePath = "/api/v1/profile/restore"
+	// purgeBatchSize bounds how many profiles a single run purges
+	purgeBatchSize = 20
+)
+
+// PendingDeletion reports whether the profile is deleted and waiting to be purged
+func (p *Profile) PendingDeletion() bool {
+	return p.DeletedAt.Valid
+}
+
+// CanReach reports whether the profile may call the endpoint at the path. A deleted account can
+// only be restored until it is purged.
+func (p *Profile) CanReach(path string) bool {
+	return !p.PendingDeletion() || path == RestorePath
+}
+
+// PurgeAfter is when a profile pending deletion is purged
+func (p *Profile) PurgeAfter() time.Time {
+	return p.DeletedAt.Time.Add(DeletionGracePeriod)
+}
+
+// IdentityProvider removes the identity a profile signs in with once the profile is purged
+type IdentityProvider interface {
+	DeleteIdentity(ctx context.Context, externalID string) error
+}
+
+// DeletionService deletes accounts. A deleted profile is kept for the grace period, during which
+// it can be restored, and then purged with everything it owns.
+type DeletionService interface {
+	Delete(ctx context.Context, profileID string, now time.Time) (*Profile, error)
+	Restore(ctx context.Context, profileID string) (*Profile, error)
+	Purge(ctx context.Context, now time.Time) error
+}
+
+type deletionService struct {
+	profileRepo ProfileRepository
+	storage     storage.Storage
+	// filePrefixes are where files are kept below a directory per profile, such as "avatars/"
+	filePrefixes []string
+	// identityProvider is nil when identities are managed elsewhere
+	identityProvider IdentityProvider
+}
+
+// NewDeletionService creates the service, identityProvider may be nil
+func NewDeletionService(profileRepo ProfileRepository, storage storage.Storage, filePrefixes []string, identityProvider IdentityProvider) DeletionService {
+	return &deletionService{
+		profileRepo:      profileRepo,
+		storage:          storage,
+		filePrefixes:     filePrefixes,
+		identityProvider: identityProvider,
+	}
+}
+
+// Delete marks the profile deleted, it is purged once the grace period is over
+func (s *deletionService) Delete(ctx context.Context, profileID string, now time.Time) (*Profile, error) {
+	profile, err := s.profileRepo.GetByID(ctx, profileID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.profileRepo.UpdatePartial(ctx, profile.ID, map[string]any{"deleted_at": now}); err != nil {
+		return nil, err
+	}
+	profile.DeletedAt.Time, profile.DeletedAt.Valid = now, true
+	log.Printf("Profile %s is deleted, purging it after %s", profile.ID, profile.PurgeAfter().Format(time.RFC3339))
+	return profile, nil
+}
+
+// Restore cancels the deletion of the profile
+func (s *deletionService) Restore(ctx context.Context, profileID string) (*Profile, error) {
+	if err := s.profileRepo.Restore(ctx, profileID); err != nil {
+		return nil, err
+	}
+	log.Printf("Profile %s is restored", profileID)
+	return s.profileRepo.GetByID(ctx, profileID)
+}
+
+// Purge permanently deletes the profiles whose grace period is over. The stored files and the
+// identity go first and the data last, so a purge that fails part way is retried as a whole by
+// the next run.
+func (s *deletionService) Purge(ctx context.Context, now time.Time) error {
+	profiles, err := s.profileRepo.GetDeletedBefore(ctx, now.Add(-DeletionGracePeriod), purgeBatchSize)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for i := range profiles {
+		if err := s.purge(ctx, &profiles[i]); err != nil {
+			errs = append(errs, fmt.Errorf("failed to purge profile %s: %w", profiles[i].ID, err))
+			continue
+		}
+		log.Printf("Purged profile %s", profiles[i].ID)
+	}
+	return errors.Join(errs...)
+}
+
+func (s *deletionService) purge(ctx context.Context, profile *Profile) error {
+	for _, prefix := range s.filePrefixes {
+		if err := s.storage.DeleteAll(ctx, prefix+profile.ID); err != nil {
+			return err
+		}
+	}
+	if s.identityProvider != nil {
+		if err := s.identityProvider.DeleteIdentity(ctx, profile.ExternalID); err != nil {
+			return fmt.Errorf("failed to delete identity: %w", err)
+		}
+	}
+	return s.profileRepo.PermanentDelete(ctx, profile.ID)
+}
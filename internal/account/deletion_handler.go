@@ -0,0 +1,83 @@
+package account
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	openapi "github.com/VladimirKholomyanskyy/gym-api/internal/api/go"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/common"
+	customerrors "github.com/VladimirKholomyanskyy/gym-api/internal/customErrors"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/utils"
+)
+
+// deletionResponse tells when a deleted profile is purged
+type deletionResponse struct {
+	Status     string    `json:"status"`
+	DeletedAt  time.Time `json:"deletedAt"`
+	PurgeAfter time.Time `json:"purgeAfter"`
+}
+
+// deletionHandler deletes the account of the profile and restores it during the grace period
+type deletionHandler struct {
+	deletionService DeletionService
+}
+
+// NewDeletionHandler serves DELETE to delete the account and POST on RestorePath to restore it
+func NewDeletionHandler(deletionService DeletionService) http.Handler {
+	return &deletionHandler{deletionService: deletionService}
+}
+
+func (h *deletionHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var response openapi.ImplResponse
+	switch {
+	case r.Method == http.MethodDelete:
+		response, _ = h.delete(r)
+	case r.Method == http.MethodPost && r.URL.Path == RestorePath:
+		response, _ = h.restore(r)
+	default:
+		response, _ = utils.ErrorResponse(http.StatusMethodNotAllowed, openapi.INVALID_REQUEST, "Method not allowed")
+	}
+	openapi.EncodeJSONResponse(response.Body, &response.Code, w)
+}
+
+// delete - Delete the account, it is purged with all its data after the grace period
+func (h *deletionHandler) delete(r *http.Request) (openapi.ImplResponse, error) {
+	ctx := r.Context()
+	profileID, err := common.ExtractProfileID(ctx)
+	if err != nil {
+		return utils.ErrorResponse(http.StatusUnauthorized, openapi.UNAUTHORIZED, err.Error())
+	}
+	profile, err := h.deletionService.Delete(ctx, profileID, time.Now())
+	if err != nil {
+		if errors.Is(err, customerrors.ErrEntityNotFound) {
+			return utils.ErrorResponse(http.StatusNotFound, openapi.RESOURCE_NOT_FOUND, "User profile not found")
+		}
+		log.Printf("Failed to delete profile: %v", err)
+		return utils.ErrorResponse(http.StatusInternalServerError, openapi.INTERNAL_SERVER_ERROR, "Failed to delete user profile")
+	}
+	return openapi.Response(http.StatusAccepted, deletionResponse{
+		Status:     "pending_deletion",
+		DeletedAt:  profile.DeletedAt.Time,
+		PurgeAfter: profile.PurgeAfter(),
+	}), nil
+}
+
+// restore - Cancel the deletion of the account during the grace period
+func (h *deletionHandler) restore(r *http.Request) (openapi.ImplResponse, error) {
+	ctx := r.Context()
+	profileID, err := common.ExtractProfileID(ctx)
+	if err != nil {
+		return utils.ErrorResponse(http.StatusUnauthorized, openapi.UNAUTHORIZED, err.Error())
+	}
+	profile, err := h.deletionService.Restore(ctx, profileID)
+	if err != nil {
+		if errors.Is(err, customerrors.ErrEntityNotFound) {
+			return utils.ErrorResponse(http.StatusConflict, openapi.INVALID_REQUEST, "User profile is not pending deletion")
+		}
+		log.Printf("Failed to restore profile: %v", err)
+		return utils.ErrorResponse(http.StatusInternalServerError, openapi.INTERNAL_SERVER_ERROR, "Failed to restore user profile")
+	}
+	return openapi.Response(http.StatusOK, ConvertProfileToOpenAPI(profile, common.ExtractUnits(ctx))), nil
+}
@@ -0,0 +1,177 @@
+package account
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	customerrors "github.com/VladimirKholomyanskyy/gym-api/internal/customErrors"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/storage"
+	"gorm.io/gorm"
+)
+
+// calls records what the fakes are asked to do, in order
+type calls []string
+
+type fakeProfileRepository struct {
+	ProfileRepository
+	profiles map[string]*Profile
+	before   time.Time
+	calls    *calls
+}
+
+func (r *fakeProfileRepository) GetByID(ctx context.Context, id string) (*Profile, error) {
+	if profile, ok := r.profiles[id]; ok && !profile.PendingDeletion() {
+		return profile, nil
+	}
+	return nil, customerrors.ErrEntityNotFound
+}
+
+func (r *fakeProfileRepository) Restore(ctx context.Context, id string) error {
+	profile, ok := r.profiles[id]
+	if !ok || !profile.PendingDeletion() {
+		return customerrors.ErrEntityNotFound
+	}
+	profile.DeletedAt = gorm.DeletedAt{}
+	return nil
+}
+
+func (r *fakeProfileRepository) GetDeletedBefore(ctx context.Context, before time.Time, limit int) ([]Profile, error) {
+	r.before = before
+	var profiles []Profile
+	for _, profile := range r.profiles {
+		if profile.PendingDeletion() && !profile.DeletedAt.Time.After(before) {
+			profiles = append(profiles, *profile)
+		}
+	}
+	sort.Slice(profiles, func(i, j int) bool { return profiles[i].DeletedAt.Time.Before(profiles[j].DeletedAt.Time) })
+	return profiles, nil
+}
+
+func (r *fakeProfileRepository) PermanentDelete(ctx context.Context, id string) error {
+	*r.calls = append(*r.calls, "data "+id)
+	delete(r.profiles, id)
+	return nil
+}
+
+type fakeStorage struct {
+	storage.Storage
+	calls *calls
+}
+
+func (s *fakeStorage) DeleteAll(ctx context.Context, prefix string) error {
+	*s.calls = append(*s.calls, "files "+prefix)
+	return nil
+}
+
+type fakeIdentityProvider struct {
+	err   error
+	calls *calls
+}
+
+func (p *fakeIdentityProvider) DeleteIdentity(ctx context.Context, externalID string) error {
+	*p.calls = append(*p.calls, "identity "+externalID)
+	return p.err
+}
+
+func deletedProfile(id string, deletedAt time.Time) *Profile {
+	return &Profile{ID: id, ExternalID: "sub-" + id, DeletedAt: gorm.DeletedAt{Time: deletedAt, Valid: true}}
+}
+
+func TestPurge(t *testing.T) {
+	now := time.Date(2024, 3, 31, 12, 0, 0, 0, time.UTC)
+	var recorded calls
+	repo := &fakeProfileRepository{calls: &recorded, profiles: map[string]*Profile{
+		"expired":   deletedProfile("expired", now.Add(-DeletionGracePeriod-time.Hour)),
+		"due":       deletedProfile("due", now.Add(-DeletionGracePeriod)),
+		"in-grace":  deletedProfile("in-grace", now.Add(-DeletionGracePeriod+time.Hour)),
+		"active":    {ID: "active", ExternalID: "sub-active"},
+		"yesterday": deletedProfile("yesterday", now.AddDate(0, 0, -1)),
+	}}
+	service := NewDeletionService(repo, &fakeStorage{calls: &recorded}, []string{"avatars/", "photos/"}, &fakeIdentityProvider{calls: &recorded})
+
+	if err := service.Purge(context.Background(), now); err != nil {
+		t.Fatalf("Purge() error = %v", err)
+	}
+	if want := now.Add(-DeletionGracePeriod); !repo.before.Equal(want) {
+		t.Errorf("purged profiles deleted before %v, want %v", repo.before, want)
+	}
+	// Files and the identity go before the data, which is what a retry finds the profile by
+	want := calls{
+		"files avatars/expired", "files photos/expired", "identity sub-expired", "data expired",
+		"files avatars/due", "files photos/due", "identity sub-due", "data due",
+	}
+	if !reflect.DeepEqual(recorded, want) {
+		t.Errorf("Purge() did %q, want %q", recorded, want)
+	}
+	for _, id := range []string{"in-grace", "active", "yesterday"} {
+		if _, ok := repo.profiles[id]; !ok {
+			t.Errorf("profile %s purged before its grace period is over", id)
+		}
+	}
+}
+
+func TestPurgeRetriesAfterIdentityFailure(t *testing.T) {
+	now := time.Date(2024, 3, 31, 12, 0, 0, 0, time.UTC)
+	var recorded calls
+	repo := &fakeProfileRepository{calls: &recorded, profiles: map[string]*Profile{
+		"expired": deletedProfile("expired", now.Add(-DeletionGracePeriod-time.Hour)),
+	}}
+	identity := &fakeIdentityProvider{err: errors.New("connection reset"), calls: &recorded}
+	service := NewDeletionService(repo, &fakeStorage{calls: &recorded}, []string{"avatars/"}, identity)
+
+	if err := service.Purge(context.Background(), now); err == nil {
+		t.Fatal("Purge() error = nil while the identity provider fails")
+	}
+	if want := (calls{"files avatars/expired", "identity sub-expired"}); !reflect.DeepEqual(recorded, want) {
+		t.Errorf("Purge() did %q, want %q without deleting the data", recorded, want)
+	}
+	if _, ok := repo.profiles["expired"]; !ok {
+		t.Fatal("profile data deleted although its identity is left")
+	}
+
+	recorded, identity.err = nil, nil
+	if err := service.Purge(context.Background(), now.Add(time.Hour)); err != nil {
+		t.Fatalf("retried Purge() error = %v", err)
+	}
+	if _, ok := repo.profiles["expired"]; ok {
+		t.Errorf("profile kept by the retry, it did %q", recorded)
+	}
+}
+
+func TestRestore(t *testing.T) {
+	now := time.Date(2024, 3, 31, 12, 0, 0, 0, time.UTC)
+	repo := &fakeProfileRepository{profiles: map[string]*Profile{
+		"deleted": deletedProfile("deleted", now.AddDate(0, 0, -1)),
+		"active":  {ID: "active", ExternalID: "sub-active"},
+	}}
+	service := NewDeletionService(repo, &fakeStorage{}, nil, nil)
+
+	profile, err := service.Restore(context.Background(), "deleted")
+	if err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	if profile.PendingDeletion() || repo.profiles["deleted"].DeletedAt.Valid {
+		t.Errorf("restored profile still deleted at %v", profile.DeletedAt.Time)
+	}
+	if _, err := service.Restore(context.Background(), "active"); !errors.Is(err, customerrors.ErrEntityNotFound) {
+		t.Errorf("Restore() of a profile that isn't deleted error = %v, want not found", err)
+	}
+}
+
+func TestCanReach(t *testing.T) {
+	active := &Profile{ID: "active"}
+	deleted := deletedProfile("deleted", time.Date(2024, 3, 31, 12, 0, 0, 0, time.UTC))
+	paths := []string{"/api/v1/profile", "/api/v1/workout-sessions", "/api/v1/profile/restore/", RestorePath}
+	for _, path := range paths {
+		if !active.CanReach(path) {
+			t.Errorf("active profile can't reach %s", path)
+		}
+		if want := path == RestorePath; deleted.CanReach(path) != want {
+			t.Errorf("deleted profile reaching %s = %v, want %v", path, !want, want)
+		}
+	}
+}
@@ -1,9 +1,11 @@
 package account
 
 import (
+	"strings"
 	"time"
 
 	openapi "github.com/VladimirKholomyanskyy/gym-api/internal/api/go"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/common"
 	"gorm.io/gorm"
 )
 
@@ -27,7 +29,95 @@ type Setting struct {
 	MeasurementUnits     openapi.MeasurementUnits
 	Timezone             string
 	NotificationsEnabled bool
-	CreatedAt            time.Time `gorm:"autoCreateTime"`
-	UpdatedAt            time.Time `gorm:"autoUpdateTime"`
+	WeekStart            string
+	ReminderTime         string `gorm:"default:'08:00'"` // local time of day reminders go out, "HH:MM"
+	QuietHoursStart      *string
+	QuietHoursEnd        *string
+	MissedWorkoutPolicy  MissedWorkoutPolicy `gorm:"default:mark_missed"`
+	CreatedAt            time.Time           `gorm:"autoCreateTime"`
+	UpdatedAt            time.Time           `gorm:"autoUpdateTime"`
 	DeletedAt            gorm.DeletedAt
 }
+
+// DefaultWeekStart is the first day of the week for profiles without settings
+const DefaultWeekStart = time.Monday
+
+// MissedWorkoutPolicy is what happens to a scheduled workout whose date passed without a session
+type MissedWorkoutPolicy string
+
+const (
+	// MissedWorkoutMarkMissed keeps the workout on its date and marks it missed
+	MissedWorkoutMarkMissed MissedWorkoutPolicy = "mark_missed"
+	// MissedWorkoutNextFreeDay moves the workout to the next day without a scheduled workout
+	MissedWorkoutNextFreeDay MissedWorkoutPolicy = "next_free_day"
+	// MissedWorkoutShiftWeek moves the workout to today and the rest of the week along with it
+	MissedWorkoutShiftWeek MissedWorkoutPolicy = "shift_week"
+)
+
+// ParseMissedWorkoutPolicy parses a policy name, the default policy for an empty name
+func ParseMissedWorkoutPolicy(name string) (MissedWorkoutPolicy, bool) {
+	switch MissedWorkoutPolicy(name) {
+	case "":
+		return MissedWorkoutMarkMissed, true
+	case MissedWorkoutMarkMissed, MissedWorkoutNextFreeDay, MissedWorkoutShiftWeek:
+		return MissedWorkoutPolicy(name), true
+	}
+	return "", false
+}
+
+// DefaultReminderTime is when reminders go out for profiles without a valid reminder time
+const DefaultReminderTime = 8 * time.Hour
+
+// ParseClock parses a time of day such as "07:30" into its offset from midnight
+func ParseClock(value string) (time.Duration, bool) {
+	t, err := time.Parse("15:04", value)
+	if err != nil || len(value) != len("15:04") {
+		return 0, false
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, true
+}
+
+// ParseWeekday parses a lower case weekday name such as "monday"
+func ParseWeekday(name string) (time.Weekday, bool) {
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if strings.ToLower(day.String()) == name {
+			return day, true
+		}
+	}
+	return 0, false
+}
+
+// WeekStartDay returns the first day of the week the profile plans with
+func (s *Setting) WeekStartDay() time.Weekday {
+	if day, ok := ParseWeekday(s.WeekStart); ok {
+		return day
+	}
+	return DefaultWeekStart
+}
+
+// ReminderClock returns the offset from midnight at which reminders go out
+func (s *Setting) ReminderClock() time.Duration {
+	if clock, ok := ParseClock(s.ReminderTime); ok {
+		return clock
+	}
+	return DefaultReminderTime
+}
+
+// QuietHours returns the start and end of the quiet hours as offsets from midnight, false when
+// the profile has none. The end may be before the start when the quiet hours span midnight.
+func (s *Setting) QuietHours() (time.Duration, time.Duration, bool) {
+	if s.QuietHoursStart == nil || s.QuietHoursEnd == nil {
+		return 0, 0, false
+	}
+	start, okStart := ParseClock(*s.QuietHoursStart)
+	end, okEnd := ParseClock(*s.QuietHoursEnd)
+	if !okStart || !okEnd || start == end {
+		return 0, 0, false
+	}
+	return start, end, true
+}
+
+// Location returns the time zone of the profile, UTC when it isn't set or unknown
+func (s *Setting) Location() *time.Location {
+	return common.LoadLocation(s.Timezone)
+}
@@ -0,0 +1,171 @@
+package account
+
+import (
+	"context"
+	"errors"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	openapi "github.com/VladimirKholomyanskyy/gym-api/internal/api/go"
+	customerrors "github.com/VladimirKholomyanskyy/gym-api/internal/customErrors"
+)
+
+const (
+	// DefaultLanguage is the language of profiles whose locale isn't known
+	DefaultLanguage = "en"
+	// DefaultTimezone is the time zone of profiles whose time zone isn't known
+	DefaultTimezone = "UTC"
+)
+
+var languageTagPattern = regexp.MustCompile(`^[A-Za-z]{2,3}(-[A-Za-z0-9]{2,8})*$`)
+
+// imperialRegions measure in pounds and inches
+var imperialRegions = map[string]bool{"US": true, "LR": true, "MM": true}
+
+// sundayRegions start their week on Sunday
+var sundayRegions = map[string]bool{
+	"US": true, "CA": true, "MX": true, "BR": true, "JP": true,
+	"KR": true, "TW": true, "HK": true, "PH": true, "IL": true,
+}
+
+// LocaleHints is what is known about the locale of an identity signing in for the first time.
+// Token claims are preferred over the Accept-Language header of the request.
+type LocaleHints struct {
+	// Locale is the "locale" claim of the token, a language tag such as "en-US"
+	Locale string
+	// Timezone is the "zoneinfo" claim of the token, an IANA time zone
+	Timezone string
+	// AcceptLanguage is the Accept-Language header of the request
+	AcceptLanguage string
+}
+
+// OnboardingService provides the profile of a signed in identity. The first time an identity signs
+// in its profile is created together with default settings, so every profile has settings.
+// Profiles pending deletion are returned as well, callers check PendingDeletion.
+type OnboardingService interface {
+	GetOrCreateProfile(ctx context.Context, externalID string, hints LocaleHints) (*Profile, error)
+}
+
+type onboardingService struct {
+	profileRepo ProfileRepository
+}
+
+func NewOnboardingService(profileRepo ProfileRepository) OnboardingService {
+	return &onboardingService{profileRepo: profileRepo}
+}
+
+func (s *onboardingService) GetOrCreateProfile(ctx context.Context, externalID string, hints LocaleHints) (*Profile, error) {
+	profile, err := s.profileRepo.GetByExternalID(ctx, externalID)
+	if err == nil {
+		return profile, nil
+	}
+	if !errors.Is(err, customerrors.ErrEntityNotFound) {
+		return nil, err
+	}
+
+	profile = &Profile{ExternalID: externalID}
+	setting := DefaultSetting(hints)
+	if err := s.profileRepo.CreateWithSetting(ctx, profile, setting); err != nil {
+		// The first requests of an identity can race to create its profile, the loser uses the
+		// profile of the winner
+		if existing, getErr := s.profileRepo.GetByExternalID(ctx, externalID); getErr == nil {
+			return existing, nil
+		}
+		return nil, err
+	}
+	log.Printf("Created profile %s with language %s and time zone %s", profile.ID, setting.Language, setting.Timezone)
+	return profile, nil
+}
+
+// DefaultSetting derives the settings of a new profile from the hints about its locale. The
+// region of the language tag picks the units and the first day of the week.
+func DefaultSetting(hints LocaleHints) *Setting {
+	setting := &Setting{
+		Language:             DefaultLanguage,
+		MeasurementUnits:     openapi.METRIC,
+		Timezone:             DefaultTimezone,
+		NotificationsEnabled: true,
+		WeekStart:            "monday",
+		MissedWorkoutPolicy:  MissedWorkoutMarkMissed,
+	}
+	if hints.Timezone != "" && !strings.EqualFold(hints.Timezone, "local") {
+		if _, err := time.LoadLocation(hints.Timezone); err == nil {
+			setting.Timezone = hints.Timezone
+		}
+	}
+
+	tag, ok := normalizeLanguageTag(hints.Locale)
+	if !ok {
+		tag, ok = preferredLanguage(hints.AcceptLanguage)
+	}
+	if !ok {
+		return setting
+	}
+	setting.Language = tag
+	if parts := strings.Split(tag, "-"); len(parts) > 1 {
+		region := parts[len(parts)-1]
+		if imperialRegions[region] {
+			setting.MeasurementUnits = openapi.IMPERIAL
+		}
+		if sundayRegions[region] {
+			setting.WeekStart = "sunday"
+		}
+	}
+	return setting
+}
+
+// normalizeLanguageTag checks a language tag fits the settings and writes it the usual way, such
+// as "en-US". Underscores of POSIX locales are accepted.
+func normalizeLanguageTag(tag string) (string, bool) {
+	tag = strings.ReplaceAll(strings.TrimSpace(tag), "_", "-")
+	if len(tag) > 10 || !languageTagPattern.MatchString(tag) {
+		return "", false
+	}
+	parts := strings.Split(tag, "-")
+	parts[0] = strings.ToLower(parts[0])
+	for i := 1; i < len(parts); i++ {
+		switch len(parts[i]) {
+		case 2:
+			parts[i] = strings.ToUpper(parts[i])
+		case 4:
+			parts[i] = strings.ToUpper(parts[i][:1]) + strings.ToLower(parts[i][1:])
+		default:
+			parts[i] = strings.ToLower(parts[i])
+		}
+	}
+	return strings.Join(parts, "-"), true
+}
+
+// preferredLanguage picks the language with the highest weight from an Accept-Language header
+func preferredLanguage(header string) (string, bool) {
+	type weighted struct {
+		tag    string
+		weight float64
+	}
+	var languages []weighted
+	for _, entry := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(entry, ";")
+		weight := 1.0
+		if q, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+			parsed, err := strconv.ParseFloat(q, 64)
+			if err != nil {
+				continue
+			}
+			weight = parsed
+		}
+		normalized, ok := normalizeLanguageTag(tag)
+		if !ok || weight <= 0 {
+			continue
+		}
+		languages = append(languages, weighted{tag: normalized, weight: weight})
+	}
+	if len(languages) == 0 {
+		return "", false
+	}
+	sort.SliceStable(languages, func(i, j int) bool { return languages[i].weight > languages[j].weight })
+	return languages[0].tag, true
+}
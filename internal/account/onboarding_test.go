@@ -0,0 +1,35 @@
+package account
+
+import (
+	"testing"
+
+	openapi "github.com/VladimirKholomyanskyy/gym-api/internal/api/go"
+)
+
+func TestDefaultSetting(t *testing.T) {
+	tests := []struct {
+		name      string
+		hints     LocaleHints
+		language  string
+		units     openapi.MeasurementUnits
+		timezone  string
+		weekStart string
+	}{
+		{"nothing known", LocaleHints{}, "en", openapi.METRIC, "UTC", "monday"},
+		{"claims", LocaleHints{Locale: "en_us", Timezone: "America/Chicago"}, "en-US", openapi.IMPERIAL, "America/Chicago", "sunday"},
+		{"claim over header", LocaleHints{Locale: "de-DE", AcceptLanguage: "en-US"}, "de-DE", openapi.METRIC, "UTC", "monday"},
+		{"header by weight", LocaleHints{AcceptLanguage: "fr;q=0.5, en-GB;q=0.9, *;q=0.1"}, "en-GB", openapi.METRIC, "UTC", "monday"},
+		{"script and region", LocaleHints{AcceptLanguage: "zh-hant-tw"}, "zh-Hant-TW", openapi.METRIC, "UTC", "sunday"},
+		{"invalid hints", LocaleHints{Locale: "not a locale", Timezone: "Mars/Olympus", AcceptLanguage: "*"}, "en", openapi.METRIC, "UTC", "monday"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setting := DefaultSetting(tt.hints)
+			if setting.Language != tt.language || setting.MeasurementUnits != tt.units || setting.Timezone != tt.timezone || setting.WeekStart != tt.weekStart {
+				t.Errorf("DefaultSetting() = %s %s %s %s, want %s %s %s %s",
+					setting.Language, setting.MeasurementUnits, setting.Timezone, setting.WeekStart,
+					tt.language, tt.units, tt.timezone, tt.weekStart)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package account
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	openapi "github.com/VladimirKholomyanskyy/gym-api/internal/api/go"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/common"
+	customerrors "github.com/VladimirKholomyanskyy/gym-api/internal/customErrors"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/utils"
+)
+
+// NewPreferencesMiddleware puts the time zone and units from the settings of the authenticated
+// profile into the request context, so dates are read in the profile's time zone and values are
+// exchanged in its units. It has to run after the authentication middleware; without settings
+// UTC and metric units are used, failing to read them fails the request.
+func NewPreferencesMiddleware(settingsRepo SettingRepository) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			profileID, err := common.ExtractProfileID(r.Context())
+			if err != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			settings, err := settingsRepo.GetByProfileID(r.Context(), profileID)
+			if err != nil {
+				if errors.Is(err, customerrors.ErrEntityNotFound) {
+					next.ServeHTTP(w, r)
+					return
+				}
+				// Guessing metric units would store values of an imperial profile unconverted
+				log.Printf("Failed to load settings of profile %s: %v", profileID, err)
+				response, _ := utils.ErrorResponse(http.StatusInternalServerError, openapi.INTERNAL_SERVER_ERROR, "Failed to load profile settings")
+				openapi.EncodeJSONResponse(response.Body, &response.Code, w)
+				return
+			}
+			ctx := common.WithLocation(r.Context(), settings.Location())
+			ctx = common.WithUnits(ctx, common.Units(settings.MeasurementUnits))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package account
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	openapi "github.com/VladimirKholomyanskyy/gym-api/internal/api/go"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/common"
+	customerrors "github.com/VladimirKholomyanskyy/gym-api/internal/customErrors"
+)
+
+type fakeSettingRepository struct {
+	SettingRepository
+	setting *Setting
+	err     error
+}
+
+func (r *fakeSettingRepository) GetByProfileID(ctx context.Context, id string) (*Setting, error) {
+	return r.setting, r.err
+}
+
+func TestPreferencesMiddleware(t *testing.T) {
+	tests := []struct {
+		name       string
+		repo       *fakeSettingRepository
+		wantStatus int
+		wantUnits  common.Units
+	}{
+		{"imperial profile", &fakeSettingRepository{setting: &Setting{MeasurementUnits: openapi.IMPERIAL, Timezone: "UTC"}}, http.StatusOK, common.ImperialUnits},
+		{"no settings", &fakeSettingRepository{err: customerrors.ErrEntityNotFound}, http.StatusOK, common.MetricUnits},
+		{"settings unavailable", &fakeSettingRepository{err: errors.New("connection reset")}, http.StatusInternalServerError, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var units common.Units
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				units = common.ExtractUnits(r.Context())
+			})
+			request := httptest.NewRequest(http.MethodGet, "/", nil)
+			request = request.WithContext(context.WithValue(request.Context(), common.ProfileIDKey, "profile"))
+			recorder := httptest.NewRecorder()
+
+			NewPreferencesMiddleware(tt.repo)(next).ServeHTTP(recorder, request)
+
+			if recorder.Code != tt.wantStatus || units != tt.wantUnits {
+				t.Errorf("status %d, units %q, want %d, %q", recorder.Code, units, tt.wantStatus, tt.wantUnits)
+			}
+		})
+	}
+}
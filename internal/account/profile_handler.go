@@ -2,7 +2,10 @@ package account
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
+	"time"
 
 	openapi "github.com/VladimirKholomyanskyy/gym-api/internal/api/go"
 	"github.com/VladimirKholomyanskyy/gym-api/internal/common"
@@ -10,12 +13,25 @@ import (
 	"github.com/VladimirKholomyanskyy/gym-api/internal/utils"
 )
 
+// BodyweightRecorder records a weight as a bodyweight measurement, which also makes it the weight
+// of the profile, so setting the weight doesn't overwrite the bodyweight history
+type BodyweightRecorder interface {
+	RecordBodyweight(ctx context.Context, profileID string, weight float64, at time.Time) error
+}
+
+const (
+	// maxWeight in kg and maxHeight in meters bound realistic profiles, as the database does
+	maxWeight = 500
+	maxHeight = 3
+)
+
 type profileHandler struct {
-	profileRepo ProfileRepository
+	profileRepo        ProfileRepository
+	bodyweightRecorder BodyweightRecorder
 }
 
-func NewProfileHandler(profileRepo ProfileRepository) openapi.ProfileAPIServicer {
-	return &profileHandler{profileRepo: profileRepo}
+func NewProfileHandler(profileRepo ProfileRepository, bodyweightRecorder BodyweightRecorder) openapi.ProfileAPIServicer {
+	return &profileHandler{profileRepo: profileRepo, bodyweightRecorder: bodyweightRecorder}
 }
 
 func (h *profileHandler) GetProfile(ctx context.Context) (openapi.ImplResponse, error) {
@@ -26,13 +42,13 @@ func (h *profileHandler) GetProfile(ctx context.Context) (openapi.ImplResponse,
 
 	profile, err := h.profileRepo.GetByID(ctx, profileID)
 	if err != nil {
-		if err == customerrors.ErrEntityNotFound {
+		if errors.Is(err, customerrors.ErrEntityNotFound) {
 			return utils.ErrorResponse(http.StatusNotFound, openapi.RESOURCE_NOT_FOUND, "User profile not found")
 		}
 		return utils.ErrorResponse(http.StatusInternalServerError, openapi.INTERNAL_SERVER_ERROR, "Failed to fetch user profile")
 	}
 
-	return openapi.Response(http.StatusOK, ConvertProfileToOpenAPI(profile)), nil
+	return openapi.Response(http.StatusOK, ConvertProfileToOpenAPI(profile, common.ExtractUnits(ctx))), nil
 }
 
 func (h *profileHandler) UpdateProfile(ctx context.Context, request openapi.PatchProfileRequest) (openapi.ImplResponse, error) {
@@ -43,16 +59,16 @@ func (h *profileHandler) UpdateProfile(ctx context.Context, request openapi.Patc
 
 	profile, err := h.profileRepo.GetByID(ctx, profileID)
 	if err != nil {
-		if err == customerrors.ErrEntityNotFound {
+		if errors.Is(err, customerrors.ErrEntityNotFound) {
 			return utils.ErrorResponse(http.StatusNotFound, openapi.RESOURCE_NOT_FOUND, "User profile not found")
 		}
 		return utils.ErrorResponse(http.StatusInternalServerError, openapi.INTERNAL_SERVER_ERROR, "Failed to fetch user profile")
 	}
-	updates := make(map[string]any)
-
+	// The avatar URL points at an uploaded avatar, it's only set by uploading one
 	if request.AvatarUrl != nil {
-		updates["avatar_url"] = request.AvatarUrl
+		return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_REQUEST, "avatarUrl can't be set, upload an avatar instead")
 	}
+	updates := make(map[string]any)
 
 	if request.Birthday != nil {
 		birthday, err := utils.ParseTime(*request.Birthday)
@@ -62,22 +78,52 @@ func (h *profileHandler) UpdateProfile(ctx context.Context, request openapi.Patc
 		updates["birthday"] = &birthday
 	}
 
+	// Weight and height come in the units of the profile and are stored metric
+	units := common.ExtractUnits(ctx)
 	if request.Height != nil {
-		updates["height"] = request.Height
+		height := units.ToMeters(*request.Height)
+		if height <= 0 || height >= maxHeight {
+			return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_REQUEST, fmt.Sprintf("Height must be between 0 and %g %s", units.FromMeters(maxHeight), heightUnit(units)))
+		}
+		updates["height"] = height
 	}
 
+	var weight float64
 	if request.Weight != nil {
-		updates["weight"] = request.Weight
+		weight = units.ToKilograms(*request.Weight)
+		if weight <= 0 || weight >= maxWeight {
+			return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_REQUEST, fmt.Sprintf("Weight must be between 0 and %g %s", units.FromKilograms(maxWeight), units.WeightUnit()))
+		}
 	}
 
 	if request.Sex != nil {
 		updates["sex"] = request.Sex
 	}
 
-	err = h.profileRepo.UpdatePartial(ctx, profile.ID, updates)
+	if len(updates) > 0 {
+		err = h.profileRepo.UpdatePartial(ctx, profile.ID, updates)
+		if err != nil {
+			return utils.ErrorResponse(http.StatusInternalServerError, openapi.INTERNAL_SERVER_ERROR, "Failed to update user profile")
+		}
+	}
+
+	if request.Weight != nil {
+		if err := h.bodyweightRecorder.RecordBodyweight(ctx, profile.ID, weight, time.Now()); err != nil {
+			return utils.ErrorResponse(http.StatusInternalServerError, openapi.INTERNAL_SERVER_ERROR, "Failed to record weight")
+		}
+	}
+
+	profile, err = h.profileRepo.GetByID(ctx, profile.ID)
 	if err != nil {
-		return utils.ErrorResponse(http.StatusInternalServerError, openapi.INTERNAL_SERVER_ERROR, "Failed to update user profile")
+		return utils.ErrorResponse(http.StatusInternalServerError, openapi.INTERNAL_SERVER_ERROR, "Failed to fetch updated user profile")
 	}
 
-	return openapi.Response(http.StatusOK, ConvertProfileToOpenAPI(profile)), nil
+	return openapi.Response(http.StatusOK, ConvertProfileToOpenAPI(profile, common.ExtractUnits(ctx))), nil
+}
+
+func heightUnit(units common.Units) string {
+	if units == common.ImperialUnits {
+		return "in"
+	}
+	return "m"
 }
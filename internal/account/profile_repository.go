@@ -3,6 +3,7 @@ package account
 import (
 	"context"
 	"fmt"
+	"time"
 
 	customerrors "github.com/VladimirKholomyanskyy/gym-api/internal/customErrors"
 	"gorm.io/gorm"
@@ -11,13 +12,30 @@ import (
 // ProfileRepository defines CRUD operations for profiles
 type ProfileRepository interface {
 	Create(ctx context.Context, profile *Profile) error
+	CreateWithSetting(ctx context.Context, profile *Profile, setting *Setting) error
 	GetByID(ctx context.Context, id string) (*Profile, error)
 	GetByExternalID(ctx context.Context, id string) (*Profile, error)
 	UpdatePartial(ctx context.Context, id string, updates map[string]any) error
 	Delete(ctx context.Context, id string) error
+	Restore(ctx context.Context, id string) error
+	GetDeletedBefore(ctx context.Context, before time.Time, limit int) ([]Profile, error)
 	PermanentDelete(ctx context.Context, id string) error
 }
 
+// ownedData deletes what a profile owns, children before their parents, ahead of the profile
+// itself. The foreign keys cascade as well, deleting explicitly keeps the purge independent of
+// the order Postgres runs the cascades in. Tables referencing the profile only, such as settings
+// and photos, go with the profile.
+var ownedData = []string{
+	`DELETE FROM personal_records WHERE profile_id = ?`,
+	`DELETE FROM exercise_logs WHERE profile_id = ?`,
+	`DELETE FROM workout_sessions WHERE profile_id = ?`,
+	`DELETE FROM scheduled_workouts WHERE profile_id = ?`,
+	`DELETE FROM program_schedules WHERE profile_id = ?`,
+	`DELETE FROM workouts WHERE training_program_id IN (SELECT id FROM training_programs WHERE profile_id = ?)`,
+	`DELETE FROM training_programs WHERE profile_id = ?`,
+}
+
 // profileRepository implements ProfileRepository using GORM
 type profileRepository struct {
 	db *gorm.DB
@@ -35,6 +53,21 @@ func (r *profileRepository) Create(ctx context.Context, profile *Profile) error
 	return nil
 }
 
+// CreateWithSetting creates a profile and its settings in one transaction, a profile is never
+// left without settings
+func (r *profileRepository) CreateWithSetting(ctx context.Context, profile *Profile, setting *Setting) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(profile).Error; err != nil {
+			return fmt.Errorf("failed to create profile: %w", err)
+		}
+		setting.ProfileID = profile.ID
+		if err := tx.Create(setting).Error; err != nil {
+			return fmt.Errorf("failed to create settings: %w", err)
+		}
+		return nil
+	})
+}
+
 func (r *profileRepository) GetByID(ctx context.Context, id string) (*Profile, error) {
 	var profile Profile
 	err := r.db.WithContext(ctx).Where("id = ?", id).First(&profile).Error
@@ -47,9 +80,11 @@ func (r *profileRepository) GetByID(ctx context.Context, id string) (*Profile, e
 	return &profile, nil
 }
 
+// GetByExternalID retrieves the profile of an identity, including a profile pending deletion, so
+// signing in during the grace period finds the profile instead of creating another one
 func (r *profileRepository) GetByExternalID(ctx context.Context, id string) (*Profile, error) {
 	var profile Profile
-	err := r.db.WithContext(ctx).Where("external_id = ?", id).First(&profile).Error
+	err := r.db.WithContext(ctx).Unscoped().Where("external_id = ?", id).First(&profile).Error
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, customerrors.ErrEntityNotFound
@@ -81,13 +116,50 @@ func (r *profileRepository) Delete(ctx context.Context, id string) error {
 	return nil
 }
 
-func (r *profileRepository) PermanentDelete(ctx context.Context, id string) error {
-	result := r.db.WithContext(ctx).Unscoped().Where("id = ?", id).Delete(&Profile{})
+// Restore cancels the pending deletion of a profile
+func (r *profileRepository) Restore(ctx context.Context, id string) error {
+	result := r.db.WithContext(ctx).Unscoped().
+		Model(&Profile{}).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Update("deleted_at", nil)
 	if result.Error != nil {
-		return fmt.Errorf("failed to permanent delete profile: %w", result.Error)
+		return fmt.Errorf("failed to restore profile: %w", result.Error)
 	}
 	if result.RowsAffected == 0 {
 		return customerrors.ErrEntityNotFound
 	}
 	return nil
 }
+
+// GetDeletedBefore retrieves profiles deleted before the time, oldest first
+func (r *profileRepository) GetDeletedBefore(ctx context.Context, before time.Time, limit int) ([]Profile, error) {
+	var profiles []Profile
+	err := r.db.WithContext(ctx).Unscoped().
+		Where("deleted_at IS NOT NULL AND deleted_at <= ?", before).
+		Order("deleted_at ASC").
+		Limit(limit).
+		Find(&profiles).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch deleted profiles: %w", err)
+	}
+	return profiles, nil
+}
+
+// PermanentDelete deletes a profile and everything it owns in one transaction
+func (r *profileRepository) PermanentDelete(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, statement := range ownedData {
+			if err := tx.Exec(statement, id).Error; err != nil {
+				return fmt.Errorf("failed to delete data of profile: %w", err)
+			}
+		}
+		result := tx.Unscoped().Where("id = ?", id).Delete(&Profile{})
+		if result.Error != nil {
+			return fmt.Errorf("failed to permanent delete profile: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return customerrors.ErrEntityNotFound
+		}
+		return nil
+	})
+}
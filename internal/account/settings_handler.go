@@ -2,7 +2,9 @@ package account
 
 import (
 	"context"
+	"errors"
 	"net/http"
+	"time"
 
 	openapi "github.com/VladimirKholomyanskyy/gym-api/internal/api/go"
 	"github.com/VladimirKholomyanskyy/gym-api/internal/common"
@@ -26,7 +28,7 @@ func (h *settingsHandler) GetSettings(ctx context.Context) (openapi.ImplResponse
 	}
 	settings, err := h.settingsRepo.GetByProfileID(ctx, profileID)
 	if err != nil {
-		if err == customerrors.ErrEntityNotFound {
+		if errors.Is(err, customerrors.ErrEntityNotFound) {
 			return utils.ErrorResponse(http.StatusNotFound, openapi.RESOURCE_NOT_FOUND, "User settings not found")
 		}
 		return utils.ErrorResponse(http.StatusInternalServerError, openapi.INTERNAL_SERVER_ERROR, "Failed to fetch user settings")
@@ -41,7 +43,7 @@ func (h *settingsHandler) UpdateSettings(ctx context.Context, request openapi.Pa
 	}
 	settings, err := h.settingsRepo.GetByProfileID(ctx, profileID)
 	if err != nil {
-		if err == customerrors.ErrEntityNotFound {
+		if errors.Is(err, customerrors.ErrEntityNotFound) {
 			return utils.ErrorResponse(http.StatusNotFound, openapi.RESOURCE_NOT_FOUND, "User settings not found")
 		}
 		return utils.ErrorResponse(http.StatusInternalServerError, openapi.INTERNAL_SERVER_ERROR, "Failed to fetch user settings")
@@ -57,8 +59,50 @@ func (h *settingsHandler) UpdateSettings(ctx context.Context, request openapi.Pa
 		updates["notifications_enabled"] = *request.NotificationsEnabled
 	}
 	if request.Timezone != nil {
+		if _, err := time.LoadLocation(*request.Timezone); err != nil || *request.Timezone == "" {
+			return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_REQUEST, "timezone must be an IANA time zone such as Europe/Berlin")
+		}
 		updates["timezone"] = *request.Timezone
 	}
+	if request.WeekStart != nil {
+		if _, ok := ParseWeekday(*request.WeekStart); !ok {
+			return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_REQUEST, "weekStart must be a lower case weekday name")
+		}
+		updates["week_start"] = *request.WeekStart
+	}
+	if request.ReminderTime != nil {
+		if _, ok := ParseClock(*request.ReminderTime); !ok {
+			return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_REQUEST, "reminderTime must be a time of day such as 08:00")
+		}
+		updates["reminder_time"] = *request.ReminderTime
+	}
+	if request.MissedWorkoutPolicy != nil {
+		policy, ok := ParseMissedWorkoutPolicy(*request.MissedWorkoutPolicy)
+		if !ok || *request.MissedWorkoutPolicy == "" {
+			return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_REQUEST, "missedWorkoutPolicy must be one of mark_missed, next_free_day, shift_week")
+		}
+		updates["missed_workout_policy"] = policy
+	}
+	// Quiet hours are set or cleared together, an empty start and end clears them
+	if request.QuietHoursStart != nil || request.QuietHoursEnd != nil {
+		if request.QuietHoursStart == nil || request.QuietHoursEnd == nil {
+			return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_REQUEST, "quietHoursStart and quietHoursEnd must be given together")
+		}
+		start, end := *request.QuietHoursStart, *request.QuietHoursEnd
+		switch {
+		case start == "" && end == "":
+			updates["quiet_hours_start"] = nil
+			updates["quiet_hours_end"] = nil
+		default:
+			_, okStart := ParseClock(start)
+			_, okEnd := ParseClock(end)
+			if !okStart || !okEnd || start == end {
+				return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_REQUEST, "quiet hours must be two different times of day such as 22:00 and 07:00")
+			}
+			updates["quiet_hours_start"] = start
+			updates["quiet_hours_end"] = end
+		}
+	}
 
 	if err := h.settingsRepo.UpdatePartial(ctx, settings.ID, updates); err != nil {
 		return utils.ErrorResponse(http.StatusInternalServerError, openapi.INTERNAL_SERVER_ERROR, "Failed to update user settings")
@@ -1,21 +1,33 @@
 package account
 
 import (
+	"fmt"
+	"strings"
+	"time"
+
 	openapi "github.com/VladimirKholomyanskyy/gym-api/internal/api/go"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/common"
 	"github.com/VladimirKholomyanskyy/gym-api/internal/utils"
 )
 
-// ConvertProfileToOpenAPI converts a GORM Profile model to an OpenAPI Profile model
-func ConvertProfileToOpenAPI(profile *Profile) openapi.Profile {
+// ConvertProfileToOpenAPI converts a GORM Profile model to an OpenAPI Profile model in the given units
+func ConvertProfileToOpenAPI(profile *Profile, units common.Units) openapi.Profile {
 	converted := utils.FormatTime(profile.Birthday)
-	return openapi.Profile{
+	apiProfile := openapi.Profile{
 		Id:        profile.ID,
 		Sex:       profile.Sex,
-		Weight:    profile.Weight,
-		Height:    profile.Height,
 		Birthday:  &converted,
 		AvatarUrl: profile.AvatarURL,
 	}
+	if profile.Weight != nil {
+		weight := units.FromKilograms(*profile.Weight)
+		apiProfile.Weight = &weight
+	}
+	if profile.Height != nil {
+		height := units.FromMeters(*profile.Height)
+		apiProfile.Height = &height
+	}
+	return apiProfile
 }
 func ConvertSettingToOpenAPI(setting *Setting) openapi.Settings {
 	return openapi.Settings{
@@ -24,5 +36,14 @@ func ConvertSettingToOpenAPI(setting *Setting) openapi.Settings {
 		Timezone:             setting.Timezone,
 		MeasurementUnits:     (*openapi.MeasurementUnits)(&setting.MeasurementUnits),
 		NotificationsEnabled: setting.NotificationsEnabled,
+		WeekStart:            strings.ToLower(setting.WeekStartDay().String()),
+		ReminderTime:         formatClock(setting.ReminderClock()),
+		QuietHoursStart:      setting.QuietHoursStart,
+		QuietHoursEnd:        setting.QuietHoursEnd,
+		MissedWorkoutPolicy:  string(setting.MissedWorkoutPolicy),
 	}
 }
+
+func formatClock(clock time.Duration) string {
+	return fmt.Sprintf("%02d:%02d", int(clock.Hours()), int(clock.Minutes())%60)
+}
@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/VladimirKholomyanskyy/gym-api/internal/analytics/model"
+	usecase "github.com/VladimirKholomyanskyy/gym-api/internal/analytics/usecase"
+	openapi "github.com/VladimirKholomyanskyy/gym-api/internal/api/go"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/common"
+	customerrors "github.com/VladimirKholomyanskyy/gym-api/internal/customErrors"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/strength"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/utils"
+)
+
+const (
+	// defaultRangeDays is how far back analytics look when no start date is given
+	defaultRangeDays = 90
+	// maxRangeDays bounds the history a single analytics request reads
+	maxRangeDays = 3 * 366
+)
+
+type analyticsHandler struct {
+	useCase usecase.AnalyticsUseCase
+}
+
+// NewAnalyticsHandler creates a default api service
+func NewAnalyticsHandler(useCase usecase.AnalyticsUseCase) openapi.AnalyticsAPIServicer {
+	return &analyticsHandler{useCase: useCase}
+}
+
+// GetEstimatedOneRepMaxTrend - Retrieve the best estimated one rep max of an exercise per day or week
+func (h *analyticsHandler) GetEstimatedOneRepMaxTrend(ctx context.Context, exerciseId, formula, granularity, startDate, endDate string) (openapi.ImplResponse, error) {
+	profileId, err := common.ExtractProfileID(ctx)
+	if err != nil {
+		return utils.ErrorResponse(http.StatusUnauthorized, openapi.FORBIDDEN, err.Error())
+	}
+	if !common.IsUUIDValid(exerciseId) {
+		return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_ID, "Exercise ID is not a valid UUID")
+	}
+	oneRepMaxFormula, ok := strength.ParseFormula(formula)
+	if !ok {
+		return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_REQUEST, "formula must be one of epley, brzycki, lombardi or rpe")
+	}
+	periodGranularity, ok := parseGranularity(granularity)
+	if !ok {
+		return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_REQUEST, "granularity must be day or week")
+	}
+	from, to, errResponse := parseDateRange(startDate, endDate, common.ExtractLocation(ctx))
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+	trend, err := h.useCase.GetOneRepMaxTrend(ctx, profileId, exerciseId, oneRepMaxFormula, periodGranularity, from, to)
+	if err != nil {
+		if errors.Is(err, customerrors.ErrEntityNotFound) {
+			return utils.ErrorResponse(http.StatusNotFound, openapi.RESOURCE_NOT_FOUND, "Exercise not found")
+		}
+		return utils.ErrorResponse(http.StatusInternalServerError, openapi.INTERNAL_SERVER_ERROR, "failed to compute one rep max trend")
+	}
+	return openapi.Response(http.StatusOK, convertOneRepMaxTrend(trend, common.ExtractUnits(ctx))), nil
+}
+
+// GetMuscleVolume - Retrieve the weekly sets, reps and tonnage per muscle
+func (h *analyticsHandler) GetMuscleVolume(ctx context.Context, startDate, endDate string) (openapi.ImplResponse, error) {
+	profileId, err := common.ExtractProfileID(ctx)
+	if err != nil {
+		return utils.ErrorResponse(http.StatusUnauthorized, openapi.FORBIDDEN, err.Error())
+	}
+	from, to, errResponse := parseDateRange(startDate, endDate, common.ExtractLocation(ctx))
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+	weeks, err := h.useCase.GetMuscleVolume(ctx, profileId, from, to)
+	if err != nil {
+		return utils.ErrorResponse(http.StatusInternalServerError, openapi.INTERNAL_SERVER_ERROR, "failed to compute muscle volume")
+	}
+	return openapi.Response(http.StatusOK, openapi.GetMuscleVolume200Response{
+		Weeks: convertMuscleVolumeWeeks(weeks, common.ExtractUnits(ctx)),
+	}), nil
+}
+
+// GetTrainingCalendar - Retrieve the training days of a date range and the training streaks
+func (h *analyticsHandler) GetTrainingCalendar(ctx context.Context, startDate, endDate string) (openapi.ImplResponse, error) {
+	profileId, err := common.ExtractProfileID(ctx)
+	if err != nil {
+		return utils.ErrorResponse(http.StatusUnauthorized, openapi.FORBIDDEN, err.Error())
+	}
+	from, to, errResponse := parseDateRange(startDate, endDate, common.ExtractLocation(ctx))
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+	calendar, err := h.useCase.GetTrainingCalendar(ctx, profileId, from, to)
+	if err != nil {
+		return utils.ErrorResponse(http.StatusInternalServerError, openapi.INTERNAL_SERVER_ERROR, "failed to compute training calendar")
+	}
+	return openapi.Response(http.StatusOK, convertTrainingCalendar(calendar, common.ExtractUnits(ctx))), nil
+}
+
+// GetTrainingLoad - Retrieve the daily training load with the acute:chronic workload ratio, monotony and strain
+func (h *analyticsHandler) GetTrainingLoad(ctx context.Context, method, startDate, endDate string) (openapi.ImplResponse, error) {
+	profileId, err := common.ExtractProfileID(ctx)
+	if err != nil {
+		return utils.ErrorResponse(http.StatusUnauthorized, openapi.FORBIDDEN, err.Error())
+	}
+	loadMethod, ok := parseLoadMethod(method)
+	if !ok {
+		return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_REQUEST, "method must be srpe or tonnage")
+	}
+	from, to, errResponse := parseDateRange(startDate, endDate, common.ExtractLocation(ctx))
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+	load, err := h.useCase.GetTrainingLoad(ctx, profileId, loadMethod, from, to)
+	if err != nil {
+		return utils.ErrorResponse(http.StatusInternalServerError, openapi.INTERNAL_SERVER_ERROR, "failed to compute training load")
+	}
+	return openapi.Response(http.StatusOK, convertTrainingLoad(load, common.ExtractUnits(ctx))), nil
+}
+
+// ListExerciseInsights - Retrieve the exercises that plateaued or regressed over their recent sessions
+func (h *analyticsHandler) ListExerciseInsights(ctx context.Context, exerciseId string, window int32, sensitivity *float64) (openapi.ImplResponse, error) {
+	profileId, err := common.ExtractProfileID(ctx)
+	if err != nil {
+		return utils.ErrorResponse(http.StatusUnauthorized, openapi.FORBIDDEN, err.Error())
+	}
+	var exerciseID *string
+	if exerciseId != "" {
+		if !common.IsUUIDValid(exerciseId) {
+			return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_ID, "Exercise ID is not a valid UUID")
+		}
+		exerciseID = &exerciseId
+	}
+	sessions := model.DefaultInsightWindow
+	if window != 0 {
+		if window < model.MinInsightWindow || window > model.MaxInsightWindow {
+			return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_REQUEST, "window must be between 2 and 20")
+		}
+		sessions = int(window)
+	}
+	threshold := model.DefaultInsightSensitivity
+	if sensitivity != nil {
+		if *sensitivity < 0 || *sensitivity > model.MaxInsightSensitivity {
+			return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_REQUEST, "sensitivity must be between 0 and 50")
+		}
+		threshold = *sensitivity
+	}
+	insights, err := h.useCase.GetExerciseInsights(ctx, profileId, exerciseID, sessions, threshold)
+	if err != nil {
+		if errors.Is(err, customerrors.ErrEntityNotFound) {
+			return utils.ErrorResponse(http.StatusNotFound, openapi.RESOURCE_NOT_FOUND, "Exercise not found")
+		}
+		return utils.ErrorResponse(http.StatusInternalServerError, openapi.INTERNAL_SERVER_ERROR, "failed to compute exercise insights")
+	}
+	return openapi.Response(http.StatusOK, openapi.ListExerciseInsights200Response{
+		Items: convertExerciseInsights(insights, common.ExtractUnits(ctx)),
+	}), nil
+}
+
+func parseLoadMethod(method string) (model.LoadMethod, bool) {
+	switch model.LoadMethod(method) {
+	case "", model.LoadMethodSessionRPE:
+		return model.LoadMethodSessionRPE, true
+	case model.LoadMethodTonnage:
+		return model.LoadMethodTonnage, true
+	}
+	return "", false
+}
+
+func parseGranularity(granularity string) (model.Granularity, bool) {
+	switch model.Granularity(granularity) {
+	case "", model.GranularityDay:
+		return model.GranularityDay, true
+	case model.GranularityWeek:
+		return model.GranularityWeek, true
+	}
+	return "", false
+}
+
+// parseDateRange turns the inclusive startDate and endDate query parameters into the half open
+// range [from, to) in the profile's time zone. Missing dates default to the last defaultRangeDays days.
+func parseDateRange(startDate, endDate string, location *time.Location) (time.Time, time.Time, *openapi.ImplResponse) {
+	to := common.StartOfDay(time.Now(), location).AddDate(0, 0, 1)
+	if endDate != "" {
+		end, err := common.ParseDate(endDate, location)
+		if err != nil {
+			response, _ := utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_DATE_FORMAT, "Invalid endDate format")
+			return time.Time{}, time.Time{}, &response
+		}
+		to = end.AddDate(0, 0, 1)
+	}
+	from := to.AddDate(0, 0, -defaultRangeDays)
+	if startDate != "" {
+		start, err := common.ParseDate(startDate, location)
+		if err != nil {
+			response, _ := utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_DATE_FORMAT, "Invalid startDate format")
+			return time.Time{}, time.Time{}, &response
+		}
+		from = start
+	}
+	if !from.Before(to) {
+		response, _ := utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_DATE_RANGE, "startDate can't be after endDate")
+		return time.Time{}, time.Time{}, &response
+	}
+	if from.AddDate(0, 0, maxRangeDays).Before(to) {
+		response, _ := utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_DATE_RANGE, "Date range can't be longer than 3 years")
+		return time.Time{}, time.Time{}, &response
+	}
+	return from, to, nil
+}
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"github.com/VladimirKholomyanskyy/gym-api/internal/analytics/model"
+	openapi "github.com/VladimirKholomyanskyy/gym-api/internal/api/go"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/common"
+	progressmodel "github.com/VladimirKholomyanskyy/gym-api/internal/progress/model"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/utils"
+)
+
+func convertTopSet(log *progressmodel.ExerciseLog, units common.Units) openapi.TopSet {
+	return openapi.TopSet{
+		ExerciseLogId:    log.ID,
+		WorkoutSessionId: log.SessionID,
+		Weight:           units.FromKilograms(log.Weight),
+		Reps:             int32(log.Reps),
+		Rpe:              log.RPE,
+		LoggedAt:         log.LoggedAt,
+	}
+}
+
+func convertOneRepMaxTrend(trend *model.OneRepMaxTrend, units common.Units) *openapi.EstimatedOneRepMaxTrend {
+	points := make([]openapi.EstimatedOneRepMaxPoint, len(trend.Points))
+	for i, p := range trend.Points {
+		points[i] = openapi.EstimatedOneRepMaxPoint{
+			Date:               utils.FormatTime(&p.PeriodStart),
+			EstimatedOneRepMax: units.FromKilograms(p.EstimatedOneRepMax),
+			TopSet:             convertTopSet(&p.TopSet, units),
+		}
+	}
+	return &openapi.EstimatedOneRepMaxTrend{
+		ExerciseId:  trend.ExerciseID,
+		Formula:     string(trend.Formula),
+		Granularity: string(trend.Granularity),
+		Points:      points,
+	}
+}
+
+func convertMuscleVolumeWeeks(weeks []model.MuscleVolumeWeek, units common.Units) []openapi.MuscleVolumeWeek {
+	apiWeeks := make([]openapi.MuscleVolumeWeek, len(weeks))
+	for i, w := range weeks {
+		muscles := make([]openapi.MuscleVolume, len(w.Muscles))
+		for j, m := range w.Muscles {
+			muscles[j] = openapi.MuscleVolume{
+				Muscle:       m.Muscle,
+				Sets:         m.Sets,
+				DirectSets:   int32(m.DirectSets),
+				IndirectSets: int32(m.IndirectSets),
+				Reps:         m.Reps,
+				Tonnage:      units.FromKilograms(m.Tonnage),
+			}
+		}
+		apiWeeks[i] = openapi.MuscleVolumeWeek{WeekStart: utils.FormatTime(&w.WeekStart), Muscles: muscles}
+	}
+	return apiWeeks
+}
+
+func convertTrainingCalendar(calendar *model.TrainingCalendar, units common.Units) *openapi.TrainingCalendar {
+	days := make([]openapi.CalendarDay, len(calendar.Days))
+	for i, d := range calendar.Days {
+		days[i] = openapi.CalendarDay{
+			Date:         utils.FormatTime(&d.Date),
+			Trained:      d.Trained(),
+			SessionCount: int32(d.SessionCount),
+			Volume:       units.FromKilograms(d.Volume),
+		}
+	}
+	return &openapi.TrainingCalendar{
+		Days: days,
+		Streaks: openapi.TrainingStreaks{
+			CurrentDailyStreak:  int32(calendar.Streaks.CurrentDaily),
+			LongestDailyStreak:  int32(calendar.Streaks.LongestDaily),
+			CurrentWeeklyStreak: int32(calendar.Streaks.CurrentWeekly),
+			LongestWeeklyStreak: int32(calendar.Streaks.LongestWeekly),
+		},
+	}
+}
+
+func convertTrainingLoad(load *model.TrainingLoad, units common.Units) *openapi.TrainingLoad {
+	// Session RPE loads are minutes times RPE, only tonnage is a weight
+	convert := func(value float64) float64 { return value }
+	if load.Method == model.LoadMethodTonnage {
+		convert = units.FromKilograms
+	}
+	days := make([]openapi.TrainingLoadDay, len(load.Days))
+	for i, d := range load.Days {
+		var strain *float64
+		if d.Strain != nil {
+			converted := convert(*d.Strain)
+			strain = &converted
+		}
+		flags := make([]string, len(d.Flags))
+		for j, f := range d.Flags {
+			flags[j] = string(f)
+		}
+		days[i] = openapi.TrainingLoadDay{
+			Date:        utils.FormatTime(&d.Date),
+			Load:        convert(d.Load),
+			AcuteLoad:   convert(d.AcuteLoad),
+			ChronicLoad: convert(d.ChronicLoad),
+			Acwr:        d.ACWR,
+			Monotony:    d.Monotony,
+			Strain:      strain,
+			Flags:       flags,
+		}
+	}
+	return &openapi.TrainingLoad{Method: string(load.Method), Days: days}
+}
+
+func convertExerciseInsights(insights []model.ExerciseInsight, units common.Units) []openapi.ExerciseInsight {
+	apiInsights := make([]openapi.ExerciseInsight, len(insights))
+	for i, insight := range insights {
+		apiInsights[i] = openapi.ExerciseInsight{
+			ExerciseId:        insight.ExerciseID,
+			Type:              string(insight.Type),
+			Window:            int32(insight.Window),
+			BaselineOneRepMax: units.FromKilograms(insight.BaselineOneRepMax),
+			BaselineAt:        insight.BaselineAt,
+			RecentOneRepMax:   units.FromKilograms(insight.RecentOneRepMax),
+			ChangePercent:     insight.ChangePercent,
+			LastSessionAt:     insight.LastSessionAt,
+		}
+	}
+	return apiInsights
+}
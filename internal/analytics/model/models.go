@@ -0,0 +1,192 @@
+package model
+
+import (
+	"time"
+
+	progressmodel "github.com/VladimirKholomyanskyy/gym-api/internal/progress/model"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/strength"
+	"github.com/lib/pq"
+)
+
+// Granularity is the length of the periods analytics are bucketed into
+type Granularity string
+
+const (
+	GranularityDay  Granularity = "day"
+	GranularityWeek Granularity = "week"
+)
+
+// PeriodStart returns the start of the day or week t falls into, in the location of t
+func PeriodStart(t time.Time, granularity Granularity, weekStart time.Weekday) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	if granularity != GranularityWeek {
+		return day
+	}
+	offset := (int(day.Weekday()) - int(weekStart) + 7) % 7
+	return day.AddDate(0, 0, -offset)
+}
+
+// OneRepMaxPoint is the best estimated one rep max of a period together with the set it came from
+type OneRepMaxPoint struct {
+	PeriodStart        time.Time
+	EstimatedOneRepMax float64
+	TopSet             progressmodel.ExerciseLog
+}
+
+type OneRepMaxTrend struct {
+	ExerciseID  string
+	Formula     strength.Formula
+	Granularity Granularity
+	Points      []OneRepMaxPoint
+}
+
+// SecondaryMuscleCredit is the share of a set credited to each secondary muscle of the exercise
+const SecondaryMuscleCredit = 0.5
+
+// MuscleSet is a logged set together with the muscles its exercise trains
+type MuscleSet struct {
+	LoggedAt        time.Time
+	Reps            int
+	Weight          float64
+	PrimaryMuscle   string
+	SecondaryMuscle pq.StringArray `gorm:"type:text[]"`
+}
+
+// MuscleVolume is the work a muscle got in a week. Sets, reps and tonnage count secondary
+// work with SecondaryMuscleCredit, the direct and indirect set counts are unweighted.
+type MuscleVolume struct {
+	Muscle       string
+	Sets         float64
+	DirectSets   int
+	IndirectSets int
+	Reps         float64
+	Tonnage      float64
+}
+
+type MuscleVolumeWeek struct {
+	WeekStart time.Time
+	Muscles   []MuscleVolume
+}
+
+// SessionVolume is a workout session with the tonnage and average RPE of its logged sets
+type SessionVolume struct {
+	ID          string
+	StartedAt   time.Time
+	CompletedAt *time.Time
+	Volume      float64
+	AverageRPE  *float64 `gorm:"column:average_rpe"`
+}
+
+// CalendarDay is a day of the training calendar in the profile's time zone
+type CalendarDay struct {
+	Date         time.Time
+	SessionCount int
+	Volume       float64
+}
+
+// Trained reports whether a session was started on the day
+func (d CalendarDay) Trained() bool {
+	return d.SessionCount > 0
+}
+
+// Streaks count consecutive days and weeks with at least one session. A current streak is
+// still alive when the last training was the day or week before the current one.
+type Streaks struct {
+	CurrentDaily  int
+	LongestDaily  int
+	CurrentWeekly int
+	LongestWeekly int
+}
+
+type TrainingCalendar struct {
+	Days    []CalendarDay
+	Streaks Streaks
+}
+
+// LoadMethod is how the load of a session is measured
+type LoadMethod string
+
+const (
+	// LoadMethodSessionRPE multiplies the session duration in minutes by the session RPE, the
+	// average RPE of its sets. Sessions that aren't completed or have no RPE carry no load.
+	LoadMethodSessionRPE LoadMethod = "srpe"
+	// LoadMethodTonnage uses the tonnage of the session
+	LoadMethodTonnage LoadMethod = "tonnage"
+)
+
+const (
+	AcuteWindowDays   = 7
+	ChronicWindowDays = 28
+
+	// Thresholds of the acute:chronic workload ratio outside of which injury risk rises
+	HighACWRThreshold = 1.5
+	LowACWRThreshold  = 0.8
+	// HighMonotonyThreshold flags weeks without enough variation between hard and easy days
+	HighMonotonyThreshold = 2.0
+)
+
+// LoadFlag marks a day on which a load metric crossed its threshold
+type LoadFlag string
+
+const (
+	LoadFlagHighACWR     LoadFlag = "high_acwr"
+	LoadFlagLowACWR      LoadFlag = "low_acwr"
+	LoadFlagHighMonotony LoadFlag = "high_monotony"
+)
+
+// LoadDay holds the load metrics of a day. Acute load is the load of the last AcuteWindowDays days,
+// chronic load the average weekly load of the last ChronicWindowDays days. Monotony is the mean
+// daily load of the acute window divided by its standard deviation and strain the acute load times
+// monotony. Ratios are nil when their denominator is zero.
+type LoadDay struct {
+	Date        time.Time
+	Load        float64
+	AcuteLoad   float64
+	ChronicLoad float64
+	ACWR        *float64
+	Monotony    *float64
+	Strain      *float64
+	Flags       []LoadFlag
+}
+
+type TrainingLoad struct {
+	Method LoadMethod
+	Days   []LoadDay
+}
+
+// InsightType is the kind of stall detected in the progress of an exercise
+type InsightType string
+
+const (
+	// InsightPlateau means the recent sessions didn't beat the previous best by more than the sensitivity
+	InsightPlateau InsightType = "plateau"
+	// InsightRegression means the recent sessions fell short of the previous best by more than the sensitivity
+	InsightRegression InsightType = "regression"
+)
+
+const (
+	// DefaultInsightWindow is the number of recent sessions compared with the history before them
+	DefaultInsightWindow = 5
+	MinInsightWindow     = 2
+	MaxInsightWindow     = 20
+	// InsightBaselineWindows is how many windows of sessions before the recent one make up the
+	// baseline, older history doesn't count
+	InsightBaselineWindows = 3
+	// DefaultInsightSensitivity is the change of the estimated one rep max, in percent, that
+	// separates progress and regression from a plateau
+	DefaultInsightSensitivity = 2.5
+	MaxInsightSensitivity     = 50
+)
+
+// ExerciseInsight compares the best estimated one rep max of the last Window sessions of an
+// exercise with the best one of the InsightBaselineWindows windows of sessions before them
+type ExerciseInsight struct {
+	ExerciseID        string
+	Type              InsightType
+	Window            int
+	BaselineOneRepMax float64
+	BaselineAt        time.Time
+	RecentOneRepMax   float64
+	ChangePercent     float64
+	LastSessionAt     time.Time
+}
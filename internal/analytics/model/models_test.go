@@ -0,0 +1,38 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPeriodStartAcrossDST(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("time zone not available: %v", err)
+	}
+	tests := []struct {
+		name        string
+		instant     string
+		granularity Granularity
+		weekStart   time.Weekday
+		want        string
+	}{
+		{"day after spring forward", "2024-03-31T12:00:00Z", GranularityDay, time.Monday, "2024-03-31T00:00:00+01:00"},
+		{"week containing spring forward", "2024-03-31T12:00:00Z", GranularityWeek, time.Monday, "2024-03-25T00:00:00+01:00"},
+		{"week starting after spring forward", "2024-04-01T00:30:00+02:00", GranularityWeek, time.Monday, "2024-04-01T00:00:00+02:00"},
+		{"sunday week containing fall back", "2024-10-29T10:00:00Z", GranularityWeek, time.Sunday, "2024-10-27T00:00:00+02:00"},
+		{"late evening UTC is the next day in Berlin", "2024-10-27T23:30:00Z", GranularityDay, time.Monday, "2024-10-28T00:00:00+01:00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			instant, err := time.Parse(time.RFC3339, tt.instant)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := PeriodStart(instant.In(berlin), tt.granularity, tt.weekStart)
+			if got.Format(time.RFC3339) != tt.want {
+				t.Errorf("PeriodStart = %s, want %s", got.Format(time.RFC3339), tt.want)
+			}
+		})
+	}
+}
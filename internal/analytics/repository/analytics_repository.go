@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/VladimirKholomyanskyy/gym-api/internal/analytics/model"
+	progressmodel "github.com/VladimirKholomyanskyy/gym-api/internal/progress/model"
+	"gorm.io/gorm"
+)
+
+// AnalyticsRepository reads the training history analytics are computed from
+type AnalyticsRepository interface {
+	GetExerciseLogs(ctx context.Context, profileID string, exerciseID *string, from, to time.Time) ([]progressmodel.ExerciseLog, error)
+	GetRecentSessionLogs(ctx context.Context, profileID string, exerciseID *string, sessions int) ([]progressmodel.ExerciseLog, error)
+	GetMuscleSets(ctx context.Context, profileID string, from, to time.Time) ([]model.MuscleSet, error)
+	GetSessionVolumes(ctx context.Context, profileID string, from, to time.Time) ([]model.SessionVolume, error)
+	GetTrainingDays(ctx context.Context, profileID string, location *time.Location) ([]time.Time, error)
+}
+
+// analyticsRepository implements AnalyticsRepository
+type analyticsRepository struct {
+	db *gorm.DB
+}
+
+// NewAnalyticsRepository creates a new repository instance
+func NewAnalyticsRepository(db *gorm.DB) AnalyticsRepository {
+	return &analyticsRepository{db: db}
+}
+
+// GetExerciseLogs retrieves the logs of a profile logged in [from, to), oldest first,
+// optionally limited to a single exercise
+func (r *analyticsRepository) GetExerciseLogs(ctx context.Context, profileID string, exerciseID *string, from, to time.Time) ([]progressmodel.ExerciseLog, error) {
+	var exerciseLogs []progressmodel.ExerciseLog
+	query := r.db.WithContext(ctx).
+		Where("profile_id = ? AND logged_at >= ? AND logged_at < ?", profileID, from, to)
+	if exerciseID != nil {
+		query = query.Where("exercise_id = ?", *exerciseID)
+	}
+	if err := query.Order("logged_at ASC").Find(&exerciseLogs).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch exercise logs for analytics: %w", err)
+	}
+	return exerciseLogs, nil
+}
+
+// GetRecentSessionLogs retrieves the sets with reps and weight of the last sessions of every exercise
+// of a profile, oldest first, optionally limited to a single exercise. Sessions are ranked per
+// exercise by their last such set.
+func (r *analyticsRepository) GetRecentSessionLogs(ctx context.Context, profileID string, exerciseID *string, sessions int) ([]progressmodel.ExerciseLog, error) {
+	recent := r.db.
+		Table("exercise_logs").
+		Select("exercise_id, session_id, ROW_NUMBER() OVER (PARTITION BY exercise_id ORDER BY MAX(logged_at) DESC) AS session_rank").
+		Where("profile_id = ? AND reps > 0 AND weight > 0", profileID).
+		Group("exercise_id, session_id")
+	if exerciseID != nil {
+		recent = recent.Where("exercise_id = ?", *exerciseID)
+	}
+	var exerciseLogs []progressmodel.ExerciseLog
+	err := r.db.WithContext(ctx).
+		Select("exercise_logs.*").
+		Joins("JOIN (?) AS recent ON recent.exercise_id = exercise_logs.exercise_id AND recent.session_id = exercise_logs.session_id", recent).
+		Where("recent.session_rank <= ?", sessions).
+		Where("exercise_logs.reps > 0 AND exercise_logs.weight > 0").
+		Order("exercise_logs.logged_at ASC").
+		Find(&exerciseLogs).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch recent session logs for analytics: %w", err)
+	}
+	return exerciseLogs, nil
+}
+
+// GetMuscleSets retrieves the working sets of a profile logged in [from, to) joined with the
+// muscles of their exercises, oldest first
+func (r *analyticsRepository) GetMuscleSets(ctx context.Context, profileID string, from, to time.Time) ([]model.MuscleSet, error) {
+	var sets []model.MuscleSet
+	err := r.db.WithContext(ctx).
+		Table("exercise_logs").
+		Select("exercise_logs.logged_at, exercise_logs.reps, exercise_logs.weight, exercises.primary_muscle, exercises.secondary_muscle").
+		Joins("JOIN exercises ON exercises.id = exercise_logs.exercise_id").
+		Where("exercise_logs.profile_id = ? AND exercise_logs.logged_at >= ? AND exercise_logs.logged_at < ?", profileID, from, to).
+		Where("exercise_logs.reps > 0").
+		Order("exercise_logs.logged_at ASC").
+		Scan(&sets).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch muscle sets: %w", err)
+	}
+	return sets, nil
+}
+
+// GetSessionVolumes retrieves the sessions of a profile started in [from, to) with the tonnage
+// and average RPE of their logged sets, oldest first
+func (r *analyticsRepository) GetSessionVolumes(ctx context.Context, profileID string, from, to time.Time) ([]model.SessionVolume, error) {
+	var sessions []model.SessionVolume
+	err := r.db.WithContext(ctx).
+		Table("workout_sessions").
+		Select("workout_sessions.id, workout_sessions.started_at, workout_sessions.completed_at, "+
+			"COALESCE(SUM(exercise_logs.weight * exercise_logs.reps), 0) AS volume, AVG(exercise_logs.rpe) AS average_rpe").
+		Joins("LEFT JOIN exercise_logs ON exercise_logs.session_id = workout_sessions.id").
+		Where("workout_sessions.profile_id = ? AND workout_sessions.started_at >= ? AND workout_sessions.started_at < ?", profileID, from, to).
+		Group("workout_sessions.id, workout_sessions.started_at, workout_sessions.completed_at").
+		Order("workout_sessions.started_at ASC").
+		Scan(&sessions).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch session volumes: %w", err)
+	}
+	return sessions, nil
+}
+
+// GetTrainingDays retrieves the days of the time zone on which the profile started at least one
+// session, oldest first, as midnight of the time zone
+func (r *analyticsRepository) GetTrainingDays(ctx context.Context, profileID string, location *time.Location) ([]time.Time, error) {
+	var rows []struct{ Day time.Time }
+	err := r.db.WithContext(ctx).
+		Table("workout_sessions").
+		Select("DATE(started_at AT TIME ZONE ?) AS day", location.String()).
+		Where("profile_id = ?", profileID).
+		Group("day").
+		Order("day ASC").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch training days: %w", err)
+	}
+	days := make([]time.Time, len(rows))
+	for i, row := range rows {
+		days[i] = time.Date(row.Day.Year(), row.Day.Month(), row.Day.Day(), 0, 0, 0, 0, location)
+	}
+	return days, nil
+}
@@ -0,0 +1,232 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/VladimirKholomyanskyy/gym-api/internal/account"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/analytics/model"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/analytics/repository"
+	customerrors "github.com/VladimirKholomyanskyy/gym-api/internal/customErrors"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/strength"
+	training "github.com/VladimirKholomyanskyy/gym-api/internal/training/usecase"
+)
+
+type AnalyticsUseCase interface {
+	GetOneRepMaxTrend(ctx context.Context, profileID, exerciseID string, formula strength.Formula, granularity model.Granularity, from, to time.Time) (*model.OneRepMaxTrend, error)
+	GetMuscleVolume(ctx context.Context, profileID string, from, to time.Time) ([]model.MuscleVolumeWeek, error)
+	GetTrainingCalendar(ctx context.Context, profileID string, from, to time.Time) (*model.TrainingCalendar, error)
+	GetTrainingLoad(ctx context.Context, profileID string, method model.LoadMethod, from, to time.Time) (*model.TrainingLoad, error)
+	GetExerciseInsights(ctx context.Context, profileID string, exerciseID *string, window int, sensitivity float64) ([]model.ExerciseInsight, error)
+}
+
+type analyticsUseCase struct {
+	repo            repository.AnalyticsRepository
+	settingsRepo    account.SettingRepository
+	exerciseUseCase training.ExerciseUseCase
+}
+
+func NewAnalyticsUseCase(repo repository.AnalyticsRepository, settingsRepo account.SettingRepository, exerciseUseCase training.ExerciseUseCase) AnalyticsUseCase {
+	return &analyticsUseCase{repo: repo, settingsRepo: settingsRepo, exerciseUseCase: exerciseUseCase}
+}
+
+// GetOneRepMaxTrend estimates the one rep max of every set of an exercise logged in [from, to)
+// and keeps the best one per period. Sets the formula can't estimate are skipped.
+func (uc *analyticsUseCase) GetOneRepMaxTrend(ctx context.Context, profileID, exerciseID string, formula strength.Formula, granularity model.Granularity, from, to time.Time) (*model.OneRepMaxTrend, error) {
+	if _, err := uc.exerciseUseCase.GetByID(ctx, exerciseID); err != nil {
+		return nil, fmt.Errorf("exercise not found: %w", err)
+	}
+	prefs, err := uc.preferences(ctx, profileID)
+	if err != nil {
+		return nil, err
+	}
+	from, to = prefs.dateRange(from, to)
+	logs, err := uc.repo.GetExerciseLogs(ctx, profileID, &exerciseID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	trend := &model.OneRepMaxTrend{ExerciseID: exerciseID, Formula: formula, Granularity: granularity}
+	for _, log := range logs {
+		estimate := strength.EstimateOneRepMax(formula, log.Weight, log.Reps, log.RPE)
+		if estimate <= 0 {
+			continue
+		}
+		estimate = math.Round(estimate*100) / 100
+		period := model.PeriodStart(log.LoggedAt.In(prefs.location), granularity, prefs.weekStart)
+		// Logs come oldest first, so a period is always the last point or a new one
+		last := len(trend.Points) - 1
+		if last >= 0 && trend.Points[last].PeriodStart.Equal(period) {
+			if estimate > trend.Points[last].EstimatedOneRepMax {
+				trend.Points[last].EstimatedOneRepMax = estimate
+				trend.Points[last].TopSet = log
+			}
+			continue
+		}
+		trend.Points = append(trend.Points, model.OneRepMaxPoint{PeriodStart: period, EstimatedOneRepMax: estimate, TopSet: log})
+	}
+	return trend, nil
+}
+
+// GetMuscleVolume aggregates the working sets logged in [from, to) per week and muscle. The range
+// is widened to the start of its first week, so the first week isn't reported partially.
+func (uc *analyticsUseCase) GetMuscleVolume(ctx context.Context, profileID string, from, to time.Time) ([]model.MuscleVolumeWeek, error) {
+	prefs, err := uc.preferences(ctx, profileID)
+	if err != nil {
+		return nil, err
+	}
+	from, to = prefs.dateRange(from, to)
+	from = model.PeriodStart(from, model.GranularityWeek, prefs.weekStart)
+	sets, err := uc.repo.GetMuscleSets(ctx, profileID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	var weeks []model.MuscleVolumeWeek
+	var muscles map[string]*model.MuscleVolume
+	flush := func() {
+		if len(weeks) == 0 {
+			return
+		}
+		last := &weeks[len(weeks)-1]
+		for _, volume := range muscles {
+			volume.Tonnage = math.Round(volume.Tonnage*100) / 100
+			last.Muscles = append(last.Muscles, *volume)
+		}
+		sort.Slice(last.Muscles, func(i, j int) bool { return last.Muscles[i].Muscle < last.Muscles[j].Muscle })
+	}
+	credit := func(muscle string, set model.MuscleSet, share float64, direct bool) {
+		volume, ok := muscles[muscle]
+		if !ok {
+			volume = &model.MuscleVolume{Muscle: muscle}
+			muscles[muscle] = volume
+		}
+		volume.Sets += share
+		volume.Reps += share * float64(set.Reps)
+		volume.Tonnage += share * float64(set.Reps) * set.Weight
+		if direct {
+			volume.DirectSets++
+		} else {
+			volume.IndirectSets++
+		}
+	}
+
+	for _, set := range sets {
+		week := model.PeriodStart(set.LoggedAt.In(prefs.location), model.GranularityWeek, prefs.weekStart)
+		// Sets come oldest first, so a week is always the last one or a new one
+		if len(weeks) == 0 || !weeks[len(weeks)-1].WeekStart.Equal(week) {
+			flush()
+			weeks = append(weeks, model.MuscleVolumeWeek{WeekStart: week})
+			muscles = make(map[string]*model.MuscleVolume)
+		}
+		credit(set.PrimaryMuscle, set, 1, true)
+		for _, muscle := range set.SecondaryMuscle {
+			if muscle != set.PrimaryMuscle {
+				credit(muscle, set, model.SecondaryMuscleCredit, false)
+			}
+		}
+	}
+	flush()
+	return weeks, nil
+}
+
+// GetTrainingCalendar returns every day of [from, to) with the sessions started on it, bucketed
+// in the profile's time zone, and the streaks over the distinct training days of the profile.
+func (uc *analyticsUseCase) GetTrainingCalendar(ctx context.Context, profileID string, from, to time.Time) (*model.TrainingCalendar, error) {
+	prefs, err := uc.preferences(ctx, profileID)
+	if err != nil {
+		return nil, err
+	}
+	from, to = prefs.dateRange(from, to)
+	sessions, err := uc.repo.GetSessionVolumes(ctx, profileID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	days, err := uc.repo.GetTrainingDays(ctx, profileID, prefs.location)
+	if err != nil {
+		return nil, err
+	}
+
+	calendar := &model.TrainingCalendar{}
+	dayIndex := make(map[string]int)
+	for day := from; day.Before(to); day = day.AddDate(0, 0, 1) {
+		dayIndex[day.Format(time.DateOnly)] = len(calendar.Days)
+		calendar.Days = append(calendar.Days, model.CalendarDay{Date: day})
+	}
+	for _, session := range sessions {
+		day := session.StartedAt.In(prefs.location).Format(time.DateOnly)
+		if i, ok := dayIndex[day]; ok {
+			calendar.Days[i].SessionCount++
+			calendar.Days[i].Volume = math.Round((calendar.Days[i].Volume+session.Volume)*100) / 100
+		}
+	}
+
+	var weeks []time.Time
+	for _, day := range days {
+		weeks = appendPeriod(weeks, model.PeriodStart(day, model.GranularityWeek, prefs.weekStart))
+	}
+	now := time.Now().In(prefs.location)
+	today := model.PeriodStart(now, model.GranularityDay, prefs.weekStart)
+	thisWeek := model.PeriodStart(now, model.GranularityWeek, prefs.weekStart)
+	calendar.Streaks.CurrentDaily, calendar.Streaks.LongestDaily = streaks(days, today, 1)
+	calendar.Streaks.CurrentWeekly, calendar.Streaks.LongestWeekly = streaks(weeks, thisWeek, 7)
+	return calendar, nil
+}
+
+// appendPeriod appends a period start to a sorted list unless it is already the last one
+func appendPeriod(periods []time.Time, period time.Time) []time.Time {
+	if len(periods) > 0 && periods[len(periods)-1].Equal(period) {
+		return periods
+	}
+	return append(periods, period)
+}
+
+// streaks counts runs of consecutive periods, each stepDays after the previous one, in a sorted
+// list of period starts. The current run only counts when it reaches the current period or the
+// one before it. Periods are stepped with AddDate so days keep their length across DST changes.
+func streaks(periods []time.Time, current time.Time, stepDays int) (int, int) {
+	longest, run := 0, 0
+	for i, period := range periods {
+		if i > 0 && periods[i-1].AddDate(0, 0, stepDays).Equal(period) {
+			run++
+		} else {
+			run = 1
+		}
+		longest = max(longest, run)
+	}
+	if len(periods) == 0 {
+		return 0, 0
+	}
+	last := periods[len(periods)-1]
+	if last.Equal(current) || last.AddDate(0, 0, stepDays).Equal(current) {
+		return run, longest
+	}
+	return 0, longest
+}
+
+// preferences are the calendar settings of a profile analytics are bucketed with
+type preferences struct {
+	weekStart time.Weekday
+	location  *time.Location
+}
+
+// dateRange moves a range of calendar dates to midnight in the profile's time zone, whatever zone they were parsed in
+func (p preferences) dateRange(from, to time.Time) (time.Time, time.Time) {
+	return time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, p.location),
+		time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, p.location)
+}
+
+// preferences reads the week start and time zone from the profile settings
+func (uc *analyticsUseCase) preferences(ctx context.Context, profileID string) (preferences, error) {
+	settings, err := uc.settingsRepo.GetByProfileID(ctx, profileID)
+	if err != nil {
+		if errors.Is(err, customerrors.ErrEntityNotFound) {
+			return preferences{weekStart: account.DefaultWeekStart, location: time.UTC}, nil
+		}
+		return preferences{}, err
+	}
+	return preferences{weekStart: settings.WeekStartDay(), location: settings.Location()}, nil
+}
@@ -0,0 +1,123 @@
+package usecase
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/VladimirKholomyanskyy/gym-api/internal/account"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/analytics/model"
+)
+
+func (r *fakeAnalyticsRepository) GetMuscleSets(ctx context.Context, profileID string, from, to time.Time) ([]model.MuscleSet, error) {
+	r.from, r.to = from, to
+	return r.muscleSets, nil
+}
+
+func TestGetMuscleVolume(t *testing.T) {
+	day := func(day, hour int) time.Time { return time.Date(2024, 3, day, hour, 0, 0, 0, time.UTC) }
+	bench := func(loggedAt time.Time, reps int, weight float64) model.MuscleSet {
+		return model.MuscleSet{LoggedAt: loggedAt, Reps: reps, Weight: weight, PrimaryMuscle: "chest", SecondaryMuscle: []string{"triceps", "shoulders"}}
+	}
+	repo := &fakeAnalyticsRepository{muscleSets: []model.MuscleSet{
+		bench(day(4, 18), 5, 100),
+		// A primary muscle listed as secondary too is credited once
+		{LoggedAt: day(5, 18), Reps: 5, Weight: 100, PrimaryMuscle: "quads", SecondaryMuscle: []string{"glutes", "quads"}},
+		// Sunday starts the next week for this profile
+		bench(day(10, 9), 10, 60),
+		bench(day(10, 9), 10, 60),
+	}}
+	settings := &fakeSettingRepository{setting: &account.Setting{Timezone: "UTC", WeekStart: "sunday"}}
+	uc := NewAnalyticsUseCase(repo, settings, nil)
+
+	weeks, err := uc.GetMuscleVolume(context.Background(), "profile", day(6, 0), day(17, 0))
+	if err != nil {
+		t.Fatalf("GetMuscleVolume() error = %v", err)
+	}
+	if want := day(3, 0); !repo.from.Equal(want) {
+		t.Errorf("sets read from %v, want the start of the first week %v", repo.from, want)
+	}
+	want := []model.MuscleVolumeWeek{
+		{WeekStart: day(3, 0), Muscles: []model.MuscleVolume{
+			{Muscle: "chest", Sets: 1, DirectSets: 1, Reps: 5, Tonnage: 500},
+			{Muscle: "glutes", Sets: 0.5, IndirectSets: 1, Reps: 2.5, Tonnage: 250},
+			{Muscle: "quads", Sets: 1, DirectSets: 1, Reps: 5, Tonnage: 500},
+			{Muscle: "shoulders", Sets: 0.5, IndirectSets: 1, Reps: 2.5, Tonnage: 250},
+			{Muscle: "triceps", Sets: 0.5, IndirectSets: 1, Reps: 2.5, Tonnage: 250},
+		}},
+		{WeekStart: day(10, 0), Muscles: []model.MuscleVolume{
+			{Muscle: "chest", Sets: 2, DirectSets: 2, Reps: 20, Tonnage: 1200},
+			{Muscle: "shoulders", Sets: 1, IndirectSets: 2, Reps: 10, Tonnage: 600},
+			{Muscle: "triceps", Sets: 1, IndirectSets: 2, Reps: 10, Tonnage: 600},
+		}},
+	}
+	if !reflect.DeepEqual(weeks, want) {
+		t.Errorf("GetMuscleVolume() = %+v, want %+v", weeks, want)
+	}
+}
+
+func TestGetMuscleVolumeWithoutSets(t *testing.T) {
+	uc := NewAnalyticsUseCase(&fakeAnalyticsRepository{}, &fakeSettingRepository{}, nil)
+	weeks, err := uc.GetMuscleVolume(context.Background(), "profile", time.Date(2024, 3, 6, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 17, 0, 0, 0, 0, time.UTC))
+	if err != nil || len(weeks) != 0 {
+		t.Errorf("GetMuscleVolume() = %v, %v, want no weeks", weeks, err)
+	}
+}
+
+func TestStreaks(t *testing.T) {
+	march := func(days ...int) []time.Time {
+		periods := make([]time.Time, len(days))
+		for i, day := range days {
+			periods[i] = time.Date(2024, 3, day, 0, 0, 0, 0, time.UTC)
+		}
+		return periods
+	}
+	today := time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name        string
+		periods     []time.Time
+		current     time.Time
+		stepDays    int
+		wantCurrent int
+		wantLongest int
+	}{
+		{"no training", nil, today, 1, 0, 0},
+		{"trained today", march(10), today, 1, 1, 1},
+		{"run ending yesterday", march(8, 9), today, 1, 2, 2},
+		{"run ended two days ago", march(7, 8), today, 1, 0, 2},
+		{"broken run", march(1, 2, 3, 5, 6), today, 1, 0, 3},
+		{"current run is the longest", march(1, 2, 6, 7, 8, 9, 10), today, 1, 5, 5},
+		{"weekly run", []time.Time{
+			time.Date(2024, 2, 19, 0, 0, 0, 0, time.UTC),
+			time.Date(2024, 2, 26, 0, 0, 0, 0, time.UTC),
+			time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC),
+		}, time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC), 7, 3, 3},
+		{"skipped week", []time.Time{
+			time.Date(2024, 2, 12, 0, 0, 0, 0, time.UTC),
+			time.Date(2024, 2, 26, 0, 0, 0, 0, time.UTC),
+		}, time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC), 7, 1, 1},
+	}
+	for _, tt := range tests {
+		current, longest := streaks(tt.periods, tt.current, tt.stepDays)
+		if current != tt.wantCurrent || longest != tt.wantLongest {
+			t.Errorf("%s: streaks() = %d, %d, want %d, %d", tt.name, current, longest, tt.wantCurrent, tt.wantLongest)
+		}
+	}
+}
+
+func TestStreaksAcrossDaylightSaving(t *testing.T) {
+	location, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("time zone Europe/Berlin not available: %v", err)
+	}
+	// The clocks go forward on March 31, that day is only 23 hours long
+	days := []time.Time{
+		time.Date(2024, 3, 30, 0, 0, 0, 0, location),
+		time.Date(2024, 3, 31, 0, 0, 0, 0, location),
+		time.Date(2024, 4, 1, 0, 0, 0, 0, location),
+	}
+	if current, longest := streaks(days, days[2], 1); current != 3 || longest != 3 {
+		t.Errorf("streaks() = %d, %d, want 3, 3", current, longest)
+	}
+}
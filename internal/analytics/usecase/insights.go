@@ -0,0 +1,121 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/VladimirKholomyanskyy/gym-api/internal/analytics/model"
+	progressmodel "github.com/VladimirKholomyanskyy/gym-api/internal/progress/model"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/strength"
+)
+
+// sessionBest is the best estimated one rep max of an exercise in a session
+type sessionBest struct {
+	sessionID  string
+	oneRepMax  float64
+	achievedAt time.Time
+	lastLogAt  time.Time
+}
+
+// GetExerciseInsights scans the recent sessions of every exercise of a profile, or of a single
+// exercise, and reports the exercises that stalled or regressed over their last window sessions,
+// worst first. Only the sessions the comparison needs are read. Exercises with no more sessions
+// than the window have nothing to compare with and are skipped.
+func (uc *analyticsUseCase) GetExerciseInsights(ctx context.Context, profileID string, exerciseID *string, window int, sensitivity float64) ([]model.ExerciseInsight, error) {
+	if exerciseID != nil {
+		if _, err := uc.exerciseUseCase.GetByID(ctx, *exerciseID); err != nil {
+			return nil, fmt.Errorf("exercise not found: %w", err)
+		}
+	}
+	logs, err := uc.repo.GetRecentSessionLogs(ctx, profileID, exerciseID, window*(model.InsightBaselineWindows+1))
+	if err != nil {
+		return nil, err
+	}
+
+	var exerciseOrder []string
+	sessions := make(map[string][]sessionBest)
+	for _, log := range logs {
+		if _, ok := sessions[log.ExerciseID]; !ok {
+			exerciseOrder = append(exerciseOrder, log.ExerciseID)
+		}
+		sessions[log.ExerciseID] = appendSessionBest(sessions[log.ExerciseID], log)
+	}
+
+	insights := []model.ExerciseInsight{}
+	for _, id := range exerciseOrder {
+		if insight := detectInsight(id, sessions[id], window, sensitivity); insight != nil {
+			insights = append(insights, *insight)
+		}
+	}
+	sort.SliceStable(insights, func(i, j int) bool {
+		return insights[i].ChangePercent < insights[j].ChangePercent
+	})
+	return insights, nil
+}
+
+// appendSessionBest adds a log to the session list of its exercise. Logs come oldest first, so a
+// session is always the last one or a new one. Sets without an estimate still mark the session.
+func appendSessionBest(sessions []sessionBest, log progressmodel.ExerciseLog) []sessionBest {
+	estimate := math.Round(strength.Epley(log.Weight, log.Reps)*100) / 100
+	last := len(sessions) - 1
+	if last < 0 || sessions[last].sessionID != log.SessionID {
+		return append(sessions, sessionBest{sessionID: log.SessionID, oneRepMax: estimate, achievedAt: log.LoggedAt, lastLogAt: log.LoggedAt})
+	}
+	if estimate > sessions[last].oneRepMax {
+		sessions[last].oneRepMax = estimate
+		sessions[last].achievedAt = log.LoggedAt
+	}
+	sessions[last].lastLogAt = log.LoggedAt
+	return sessions
+}
+
+// detectInsight compares the best of the last window sessions with the best of the baseline windows
+// before them. A change within the sensitivity, in percent, is a plateau and a drop beyond it a regression.
+func detectInsight(exerciseID string, sessions []sessionBest, window int, sensitivity float64) *model.ExerciseInsight {
+	var estimated []sessionBest
+	for _, session := range sessions {
+		if session.oneRepMax > 0 {
+			estimated = append(estimated, session)
+		}
+	}
+	if len(estimated) <= window {
+		return nil
+	}
+	split := len(estimated) - window
+	baseline := bestSession(estimated[max(0, split-window*model.InsightBaselineWindows):split])
+	recent := bestSession(estimated[split:])
+	change := math.Round((recent.oneRepMax-baseline.oneRepMax)/baseline.oneRepMax*10000) / 100
+
+	insight := &model.ExerciseInsight{
+		ExerciseID:        exerciseID,
+		Window:            window,
+		BaselineOneRepMax: baseline.oneRepMax,
+		BaselineAt:        baseline.achievedAt,
+		RecentOneRepMax:   recent.oneRepMax,
+		ChangePercent:     change,
+		LastSessionAt:     estimated[len(estimated)-1].lastLogAt,
+	}
+	switch {
+	case change < -sensitivity:
+		insight.Type = model.InsightRegression
+	case change <= sensitivity:
+		insight.Type = model.InsightPlateau
+	default:
+		return nil
+	}
+	return insight
+}
+
+// bestSession returns the session with the highest estimate, the earliest one on a tie
+func bestSession(sessions []sessionBest) sessionBest {
+	best := sessions[0]
+	for _, session := range sessions[1:] {
+		if session.oneRepMax > best.oneRepMax {
+			best = session
+		}
+	}
+	return best
+}
@@ -0,0 +1,145 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/VladimirKholomyanskyy/gym-api/internal/analytics/model"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/common"
+	progressmodel "github.com/VladimirKholomyanskyy/gym-api/internal/progress/model"
+)
+
+func (r *fakeAnalyticsRepository) GetRecentSessionLogs(ctx context.Context, profileID string, exerciseID *string, sessions int) ([]progressmodel.ExerciseLog, error) {
+	r.recentSessions = sessions
+	return r.logs, nil
+}
+
+var insightStart = time.Date(2024, 3, 4, 18, 0, 0, 0, time.UTC)
+
+// sessionBests returns one session a day with the given estimates, zero for sessions without one
+func sessionBests(oneRepMaxes ...float64) []sessionBest {
+	sessions := make([]sessionBest, len(oneRepMaxes))
+	for i, oneRepMax := range oneRepMaxes {
+		day := insightStart.AddDate(0, 0, i)
+		sessions[i] = sessionBest{oneRepMax: oneRepMax, achievedAt: day, lastLogAt: day.Add(time.Hour)}
+	}
+	return sessions
+}
+
+func TestDetectInsight(t *testing.T) {
+	tests := []struct {
+		name         string
+		sessions     []sessionBest
+		want         model.InsightType
+		wantChange   float64
+		wantBaseline int // day of the baseline session
+		wantLast     int // day of the last session with an estimate
+	}{
+		{
+			name:     "not more sessions than the window",
+			sessions: sessionBests(100, 100),
+		},
+		{
+			name:         "plateau",
+			sessions:     sessionBests(100, 110, 111, 109),
+			want:         model.InsightPlateau,
+			wantChange:   0.91,
+			wantBaseline: 1,
+			wantLast:     3,
+		},
+		{
+			name:         "regression",
+			sessions:     sessionBests(100, 120, 110, 105),
+			want:         model.InsightRegression,
+			wantChange:   -8.33,
+			wantBaseline: 1,
+			wantLast:     3,
+		},
+		{
+			name:     "progress",
+			sessions: sessionBests(100, 100, 110, 104),
+		},
+		{
+			name:         "a drop within the sensitivity is a plateau",
+			sessions:     sessionBests(100, 100, 97.5, 97),
+			want:         model.InsightPlateau,
+			wantChange:   -2.5,
+			wantBaseline: 0,
+			wantLast:     3,
+		},
+		{
+			name:         "sessions without an estimate don't count",
+			sessions:     sessionBests(100, 0, 100, 101, 0),
+			want:         model.InsightPlateau,
+			wantChange:   1,
+			wantBaseline: 0,
+			wantLast:     3,
+		},
+		{
+			// The best from day 0 is older than the baseline windows
+			name:         "old bests are forgotten",
+			sessions:     sessionBests(200, 100, 100, 100, 100, 100, 100, 101, 100),
+			want:         model.InsightPlateau,
+			wantChange:   1,
+			wantBaseline: 1,
+			wantLast:     8,
+		},
+	}
+	for _, tt := range tests {
+		insight := detectInsight("bench", tt.sessions, 2, 2.5)
+		if insight == nil {
+			if tt.want != "" {
+				t.Errorf("%s: detectInsight() = nil, want %s", tt.name, tt.want)
+			}
+			continue
+		}
+		if tt.want == "" {
+			t.Errorf("%s: detectInsight() = %s of %v%%, want none", tt.name, insight.Type, insight.ChangePercent)
+			continue
+		}
+		if insight.Type != tt.want || insight.ChangePercent != tt.wantChange {
+			t.Errorf("%s: detectInsight() = %s of %v%%, want %s of %v%%", tt.name, insight.Type, insight.ChangePercent, tt.want, tt.wantChange)
+		}
+		if want := insightStart.AddDate(0, 0, tt.wantBaseline); !insight.BaselineAt.Equal(want) {
+			t.Errorf("%s: baseline at %v, want %v", tt.name, insight.BaselineAt, want)
+		}
+		if want := insightStart.AddDate(0, 0, tt.wantLast).Add(time.Hour); !insight.LastSessionAt.Equal(want) {
+			t.Errorf("%s: last session at %v, want %v", tt.name, insight.LastSessionAt, want)
+		}
+	}
+}
+
+func TestGetExerciseInsights(t *testing.T) {
+	logged := func(exerciseID string, day int, weight float64) progressmodel.ExerciseLog {
+		return progressmodel.ExerciseLog{
+			Base:       common.Base{ID: exerciseID + insightStart.AddDate(0, 0, day).Format(time.DateOnly)},
+			SessionID:  insightStart.AddDate(0, 0, day).Format(time.DateOnly),
+			ExerciseID: exerciseID,
+			Reps:       1,
+			Weight:     weight,
+			LoggedAt:   insightStart.AddDate(0, 0, day),
+		}
+	}
+	repo := &fakeAnalyticsRepository{logs: []progressmodel.ExerciseLog{
+		logged("bench", 0, 100), logged("squat", 0, 150),
+		logged("bench", 1, 100), logged("squat", 1, 150),
+		logged("bench", 2, 100), logged("squat", 2, 130),
+		logged("bench", 3, 101), logged("squat", 3, 135),
+	}}
+	uc := NewAnalyticsUseCase(repo, &fakeSettingRepository{}, nil)
+
+	insights, err := uc.GetExerciseInsights(context.Background(), "profile", nil, 2, 2.5)
+	if err != nil {
+		t.Fatalf("GetExerciseInsights() error = %v", err)
+	}
+	if want := 2 * (model.InsightBaselineWindows + 1); repo.recentSessions != want {
+		t.Errorf("read %d sessions per exercise, want %d", repo.recentSessions, want)
+	}
+	if len(insights) != 2 || insights[0].ExerciseID != "squat" || insights[1].ExerciseID != "bench" {
+		t.Fatalf("GetExerciseInsights() = %+v, want the squat regression before the bench plateau", insights)
+	}
+	if insights[0].Type != model.InsightRegression || insights[1].Type != model.InsightPlateau {
+		t.Errorf("insight types = %s, %s", insights[0].Type, insights[1].Type)
+	}
+}
@@ -0,0 +1,107 @@
+package usecase
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/VladimirKholomyanskyy/gym-api/internal/analytics/model"
+)
+
+// GetTrainingLoad computes the load metrics of every day of [from, to) in the profile's time zone.
+// Sessions of the ChronicWindowDays days before from are read as well, so the first days of the
+// range have complete windows.
+func (uc *analyticsUseCase) GetTrainingLoad(ctx context.Context, profileID string, method model.LoadMethod, from, to time.Time) (*model.TrainingLoad, error) {
+	prefs, err := uc.preferences(ctx, profileID)
+	if err != nil {
+		return nil, err
+	}
+	from, to = prefs.dateRange(from, to)
+	historyStart := from.AddDate(0, 0, -(model.ChronicWindowDays - 1))
+	sessions, err := uc.repo.GetSessionVolumes(ctx, profileID, historyStart, to)
+	if err != nil {
+		return nil, err
+	}
+
+	dailyLoads := make(map[string]float64)
+	for _, session := range sessions {
+		day := session.StartedAt.In(prefs.location).Format(time.DateOnly)
+		dailyLoads[day] += sessionLoad(session, method)
+	}
+	// loads holds one entry per day from historyStart, windows are slices ending at a day
+	var loads []float64
+	for day := historyStart; day.Before(to); day = day.AddDate(0, 0, 1) {
+		loads = append(loads, dailyLoads[day.Format(time.DateOnly)])
+	}
+
+	result := &model.TrainingLoad{Method: method}
+	i := model.ChronicWindowDays - 1
+	for day := from; day.Before(to); day = day.AddDate(0, 0, 1) {
+		result.Days = append(result.Days, loadDay(day, loads[i-model.AcuteWindowDays+1:i+1], loads[i-model.ChronicWindowDays+1:i+1]))
+		i++
+	}
+	return result, nil
+}
+
+// sessionLoad returns the load of a session measured with the given method
+func sessionLoad(session model.SessionVolume, method model.LoadMethod) float64 {
+	if method == model.LoadMethodTonnage {
+		return session.Volume
+	}
+	if session.CompletedAt == nil || session.AverageRPE == nil {
+		return 0
+	}
+	minutes := session.CompletedAt.Sub(session.StartedAt).Minutes()
+	if minutes <= 0 {
+		return 0
+	}
+	return minutes * *session.AverageRPE
+}
+
+// loadDay computes the metrics of a day from the daily loads of its acute and chronic windows,
+// both ending with the day itself
+func loadDay(day time.Time, acute, chronic []float64) model.LoadDay {
+	result := model.LoadDay{
+		Date:        day,
+		Load:        roundLoad(acute[len(acute)-1]),
+		AcuteLoad:   roundLoad(sum(acute)),
+		ChronicLoad: roundLoad(sum(chronic) / (model.ChronicWindowDays / model.AcuteWindowDays)),
+	}
+	if result.ChronicLoad > 0 {
+		acwr := roundLoad(result.AcuteLoad / result.ChronicLoad)
+		result.ACWR = &acwr
+		if acwr > model.HighACWRThreshold {
+			result.Flags = append(result.Flags, model.LoadFlagHighACWR)
+		} else if acwr < model.LowACWRThreshold {
+			result.Flags = append(result.Flags, model.LoadFlagLowACWR)
+		}
+	}
+	mean := sum(acute) / float64(len(acute))
+	variance := 0.0
+	for _, load := range acute {
+		variance += (load - mean) * (load - mean)
+	}
+	deviation := math.Sqrt(variance / float64(len(acute)))
+	if deviation > 0 {
+		monotony := roundLoad(mean / deviation)
+		strain := roundLoad(sum(acute) * mean / deviation)
+		result.Monotony = &monotony
+		result.Strain = &strain
+		if monotony > model.HighMonotonyThreshold {
+			result.Flags = append(result.Flags, model.LoadFlagHighMonotony)
+		}
+	}
+	return result
+}
+
+func sum(values []float64) float64 {
+	total := 0.0
+	for _, v := range values {
+		total += v
+	}
+	return total
+}
+
+func roundLoad(value float64) float64 {
+	return math.Round(value*100) / 100
+}
@@ -0,0 +1,174 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/VladimirKholomyanskyy/gym-api/internal/account"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/analytics/model"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/analytics/repository"
+	customerrors "github.com/VladimirKholomyanskyy/gym-api/internal/customErrors"
+	progressmodel "github.com/VladimirKholomyanskyy/gym-api/internal/progress/model"
+)
+
+type fakeAnalyticsRepository struct {
+	repository.AnalyticsRepository
+	sessions       []model.SessionVolume
+	logs           []progressmodel.ExerciseLog
+	muscleSets     []model.MuscleSet
+	from, to       time.Time
+	recentSessions int
+}
+
+func (r *fakeAnalyticsRepository) GetSessionVolumes(ctx context.Context, profileID string, from, to time.Time) ([]model.SessionVolume, error) {
+	r.from, r.to = from, to
+	var sessions []model.SessionVolume
+	for _, session := range r.sessions {
+		if !session.StartedAt.Before(from) && session.StartedAt.Before(to) {
+			sessions = append(sessions, session)
+		}
+	}
+	return sessions, nil
+}
+
+type fakeSettingRepository struct {
+	account.SettingRepository
+	setting *account.Setting
+}
+
+func (r *fakeSettingRepository) GetByProfileID(ctx context.Context, id string) (*account.Setting, error) {
+	if r.setting == nil {
+		return nil, customerrors.ErrEntityNotFound
+	}
+	return r.setting, nil
+}
+
+func repeat(load float64, days int) []float64 {
+	loads := make([]float64, days)
+	for i := range loads {
+		loads[i] = load
+	}
+	return loads
+}
+
+func TestLoadDay(t *testing.T) {
+	value := func(v float64) *float64 { return &v }
+	alternating := []float64{100, 0, 100, 0, 100, 0, 100}
+	steadyWeek := append(repeat(100, 6), 90)
+	tests := []struct {
+		name    string
+		acute   []float64
+		chronic []float64
+		want    model.LoadDay
+	}{
+		{
+			name:    "no load",
+			acute:   repeat(0, 7),
+			chronic: repeat(0, 28),
+			want:    model.LoadDay{},
+		},
+		{
+			// Monotony and strain need some variation, identical days have none
+			name:    "identical days",
+			acute:   repeat(100, 7),
+			chronic: repeat(100, 28),
+			want:    model.LoadDay{Load: 100, AcuteLoad: 700, ChronicLoad: 700, ACWR: value(1)},
+		},
+		{
+			name:    "spike after a break",
+			acute:   alternating,
+			chronic: append(repeat(0, 21), alternating...),
+			want: model.LoadDay{Load: 100, AcuteLoad: 400, ChronicLoad: 100, ACWR: value(4),
+				Monotony: value(1.15), Strain: value(461.88), Flags: []model.LoadFlag{model.LoadFlagHighACWR}},
+		},
+		{
+			name:    "deload",
+			acute:   append(repeat(0, 6), 100),
+			chronic: append(repeat(100, 21), append(repeat(0, 6), 100)...),
+			want: model.LoadDay{Load: 100, AcuteLoad: 100, ChronicLoad: 550, ACWR: value(0.18),
+				Monotony: value(0.41), Strain: value(40.82), Flags: []model.LoadFlag{model.LoadFlagLowACWR}},
+		},
+		{
+			name:    "same hard training every day",
+			acute:   steadyWeek,
+			chronic: append(append(append(append([]float64{}, steadyWeek...), steadyWeek...), steadyWeek...), steadyWeek...),
+			want: model.LoadDay{Load: 90, AcuteLoad: 690, ChronicLoad: 690, ACWR: value(1),
+				Monotony: value(28.17), Strain: value(19436.7), Flags: []model.LoadFlag{model.LoadFlagHighMonotony}},
+		},
+	}
+	for _, tt := range tests {
+		if got := loadDay(time.Time{}, tt.acute, tt.chronic); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: loadDay() = %s, want %s", tt.name, describeLoadDay(got), describeLoadDay(tt.want))
+		}
+	}
+}
+
+func describeLoadDay(day model.LoadDay) string {
+	ratio := func(value *float64) string {
+		if value == nil {
+			return "nil"
+		}
+		return fmt.Sprint(*value)
+	}
+	return fmt.Sprintf("load %v, acute %v, chronic %v, ACWR %s, monotony %s, strain %s, flags %v",
+		day.Load, day.AcuteLoad, day.ChronicLoad, ratio(day.ACWR), ratio(day.Monotony), ratio(day.Strain), day.Flags)
+}
+
+func TestSessionLoad(t *testing.T) {
+	start := time.Date(2024, 3, 4, 18, 0, 0, 0, time.UTC)
+	hourLater := start.Add(time.Hour)
+	before := start.Add(-time.Minute)
+	rpe := 7.5
+	tests := []struct {
+		name    string
+		session model.SessionVolume
+		method  model.LoadMethod
+		want    float64
+	}{
+		{"tonnage", model.SessionVolume{StartedAt: start, Volume: 4200}, model.LoadMethodTonnage, 4200},
+		{"session RPE", model.SessionVolume{StartedAt: start, CompletedAt: &hourLater, AverageRPE: &rpe}, model.LoadMethodSessionRPE, 450},
+		{"not completed", model.SessionVolume{StartedAt: start, AverageRPE: &rpe}, model.LoadMethodSessionRPE, 0},
+		{"without RPE", model.SessionVolume{StartedAt: start, CompletedAt: &hourLater}, model.LoadMethodSessionRPE, 0},
+		{"completed before it started", model.SessionVolume{StartedAt: start, CompletedAt: &before, AverageRPE: &rpe}, model.LoadMethodSessionRPE, 0},
+	}
+	for _, tt := range tests {
+		if got := sessionLoad(tt.session, tt.method); got != tt.want {
+			t.Errorf("%s: sessionLoad() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestGetTrainingLoadWithShortHistory(t *testing.T) {
+	from := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	repo := &fakeAnalyticsRepository{sessions: []model.SessionVolume{
+		{StartedAt: from.Add(18 * time.Hour), Volume: 1000},
+	}}
+	uc := NewAnalyticsUseCase(repo, &fakeSettingRepository{}, nil)
+
+	load, err := uc.GetTrainingLoad(context.Background(), "profile", model.LoadMethodTonnage, from, from.AddDate(0, 0, 2))
+	if err != nil {
+		t.Fatalf("GetTrainingLoad() error = %v", err)
+	}
+	if want := from.AddDate(0, 0, -27); !repo.from.Equal(want) {
+		t.Errorf("sessions read from %v, want %v to fill the chronic window", repo.from, want)
+	}
+	if len(load.Days) != 2 {
+		t.Fatalf("GetTrainingLoad() got %d days, want 2", len(load.Days))
+	}
+	// Days before the first session count as rest days
+	for i, wantLoad := range []float64{1000, 0} {
+		day := load.Days[i]
+		if !day.Date.Equal(from.AddDate(0, 0, i)) || day.Load != wantLoad || day.AcuteLoad != 1000 || day.ChronicLoad != 250 {
+			t.Errorf("day %d = %v load %v, acute %v, chronic %v", i, day.Date, day.Load, day.AcuteLoad, day.ChronicLoad)
+		}
+		if day.ACWR == nil || *day.ACWR != 4 || !reflect.DeepEqual(day.Flags, []model.LoadFlag{model.LoadFlagHighACWR}) {
+			t.Errorf("day %d ACWR = %v, flags %v, want 4 flagged high", i, day.ACWR, day.Flags)
+		}
+		if day.Monotony == nil || *day.Monotony != 0.41 || day.Strain == nil || *day.Strain != 408.25 {
+			t.Errorf("day %d monotony = %v, strain %v, want 0.41 and 408.25", i, day.Monotony, day.Strain)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// AWSCredentials are the access keys requests to AWS are signed with. SessionToken is only set
+// for temporary credentials.
+type AWSCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// CognitoIdentityProvider deletes users from the Cognito user pool when their profile is purged.
+// The credentials need the cognito-idp:AdminDeleteUser permission on the pool.
+type CognitoIdentityProvider struct {
+	client      *http.Client
+	endpoint    string
+	region      string
+	userPoolID  string
+	credentials AWSCredentials
+}
+
+// NewCognitoIdentityProvider creates the provider for the user pool the Cognito middleware
+// authenticates against
+func NewCognitoIdentityProvider(client *http.Client, region, userPoolID string, credentials AWSCredentials) *CognitoIdentityProvider {
+	return &CognitoIdentityProvider{
+		client:      client,
+		endpoint:    fmt.Sprintf("https://cognito-idp.%s.amazonaws.com/", region),
+		region:      region,
+		userPoolID:  userPoolID,
+		credentials: credentials,
+	}
+}
+
+// DeleteIdentity deletes the user through AdminDeleteUser. Profiles keep the sub of the user,
+// which Cognito accepts as the username. A user that is already gone counts as deleted.
+func (p *CognitoIdentityProvider) DeleteIdentity(ctx context.Context, externalID string) error {
+	body, err := json.Marshal(map[string]string{"UserPoolId": p.userPoolID, "Username": externalID})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "AWSCognitoIdentityProviderService.AdminDeleteUser")
+	signRequest(req, body, p.credentials, p.region, "cognito-idp", time.Now())
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to delete Cognito user: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	var failure struct {
+		Type    string `json:"__type"`
+		Message string `json:"message"`
+	}
+	json.NewDecoder(resp.Body).Decode(&failure)
+	// The type may carry a namespace, such as "com.amazonaws...#UserNotFoundException"
+	if strings.HasSuffix(failure.Type, "UserNotFoundException") {
+		return nil
+	}
+	return fmt.Errorf("failed to delete Cognito user: status %d %s %s", resp.StatusCode, failure.Type, failure.Message)
+}
+
+// signRequest signs the request with AWS Signature Version 4. The signature covers the host,
+// the body and every header set so far.
+func signRequest(req *http.Request, body []byte, credentials AWSCredentials, region, service string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	scope := amzDate[:8] + "/" + region + "/" + service + "/aws4_request"
+	req.Header.Set("X-Amz-Date", amzDate)
+	if credentials.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", credentials.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		hashHex(body),
+	}, "\n")
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, hashHex([]byte(canonicalRequest))}, "\n")
+
+	key := []byte("AWS4" + credentials.SecretAccessKey)
+	for _, part := range []string{amzDate[:8], region, service, "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		credentials.AccessKeyID, scope, signedHeaders, signature))
+}
+
+// canonicalQuery sorts the query parameters by name and value and encodes them as SigV4 expects
+func canonicalQuery(query url.Values) string {
+	var pairs []string
+	for name, values := range query {
+		for _, value := range values {
+			pairs = append(pairs, awsEscape(name)+"="+awsEscape(value))
+		}
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "&")
+}
+
+// awsEscape percent-encodes everything but unreserved characters, spaces become %20
+func awsEscape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+func hashHex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
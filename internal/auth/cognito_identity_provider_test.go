@@ -0,0 +1,65 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignRequest(t *testing.T) {
+	// The get-vanilla case of the AWS Signature Version 4 test suite
+	req, _ := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	credentials := AWSCredentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"}
+	signRequest(req, nil, credentials, "us-east-1", "service", time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+
+	want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+		"SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+	if got := req.Header.Get("Authorization"); got != want {
+		t.Errorf("Authorization = %s, want %s", got, want)
+	}
+}
+
+func TestCognitoDeleteIdentity(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		wantErr bool
+	}{
+		{"deleted", http.StatusOK, "{}", false},
+		{"already gone", http.StatusBadRequest, `{"__type":"UserNotFoundException","message":"User does not exist."}`, false},
+		{"not allowed", http.StatusBadRequest, `{"__type":"AccessDeniedException","message":"not authorized"}`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var target, authorization string
+			var body map[string]string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				target, authorization = r.Header.Get("X-Amz-Target"), r.Header.Get("Authorization")
+				data, _ := io.ReadAll(r.Body)
+				json.Unmarshal(data, &body)
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+			provider := NewCognitoIdentityProvider(server.Client(), "eu-west-1", "eu-west-1_pool", AWSCredentials{AccessKeyID: "key", SecretAccessKey: "secret"})
+			provider.endpoint = server.URL + "/"
+
+			err := provider.DeleteIdentity(context.Background(), "sub-1")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("DeleteIdentity() error = %v, want error %v", err, tt.wantErr)
+			}
+			if target != "AWSCognitoIdentityProviderService.AdminDeleteUser" || body["UserPoolId"] != "eu-west-1_pool" || body["Username"] != "sub-1" {
+				t.Errorf("request %s %v, want AdminDeleteUser of sub-1 in the pool", target, body)
+			}
+			if !strings.HasPrefix(authorization, "AWS4-HMAC-SHA256 Credential=key/") || !strings.Contains(authorization, "/eu-west-1/cognito-idp/aws4_request") {
+				t.Errorf("Authorization = %s, want a cognito-idp signature", authorization)
+			}
+		})
+	}
+}
@@ -16,16 +16,16 @@ import (
 
 // CognitoMiddleware handles authentication using AWS Cognito tokens
 type CognitoMiddleware struct {
-	userPoolID  string
-	region      string
-	clientID    string
-	profileRepo account.ProfileRepository
-	jwkCache    jwk.Set
-	cacheTTL    time.Time
+	userPoolID string
+	region     string
+	clientID   string
+	onboarding account.OnboardingService
+	jwkCache   jwk.Set
+	cacheTTL   time.Time
 }
 
 // NewCognitoMiddleware creates a new instance of CognitoMiddleware
-func NewCognitoMiddleware(profileRepo account.ProfileRepository, userPoolID, region, clientID string) (*CognitoMiddleware, error) {
+func NewCognitoMiddleware(onboarding account.OnboardingService, userPoolID, region, clientID string) (*CognitoMiddleware, error) {
 	// Fetch the JWKs from Cognito
 	keySet, err := fetchJWKS(userPoolID, region)
 	if err != nil {
@@ -33,12 +33,12 @@ func NewCognitoMiddleware(profileRepo account.ProfileRepository, userPoolID, reg
 	}
 
 	return &CognitoMiddleware{
-		userPoolID:  userPoolID,
-		region:      region,
-		clientID:    clientID,
-		profileRepo: profileRepo,
-		jwkCache:    keySet,
-		cacheTTL:    time.Now().Add(24 * time.Hour), // Cache JWKs for 24 hours
+		userPoolID: userPoolID,
+		region:     region,
+		clientID:   clientID,
+		onboarding: onboarding,
+		jwkCache:   keySet,
+		cacheTTL:   time.Now().Add(24 * time.Hour), // Cache JWKs for 24 hours
 	}, nil
 }
 
@@ -101,17 +101,21 @@ func (cm *CognitoMiddleware) Authenticate(next http.Handler) http.Handler {
 			return
 		}
 
-		// Get or create user profile
-		profile, err := cm.profileRepo.GetByExternalID(r.Context(), subStr)
+		// Get the user profile, creating it with default settings on the first sign-in
+		hints := account.LocaleHints{
+			Locale:         stringClaim(token, "locale"),
+			Timezone:       stringClaim(token, "zoneinfo"),
+			AcceptLanguage: r.Header.Get("Accept-Language"),
+		}
+		profile, err := cm.onboarding.GetOrCreateProfile(r.Context(), subStr, hints)
 		if err != nil {
-			log.Println("User profile doesn't exist, creating a new one.")
-			profile = &account.Profile{ExternalID: subStr}
-			err = cm.profileRepo.Create(r.Context(), profile)
-			if err != nil {
-				writeErrorResponse(w, http.StatusInternalServerError, "PROFILE_CREATION_ERROR", "Failed to create user profile", nil)
-				return
-			}
-
+			log.Printf("Failed to get or create user profile: %v", err)
+			writeErrorResponse(w, http.StatusInternalServerError, "PROFILE_CREATION_ERROR", "Failed to create user profile", nil)
+			return
+		}
+		if !profile.CanReach(r.URL.Path) {
+			writeErrorResponse(w, http.StatusForbidden, "ACCOUNT_PENDING_DELETION", "Account is deleted and will be purged, restore it to continue", nil)
+			return
 		}
 
 		// Add profile ID to the request context
@@ -119,3 +123,13 @@ func (cm *CognitoMiddleware) Authenticate(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
+
+// stringClaim returns a string claim of the token, empty when it's missing
+func stringClaim(token jwt.Token, name string) string {
+	value, ok := token.Get(name)
+	if !ok {
+		return ""
+	}
+	claim, _ := value.(string)
+	return claim
+}
@@ -0,0 +1,91 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// KeycloakIdentityProvider deletes users through the Keycloak admin API when their profile is
+// purged. It authenticates as a confidential client whose service account has the manage-users
+// role of the realm.
+type KeycloakIdentityProvider struct {
+	client       *http.Client
+	tokenURL     string
+	usersURL     string
+	clientID     string
+	clientSecret string
+}
+
+// NewKeycloakIdentityProvider creates the provider for the realm of the issuer, such as
+// "http://localhost:8080/realms/gym"
+func NewKeycloakIdentityProvider(client *http.Client, issuer, clientID, clientSecret string) (*KeycloakIdentityProvider, error) {
+	issuer = strings.TrimSuffix(issuer, "/")
+	base, realm, found := strings.Cut(issuer, "/realms/")
+	if !found || realm == "" {
+		return nil, fmt.Errorf("issuer %q is not a Keycloak realm", issuer)
+	}
+	return &KeycloakIdentityProvider{
+		client:       client,
+		tokenURL:     issuer + "/protocol/openid-connect/token",
+		usersURL:     base + "/admin/realms/" + realm + "/users/",
+		clientID:     clientID,
+		clientSecret: clientSecret,
+	}, nil
+}
+
+// DeleteIdentity deletes the user, a user that is already gone counts as deleted
+func (p *KeycloakIdentityProvider) DeleteIdentity(ctx context.Context, externalID string) error {
+	token, err := p.accessToken(ctx)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, p.usersURL+url.PathEscape(externalID), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to delete Keycloak user: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode/100 == 2 {
+		return nil
+	}
+	return fmt.Errorf("failed to delete Keycloak user: status %d", resp.StatusCode)
+}
+
+// accessToken gets a token of the service account through the client credentials grant
+func (p *KeycloakIdentityProvider) accessToken(ctx context.Context) (string, error) {
+	form := url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {p.clientID},
+		"client_secret": {p.clientSecret},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to get Keycloak admin token: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to get Keycloak admin token: status %d", resp.StatusCode)
+	}
+	var body struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil || body.AccessToken == "" {
+		return "", fmt.Errorf("failed to read Keycloak admin token: %v", err)
+	}
+	return body.AccessToken, nil
+}
@@ -13,19 +13,19 @@ import (
 )
 
 type KeycloakMiddleware struct {
-	verifier    *oidc.IDTokenVerifier
-	clientID    string
-	profileRepo account.ProfileRepository
+	verifier   *oidc.IDTokenVerifier
+	clientID   string
+	onboarding account.OnboardingService
 }
 
-func NewKeycloakMiddleware(profileRepo account.ProfileRepository, issuer, clientID string) (*KeycloakMiddleware, error) {
+func NewKeycloakMiddleware(onboarding account.OnboardingService, issuer, clientID string) (*KeycloakMiddleware, error) {
 	provider, err := oidc.NewProvider(context.Background(), issuer)
 	if err != nil {
 		return nil, err
 	}
 
 	verifier := provider.Verifier(&oidc.Config{ClientID: clientID})
-	return &KeycloakMiddleware{verifier: verifier, clientID: clientID, profileRepo: profileRepo}, nil
+	return &KeycloakMiddleware{verifier: verifier, clientID: clientID, onboarding: onboarding}, nil
 }
 
 // Helper function to write error responses in JSON format
@@ -58,22 +58,29 @@ func (km *KeycloakMiddleware) Authenticate(next http.Handler) http.Handler {
 		}
 
 		var claims struct {
-			Sub string `json:"sub"`
+			Sub      string `json:"sub"`
+			Locale   string `json:"locale"`
+			Zoneinfo string `json:"zoneinfo"`
 		}
 		if err := idToken.Claims(&claims); err != nil {
 			writeErrorResponse(w, http.StatusInternalServerError, "TOKEN_CLAIMS_ERROR", "Failed to parse claims", nil)
 			return
 		}
 
-		profile, err := km.profileRepo.GetByExternalID(r.Context(), claims.Sub)
+		hints := account.LocaleHints{
+			Locale:         claims.Locale,
+			Timezone:       claims.Zoneinfo,
+			AcceptLanguage: r.Header.Get("Accept-Language"),
+		}
+		profile, err := km.onboarding.GetOrCreateProfile(r.Context(), claims.Sub, hints)
 		if err != nil {
-			log.Println("User profile doesn't exist, creating a new one.")
-			profile = &account.Profile{ExternalID: claims.Sub}
-			err = km.profileRepo.Create(r.Context(), profile)
-			if err != nil {
-				writeErrorResponse(w, http.StatusInternalServerError, "PROFILE_CREATION_ERROR", "Failed to create user profile", nil)
-				return
-			}
+			log.Printf("Failed to get or create user profile: %v", err)
+			writeErrorResponse(w, http.StatusInternalServerError, "PROFILE_CREATION_ERROR", "Failed to create user profile", nil)
+			return
+		}
+		if !profile.CanReach(r.URL.Path) {
+			writeErrorResponse(w, http.StatusForbidden, "ACCOUNT_PENDING_DELETION", "Account is deleted and will be purged, restore it to continue", nil)
+			return
 		}
 
 		ctx := context.WithValue(r.Context(), common.ProfileIDKey, profile.ID)
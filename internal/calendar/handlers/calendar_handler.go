@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	openapi "github.com/VladimirKholomyanskyy/gym-api/internal/api/go"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/calendar/ics"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/calendar/model"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/calendar/usecase"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/common"
+	customerrors "github.com/VladimirKholomyanskyy/gym-api/internal/customErrors"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/utils"
+)
+
+// maxImportBytes bounds the size of an imported calendar
+const maxImportBytes = 1 << 20
+
+type calendarHandler struct {
+	useCase usecase.CalendarUseCase
+	// feedBaseURL is the public address of the API the feed URLs are built on
+	feedBaseURL string
+}
+
+func NewCalendarHandler(useCase usecase.CalendarUseCase, publicURL string) openapi.CalendarAPIServicer {
+	return &calendarHandler{useCase: useCase, feedBaseURL: strings.TrimSuffix(publicURL, "/") + "/api/v1/calendar/"}
+}
+
+// CreateCalendarFeedToken - Create the calendar feed of the profile, replacing its previous token
+func (h *calendarHandler) CreateCalendarFeedToken(ctx context.Context) (openapi.ImplResponse, error) {
+	profileId, err := common.ExtractProfileID(ctx)
+	if err != nil {
+		return utils.ErrorResponse(http.StatusUnauthorized, openapi.FORBIDDEN, err.Error())
+	}
+	token, err := h.useCase.CreateFeedToken(ctx, profileId)
+	if err != nil {
+		return utils.ErrorResponse(http.StatusInternalServerError, openapi.INTERNAL_SERVER_ERROR, "Failed to create calendar feed")
+	}
+	return openapi.Response(http.StatusCreated, openapi.CalendarFeedToken{Token: token, Url: h.feedBaseURL + token + ".ics"}), nil
+}
+
+// RevokeCalendarFeedToken - Close the calendar feed of the profile
+func (h *calendarHandler) RevokeCalendarFeedToken(ctx context.Context) (openapi.ImplResponse, error) {
+	profileId, err := common.ExtractProfileID(ctx)
+	if err != nil {
+		return utils.ErrorResponse(http.StatusUnauthorized, openapi.FORBIDDEN, err.Error())
+	}
+	if err := h.useCase.RevokeFeedToken(ctx, profileId); err != nil {
+		if errors.Is(err, customerrors.ErrEntityNotFound) {
+			return utils.ErrorResponse(http.StatusNotFound, openapi.RESOURCE_NOT_FOUND, "Calendar feed not found")
+		}
+		return utils.ErrorResponse(http.StatusInternalServerError, openapi.INTERNAL_SERVER_ERROR, "Failed to revoke calendar feed")
+	}
+	return openapi.Response(http.StatusNoContent, nil), nil
+}
+
+// ImportCalendar - Import the events of an iCalendar file as scheduled workouts
+func (h *calendarHandler) ImportCalendar(ctx context.Context, request openapi.ImportCalendarRequest) (openapi.ImplResponse, error) {
+	profileId, err := common.ExtractProfileID(ctx)
+	if err != nil {
+		return utils.ErrorResponse(http.StatusUnauthorized, openapi.FORBIDDEN, err.Error())
+	}
+	if request.Content == "" {
+		return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_REQUEST, "content is required")
+	}
+	if len(request.Content) > maxImportBytes {
+		return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_REQUEST, "content must not exceed 1 MB")
+	}
+	if request.WorkoutId != nil && !common.IsUUIDValid(*request.WorkoutId) {
+		return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_ID, "Workout ID is not a valid UUID")
+	}
+
+	result, err := h.useCase.Import(ctx, profileId, strings.NewReader(request.Content), request.WorkoutId)
+	if err != nil {
+		switch {
+		case errors.Is(err, ics.ErrInvalidCalendar), errors.Is(err, customerrors.ErrTooManyCalendarEvents):
+			return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_REQUEST, err.Error())
+		case errors.Is(err, customerrors.ErrAccessForbidden):
+			return utils.ErrorResponse(http.StatusForbidden, openapi.FORBIDDEN, err.Error())
+		}
+		return utils.ErrorResponse(http.StatusInternalServerError, openapi.INTERNAL_SERVER_ERROR, "Failed to import calendar")
+	}
+	return openapi.Response(http.StatusOK, convertImportResult(result)), nil
+}
+
+func convertImportResult(result *model.ImportResult) openapi.ImportCalendarResponse {
+	response := openapi.ImportCalendarResponse{
+		Imported: utils.ConvertScheduledWorkouts(result.Imported),
+		Skipped:  make([]openapi.SkippedCalendarEvent, 0, len(result.Skipped)),
+	}
+	for _, skipped := range result.Skipped {
+		response.Skipped = append(response.Skipped, openapi.SkippedCalendarEvent{Uid: skipped.UID, Summary: skipped.Summary, Reason: skipped.Reason})
+	}
+	return response
+}
@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/VladimirKholomyanskyy/gym-api/internal/calendar/usecase"
+	customerrors "github.com/VladimirKholomyanskyy/gym-api/internal/customErrors"
+	"github.com/gorilla/mux"
+)
+
+// feedHandler serves the calendar feeds. Calendar apps subscribe to a plain URL, so the feed is
+// public and the secret token in its path is the only credential.
+type feedHandler struct {
+	useCase usecase.CalendarUseCase
+}
+
+func NewCalendarFeedHandler(useCase usecase.CalendarUseCase) http.Handler {
+	return &feedHandler{useCase: useCase}
+}
+
+func (h *feedHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	buf := &bytes.Buffer{}
+	err := h.useCase.RenderFeed(r.Context(), mux.Vars(r)["token"], time.Now(), buf)
+	if err != nil {
+		if errors.Is(err, customerrors.ErrEntityNotFound) {
+			http.NotFound(w, r)
+			return
+		}
+		log.Printf("Failed to render calendar feed: %v", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	// The token is a credential, keep the feed out of shared caches
+	w.Header().Set("Cache-Control", "private, max-age=900")
+	w.Write(buf.Bytes())
+}
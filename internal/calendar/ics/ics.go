@@ -0,0 +1,246 @@
+// Package ics reads and writes the parts of iCalendar (RFC 5545) that scheduled workouts need:
+// all-day VEVENTs with a summary, description, UID and an optional recurrence rule.
+package ics
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	dateFormat     = "20060102"
+	dateTimeFormat = "20060102T150405Z"
+	// maxLineOctets is the longest a content line may be before it is folded
+	maxLineOctets = 75
+)
+
+var ErrInvalidCalendar = errors.New("invalid iCalendar content")
+
+// Event is an all-day event
+type Event struct {
+	UID         string
+	Summary     string
+	Description string
+	Date        time.Time
+	// RecurrenceRule is the raw RRULE value, empty for a single event
+	RecurrenceRule string
+	// Properties holds the other properties of a parsed event by name, such as X- extensions
+	Properties map[string]string
+}
+
+// Calendar is a VCALENDAR with its events
+type Calendar struct {
+	Name   string
+	Events []Event
+}
+
+// Encode writes the calendar with CRLF line endings and folded lines
+func Encode(w io.Writer, calendar Calendar, stamp time.Time) error {
+	buf := &bytes.Buffer{}
+	writeLine(buf, "BEGIN:VCALENDAR")
+	writeLine(buf, "VERSION:2.0")
+	writeLine(buf, "PRODID:-//gym-api//scheduled workouts//EN")
+	writeLine(buf, "CALSCALE:GREGORIAN")
+	writeLine(buf, "METHOD:PUBLISH")
+	if calendar.Name != "" {
+		writeLine(buf, "X-WR-CALNAME:"+escape(calendar.Name))
+	}
+	writeLine(buf, "REFRESH-INTERVAL;VALUE=DURATION:PT1H")
+	writeLine(buf, "X-PUBLISHED-TTL:PT1H")
+	for _, event := range calendar.Events {
+		writeLine(buf, "BEGIN:VEVENT")
+		writeLine(buf, "UID:"+escape(event.UID))
+		writeLine(buf, "DTSTAMP:"+stamp.UTC().Format(dateTimeFormat))
+		writeLine(buf, "DTSTART;VALUE=DATE:"+event.Date.Format(dateFormat))
+		writeLine(buf, "DTEND;VALUE=DATE:"+event.Date.AddDate(0, 0, 1).Format(dateFormat))
+		writeLine(buf, "SUMMARY:"+escape(event.Summary))
+		if event.Description != "" {
+			writeLine(buf, "DESCRIPTION:"+escape(event.Description))
+		}
+		if event.RecurrenceRule != "" {
+			writeLine(buf, "RRULE:"+event.RecurrenceRule)
+		}
+		names := make([]string, 0, len(event.Properties))
+		for name := range event.Properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			writeLine(buf, name+":"+escape(event.Properties[name]))
+		}
+		writeLine(buf, "TRANSP:TRANSPARENT")
+		writeLine(buf, "END:VEVENT")
+	}
+	writeLine(buf, "END:VCALENDAR")
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// writeLine writes a content line, folding it into continuation lines of at most maxLineOctets
+// octets without splitting UTF-8 sequences
+func writeLine(buf *bytes.Buffer, line string) {
+	limit := maxLineOctets
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !isRuneStart(line[cut]) {
+			cut--
+		}
+		buf.WriteString(line[:cut])
+		buf.WriteString("\r\n ")
+		line = line[cut:]
+		// The leading space of a continuation line counts towards its length
+		limit = maxLineOctets - 1
+	}
+	buf.WriteString(line)
+	buf.WriteString("\r\n")
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
+
+var escaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+func escape(value string) string {
+	return escaper.Replace(value)
+}
+
+func unescape(value string) string {
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] == '\\' && i+1 < len(value) {
+			i++
+			switch value[i] {
+			case 'n', 'N':
+				b.WriteByte('\n')
+			default:
+				b.WriteByte(value[i])
+			}
+			continue
+		}
+		b.WriteByte(value[i])
+	}
+	return b.String()
+}
+
+// Parse reads the events of a calendar. Events start on the date of their DTSTART; for date
+// times with a TZID the local date is used as written, UTC date times use their UTC date.
+func Parse(r io.Reader) (*Calendar, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+	calendar := &Calendar{}
+	var event *Event
+	inCalendar := false
+	for _, line := range lines {
+		name, params, value, ok := splitLine(line)
+		if !ok {
+			return nil, fmt.Errorf("%w: malformed line %q", ErrInvalidCalendar, line)
+		}
+		switch {
+		case name == "BEGIN" && value == "VCALENDAR":
+			inCalendar = true
+		case name == "END" && value == "VCALENDAR":
+			inCalendar = false
+		case !inCalendar:
+			continue
+		case name == "BEGIN" && value == "VEVENT":
+			event = &Event{Properties: make(map[string]string)}
+		case name == "END" && value == "VEVENT":
+			if event == nil {
+				return nil, fmt.Errorf("%w: END:VEVENT without BEGIN", ErrInvalidCalendar)
+			}
+			if event.Date.IsZero() {
+				return nil, fmt.Errorf("%w: event %q has no DTSTART", ErrInvalidCalendar, event.UID)
+			}
+			calendar.Events = append(calendar.Events, *event)
+			event = nil
+		case event == nil:
+			if name == "X-WR-CALNAME" {
+				calendar.Name = unescape(value)
+			}
+		case name == "UID":
+			event.UID = unescape(value)
+		case name == "SUMMARY":
+			event.Summary = unescape(value)
+		case name == "DESCRIPTION":
+			event.Description = unescape(value)
+		case name == "RRULE":
+			event.RecurrenceRule = value
+		case name == "DTSTART":
+			date, err := parseDate(value, params)
+			if err != nil {
+				return nil, err
+			}
+			event.Date = date
+		default:
+			event.Properties[name] = unescape(value)
+		}
+	}
+	if event != nil {
+		return nil, fmt.Errorf("%w: unterminated VEVENT", ErrInvalidCalendar)
+	}
+	return calendar, nil
+}
+
+// unfold joins continuation lines, which start with a space or tab, to the line before them
+func unfold(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+		if (line[0] == ' ' || line[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCalendar, err)
+	}
+	return lines, nil
+}
+
+// splitLine splits "NAME;PARAM=VALUE:value" into its upper cased name, parameters and value
+func splitLine(line string) (string, map[string]string, string, bool) {
+	head, value, ok := strings.Cut(line, ":")
+	if !ok {
+		return "", nil, "", false
+	}
+	parts := strings.Split(head, ";")
+	params := make(map[string]string, len(parts)-1)
+	for _, param := range parts[1:] {
+		key, val, _ := strings.Cut(param, "=")
+		params[strings.ToUpper(key)] = strings.Trim(val, `"`)
+	}
+	return strings.ToUpper(parts[0]), params, value, true
+}
+
+func parseDate(value string, params map[string]string) (time.Time, error) {
+	if len(value) < len(dateFormat) {
+		return time.Time{}, fmt.Errorf("%w: invalid DTSTART %q", ErrInvalidCalendar, value)
+	}
+	if params["VALUE"] != "DATE" && strings.HasSuffix(value, "Z") {
+		t, err := time.Parse(dateTimeFormat, value)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("%w: invalid DTSTART %q", ErrInvalidCalendar, value)
+		}
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC), nil
+	}
+	date, err := time.Parse(dateFormat, value[:len(dateFormat)])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: invalid DTSTART %q", ErrInvalidCalendar, value)
+	}
+	return date, nil
+}
@@ -0,0 +1,73 @@
+package ics
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEncodeFoldsAndEscapes(t *testing.T) {
+	date := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	calendar := Calendar{Name: "Workouts", Events: []Event{{
+		UID:         "abc@gym-api",
+		Summary:     "Push; heavy, day",
+		Description: strings.Repeat("Bench press — 3×8\n", 10),
+		Date:        date,
+		Properties:  map[string]string{"X-GYM-WORKOUT-ID": "w1"},
+	}}}
+	var buf bytes.Buffer
+	if err := Encode(&buf, calendar, date); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\r\n"), "\r\n") {
+		if len(line) > maxLineOctets {
+			t.Errorf("line longer than %d octets: %q", maxLineOctets, line)
+		}
+	}
+	if !strings.Contains(buf.String(), `SUMMARY:Push\; heavy\, day`) {
+		t.Errorf("summary not escaped:\n%s", buf.String())
+	}
+
+	parsed, err := Parse(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Name != "Workouts" || len(parsed.Events) != 1 {
+		t.Fatalf("Parse = %+v", parsed)
+	}
+	event := parsed.Events[0]
+	if event.UID != "abc@gym-api" || event.Summary != "Push; heavy, day" || !event.Date.Equal(date) {
+		t.Errorf("round trip changed the event: %+v", event)
+	}
+	if event.Description != calendar.Events[0].Description {
+		t.Errorf("description = %q, want %q", event.Description, calendar.Events[0].Description)
+	}
+	if event.Properties["X-GYM-WORKOUT-ID"] != "w1" {
+		t.Errorf("properties = %v", event.Properties)
+	}
+}
+
+func TestParseDates(t *testing.T) {
+	content := "BEGIN:VCALENDAR\r\n" +
+		"BEGIN:VEVENT\r\nUID:1\r\nSUMMARY:Legs\r\nDTSTART;TZID=Europe/Berlin:20250310T073000\r\nRRULE:FREQ=WEEKLY;COUNT=4\r\nEND:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\nUID:2\r\nSUMMARY:Pull\r\nDTSTART:20250311T230000Z\r\nEND:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+	calendar, err := Parse(strings.NewReader(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"2025-03-10", "2025-03-11"}
+	for i, event := range calendar.Events {
+		if got := event.Date.Format(time.DateOnly); got != want[i] {
+			t.Errorf("event %s date = %s, want %s", event.UID, got, want[i])
+		}
+	}
+	if calendar.Events[0].RecurrenceRule != "FREQ=WEEKLY;COUNT=4" {
+		t.Errorf("rrule = %q", calendar.Events[0].RecurrenceRule)
+	}
+
+	if _, err := Parse(strings.NewReader("BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nUID:1\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n")); err == nil {
+		t.Error("event without DTSTART should fail")
+	}
+}
@@ -0,0 +1,26 @@
+package model
+
+import (
+	"github.com/VladimirKholomyanskyy/gym-api/internal/common"
+	trainingmodel "github.com/VladimirKholomyanskyy/gym-api/internal/training/model"
+)
+
+// CalendarFeed gives calendar apps access to the scheduled workouts of a profile. Only the SHA-256
+// hash of the secret token is stored, the token itself is shown once when the feed is created.
+type CalendarFeed struct {
+	common.Base
+	ProfileID string
+	TokenHash string
+}
+
+// SkippedEvent is an event of an imported calendar that didn't become a scheduled workout
+type SkippedEvent struct {
+	UID     string
+	Summary string
+	Reason  string
+}
+
+type ImportResult struct {
+	Imported []trainingmodel.ScheduledWorkout
+	Skipped  []SkippedEvent
+}
@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/VladimirKholomyanskyy/gym-api/internal/calendar/model"
+	customerrors "github.com/VladimirKholomyanskyy/gym-api/internal/customErrors"
+	trainingmodel "github.com/VladimirKholomyanskyy/gym-api/internal/training/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CalendarRepository stores calendar feeds and reads the workouts they describe
+type CalendarRepository interface {
+	SaveFeed(ctx context.Context, feed *model.CalendarFeed) error
+	GetFeedByTokenHash(ctx context.Context, tokenHash string) (*model.CalendarFeed, error)
+	DeleteFeed(ctx context.Context, profileID string) error
+	GetWorkoutsByProfileID(ctx context.Context, profileID string) ([]trainingmodel.Workout, error)
+}
+
+// calendarRepository implements CalendarRepository
+type calendarRepository struct {
+	db *gorm.DB
+}
+
+// NewCalendarRepository creates a new repository instance
+func NewCalendarRepository(db *gorm.DB) CalendarRepository {
+	return &calendarRepository{db: db}
+}
+
+// SaveFeed creates the feed of a profile or replaces the token of its existing one
+func (r *calendarRepository) SaveFeed(ctx context.Context, feed *model.CalendarFeed) error {
+	err := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "profile_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"token_hash", "updated_at"}),
+		}).
+		Create(feed).Error
+	if err != nil {
+		return fmt.Errorf("failed to save calendar feed: %w", err)
+	}
+	return nil
+}
+
+// GetFeedByTokenHash retrieves the feed a token belongs to
+func (r *calendarRepository) GetFeedByTokenHash(ctx context.Context, tokenHash string) (*model.CalendarFeed, error) {
+	var feed model.CalendarFeed
+	err := r.db.WithContext(ctx).
+		// Feeds of deleted accounts stop serving while they wait to be purged
+		Where("NOT EXISTS (SELECT 1 FROM profiles WHERE profiles.id = calendar_feeds.profile_id AND profiles.deleted_at IS NOT NULL)").
+		First(&feed, "token_hash = ?", tokenHash).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, customerrors.ErrEntityNotFound
+		}
+		return nil, fmt.Errorf("failed to fetch calendar feed: %w", err)
+	}
+	return &feed, nil
+}
+
+// DeleteFeed removes the feed of a profile, which revokes its token
+func (r *calendarRepository) DeleteFeed(ctx context.Context, profileID string) error {
+	result := r.db.WithContext(ctx).
+		Where("profile_id = ?", profileID).
+		Delete(&model.CalendarFeed{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete calendar feed: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return customerrors.ErrEntityNotFound
+	}
+	return nil
+}
+
+// GetWorkoutsByProfileID retrieves the workouts of every training program of a profile with their exercises
+func (r *calendarRepository) GetWorkoutsByProfileID(ctx context.Context, profileID string) ([]trainingmodel.Workout, error) {
+	var workouts []trainingmodel.Workout
+	err := r.db.WithContext(ctx).
+		Joins("JOIN training_programs ON training_programs.id = workouts.training_program_id").
+		Where("training_programs.profile_id = ?", profileID).
+		Preload("Exercises", func(db *gorm.DB) *gorm.DB { return db.Order("position ASC") }).
+		Preload("Exercises.Exercise").
+		Order("workouts.position ASC").
+		Find(&workouts).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch workouts of profile: %w", err)
+	}
+	return workouts, nil
+}
@@ -0,0 +1,260 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/VladimirKholomyanskyy/gym-api/internal/account"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/calendar/ics"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/calendar/model"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/calendar/repository"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/common"
+	customerrors "github.com/VladimirKholomyanskyy/gym-api/internal/customErrors"
+	trainingmodel "github.com/VladimirKholomyanskyy/gym-api/internal/training/model"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/training/recurrence"
+	trainingrepository "github.com/VladimirKholomyanskyy/gym-api/internal/training/repository"
+	trainingusecase "github.com/VladimirKholomyanskyy/gym-api/internal/training/usecase"
+)
+
+const (
+	// FeedPastDays and FeedFutureDays bound the occurrences of series that are written to a feed
+	FeedPastDays   = 90
+	FeedFutureDays = 365
+	// MaxFeedEvents caps the events of a feed so a daily series can't blow it up
+	MaxFeedEvents = 1000
+	// MaxImportEvents caps the events of an imported calendar
+	MaxImportEvents = 500
+
+	feedName = "Workouts"
+	// uidDomain makes the UIDs of feed events globally unique and recognisable when imported back
+	uidDomain = "gym-api"
+	// workoutIDProperty carries the workout of an event, so exported events import back to the same workout
+	workoutIDProperty = "X-GYM-WORKOUT-ID"
+	tokenBytes        = 32
+)
+
+// CalendarUseCase publishes the scheduled workouts of a profile as an iCalendar feed and imports
+// calendar events as scheduled workouts. Calendar apps can't send bearer tokens, so the feed is
+// read with a secret token in its URL; creating a token replaces the previous one and revoking
+// it closes the feed.
+type CalendarUseCase interface {
+	CreateFeedToken(ctx context.Context, profileID string) (string, error)
+	RevokeFeedToken(ctx context.Context, profileID string) error
+	RenderFeed(ctx context.Context, token string, now time.Time, w io.Writer) error
+	Import(ctx context.Context, profileID string, r io.Reader, workoutID *string) (*model.ImportResult, error)
+}
+
+type calendarUseCase struct {
+	repo                    repository.CalendarRepository
+	scheduledWorkoutRepo    trainingrepository.ScheduledWorkoutRepository
+	scheduledWorkoutUseCase trainingusecase.ScheduledWorkoutUseCase
+	settingsRepo            account.SettingRepository
+}
+
+func NewCalendarUseCase(repo repository.CalendarRepository, scheduledWorkoutRepo trainingrepository.ScheduledWorkoutRepository, scheduledWorkoutUseCase trainingusecase.ScheduledWorkoutUseCase, settingsRepo account.SettingRepository) CalendarUseCase {
+	return &calendarUseCase{
+		repo:                    repo,
+		scheduledWorkoutRepo:    scheduledWorkoutRepo,
+		scheduledWorkoutUseCase: scheduledWorkoutUseCase,
+		settingsRepo:            settingsRepo,
+	}
+}
+
+// CreateFeedToken generates a new feed token for the profile. Only its hash is stored, so the
+// token is returned once and a lost token has to be replaced.
+func (uc *calendarUseCase) CreateFeedToken(ctx context.Context, profileID string) (string, error) {
+	raw := make([]byte, tokenBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("failed to generate feed token: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+	feed := &model.CalendarFeed{ProfileID: profileID, TokenHash: hashToken(token)}
+	if err := uc.repo.SaveFeed(ctx, feed); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+func (uc *calendarUseCase) RevokeFeedToken(ctx context.Context, profileID string) error {
+	return uc.repo.DeleteFeed(ctx, profileID)
+}
+
+// RenderFeed writes the feed the token belongs to. Series are written as their occurrences around
+// today, so moved and skipped occurrences look the same as in the app.
+func (uc *calendarUseCase) RenderFeed(ctx context.Context, token string, now time.Time, w io.Writer) error {
+	feed, err := uc.repo.GetFeedByTokenHash(ctx, hashToken(token))
+	if err != nil {
+		return err
+	}
+	location, err := uc.location(ctx, feed.ProfileID)
+	if err != nil {
+		return err
+	}
+	today := common.CalendarDate(now, location)
+	occurrences, _, err := uc.scheduledWorkoutRepo.GetAllByProfileIDAndRange(ctx, feed.ProfileID, today.AddDate(0, 0, -FeedPastDays), today.AddDate(0, 0, FeedFutureDays), 1, MaxFeedEvents)
+	if err != nil {
+		return err
+	}
+	workouts, err := uc.workoutsByID(ctx, feed.ProfileID)
+	if err != nil {
+		return err
+	}
+
+	calendar := ics.Calendar{Name: feedName}
+	for _, occurrence := range occurrences {
+		workout, ok := workouts[occurrence.WorkoutID]
+		if !ok {
+			workout = occurrence.Workout
+		}
+		calendar.Events = append(calendar.Events, feedEvent(occurrence, workout))
+	}
+	return ics.Encode(w, calendar, now)
+}
+
+func feedEvent(scheduledWorkout trainingmodel.ScheduledWorkout, workout trainingmodel.Workout) ics.Event {
+	uid := scheduledWorkout.ID
+	if scheduledWorkout.OccurrenceDate != nil {
+		uid += "-" + scheduledWorkout.OccurrenceDate.Format("20060102")
+	}
+	var description []string
+	for _, exercise := range workout.Exercises {
+		description = append(description, fmt.Sprintf("%s — %d × %d", exercise.Exercise.Name, exercise.Sets, exercise.Reps))
+	}
+	if notes := strings.TrimSpace(scheduledWorkout.Notes); notes != "" {
+		if len(description) > 0 {
+			description = append(description, "")
+		}
+		description = append(description, notes)
+	}
+	return ics.Event{
+		UID:         uid + "@" + uidDomain,
+		Summary:     workout.Name,
+		Description: strings.Join(description, "\n"),
+		Date:        scheduledWorkout.Date,
+		Properties:  map[string]string{workoutIDProperty: scheduledWorkout.WorkoutID},
+	}
+}
+
+// Import creates a scheduled workout for every event of the calendar. The workout of an event is
+// the one named by its X-GYM-WORKOUT-ID, else the workout of the profile with the event's summary
+// as name, else the given default. Events exported from the profile's own feed and events without
+// a workout or with an unsupported recurrence are skipped and reported. The rest is created at once.
+func (uc *calendarUseCase) Import(ctx context.Context, profileID string, r io.Reader, workoutID *string) (*model.ImportResult, error) {
+	calendar, err := ics.Parse(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(calendar.Events) > MaxImportEvents {
+		return nil, customerrors.ErrTooManyCalendarEvents
+	}
+	workouts, err := uc.workoutsByID(ctx, profileID)
+	if err != nil {
+		return nil, err
+	}
+	if workoutID != nil {
+		if _, ok := workouts[*workoutID]; !ok {
+			return nil, customerrors.ErrAccessForbidden
+		}
+	}
+	byName := make(map[string][]string)
+	for id, workout := range workouts {
+		name := strings.ToLower(strings.TrimSpace(workout.Name))
+		byName[name] = append(byName[name], id)
+	}
+
+	result := &model.ImportResult{}
+	var inputs []trainingmodel.CreateScheduledWorkoutInput
+	for _, event := range calendar.Events {
+		skip := func(reason string) {
+			result.Skipped = append(result.Skipped, model.SkippedEvent{UID: event.UID, Summary: event.Summary, Reason: reason})
+		}
+		if uc.isOwnEvent(ctx, profileID, event.UID) {
+			skip("event is already scheduled")
+			continue
+		}
+		matched := ""
+		if workout, ok := workouts[event.Properties[workoutIDProperty]]; ok {
+			matched = workout.ID
+		} else if ids := byName[strings.ToLower(strings.TrimSpace(event.Summary))]; len(ids) == 1 {
+			matched = ids[0]
+		} else if workoutID != nil {
+			matched = *workoutID
+		}
+		if matched == "" {
+			skip("no workout matches the event")
+			continue
+		}
+		input := trainingmodel.CreateScheduledWorkoutInput{
+			ProfileID: profileID,
+			WorkoutID: matched,
+			Date:      event.Date,
+			Notes:     event.Description,
+		}
+		if event.RecurrenceRule != "" {
+			rule, err := recurrence.Parse(event.RecurrenceRule)
+			if err == nil {
+				err = rule.Validate(event.Date)
+			}
+			if err != nil {
+				skip(err.Error())
+				continue
+			}
+			input.Recurrence = rule
+		}
+		inputs = append(inputs, input)
+	}
+	// A failed import leaves the schedule as it was, so it can simply be retried.
+	imported, err := uc.scheduledWorkoutUseCase.CreateAll(ctx, inputs)
+	if err != nil {
+		return nil, err
+	}
+	result.Imported = imported
+	return result, nil
+}
+
+// isOwnEvent tells whether the UID is one written by the feed for a scheduled workout the profile still has
+func (uc *calendarUseCase) isOwnEvent(ctx context.Context, profileID, uid string) bool {
+	id, ok := strings.CutSuffix(uid, "@"+uidDomain)
+	if !ok || len(id) < 36 || !common.IsUUIDValid(id[:36]) {
+		return false
+	}
+	scheduledWorkout, err := uc.scheduledWorkoutRepo.GetByID(ctx, id[:36])
+	return err == nil && scheduledWorkout.ProfileID == profileID
+}
+
+func (uc *calendarUseCase) workoutsByID(ctx context.Context, profileID string) (map[string]trainingmodel.Workout, error) {
+	workouts, err := uc.repo.GetWorkoutsByProfileID(ctx, profileID)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]trainingmodel.Workout, len(workouts))
+	for _, workout := range workouts {
+		byID[workout.ID] = workout
+	}
+	return byID, nil
+}
+
+// location returns the time zone of the profile, UTC when it has no settings
+func (uc *calendarUseCase) location(ctx context.Context, profileID string) (*time.Location, error) {
+	settings, err := uc.settingsRepo.GetByProfileID(ctx, profileID)
+	if err != nil {
+		if errors.Is(err, customerrors.ErrEntityNotFound) {
+			return time.UTC, nil
+		}
+		return nil, err
+	}
+	return settings.Location(), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
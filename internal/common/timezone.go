@@ -0,0 +1,54 @@
+package common
+
+import (
+	"context"
+	"time"
+)
+
+// locationKey is the context key of the profile's time zone, set through WithLocation
+type locationKey struct{}
+
+// LoadLocation returns the IANA time zone with the given name, UTC when it is empty or unknown
+func LoadLocation(name string) *time.Location {
+	if name == "" {
+		return time.UTC
+	}
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return time.UTC
+	}
+	return location
+}
+
+// WithLocation stores the time zone of the profile making the request in the context
+func WithLocation(ctx context.Context, location *time.Location) context.Context {
+	return context.WithValue(ctx, locationKey{}, location)
+}
+
+// ExtractLocation returns the time zone of the profile making the request, UTC when it isn't known
+func ExtractLocation(ctx context.Context) *time.Location {
+	location, ok := ctx.Value(locationKey{}).(*time.Location)
+	if !ok || location == nil {
+		return time.UTC
+	}
+	return location
+}
+
+// ParseDate parses a YYYY-MM-DD date as midnight in the given time zone
+func ParseDate(date string, location *time.Location) (time.Time, error) {
+	return time.ParseInLocation(time.DateOnly, date, location)
+}
+
+// StartOfDay returns midnight of the day t falls on in the given time zone. Days are not always
+// 24 hours long, so the next day has to be found with AddDate(0, 0, 1) rather than Add.
+func StartOfDay(t time.Time, location *time.Location) time.Time {
+	local := t.In(location)
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, location)
+}
+
+// CalendarDate returns the date t falls on in the given time zone as midnight UTC, the way
+// values of DATE columns are read and written
+func CalendarDate(t time.Time, location *time.Location) time.Time {
+	local := t.In(location)
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,112 @@
+package common
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func mustLoad(t *testing.T, name string) *time.Location {
+	t.Helper()
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skipf("time zone %s not available: %v", name, err)
+	}
+	return location
+}
+
+func TestLoadLocation(t *testing.T) {
+	if got := LoadLocation(""); got != time.UTC {
+		t.Errorf("LoadLocation(\"\") = %v, want UTC", got)
+	}
+	if got := LoadLocation("Not/AZone"); got != time.UTC {
+		t.Errorf("LoadLocation(unknown) = %v, want UTC", got)
+	}
+	if got := LoadLocation("America/Los_Angeles"); got.String() != "America/Los_Angeles" {
+		t.Errorf("LoadLocation(America/Los_Angeles) = %v", got)
+	}
+}
+
+func TestExtractLocation(t *testing.T) {
+	if got := ExtractLocation(context.Background()); got != time.UTC {
+		t.Errorf("ExtractLocation without location = %v, want UTC", got)
+	}
+	losAngeles := mustLoad(t, "America/Los_Angeles")
+	if got := ExtractLocation(WithLocation(context.Background(), losAngeles)); got != losAngeles {
+		t.Errorf("ExtractLocation = %v, want %v", got, losAngeles)
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	losAngeles := mustLoad(t, "America/Los_Angeles")
+	tests := []struct {
+		date string
+		want string
+	}{
+		{"2024-01-15", "2024-01-15T08:00:00Z"},
+		// Spring forward: midnight is still PST
+		{"2024-03-10", "2024-03-10T08:00:00Z"},
+		{"2024-03-11", "2024-03-11T07:00:00Z"},
+		// Fall back: midnight is still PDT
+		{"2024-11-03", "2024-11-03T07:00:00Z"},
+		{"2024-11-04", "2024-11-04T08:00:00Z"},
+	}
+	for _, tt := range tests {
+		got, err := ParseDate(tt.date, losAngeles)
+		if err != nil {
+			t.Fatalf("ParseDate(%q) error: %v", tt.date, err)
+		}
+		if got.UTC().Format(time.RFC3339) != tt.want {
+			t.Errorf("ParseDate(%q) = %s, want %s", tt.date, got.UTC().Format(time.RFC3339), tt.want)
+		}
+	}
+	if _, err := ParseDate("15/01/2024", losAngeles); err == nil {
+		t.Error("ParseDate accepted an invalid date")
+	}
+}
+
+func TestStartOfDayAcrossDST(t *testing.T) {
+	losAngeles := mustLoad(t, "America/Los_Angeles")
+	tests := []struct {
+		name    string
+		instant string
+		want    string
+		hours   float64 // length of the day
+	}{
+		{"late evening UTC-8 is the previous day", "2024-01-16T05:30:00Z", "2024-01-15T08:00:00Z", 24},
+		{"spring forward day has 23 hours", "2024-03-10T18:00:00Z", "2024-03-10T08:00:00Z", 23},
+		{"fall back day has 25 hours", "2024-11-03T18:00:00Z", "2024-11-03T07:00:00Z", 25},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			instant, _ := time.Parse(time.RFC3339, tt.instant)
+			start := StartOfDay(instant, losAngeles)
+			if start.UTC().Format(time.RFC3339) != tt.want {
+				t.Errorf("StartOfDay = %s, want %s", start.UTC().Format(time.RFC3339), tt.want)
+			}
+			if hours := start.AddDate(0, 0, 1).Sub(start).Hours(); hours != tt.hours {
+				t.Errorf("day length = %v hours, want %v", hours, tt.hours)
+			}
+		})
+	}
+}
+
+func TestCalendarDate(t *testing.T) {
+	losAngeles := mustLoad(t, "America/Los_Angeles")
+	tokyo := mustLoad(t, "Asia/Tokyo")
+	instant, _ := time.Parse(time.RFC3339, "2024-03-10T06:30:00Z")
+	tests := []struct {
+		location *time.Location
+		want     string
+	}{
+		{time.UTC, "2024-03-10"},
+		{losAngeles, "2024-03-09"},
+		{tokyo, "2024-03-10"},
+	}
+	for _, tt := range tests {
+		got := CalendarDate(instant, tt.location)
+		if got.Location() != time.UTC || got.Format(time.DateOnly) != tt.want {
+			t.Errorf("CalendarDate in %v = %v, want %s UTC", tt.location, got, tt.want)
+		}
+	}
+}
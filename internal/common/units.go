@@ -0,0 +1,106 @@
+package common
+
+import (
+	"context"
+	"math"
+)
+
+// Units is the system of units a profile exchanges values in. Values are always stored metric,
+// weights in kg, heights in meters and body measurements in cm, and converted at the API boundary.
+type Units string
+
+const (
+	MetricUnits   Units = "metric"
+	ImperialUnits Units = "imperial"
+)
+
+const (
+	kilogramsPerPound  = 0.45359237
+	centimetersPerInch = 2.54
+)
+
+// unitsKey is the context key of the profile's units, set through WithUnits
+type unitsKey struct{}
+
+// WithUnits stores the units of the profile making the request in the context
+func WithUnits(ctx context.Context, units Units) context.Context {
+	return context.WithValue(ctx, unitsKey{}, units)
+}
+
+// ExtractUnits returns the units of the profile making the request, metric when they aren't known
+func ExtractUnits(ctx context.Context) Units {
+	if units, ok := ctx.Value(unitsKey{}).(Units); ok && units == ImperialUnits {
+		return ImperialUnits
+	}
+	return MetricUnits
+}
+
+// WeightUnit is the symbol of the unit weights are exchanged in
+func (u Units) WeightUnit() string {
+	if u == ImperialUnits {
+		return "lb"
+	}
+	return "kg"
+}
+
+// LengthUnit is the symbol of the unit body measurements are exchanged in
+func (u Units) LengthUnit() string {
+	if u == ImperialUnits {
+		return "in"
+	}
+	return "cm"
+}
+
+// FromKilograms converts a stored weight, or a weight times reps, for a response. Kilograms keep
+// the two decimals they are stored with, pounds are rounded to one, finer than any plate.
+func (u Units) FromKilograms(kg float64) float64 {
+	if u == ImperialUnits {
+		return round(kg/kilogramsPerPound, 1)
+	}
+	return round(kg, 2)
+}
+
+// ToKilograms converts a weight from a request for storage
+func (u Units) ToKilograms(weight float64) float64 {
+	if u == ImperialUnits {
+		return weight * kilogramsPerPound
+	}
+	return weight
+}
+
+// FromCentimeters converts a stored body measurement for a response
+func (u Units) FromCentimeters(cm float64) float64 {
+	if u == ImperialUnits {
+		return round(cm/centimetersPerInch, 1)
+	}
+	return round(cm, 2)
+}
+
+// ToCentimeters converts a body measurement from a request for storage
+func (u Units) ToCentimeters(length float64) float64 {
+	if u == ImperialUnits {
+		return length * centimetersPerInch
+	}
+	return length
+}
+
+// FromMeters converts a stored height for a response, imperial heights are given in inches
+func (u Units) FromMeters(m float64) float64 {
+	if u == ImperialUnits {
+		return round(m*100/centimetersPerInch, 1)
+	}
+	return round(m, 3)
+}
+
+// ToMeters converts a height from a request for storage
+func (u Units) ToMeters(height float64) float64 {
+	if u == ImperialUnits {
+		return height * centimetersPerInch / 100
+	}
+	return height
+}
+
+func round(value float64, decimals int) float64 {
+	scale := math.Pow10(decimals)
+	return math.Round(value*scale) / scale
+}
@@ -0,0 +1,59 @@
+package common
+
+import (
+	"context"
+	"math"
+	"testing"
+)
+
+func TestExtractUnits(t *testing.T) {
+	if got := ExtractUnits(context.Background()); got != MetricUnits {
+		t.Errorf("ExtractUnits() without units = %q, want metric", got)
+	}
+	if got := ExtractUnits(WithUnits(context.Background(), ImperialUnits)); got != ImperialUnits {
+		t.Errorf("ExtractUnits() = %q, want imperial", got)
+	}
+	if got := ExtractUnits(WithUnits(context.Background(), Units("cubits"))); got != MetricUnits {
+		t.Errorf("ExtractUnits() of unknown units = %q, want metric", got)
+	}
+}
+
+func TestUnitsConversion(t *testing.T) {
+	if got := ImperialUnits.FromKilograms(100); got != 220.5 {
+		t.Errorf("FromKilograms(100) = %v lb, want 220.5", got)
+	}
+	if got := MetricUnits.FromKilograms(102.456); got != 102.46 {
+		t.Errorf("FromKilograms(102.456) = %v kg, want 102.46", got)
+	}
+	if got := ImperialUnits.FromMeters(1.8); got != 70.9 {
+		t.Errorf("FromMeters(1.8) = %v in, want 70.9", got)
+	}
+	if got := ImperialUnits.FromCentimeters(81.28); got != 32 {
+		t.Errorf("FromCentimeters(81.28) = %v in, want 32", got)
+	}
+
+	// Values entered in pounds and inches survive the round trip through storage, which keeps two
+	// decimals of kg and cm and four of meters
+	stored := func(value float64, decimals int) float64 {
+		scale := math.Pow10(decimals)
+		return math.Round(value*scale) / scale
+	}
+	for _, lb := range []float64{2.5, 45, 137.5, 225, 405.5, 999.9} {
+		if got := ImperialUnits.FromKilograms(stored(ImperialUnits.ToKilograms(lb), 2)); got != lb {
+			t.Errorf("%v lb came back as %v", lb, got)
+		}
+	}
+	for _, in := range []float64{12.5, 32, 48.3} {
+		if got := ImperialUnits.FromCentimeters(stored(ImperialUnits.ToCentimeters(in), 2)); got != in {
+			t.Errorf("%v in came back as %v", in, got)
+		}
+	}
+	for _, in := range []float64{59, 70, 70.5, 83.1} {
+		if got := ImperialUnits.FromMeters(stored(ImperialUnits.ToMeters(in), 4)); got != in {
+			t.Errorf("%v in came back as %v", in, got)
+		}
+	}
+	if got := MetricUnits.ToKilograms(80); got != 80 {
+		t.Errorf("metric ToKilograms(80) = %v", got)
+	}
+}
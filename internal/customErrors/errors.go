@@ -10,6 +10,34 @@ var (
 	ErrUnauthorized    = errors.New("invalid or missing profile ID")
 	ErrInvalidUUID     = errors.New("invalid UUID format")
 	ErrEntityNotFound  = errors.New("requested entity not found")
+
+	ErrSessionCompleted   = errors.New("workout session is already completed")
+	ErrSessionNotEditable = errors.New("workout session can no longer be edited")
+
+	ErrExerciseNotInWorkout = errors.New("exercise is not part of the workout")
+	ErrInvalidSetNumber     = errors.New("set number exceeds the sets planned for the exercise")
+	ErrDuplicateSet         = errors.New("set is already logged for the exercise in this session")
+	ErrWeightTooHigh        = errors.New("weight exceeds the maximum of 999.99 kg")
+
+	ErrSyncItemDeleted      = errors.New("item was deleted on the server")
+	ErrSyncWorkoutMismatch  = errors.New("workout session exists for a different workout")
+	ErrCompletedBeforeStart = errors.New("workout session can't be completed before it started")
+
+	ErrNotRecurring    = errors.New("scheduled workout does not repeat")
+	ErrNotAnOccurrence = errors.New("date is not an occurrence of the scheduled workout")
+
+	ErrProgramHasNoWorkouts = errors.New("training program has no workouts")
+
+	ErrTooManyCalendarEvents = errors.New("calendar has too many events to import")
+
+	ErrTooManyChannels  = errors.New("profile has too many notification channels")
+	ErrDuplicateChannel = errors.New("notification channel is already registered")
+
+	ErrInvalidImage = errors.New("file is not a supported JPEG or PNG image")
+
+	ErrImportCompleted   = errors.New("workout import is already completed")
+	ErrUnmappedExercises = errors.New("workout import has exercise names that are not mapped")
+	ErrEmptyImport       = errors.New("workout export has no sets to import")
 )
 
 type ErrInvalidPosition struct {
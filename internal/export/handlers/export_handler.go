@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	openapi "github.com/VladimirKholomyanskyy/gym-api/internal/api/go"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/common"
+	customerrors "github.com/VladimirKholomyanskyy/gym-api/internal/customErrors"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/export/model"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/export/usecase"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/utils"
+)
+
+type exportHandler struct {
+	useCase usecase.ExportUseCase
+}
+
+func NewExportHandler(useCase usecase.ExportUseCase) openapi.DataExportsAPIServicer {
+	return &exportHandler{useCase: useCase}
+}
+
+// RequestDataExport - Request a copy of the data of the profile, generated in the background
+func (h *exportHandler) RequestDataExport(ctx context.Context) (openapi.ImplResponse, error) {
+	profileId, err := common.ExtractProfileID(ctx)
+	if err != nil {
+		return utils.ErrorResponse(http.StatusUnauthorized, openapi.UNAUTHORIZED, err.Error())
+	}
+	export, err := h.useCase.Request(ctx, profileId)
+	if err != nil {
+		log.Printf("Failed to request data export: %v", err)
+		return utils.ErrorResponse(http.StatusInternalServerError, openapi.INTERNAL_SERVER_ERROR, "Failed to request data export")
+	}
+	return openapi.Response(http.StatusAccepted, h.convertExport(export)), nil
+}
+
+// GetDataExport - Retrieve the state of a data export, with its download URL once it is completed
+func (h *exportHandler) GetDataExport(ctx context.Context, id string) (openapi.ImplResponse, error) {
+	profileId, err := common.ExtractProfileID(ctx)
+	if err != nil {
+		return utils.ErrorResponse(http.StatusUnauthorized, openapi.UNAUTHORIZED, err.Error())
+	}
+	if !common.IsUUIDValid(id) {
+		return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_ID, "Export ID is not a valid UUID")
+	}
+	export, err := h.useCase.GetByID(ctx, profileId, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, customerrors.ErrEntityNotFound):
+			return utils.ErrorResponse(http.StatusNotFound, openapi.RESOURCE_NOT_FOUND, "Data export not found")
+		case errors.Is(err, customerrors.ErrAccessForbidden):
+			return utils.ErrorResponse(http.StatusForbidden, openapi.FORBIDDEN, "Access to data export forbidden")
+		default:
+			return utils.ErrorResponse(http.StatusInternalServerError, openapi.INTERNAL_SERVER_ERROR, "Failed to fetch data export")
+		}
+	}
+	return openapi.Response(http.StatusOK, h.convertExport(export)), nil
+}
+
+// convertExport leaves out why an export failed, the error is kept for the operators
+func (h *exportHandler) convertExport(export *model.Export) openapi.DataExport {
+	response := openapi.DataExport{
+		Id:          export.ID,
+		Status:      string(export.Status),
+		CreatedAt:   export.CreatedAt,
+		CompletedAt: export.CompletedAt,
+		ExpiresAt:   export.ExpiresAt,
+		SizeBytes:   export.SizeBytes,
+	}
+	if url, expires, ok := h.useCase.DownloadURL(export, time.Now()); ok {
+		response.DownloadUrl = url
+		response.DownloadUrlExpiresAt = &expires
+	}
+	return response
+}
@@ -0,0 +1,57 @@
+package model
+
+import (
+	"time"
+
+	"github.com/VladimirKholomyanskyy/gym-api/internal/common"
+)
+
+type Status string
+
+const (
+	StatusPending   Status = "pending"
+	StatusRunning   Status = "running"
+	StatusCompleted Status = "completed"
+	StatusFailed    Status = "failed"
+	// StatusExpired exports were completed, their archive has been deleted since
+	StatusExpired Status = "expired"
+)
+
+// Export is a request of a profile for a copy of its data. The archive is generated in the
+// background and kept in the file storage under the storage key until the export expires.
+type Export struct {
+	common.Base
+	ProfileID   string
+	Status      Status
+	StorageKey  *string
+	SizeBytes   *int64
+	Error       *string
+	StartedAt   *time.Time
+	CompletedAt *time.Time
+	ExpiresAt   *time.Time
+}
+
+func (Export) TableName() string {
+	return "data_exports"
+}
+
+// InProgress reports whether the archive of the export is still to be generated
+func (e *Export) InProgress() bool {
+	return e.Status == StatusPending || e.Status == StatusRunning
+}
+
+// TableScanner reads a table of the data of a profile row by row, so a table is never held in
+// memory. Every Scan runs the query of the table again, calling header with the columns and then
+// row for every row. Values are nil, a string, bool, int64, float64, time.Time, a json.Number for
+// decimals or a json.RawMessage for JSON columns, dates are strings such as "2024-03-01". The
+// values slice is reused between rows.
+type TableScanner interface {
+	Name() string
+	Scan(header func(columns []string) error, row func(values []any) error) error
+}
+
+// File is a stored file of a profile that is copied into the archive under the name
+type File struct {
+	Key  string
+	Name string
+}
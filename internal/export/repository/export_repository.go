@@ -0,0 +1,290 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"path"
+	"time"
+
+	customerrors "github.com/VladimirKholomyanskyy/gym-api/internal/customErrors"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/export/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// claimLease is how long a claimed export is hidden from other workers. An export whose worker
+// died while generating it is picked up again once the lease runs out.
+const claimLease = 30 * time.Minute
+
+// profileTables are the queries of the data of a profile, in the order they are exported. Every
+// query gets the profile ID as @profile. Exercises are shared by all profiles, their names are
+// joined into the rows referencing them. Calendar feed tokens and notification channels are
+// credentials rather than data and are left out.
+var profileTables = []struct {
+	name  string
+	query string
+}{
+	{"profile", `SELECT * FROM profiles WHERE id = @profile`},
+	{"settings", `SELECT * FROM settings WHERE profile_id = @profile`},
+	{"training_programs", `SELECT * FROM training_programs WHERE profile_id = @profile ORDER BY created_at`},
+	{"workouts", `SELECT w.* FROM workouts w
+		JOIN training_programs tp ON tp.id = w.training_program_id
+		WHERE tp.profile_id = @profile ORDER BY w.training_program_id, w.position`},
+	{"workout_exercises", `SELECT we.*, e.name AS exercise_name FROM workout_exercises we
+		JOIN workouts w ON w.id = we.workout_id
+		JOIN training_programs tp ON tp.id = w.training_program_id
+		JOIN exercises e ON e.id = we.exercise_id
+		WHERE tp.profile_id = @profile ORDER BY we.workout_id, we.position`},
+	{"program_schedules", `SELECT * FROM program_schedules WHERE profile_id = @profile ORDER BY created_at`},
+	{"scheduled_workouts", `SELECT * FROM scheduled_workouts WHERE profile_id = @profile ORDER BY date, created_at`},
+	{"scheduled_workout_overrides", `SELECT o.* FROM scheduled_workout_overrides o
+		JOIN scheduled_workouts sw ON sw.id = o.scheduled_workout_id
+		WHERE sw.profile_id = @profile ORDER BY o.scheduled_workout_id, o.occurrence_date`},
+	{"workout_sessions", `SELECT * FROM workout_sessions WHERE profile_id = @profile ORDER BY started_at`},
+	{"exercise_logs", `SELECT el.*, e.name AS exercise_name FROM exercise_logs el
+		JOIN exercises e ON e.id = el.exercise_id
+		WHERE el.profile_id = @profile ORDER BY el.logged_at, el.set_number`},
+	{"personal_records", `SELECT pr.*, e.name AS exercise_name FROM personal_records pr
+		JOIN exercises e ON e.id = pr.exercise_id
+		WHERE pr.profile_id = @profile ORDER BY pr.achieved_at`},
+	{"body_measurements", `SELECT * FROM body_measurements WHERE profile_id = @profile ORDER BY measured_at`},
+	{"progress_photos", `SELECT * FROM progress_photos WHERE profile_id = @profile ORDER BY taken_on, created_at`},
+	{"workout_imports", `SELECT * FROM workout_imports WHERE profile_id = @profile ORDER BY created_at`},
+}
+
+// ExportRepository stores data export requests and reads the data of a profile to be exported
+type ExportRepository interface {
+	Create(ctx context.Context, export *model.Export) error
+	GetByID(ctx context.Context, id string) (*model.Export, error)
+	GetInProgressByProfileID(ctx context.Context, profileID string) (*model.Export, error)
+	ClaimPending(ctx context.Context, now time.Time, limit int) ([]model.Export, error)
+	GetExpired(ctx context.Context, now time.Time) ([]model.Export, error)
+	UpdatePartial(ctx context.Context, id string, updates map[string]any) error
+	ScanTables(ctx context.Context, profileID string, visit func(table model.TableScanner) error) error
+	GetPhotoFiles(ctx context.Context, profileID string) ([]model.File, error)
+}
+
+// exportRepository implements ExportRepository
+type exportRepository struct {
+	db *gorm.DB
+}
+
+// NewExportRepository creates a new repository instance
+func NewExportRepository(db *gorm.DB) ExportRepository {
+	return &exportRepository{db: db}
+}
+
+func (r *exportRepository) Create(ctx context.Context, export *model.Export) error {
+	if err := r.db.WithContext(ctx).Create(export).Error; err != nil {
+		return fmt.Errorf("failed to create data export: %w", err)
+	}
+	return nil
+}
+
+func (r *exportRepository) GetByID(ctx context.Context, id string) (*model.Export, error) {
+	var export model.Export
+	err := r.db.WithContext(ctx).First(&export, "id = ?", id).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, customerrors.ErrEntityNotFound
+		}
+		return nil, fmt.Errorf("failed to fetch data export by id: %w", err)
+	}
+	return &export, nil
+}
+
+// GetInProgressByProfileID retrieves the export of a profile that is still to be generated
+func (r *exportRepository) GetInProgressByProfileID(ctx context.Context, profileID string) (*model.Export, error) {
+	var export model.Export
+	err := r.db.WithContext(ctx).
+		Where("profile_id = ? AND status IN ?", profileID, []model.Status{model.StatusPending, model.StatusRunning}).
+		Order("created_at DESC").
+		First(&export).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, customerrors.ErrEntityNotFound
+		}
+		return nil, fmt.Errorf("failed to fetch data export in progress: %w", err)
+	}
+	return &export, nil
+}
+
+// ClaimPending locks pending exports, and running ones whose lease ran out, and marks them running,
+// so concurrent workers never generate the same export
+func (r *exportRepository) ClaimPending(ctx context.Context, now time.Time, limit int) ([]model.Export, error) {
+	var exports []model.Export
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var ids []string
+		err := tx.Model(&model.Export{}).
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? OR (status = ? AND started_at <= ?)", model.StatusPending, model.StatusRunning, now.Add(-claimLease)).
+			Order("created_at ASC").
+			Limit(limit).
+			Pluck("id", &ids).Error
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+		err = tx.Model(&model.Export{}).
+			Where("id IN ?", ids).
+			Updates(map[string]any{"status": model.StatusRunning, "started_at": now}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Where("id IN ?", ids).Order("created_at ASC").Find(&exports).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim data exports: %w", err)
+	}
+	return exports, nil
+}
+
+// GetExpired retrieves completed exports whose archive is past its expiry
+func (r *exportRepository) GetExpired(ctx context.Context, now time.Time) ([]model.Export, error) {
+	var exports []model.Export
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND expires_at <= ?", model.StatusCompleted, now).
+		Find(&exports).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch expired data exports: %w", err)
+	}
+	return exports, nil
+}
+
+func (r *exportRepository) UpdatePartial(ctx context.Context, id string, updates map[string]any) error {
+	result := r.db.WithContext(ctx).Model(&model.Export{}).Where("id = ?", id).Updates(updates)
+	if result.Error != nil {
+		return fmt.Errorf("failed to update data export: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return customerrors.ErrEntityNotFound
+	}
+	return nil
+}
+
+// ScanTables passes the tables of the data of a profile to visit one by one, in one snapshot so
+// rows referencing each other are consistent however often a table is scanned. All columns are
+// read, so columns added to the tables later are exported without changes here.
+func (r *exportRepository) ScanTables(ctx context.Context, profileID string, visit func(table model.TableScanner) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, source := range profileTables {
+			if err := visit(&tableScanner{tx: tx, name: source.name, query: source.query, profileID: profileID}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+}
+
+// tableScanner implements model.TableScanner with the query of a table in the export transaction
+type tableScanner struct {
+	tx        *gorm.DB
+	name      string
+	query     string
+	profileID string
+}
+
+func (s *tableScanner) Name() string {
+	return s.name
+}
+
+func (s *tableScanner) Scan(header func(columns []string) error, row func(values []any) error) error {
+	rows, err := s.tx.Raw(s.query, map[string]any{"profile": s.profileID}).Rows()
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", s.name, err)
+	}
+	defer rows.Close()
+
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return fmt.Errorf("failed to read columns of %s: %w", s.name, err)
+	}
+	columns := make([]string, len(columnTypes))
+	for i, column := range columnTypes {
+		columns[i] = column.Name()
+	}
+	if err := header(columns); err != nil {
+		return err
+	}
+	values := make([]any, len(columnTypes))
+	pointers := make([]any, len(columnTypes))
+	for rows.Next() {
+		for i := range values {
+			values[i] = nil
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return fmt.Errorf("failed to read row of %s: %w", s.name, err)
+		}
+		for i, column := range columnTypes {
+			values[i] = normalizeValue(values[i], column.DatabaseTypeName())
+		}
+		if err := row(values); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read %s: %w", s.name, err)
+	}
+	return nil
+}
+
+// normalizeValue turns a value as the driver returns it into one of the types of model.TableScanner
+func normalizeValue(value any, databaseType string) any {
+	switch v := value.(type) {
+	case nil, bool, int64, float64:
+		return v
+	case time.Time:
+		if databaseType == "DATE" {
+			return v.Format(time.DateOnly)
+		}
+		return v
+	case int32:
+		return int64(v)
+	case int16:
+		return int64(v)
+	case float32:
+		return float64(v)
+	case []byte:
+		return normalizeValue(string(v), databaseType)
+	case string:
+		switch databaseType {
+		case "NUMERIC":
+			return json.Number(v)
+		case "JSON", "JSONB":
+			if json.Valid([]byte(v)) {
+				return json.RawMessage(v)
+			}
+		}
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// GetPhotoFiles lists the images of the progress photos of a profile, named by date and ID
+func (r *exportRepository) GetPhotoFiles(ctx context.Context, profileID string) ([]model.File, error) {
+	var photos []struct {
+		ID         string
+		TakenOn    time.Time
+		StorageKey string
+	}
+	err := r.db.WithContext(ctx).
+		Table("progress_photos").
+		Select("id, taken_on, storage_key").
+		Where("profile_id = ?", profileID).
+		Order("taken_on, created_at").
+		Find(&photos).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch progress photo files: %w", err)
+	}
+	files := make([]model.File, len(photos))
+	for i, photo := range photos {
+		files[i] = model.File{
+			Key:  photo.StorageKey,
+			Name: fmt.Sprintf("%s-%s%s", photo.TakenOn.Format(time.DateOnly), photo.ID, path.Ext(photo.StorageKey)),
+		}
+	}
+	return files, nil
+}
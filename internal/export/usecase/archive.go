@@ -0,0 +1,201 @@
+package usecase
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/VladimirKholomyanskyy/gym-api/internal/export/model"
+)
+
+// manifest describes the content of an archive in manifest.json
+type manifest struct {
+	ProfileID   string          `json:"profileId"`
+	GeneratedAt time.Time       `json:"generatedAt"`
+	Tables      []manifestTable `json:"tables"`
+	Photos      int             `json:"photos"`
+}
+
+type manifestTable struct {
+	Name string `json:"name"`
+	Rows int    `json:"rows"`
+}
+
+// writeArchive writes the data of a profile as a ZIP archive. Every table is written twice, as
+// json/<table>.json, an array of objects with the columns as keys, and as csv/<table>.csv with
+// a header row. Tables are scanned once for each file, row by row. The stored files are copied
+// into photos/ as they are, opened one at a time. The manifest comes last, when the rows are counted.
+func writeArchive(w io.Writer, profileID string, generatedAt time.Time, scanTables func(visit func(table model.TableScanner) error) error, files []model.File, open func(key string) (io.ReadCloser, error)) error {
+	archive := zip.NewWriter(w)
+	contents := manifest{ProfileID: profileID, GeneratedAt: generatedAt.UTC(), Photos: len(files)}
+	err := scanTables(func(table model.TableScanner) error {
+		rows, err := writeTableJSON(archive, table, generatedAt)
+		if err != nil {
+			return err
+		}
+		if err := writeTableCSV(archive, table, generatedAt); err != nil {
+			return err
+		}
+		contents.Tables = append(contents.Tables, manifestTable{Name: table.Name(), Rows: rows})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		content, err := open(file.Key)
+		if err != nil {
+			return fmt.Errorf("failed to open %s: %w", file.Key, err)
+		}
+		err = writeEntry(archive, "photos/"+file.Name, generatedAt, content)
+		content.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	manifestJSON, err := json.MarshalIndent(contents, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeEntry(archive, "manifest.json", generatedAt, bytes.NewReader(manifestJSON)); err != nil {
+		return err
+	}
+	return archive.Close()
+}
+
+func createEntry(archive *zip.Writer, name string, modified time.Time) (io.Writer, error) {
+	entry, err := archive.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: modified})
+	if err != nil {
+		return nil, fmt.Errorf("failed to add %s to the archive: %w", name, err)
+	}
+	return entry, nil
+}
+
+func writeEntry(archive *zip.Writer, name string, modified time.Time, content io.Reader) error {
+	entry, err := createEntry(archive, name, modified)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(entry, content); err != nil {
+		return fmt.Errorf("failed to write %s to the archive: %w", name, err)
+	}
+	return nil
+}
+
+// writeTableJSON encodes the rows as objects, keeping the keys in the order of the columns, and
+// returns how many rows were written
+func writeTableJSON(archive *zip.Writer, table model.TableScanner, modified time.Time) (int, error) {
+	name := "json/" + table.Name() + ".json"
+	entry, err := createEntry(archive, name, modified)
+	if err != nil {
+		return 0, err
+	}
+	// Write errors stick to the buffer and are returned by Flush
+	buf := bufio.NewWriter(entry)
+	var keys [][]byte
+	rows := 0
+	header := func(columns []string) error {
+		keys = make([][]byte, len(columns))
+		for i, column := range columns {
+			key, err := json.Marshal(column)
+			if err != nil {
+				return err
+			}
+			keys[i] = key
+		}
+		buf.WriteString("[")
+		return nil
+	}
+	row := func(values []any) error {
+		if rows > 0 {
+			buf.WriteString(",")
+		}
+		buf.WriteString("\n  {")
+		for i, key := range keys {
+			if i > 0 {
+				buf.WriteString(", ")
+			}
+			value, err := json.Marshal(values[i])
+			if err != nil {
+				return fmt.Errorf("failed to encode %s: %w", table.Name(), err)
+			}
+			buf.Write(key)
+			buf.WriteString(": ")
+			buf.Write(value)
+		}
+		buf.WriteString("}")
+		rows++
+		return nil
+	}
+	if err := table.Scan(header, row); err != nil {
+		return 0, err
+	}
+	if rows > 0 {
+		buf.WriteString("\n")
+	}
+	buf.WriteString("]\n")
+	if err := buf.Flush(); err != nil {
+		return 0, fmt.Errorf("failed to write %s to the archive: %w", name, err)
+	}
+	return rows, nil
+}
+
+func writeTableCSV(archive *zip.Writer, table model.TableScanner, modified time.Time) error {
+	name := "csv/" + table.Name() + ".csv"
+	entry, err := createEntry(archive, name, modified)
+	if err != nil {
+		return err
+	}
+	writer := csv.NewWriter(entry)
+	var record []string
+	header := func(columns []string) error {
+		record = make([]string, len(columns))
+		return writer.Write(columns)
+	}
+	row := func(values []any) error {
+		for i, value := range values {
+			record[i] = csvValue(value)
+		}
+		return writer.Write(record)
+	}
+	if err := table.Scan(header, row); err != nil {
+		return err
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("failed to write %s to the archive: %w", name, err)
+	}
+	return nil
+}
+
+// csvValue formats a value of a table for a CSV cell, NULL is an empty cell
+func csvValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case time.Time:
+		return v.UTC().Format(time.RFC3339)
+	case json.Number:
+		return v.String()
+	case json.RawMessage:
+		return string(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,121 @@
+package usecase
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/VladimirKholomyanskyy/gym-api/internal/export/model"
+)
+
+// memoryTable is a table held in memory, scanned like a query
+type memoryTable struct {
+	name    string
+	columns []string
+	rows    [][]any
+	scans   int
+}
+
+func (m *memoryTable) Name() string {
+	return m.name
+}
+
+func (m *memoryTable) Scan(header func(columns []string) error, row func(values []any) error) error {
+	m.scans++
+	if err := header(m.columns); err != nil {
+		return err
+	}
+	for _, values := range m.rows {
+		if err := row(values); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestWriteArchive(t *testing.T) {
+	generatedAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	tables := []*memoryTable{
+		{name: "profile", columns: []string{"id", "weight", "birthday"}, rows: [][]any{{"p1", json.Number("82.50"), nil}}},
+		{name: "workout_sessions", columns: []string{"id", "snapshot", "started_at"}, rows: [][]any{
+			{"s1", json.RawMessage(`{"name":"Push, heavy"}`), generatedAt},
+		}},
+		{name: "body_measurements", columns: []string{"id"}},
+	}
+	scanTables := func(visit func(table model.TableScanner) error) error {
+		for _, table := range tables {
+			if err := visit(table); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	files := []model.File{{Key: "progress-photos/p1/a.jpg", Name: "2024-03-01-ph1.jpg"}}
+	open := func(key string) (io.ReadCloser, error) {
+		return io.NopCloser(strings.NewReader("image of " + key)), nil
+	}
+
+	var buf bytes.Buffer
+	if err := writeArchive(&buf, "p1", generatedAt, scanTables, files, open); err != nil {
+		t.Fatalf("writeArchive() error = %v", err)
+	}
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("archive is not a ZIP: %v", err)
+	}
+	entries := make(map[string]string)
+	for _, file := range archive.File {
+		content, err := file.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(content)
+		content.Close()
+		entries[file.Name] = string(data)
+	}
+
+	var manifest manifest
+	if err := json.Unmarshal([]byte(entries["manifest.json"]), &manifest); err != nil {
+		t.Fatalf("manifest.json: %v", err)
+	}
+	if manifest.ProfileID != "p1" || len(manifest.Tables) != 3 || manifest.Photos != 1 {
+		t.Fatalf("manifest = %+v", manifest)
+	}
+	if manifest.Tables[1].Name != "workout_sessions" || manifest.Tables[1].Rows != 1 || manifest.Tables[2].Rows != 0 {
+		t.Errorf("manifest tables = %+v", manifest.Tables)
+	}
+	for _, table := range tables {
+		if table.scans != 2 {
+			t.Errorf("%s scanned %d times, want once for each file", table.name, table.scans)
+		}
+	}
+
+	var sessions []map[string]any
+	if err := json.Unmarshal([]byte(entries["json/workout_sessions.json"]), &sessions); err != nil {
+		t.Fatalf("json/workout_sessions.json: %v", err)
+	}
+	if snapshot, ok := sessions[0]["snapshot"].(map[string]any); !ok || snapshot["name"] != "Push, heavy" {
+		t.Errorf("snapshot = %v, want the JSON object", sessions[0]["snapshot"])
+	}
+	if got, want := entries["json/profile.json"], "[\n  {\"id\": \"p1\", \"weight\": 82.50, \"birthday\": null}\n]\n"; got != want {
+		t.Errorf("json/profile.json = %q, want %q", got, want)
+	}
+	if got, want := entries["json/body_measurements.json"], "[]\n"; got != want {
+		t.Errorf("json/body_measurements.json = %q, want %q", got, want)
+	}
+
+	wantCSV := "id,snapshot,started_at\ns1,\"{\"\"name\"\":\"\"Push, heavy\"\"}\",2024-03-01T12:00:00Z\n"
+	if got := entries["csv/workout_sessions.csv"]; got != wantCSV {
+		t.Errorf("csv/workout_sessions.csv = %q, want %q", got, wantCSV)
+	}
+	if got := entries["csv/profile.csv"]; got != "id,weight,birthday\np1,82.50,\n" {
+		t.Errorf("csv/profile.csv = %q", got)
+	}
+	if got := entries["photos/2024-03-01-ph1.jpg"]; got != "image of progress-photos/p1/a.jpg" {
+		t.Errorf("photo = %q", got)
+	}
+}
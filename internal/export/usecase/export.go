@@ -0,0 +1,178 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	customerrors "github.com/VladimirKholomyanskyy/gym-api/internal/customErrors"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/export/model"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/export/repository"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/storage"
+)
+
+const (
+	// ArchiveLifetime is how long a generated archive is kept for download
+	ArchiveLifetime = 7 * 24 * time.Hour
+	// URLLifetime is how long the signed download URL of an archive stays valid
+	URLLifetime = 15 * time.Minute
+	// StoragePrefix is where archives are kept in the storage, below a directory per profile
+	StoragePrefix = "exports/"
+	// generateBatchSize bounds how many exports a single run generates
+	generateBatchSize = 5
+)
+
+// ExportUseCase gives profiles a copy of their data. A requested export is generated in the
+// background into a ZIP archive in the file storage, which is downloaded through a signed URL
+// until it expires.
+type ExportUseCase interface {
+	Request(ctx context.Context, profileID string) (*model.Export, error)
+	GetByID(ctx context.Context, profileID, exportID string) (*model.Export, error)
+	DownloadURL(export *model.Export, now time.Time) (string, time.Time, bool)
+	Generate(ctx context.Context, now time.Time) error
+	Expire(ctx context.Context, now time.Time) error
+	Write(ctx context.Context, profileID string, w io.Writer, now time.Time) error
+}
+
+type exportUseCase struct {
+	repo    repository.ExportRepository
+	storage storage.Storage
+	signer  *storage.URLSigner
+}
+
+func NewExportUseCase(repo repository.ExportRepository, storage storage.Storage, signer *storage.URLSigner) ExportUseCase {
+	return &exportUseCase{repo: repo, storage: storage, signer: signer}
+}
+
+// Request queues an export of the data of the profile. While an export of the profile is still
+// to be generated it is returned instead of queueing another one.
+func (uc *exportUseCase) Request(ctx context.Context, profileID string) (*model.Export, error) {
+	export, err := uc.repo.GetInProgressByProfileID(ctx, profileID)
+	if err == nil {
+		return export, nil
+	}
+	if !errors.Is(err, customerrors.ErrEntityNotFound) {
+		return nil, err
+	}
+	export = &model.Export{ProfileID: profileID, Status: model.StatusPending}
+	if err := uc.repo.Create(ctx, export); err != nil {
+		return nil, err
+	}
+	return export, nil
+}
+
+// GetByID retrieves an export ensuring it belongs to the profile
+func (uc *exportUseCase) GetByID(ctx context.Context, profileID, exportID string) (*model.Export, error) {
+	export, err := uc.repo.GetByID(ctx, exportID)
+	if err != nil {
+		return nil, err
+	}
+	if export.ProfileID != profileID {
+		return nil, customerrors.ErrAccessForbidden
+	}
+	return export, nil
+}
+
+// DownloadURL signs the URL of the archive of a completed export. The URL never outlives the archive.
+func (uc *exportUseCase) DownloadURL(export *model.Export, now time.Time) (string, time.Time, bool) {
+	if export.Status != model.StatusCompleted || export.StorageKey == nil {
+		return "", time.Time{}, false
+	}
+	expires := now.Add(URLLifetime)
+	if export.ExpiresAt != nil && export.ExpiresAt.Before(expires) {
+		expires = *export.ExpiresAt
+	}
+	return uc.signer.URL(*export.StorageKey, expires), expires, true
+}
+
+// Generate claims pending exports and generates their archives. An export that fails is marked
+// failed, the profile has to request a new one.
+func (uc *exportUseCase) Generate(ctx context.Context, now time.Time) error {
+	exports, err := uc.repo.ClaimPending(ctx, now, generateBatchSize)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for i := range exports {
+		export := &exports[i]
+		updates := uc.generate(ctx, export, now)
+		if err := uc.repo.UpdatePartial(ctx, export.ID, updates); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// generate writes the archive of an export to the storage and returns how to update the export
+func (uc *exportUseCase) generate(ctx context.Context, export *model.Export, now time.Time) map[string]any {
+	key := fmt.Sprintf("%s%s/%s.zip", StoragePrefix, export.ProfileID, export.ID)
+	// The archive is streamed to the storage as it is written
+	reader, writer := io.Pipe()
+	go func() {
+		writer.CloseWithError(uc.Write(ctx, export.ProfileID, writer, now))
+	}()
+	size, err := uc.storage.Put(ctx, key, reader)
+	// Unblocks the writer when the storage stopped reading early
+	reader.CloseWithError(err)
+	if err != nil {
+		log.Printf("Failed to generate data export %s: %v", export.ID, err)
+		uc.deleteFile(key)
+		return map[string]any{"status": model.StatusFailed, "error": err.Error()}
+	}
+	log.Printf("Generated data export %s of %d bytes", export.ID, size)
+	return map[string]any{
+		"status":       model.StatusCompleted,
+		"storage_key":  key,
+		"size_bytes":   size,
+		"error":        nil,
+		"completed_at": now,
+		"expires_at":   now.Add(ArchiveLifetime),
+	}
+}
+
+// Expire deletes the archives of exports past their expiry
+func (uc *exportUseCase) Expire(ctx context.Context, now time.Time) error {
+	exports, err := uc.repo.GetExpired(ctx, now)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, export := range exports {
+		if export.StorageKey != nil {
+			if err := uc.storage.Delete(ctx, *export.StorageKey); err != nil && !errors.Is(err, storage.ErrNotFound) {
+				errs = append(errs, fmt.Errorf("failed to delete archive of data export %s: %w", export.ID, err))
+				continue
+			}
+		}
+		err := uc.repo.UpdatePartial(ctx, export.ID, map[string]any{"status": model.StatusExpired, "storage_key": nil})
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Write writes the archive of the data of a profile: profile, settings, programs, schedules,
+// sessions with their workout snapshots, exercise logs, records, measurements and photos
+func (uc *exportUseCase) Write(ctx context.Context, profileID string, w io.Writer, now time.Time) error {
+	files, err := uc.repo.GetPhotoFiles(ctx, profileID)
+	if err != nil {
+		return err
+	}
+	scanTables := func(visit func(table model.TableScanner) error) error {
+		return uc.repo.ScanTables(ctx, profileID, visit)
+	}
+	return writeArchive(w, profileID, now, scanTables, files, func(key string) (io.ReadCloser, error) {
+		return uc.storage.Open(ctx, key)
+	})
+}
+
+// deleteFile removes a partially written archive, failures are logged
+func (uc *exportUseCase) deleteFile(key string) {
+	if err := uc.storage.Delete(context.Background(), key); err != nil && !errors.Is(err, storage.ErrNotFound) {
+		log.Printf("Failed to delete stored file %s: %v", key, err)
+	}
+}
@@ -0,0 +1,99 @@
+// Package imaging decodes uploaded photos and scales them down with the standard library only.
+// JPEG and PNG are accepted.
+package imaging
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+)
+
+const (
+	// MaxPixels bounds the size of decoded images, a small file can claim huge dimensions
+	MaxPixels = 50_000_000
+	// jpegQuality is used for the images this package encodes
+	jpegQuality = 85
+)
+
+var ErrUnsupportedImage = errors.New("unsupported image")
+
+// DecodeConfig reads the format and dimensions of an image without decoding it
+func DecodeConfig(r io.Reader) (image.Config, string, error) {
+	config, format, err := image.DecodeConfig(r)
+	if err != nil {
+		return image.Config{}, "", fmt.Errorf("%w: %v", ErrUnsupportedImage, err)
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > MaxPixels {
+		return image.Config{}, "", fmt.Errorf("%w: %dx%d pixels", ErrUnsupportedImage, config.Width, config.Height)
+	}
+	return config, format, nil
+}
+
+// Decode decodes an image whose dimensions were checked with DecodeConfig
+func Decode(r io.Reader) (image.Image, error) {
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedImage, err)
+	}
+	return img, nil
+}
+
+// Thumbnail scales an image down so its longer side is at most maxSide, keeping the aspect ratio.
+// Smaller images keep their size.
+func Thumbnail(src image.Image, maxSide int) image.Image {
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	targetWidth, targetHeight := width, height
+	if width >= height && width > maxSide {
+		targetWidth, targetHeight = maxSide, max(1, height*maxSide/width)
+	} else if height > width && height > maxSide {
+		targetWidth, targetHeight = max(1, width*maxSide/height), maxSide
+	}
+	return scale(src, bounds, targetWidth, targetHeight)
+}
+
+// Square crops the largest centered square out of an image and scales it down to side pixels.
+// Smaller squares keep their size.
+func Square(src image.Image, side int) image.Image {
+	bounds := src.Bounds()
+	crop := min(bounds.Dx(), bounds.Dy())
+	x0 := bounds.Min.X + (bounds.Dx()-crop)/2
+	y0 := bounds.Min.Y + (bounds.Dy()-crop)/2
+	target := min(side, crop)
+	return scale(src, image.Rect(x0, y0, x0+crop, y0+crop), target, target)
+}
+
+// scale draws the area of an image into a new image of the target size. Every target pixel is the
+// average of the source pixels it covers.
+func scale(src image.Image, area image.Rectangle, targetWidth, targetHeight int) image.Image {
+	width, height := area.Dx(), area.Dy()
+	dst := image.NewRGBA(image.Rect(0, 0, targetWidth, targetHeight))
+	for y := 0; y < targetHeight; y++ {
+		y0, y1 := y*height/targetHeight, max((y+1)*height/targetHeight, y*height/targetHeight+1)
+		for x := 0; x < targetWidth; x++ {
+			x0, x1 := x*width/targetWidth, max((x+1)*width/targetWidth, x*width/targetWidth+1)
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := src.At(area.Min.X+sx, area.Min.Y+sy).RGBA()
+					r, g, b, a = r+uint64(cr), g+uint64(cg), b+uint64(cb), a+uint64(ca)
+					n++
+				}
+			}
+			dst.SetRGBA(x, y, color.RGBA{R: uint8(r / n >> 8), G: uint8(g / n >> 8), B: uint8(b / n >> 8), A: uint8(a / n >> 8)})
+		}
+	}
+	return dst
+}
+
+// EncodeJPEG writes an image as JPEG. JPEG has no transparency, transparent pixels turn black.
+func EncodeJPEG(w io.Writer, img image.Image) error {
+	if err := jpeg.Encode(w, img, &jpeg.Options{Quality: jpegQuality}); err != nil {
+		return fmt.Errorf("failed to encode JPEG: %w", err)
+	}
+	return nil
+}
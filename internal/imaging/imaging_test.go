@@ -0,0 +1,85 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func TestThumbnail(t *testing.T) {
+	// Left half red, right half blue
+	src := image.NewRGBA(image.Rect(0, 0, 400, 200))
+	for y := 0; y < 200; y++ {
+		for x := 0; x < 400; x++ {
+			c := color.RGBA{R: 255, A: 255}
+			if x >= 200 {
+				c = color.RGBA{B: 255, A: 255}
+			}
+			src.SetRGBA(x, y, c)
+		}
+	}
+
+	thumbnail := Thumbnail(src, 100)
+	if got := thumbnail.Bounds().Size(); got != image.Pt(100, 50) {
+		t.Fatalf("size = %v, want 100x50", got)
+	}
+	if r, _, b, _ := thumbnail.At(10, 10).RGBA(); r>>8 != 255 || b != 0 {
+		t.Errorf("left pixel = %v, want red", thumbnail.At(10, 10))
+	}
+	if r, _, b, _ := thumbnail.At(90, 40).RGBA(); r != 0 || b>>8 != 255 {
+		t.Errorf("right pixel = %v, want blue", thumbnail.At(90, 40))
+	}
+
+	portrait := Thumbnail(image.NewRGBA(image.Rect(0, 0, 30, 90)), 60)
+	if got := portrait.Bounds().Size(); got != image.Pt(20, 60) {
+		t.Errorf("portrait size = %v, want 20x60", got)
+	}
+	small := Thumbnail(image.NewRGBA(image.Rect(0, 0, 40, 30)), 60)
+	if got := small.Bounds().Size(); got != image.Pt(40, 30) {
+		t.Errorf("small image size = %v, want it kept at 40x30", got)
+	}
+}
+
+func TestSquare(t *testing.T) {
+	// Red borders left and right of a blue square in the middle
+	src := image.NewRGBA(image.Rect(0, 0, 300, 100))
+	for y := 0; y < 100; y++ {
+		for x := 0; x < 300; x++ {
+			c := color.RGBA{R: 255, A: 255}
+			if x >= 100 && x < 200 {
+				c = color.RGBA{B: 255, A: 255}
+			}
+			src.SetRGBA(x, y, c)
+		}
+	}
+
+	square := Square(src, 50)
+	if got := square.Bounds().Size(); got != image.Pt(50, 50) {
+		t.Fatalf("size = %v, want 50x50", got)
+	}
+	for _, p := range []image.Point{{0, 0}, {49, 49}} {
+		if r, _, b, _ := square.At(p.X, p.Y).RGBA(); r != 0 || b>>8 != 255 {
+			t.Errorf("pixel %v = %v, want blue", p, square.At(p.X, p.Y))
+		}
+	}
+	small := Square(image.NewRGBA(image.Rect(0, 0, 40, 30)), 60)
+	if got := small.Bounds().Size(); got != image.Pt(30, 30) {
+		t.Errorf("small image size = %v, want 30x30", got)
+	}
+}
+
+func TestDecodeConfig(t *testing.T) {
+	var buf bytes.Buffer
+	png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 12, 8)))
+	config, format, err := DecodeConfig(bytes.NewReader(buf.Bytes()))
+	if err != nil || format != "png" || config.Width != 12 || config.Height != 8 {
+		t.Errorf("DecodeConfig() = %+v, %q, %v", config, format, err)
+	}
+	if _, _, err := DecodeConfig(strings.NewReader("GIF89a not supported")); !errors.Is(err, ErrUnsupportedImage) {
+		t.Errorf("DecodeConfig() of a GIF error = %v, want unsupported", err)
+	}
+}
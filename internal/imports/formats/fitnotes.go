@@ -0,0 +1,33 @@
+package formats
+
+import "time"
+
+// fitNotesWorkoutName names the workouts of FitNotes, which has no workout names
+const fitNotesWorkoutName = "FitNotes"
+
+// parseFitNotes reads a row of a FitNotes export. FitNotes keeps only the date of a set, so the
+// sets of a day make up one workout starting at the start of the day.
+func parseFitNotes(cells row, location *time.Location) (Set, bool, error) {
+	date, err := parseTime(time.DateOnly, cells("date"), location)
+	if err != nil {
+		return Set{}, false, err
+	}
+	set := Set{
+		WorkoutName: fitNotesWorkoutName,
+		StartedAt:   date,
+		Exercise:    cells("exercise"),
+		Note:        cells("comment"),
+	}
+	weight := cells("weight (kgs)")
+	if weight == "" {
+		weight = cells("weight (lbs)")
+	}
+	if set.Weight, err = parseNumber(weight); err != nil {
+		return Set{}, false, err
+	}
+	var ok bool
+	if set.Reps, ok, err = parseReps(cells("reps")); err != nil || !ok {
+		return Set{}, false, err
+	}
+	return set, set.Exercise != "", nil
+}
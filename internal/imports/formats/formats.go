@@ -0,0 +1,241 @@
+// Package formats reads the CSV exports of other workout trackers: Strong, Hevy and FitNotes.
+// Every export is a row per set, the rows are turned into sets with the time the workout started,
+// so sets of the same workout share its name and start.
+package formats
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/VladimirKholomyanskyy/gym-api/internal/common"
+)
+
+// byteOrderMark starts the exports of some trackers
+const byteOrderMark = "\uFEFF"
+
+var (
+	ErrUnknownFormat = errors.New("file is not a Strong, Hevy or FitNotes CSV export")
+	ErrInvalidExport = errors.New("invalid workout export")
+)
+
+// Source is the tracker an export comes from
+type Source string
+
+const (
+	SourceStrong   Source = "strong"
+	SourceHevy     Source = "hevy"
+	SourceFitNotes Source = "fitnotes"
+)
+
+// ParseSource parses a source name
+func ParseSource(name string) (Source, bool) {
+	switch source := Source(strings.ToLower(name)); source {
+	case SourceStrong, SourceHevy, SourceFitNotes:
+		return source, true
+	}
+	return "", false
+}
+
+// Set is a set of an exported workout. Weights are in the units of the export.
+type Set struct {
+	WorkoutName string
+	StartedAt   time.Time
+	// EndedAt is when the workout ended, nil when the export doesn't tell
+	EndedAt   *time.Time
+	Exercise  string
+	Weight    float64
+	Reps      int
+	RPE       *float64
+	Warmup    bool
+	ToFailure bool
+	Note      string
+}
+
+// Export is a parsed export
+type Export struct {
+	Source Source
+	// Units are those of the weights, empty when the export doesn't tell, as Strong's doesn't
+	Units common.Units
+	Sets  []Set
+	// SkippedRows counts rows that aren't sets with reps, such as cardio and rest timers
+	SkippedRows int
+}
+
+// row gives the cells of a CSV row by their lowercased column name
+type row func(column string) string
+
+// parser reads a row of an export, it returns false for rows that are no sets
+type parser func(cells row, location *time.Location) (Set, bool, error)
+
+// Parse reads an export. Without a source it's detected from the header. Times without a zone,
+// as all three trackers write them, are read in the location.
+func Parse(r io.Reader, source Source, location *time.Location) (*Export, error) {
+	reader := bufio.NewReader(r)
+	// Strong writes semicolons where the decimal separator is a comma
+	firstLine, err := reader.Peek(4096)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, err
+	}
+	firstLine = bytes.TrimPrefix(firstLine, []byte(byteOrderMark))
+	if newline := bytes.IndexByte(firstLine, '\n'); newline >= 0 {
+		firstLine = firstLine[:newline]
+	}
+	csvReader := csv.NewReader(reader)
+	if bytes.Count(firstLine, []byte(";")) > bytes.Count(firstLine, []byte(",")) {
+		csvReader.Comma = ';'
+	}
+	csvReader.FieldsPerRecord = -1
+	csvReader.LazyQuotes = true
+
+	header, err := csvReader.Read()
+	if err != nil {
+		return nil, ErrUnknownFormat
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, byteOrderMark)))
+		columns[name] = i
+	}
+	if source == "" {
+		if source = detect(columns); source == "" {
+			return nil, ErrUnknownFormat
+		}
+	}
+	export := &Export{Source: source}
+	var parse parser
+	switch source {
+	case SourceStrong:
+		parse = parseStrong
+	case SourceHevy:
+		parse, export.Units = parseHevy, unitsOf(columns, "weight_lbs", "weight_kg")
+	case SourceFitNotes:
+		parse, export.Units = parseFitNotes, unitsOf(columns, "weight (lbs)", "weight (kgs)")
+	}
+	if err := requireColumns(source, columns); err != nil {
+		return nil, err
+	}
+
+	for {
+		record, err := csvReader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidExport, err)
+		}
+		line, _ := csvReader.FieldPos(0)
+		cells := func(column string) string {
+			if i, ok := columns[column]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		set, ok, err := parse(cells, location)
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: %v", ErrInvalidExport, line, err)
+		}
+		if !ok {
+			export.SkippedRows++
+			continue
+		}
+		export.Sets = append(export.Sets, set)
+	}
+	return export, nil
+}
+
+// detect tells the source of an export by the columns of its header
+func detect(columns map[string]int) Source {
+	has := func(names ...string) bool {
+		for _, name := range names {
+			if _, ok := columns[name]; !ok {
+				return false
+			}
+		}
+		return true
+	}
+	switch {
+	case has("workout name", "exercise name", "set order"):
+		return SourceStrong
+	case has("title", "start_time", "exercise_title"):
+		return SourceHevy
+	case has("date", "exercise", "category", "reps"):
+		return SourceFitNotes
+	}
+	return ""
+}
+
+var requiredColumns = map[Source][]string{
+	SourceStrong:   {"date", "workout name", "exercise name", "set order", "weight", "reps"},
+	SourceHevy:     {"title", "start_time", "exercise_title", "set_type", "reps"},
+	SourceFitNotes: {"date", "exercise", "reps"},
+}
+
+func requireColumns(source Source, columns map[string]int) error {
+	for _, name := range requiredColumns[source] {
+		if _, ok := columns[name]; !ok {
+			return fmt.Errorf("%w: %s export has no %q column", ErrInvalidExport, source, name)
+		}
+	}
+	return nil
+}
+
+// unitsOf tells the units from which of the weight columns an export has
+func unitsOf(columns map[string]int, imperial, metric string) common.Units {
+	if _, ok := columns[imperial]; ok {
+		return common.ImperialUnits
+	}
+	if _, ok := columns[metric]; ok {
+		return common.MetricUnits
+	}
+	return ""
+}
+
+// parseNumber reads a finite decimal, accepting a comma as the decimal separator. Empty is zero.
+func parseNumber(value string) (float64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	number, err := strconv.ParseFloat(strings.Replace(value, ",", ".", 1), 64)
+	if err != nil || number < 0 || math.IsNaN(number) || math.IsInf(number, 0) {
+		return 0, fmt.Errorf("invalid number %q", value)
+	}
+	return number, nil
+}
+
+// parseReps reads a rep count, rows without reps aren't sets
+func parseReps(value string) (int, bool, error) {
+	number, err := parseNumber(value)
+	if err != nil {
+		return 0, false, err
+	}
+	reps := int(number)
+	if float64(reps) != number {
+		return 0, false, fmt.Errorf("invalid reps %q", value)
+	}
+	return reps, reps > 0, nil
+}
+
+// parseRPE reads an RPE, values off the 1-10 scale are dropped
+func parseRPE(value string) *float64 {
+	rpe, err := parseNumber(value)
+	if err != nil || rpe < 1 || rpe > 10 {
+		return nil
+	}
+	return &rpe
+}
+
+func parseTime(layout, value string, location *time.Location) (time.Time, error) {
+	t, err := time.ParseInLocation(layout, value, location)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q", value)
+	}
+	return t, nil
+}
@@ -0,0 +1,138 @@
+package formats
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/VladimirKholomyanskyy/gym-api/internal/common"
+)
+
+func TestParse(t *testing.T) {
+	location, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("no time zone database")
+	}
+	tests := []struct {
+		name    string
+		csv     string
+		source  Source
+		units   common.Units
+		sets    []Set
+		skipped int
+	}{
+		{
+			name: "strong",
+			csv: byteOrderMark + "Date,Workout Name,Duration,Exercise Name,Set Order,Weight,Reps,Distance,Seconds,Notes,Workout Notes,RPE\n" +
+				"2024-03-04 18:12:55,Push,1h 5m,Bench Press (Barbell),W,40,10,0,0,,,\n" +
+				"2024-03-04 18:12:55,Push,1h 5m,Bench Press (Barbell),1,82.5,5,0,0,Paused,,8.5\n" +
+				"2024-03-04 18:12:55,Push,1h 5m,Bench Press (Barbell),Rest Timer,0,0,0,90,,,\n" +
+				"2024-03-04 18:12:55,Push,1h 5m,Running,1,0,0,5,1500,,,\n",
+			source: SourceStrong,
+			sets: []Set{
+				{WorkoutName: "Push", Exercise: "Bench Press (Barbell)", Weight: 40, Reps: 10, Warmup: true},
+				{WorkoutName: "Push", Exercise: "Bench Press (Barbell)", Weight: 82.5, Reps: 5, RPE: ptr(8.5), Note: "Paused"},
+			},
+			skipped: 2,
+		},
+		{
+			name: "strong with semicolons",
+			csv: "Date;Workout Name;Duration;Exercise Name;Set Order;Weight;Reps;Notes;RPE\n" +
+				"2024-03-04 18:12:55;Push;1h 5m;Squat (Barbell);F;102,5;3;;\n",
+			source: SourceStrong,
+			sets:   []Set{{WorkoutName: "Push", Exercise: "Squat (Barbell)", Weight: 102.5, Reps: 3, ToFailure: true}},
+		},
+		{
+			name: "hevy",
+			csv: `"title","start_time","end_time","description","exercise_title","superset_id","exercise_notes","set_index","set_type","weight_lbs","reps","distance_miles","duration_seconds","rpe"` + "\n" +
+				`"","4 Mar 2024, 18:12","4 Mar 2024, 19:17","","Bench Press (Barbell)",,"",0,"warmup",95,10,,,` + "\n" +
+				`"","4 Mar 2024, 18:12","4 Mar 2024, 19:17","","Bench Press (Barbell)",,"",1,"failure",185,6,,,9` + "\n",
+			source: SourceHevy,
+			units:  common.ImperialUnits,
+			sets: []Set{
+				{WorkoutName: "Workout", Exercise: "Bench Press (Barbell)", Weight: 95, Reps: 10, Warmup: true},
+				{WorkoutName: "Workout", Exercise: "Bench Press (Barbell)", Weight: 185, Reps: 6, ToFailure: true, RPE: ptr(9)},
+			},
+		},
+		{
+			name: "fitnotes",
+			csv: "Date,Exercise,Category,Weight (kgs),Reps,Distance,Distance Unit,Time,Comment\n" +
+				"2024-03-04,Flat Barbell Bench Press,Chest,82.5,5,,,,Felt good\n" +
+				"2024-03-04,Treadmill,Cardio,,,5.0,km,0:25:00,\n",
+			source:  SourceFitNotes,
+			units:   common.MetricUnits,
+			sets:    []Set{{WorkoutName: "FitNotes", Exercise: "Flat Barbell Bench Press", Weight: 82.5, Reps: 5, Note: "Felt good"}},
+			skipped: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			export, err := Parse(strings.NewReader(tt.csv), "", location)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if export.Source != tt.source || export.Units != tt.units || export.SkippedRows != tt.skipped {
+				t.Errorf("Parse() = %s %q %d skipped, want %s %q %d skipped",
+					export.Source, export.Units, export.SkippedRows, tt.source, tt.units, tt.skipped)
+			}
+			if len(export.Sets) != len(tt.sets) {
+				t.Fatalf("Parse() got %d sets, want %d", len(export.Sets), len(tt.sets))
+			}
+			for i, got := range export.Sets {
+				want := tt.sets[i]
+				if got.WorkoutName != want.WorkoutName || got.Exercise != want.Exercise || got.Weight != want.Weight ||
+					got.Reps != want.Reps || got.Warmup != want.Warmup || got.ToFailure != want.ToFailure || got.Note != want.Note {
+					t.Errorf("set %d = %+v, want %+v", i, got, want)
+				}
+				if (got.RPE == nil) != (want.RPE == nil) || got.RPE != nil && *got.RPE != *want.RPE {
+					t.Errorf("set %d RPE = %v, want %v", i, got.RPE, want.RPE)
+				}
+			}
+		})
+	}
+}
+
+func TestParseTimes(t *testing.T) {
+	location, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("no time zone database")
+	}
+	csv := "Date,Workout Name,Duration,Exercise Name,Set Order,Weight,Reps\n" +
+		"2024-03-04 18:12:55,Push,1h 5m,Squat,1,100,5\n"
+	export, err := Parse(strings.NewReader(csv), SourceStrong, location)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	set := export.Sets[0]
+	if want := time.Date(2024, 3, 4, 17, 12, 55, 0, time.UTC); !set.StartedAt.Equal(want) {
+		t.Errorf("StartedAt = %v, want %v", set.StartedAt, want)
+	}
+	if set.EndedAt == nil || set.EndedAt.Sub(set.StartedAt) != 65*time.Minute {
+		t.Errorf("EndedAt = %v, want 65 minutes after the start", set.EndedAt)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	if _, err := Parse(strings.NewReader("a,b,c\n1,2,3\n"), "", time.UTC); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("Parse() of an unknown file error = %v, want ErrUnknownFormat", err)
+	}
+	if _, err := Parse(strings.NewReader("Date,Exercise,Reps\n"), SourceHevy, time.UTC); !errors.Is(err, ErrInvalidExport) {
+		t.Errorf("Parse() with missing columns error = %v, want ErrInvalidExport", err)
+	}
+	csv := "Date,Exercise,Category,Weight (kgs),Reps\n04/03/2024,Squat,Legs,100,5\n"
+	if _, err := Parse(strings.NewReader(csv), "", time.UTC); !errors.Is(err, ErrInvalidExport) {
+		t.Errorf("Parse() of an invalid date error = %v, want ErrInvalidExport", err)
+	}
+	for _, weight := range []string{"NaN", "Inf", "-Infinity", "-5"} {
+		csv := "Date,Workout Name,Duration,Exercise Name,Set Order,Weight,Reps\n" +
+			"2024-03-04 18:12:55,Push,1h 5m,Squat,1," + weight + ",5\n"
+		if _, err := Parse(strings.NewReader(csv), SourceStrong, time.UTC); !errors.Is(err, ErrInvalidExport) {
+			t.Errorf("Parse() of weight %s error = %v, want ErrInvalidExport", weight, err)
+		}
+	}
+}
+
+func ptr(value float64) *float64 {
+	return &value
+}
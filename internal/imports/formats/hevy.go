@@ -0,0 +1,53 @@
+package formats
+
+import (
+	"strings"
+	"time"
+)
+
+// hevyTimeLayout is the layout of the start_time and end_time columns
+const hevyTimeLayout = "2 Jan 2006, 15:04"
+
+// parseHevy reads a row of a Hevy export, set_type tells normal, warmup, dropset and failure sets
+func parseHevy(cells row, location *time.Location) (Set, bool, error) {
+	set := Set{
+		WorkoutName: cells("title"),
+		Exercise:    cells("exercise_title"),
+		Note:        cells("exercise_notes"),
+	}
+	if set.WorkoutName == "" {
+		set.WorkoutName = "Workout"
+	}
+	switch strings.ToLower(cells("set_type")) {
+	case "warmup":
+		set.Warmup = true
+	case "failure":
+		set.ToFailure = true
+	}
+	var err error
+	if set.StartedAt, err = parseTime(hevyTimeLayout, cells("start_time"), location); err != nil {
+		return Set{}, false, err
+	}
+	if value := cells("end_time"); value != "" {
+		endedAt, err := parseTime(hevyTimeLayout, value, location)
+		if err != nil {
+			return Set{}, false, err
+		}
+		if endedAt.After(set.StartedAt) {
+			set.EndedAt = &endedAt
+		}
+	}
+	weight := cells("weight_kg")
+	if weight == "" {
+		weight = cells("weight_lbs")
+	}
+	if set.Weight, err = parseNumber(weight); err != nil {
+		return Set{}, false, err
+	}
+	var ok bool
+	if set.Reps, ok, err = parseReps(cells("reps")); err != nil || !ok {
+		return Set{}, false, err
+	}
+	set.RPE = parseRPE(cells("rpe"))
+	return set, set.Exercise != "", nil
+}
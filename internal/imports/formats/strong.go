@@ -0,0 +1,61 @@
+package formats
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// strongTimeLayout is the layout of the Date column, the start of the workout
+const strongTimeLayout = "2006-01-02 15:04:05"
+
+// parseStrong reads a row of a Strong export. The Set Order column numbers the sets, marks warmup,
+// drop and failure sets with W, D and F, and holds other values for rest timers and notes.
+func parseStrong(cells row, location *time.Location) (Set, bool, error) {
+	set := Set{
+		WorkoutName: cells("workout name"),
+		Exercise:    cells("exercise name"),
+		Note:        cells("notes"),
+	}
+	switch order := strings.ToUpper(cells("set order")); order {
+	case "W":
+		set.Warmup = true
+	case "F":
+		set.ToFailure = true
+	case "D":
+	default:
+		if _, err := strconv.Atoi(order); err != nil {
+			return Set{}, false, nil
+		}
+	}
+	var err error
+	if set.StartedAt, err = parseTime(strongTimeLayout, cells("date"), location); err != nil {
+		return Set{}, false, err
+	}
+	if duration, ok := parseStrongDuration(cells("duration")); ok {
+		endedAt := set.StartedAt.Add(duration)
+		set.EndedAt = &endedAt
+	}
+	if set.Weight, err = parseNumber(cells("weight")); err != nil {
+		return Set{}, false, err
+	}
+	var ok bool
+	if set.Reps, ok, err = parseReps(cells("reps")); err != nil || !ok {
+		return Set{}, false, err
+	}
+	set.RPE = parseRPE(cells("rpe"))
+	return set, set.Exercise != "", nil
+}
+
+// parseStrongDuration reads durations such as "1h 5m", "45m" and "50s"
+func parseStrongDuration(value string) (time.Duration, bool) {
+	value = strings.ReplaceAll(value, " ", "")
+	if value == "" {
+		return 0, false
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration <= 0 {
+		return 0, false
+	}
+	return duration, true
+}
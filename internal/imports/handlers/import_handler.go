@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	openapi "github.com/VladimirKholomyanskyy/gym-api/internal/api/go"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/common"
+	customerrors "github.com/VladimirKholomyanskyy/gym-api/internal/customErrors"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/imports/model"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/imports/usecase"
+	trainingmodel "github.com/VladimirKholomyanskyy/gym-api/internal/training/model"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/utils"
+)
+
+type importHandler struct {
+	useCase usecase.ImportUseCase
+}
+
+func NewImportHandler(useCase usecase.ImportUseCase) openapi.WorkoutImportsAPIServicer {
+	return &importHandler{useCase: useCase}
+}
+
+// GetWorkoutImport - Retrieve an import with the exercise names of its export to review
+func (h *importHandler) GetWorkoutImport(ctx context.Context, id string) (openapi.ImplResponse, error) {
+	profileId, err := common.ExtractProfileID(ctx)
+	if err != nil {
+		return utils.ErrorResponse(http.StatusUnauthorized, openapi.UNAUTHORIZED, err.Error())
+	}
+	if !common.IsUUIDValid(id) {
+		return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_ID, "Import ID is not a valid UUID")
+	}
+	imp, err := h.useCase.GetByID(ctx, profileId, id)
+	if err != nil {
+		return importErrorResponse(err, "Failed to fetch workout import")
+	}
+	return importResponse(ctx, h.useCase, http.StatusOK, imp)
+}
+
+// UpdateWorkoutImportMappings - Map exercise names of the export onto exercises, or skip them with null
+func (h *importHandler) UpdateWorkoutImportMappings(ctx context.Context, id string, request openapi.UpdateWorkoutImportMappingsRequest) (openapi.ImplResponse, error) {
+	profileId, err := common.ExtractProfileID(ctx)
+	if err != nil {
+		return utils.ErrorResponse(http.StatusUnauthorized, openapi.UNAUTHORIZED, err.Error())
+	}
+	if !common.IsUUIDValid(id) {
+		return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_ID, "Import ID is not a valid UUID")
+	}
+	if request.Mappings == nil {
+		return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_REQUEST, "Body must be an object with mappings")
+	}
+	for _, exerciseId := range request.Mappings {
+		if exerciseId != nil && !common.IsUUIDValid(*exerciseId) {
+			return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_ID, "Exercise ID is not a valid UUID")
+		}
+	}
+	imp, err := h.useCase.UpdateMappings(ctx, profileId, id, request.Mappings)
+	if err != nil {
+		if errors.Is(err, customerrors.ErrEntityNotFound) {
+			return utils.ErrorResponse(http.StatusNotFound, openapi.RESOURCE_NOT_FOUND, "Workout import or exercise not found")
+		}
+		return importErrorResponse(err, "Failed to update workout import")
+	}
+	return importResponse(ctx, h.useCase, http.StatusOK, imp)
+}
+
+// CommitWorkoutImport - Create the workout sessions and exercise logs of an import whose names are all reviewed
+func (h *importHandler) CommitWorkoutImport(ctx context.Context, id string) (openapi.ImplResponse, error) {
+	profileId, err := common.ExtractProfileID(ctx)
+	if err != nil {
+		return utils.ErrorResponse(http.StatusUnauthorized, openapi.UNAUTHORIZED, err.Error())
+	}
+	if !common.IsUUIDValid(id) {
+		return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_ID, "Import ID is not a valid UUID")
+	}
+	imp, err := h.useCase.Commit(ctx, profileId, id, time.Now())
+	if err != nil {
+		return importErrorResponse(err, "Failed to commit workout import")
+	}
+	return importResponse(ctx, h.useCase, http.StatusOK, imp)
+}
+
+func importErrorResponse(err error, message string) (openapi.ImplResponse, error) {
+	switch {
+	case errors.Is(err, customerrors.ErrEntityNotFound):
+		return utils.ErrorResponse(http.StatusNotFound, openapi.RESOURCE_NOT_FOUND, "Workout import not found")
+	case errors.Is(err, customerrors.ErrAccessForbidden):
+		return utils.ErrorResponse(http.StatusForbidden, openapi.FORBIDDEN, "Access to workout import forbidden")
+	case errors.Is(err, customerrors.ErrImportCompleted), errors.Is(err, customerrors.ErrUnmappedExercises):
+		return utils.ErrorResponse(http.StatusConflict, openapi.INVALID_REQUEST, err.Error())
+	default:
+		log.Printf("%s: %v", message, err)
+		return utils.ErrorResponse(http.StatusInternalServerError, openapi.INTERNAL_SERVER_ERROR, message)
+	}
+}
+
+// importResponse converts an import, naming the exercises its names map onto
+func importResponse(ctx context.Context, useCase usecase.ImportUseCase, code int, imp *model.Import) (openapi.ImplResponse, error) {
+	sets, err := imp.DecodeSets()
+	if err != nil {
+		return importErrorResponse(err, "Failed to read workout import")
+	}
+	mappings, err := imp.DecodeMappings()
+	if err != nil {
+		return importErrorResponse(err, "Failed to read workout import")
+	}
+	exercises, err := useCase.GetExercises(ctx)
+	if err != nil {
+		return importErrorResponse(err, "Failed to read workout import")
+	}
+	return openapi.Response(code, convertImport(imp, sets, mappings, exercises)), nil
+}
+
+// convertImport lists the exercise names of the export with the exercises they map onto. Names
+// that are neither mapped nor skipped have to be reviewed before the import is committed.
+func convertImport(imp *model.Import, sets []model.Set, mappings model.Mappings, exercises map[string]trainingmodel.Exercise) openapi.WorkoutImport {
+	response := openapi.WorkoutImport{
+		Id:                     imp.ID,
+		Source:                 imp.Source,
+		Status:                 string(imp.Status),
+		CreatedAt:              imp.CreatedAt,
+		CompletedAt:            imp.CompletedAt,
+		Sets:                   int32(len(sets)),
+		SkippedRows:            int32(imp.SkippedRows),
+		Exercises:              []openapi.WorkoutImportExercise{},
+		SessionsImported:       int32(imp.SessionsImported),
+		SetsImported:           int32(imp.SetsImported),
+		SessionsSkipped:        int32(imp.SessionsSkipped),
+		PersonalRecordsPending: len(imp.RecordsPending) > 0,
+	}
+	setCounts := make(map[string]int)
+	sessions := make(map[string]bool)
+	for _, set := range sets {
+		setCounts[set.Exercise]++
+		sessions[set.WorkoutName+"\x00"+set.StartedAt.UTC().Format(time.RFC3339)] = true
+	}
+	response.Sessions = int32(len(sessions))
+	for _, name := range model.ExerciseNames(sets) {
+		exercise := openapi.WorkoutImportExercise{Name: name, Sets: int32(setCounts[name])}
+		exerciseId, mapped := mappings[name]
+		switch {
+		case !mapped:
+			response.UnmappedCount++
+		case exerciseId == "":
+			exercise.Skipped = true
+		default:
+			exercise.ExerciseId = &exerciseId
+			if mappedExercise, ok := exercises[exerciseId]; ok {
+				exercise.ExerciseName = &mappedExercise.Name
+			}
+		}
+		response.Exercises = append(response.Exercises, exercise)
+	}
+	return response
+}
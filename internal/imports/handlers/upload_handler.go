@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	openapi "github.com/VladimirKholomyanskyy/gym-api/internal/api/go"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/common"
+	customerrors "github.com/VladimirKholomyanskyy/gym-api/internal/customErrors"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/imports/formats"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/imports/usecase"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/utils"
+)
+
+// MaxUploadBytes bounds the size of an uploaded export
+const MaxUploadBytes = 10 << 20
+
+// uploadHandler takes the CSV file exactly as the tracker exported it, so clients can send the
+// file they got without wrapping it. The source and the units are query parameters.
+type uploadHandler struct {
+	useCase usecase.ImportUseCase
+}
+
+func NewImportUploadHandler(useCase usecase.ImportUseCase) http.Handler {
+	return &uploadHandler{useCase: useCase}
+}
+
+// ServeHTTP - Upload the CSV export of another tracker. The tracker is detected from the header
+// unless given as "source", weights are read in the "units" of the query when the export doesn't
+// tell, otherwise in the units of the profile.
+func (h *uploadHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	response, _ := h.upload(w, r)
+	openapi.EncodeJSONResponse(response.Body, &response.Code, w)
+}
+
+func (h *uploadHandler) upload(w http.ResponseWriter, r *http.Request) (openapi.ImplResponse, error) {
+	ctx := r.Context()
+	profileId, err := common.ExtractProfileID(ctx)
+	if err != nil {
+		return utils.ErrorResponse(http.StatusUnauthorized, openapi.UNAUTHORIZED, err.Error())
+	}
+	query := r.URL.Query()
+	var source formats.Source
+	if name := query.Get("source"); name != "" {
+		parsed, ok := formats.ParseSource(name)
+		if !ok {
+			return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_REQUEST, "source must be one of strong, hevy, fitnotes")
+		}
+		source = parsed
+	}
+	units := common.ExtractUnits(ctx)
+	if name := query.Get("units"); name != "" {
+		switch common.Units(name) {
+		case common.MetricUnits, common.ImperialUnits:
+			units = common.Units(name)
+		default:
+			return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_REQUEST, "units must be one of metric, imperial")
+		}
+	}
+	if r.ContentLength > MaxUploadBytes {
+		return utils.ErrorResponse(http.StatusRequestEntityTooLarge, openapi.INVALID_REQUEST, "export must not exceed 10 MB")
+	}
+	body := http.MaxBytesReader(w, r.Body, MaxUploadBytes)
+
+	imp, err := h.useCase.Upload(ctx, profileId, body, source, units, common.ExtractLocation(ctx))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		switch {
+		case errors.As(err, &tooLarge):
+			return utils.ErrorResponse(http.StatusRequestEntityTooLarge, openapi.INVALID_REQUEST, "export must not exceed 10 MB")
+		case errors.Is(err, formats.ErrUnknownFormat), errors.Is(err, formats.ErrInvalidExport), errors.Is(err, customerrors.ErrEmptyImport):
+			return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_REQUEST, err.Error())
+		default:
+			log.Printf("Failed to upload workout import: %v", err)
+			return utils.ErrorResponse(http.StatusInternalServerError, openapi.INTERNAL_SERVER_ERROR, "Failed to upload workout import")
+		}
+	}
+	return importResponse(ctx, h.useCase, http.StatusCreated, imp)
+}
@@ -0,0 +1,148 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/VladimirKholomyanskyy/gym-api/internal/common"
+	progressmodel "github.com/VladimirKholomyanskyy/gym-api/internal/progress/model"
+	trainingmodel "github.com/VladimirKholomyanskyy/gym-api/internal/training/model"
+	"github.com/lib/pq"
+	"gorm.io/datatypes"
+)
+
+type Status string
+
+const (
+	// StatusPendingReview imports wait for the profile to map the exercise names of the export
+	StatusPendingReview Status = "pending_review"
+	StatusCompleted     Status = "completed"
+)
+
+// Import is an uploaded export of another workout tracker. The sets are kept until the profile has
+// reviewed how the exercise names of the export map onto exercises, then it is committed into
+// workout sessions and exercise logs.
+type Import struct {
+	common.Base
+	ProfileID string
+	Source    string
+	Status    Status
+	// Sets are the sets of the export as a JSON array of Set
+	Sets datatypes.JSON `gorm:"type:jsonb;not null"`
+	// Mappings are the exercise IDs by exercise name of the export as a JSON object, an empty ID
+	// skips the sets of the name
+	Mappings         datatypes.JSON `gorm:"type:jsonb;not null"`
+	SkippedRows      int
+	SessionsImported int
+	SetsImported     int
+	// SessionsSkipped counts sessions that an earlier import already created
+	SessionsSkipped int
+	CompletedAt     *time.Time
+	// RecordsPending are the exercises whose personal records failed to be recomputed after the
+	// commit, they are retried in the background
+	RecordsPending pq.StringArray `gorm:"type:text[]"`
+}
+
+func (Import) TableName() string {
+	return "workout_imports"
+}
+
+// Set is an imported set with its weight in kilograms
+type Set struct {
+	WorkoutName string     `json:"workoutName"`
+	StartedAt   time.Time  `json:"startedAt"`
+	EndedAt     *time.Time `json:"endedAt,omitempty"`
+	Exercise    string     `json:"exercise"`
+	Weight      float64    `json:"weight"`
+	Reps        int        `json:"reps"`
+	RPE         *float64   `json:"rpe,omitempty"`
+	ToFailure   bool       `json:"toFailure,omitempty"`
+	Note        string     `json:"note,omitempty"`
+}
+
+// Mappings are exercise IDs by exercise name of an export, an empty ID skips the name
+type Mappings map[string]string
+
+// DecodeSets reads the stored sets
+func (i *Import) DecodeSets() ([]Set, error) {
+	var sets []Set
+	if err := json.Unmarshal(i.Sets, &sets); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal import sets: %w", err)
+	}
+	return sets, nil
+}
+
+// DecodeMappings reads the stored mappings
+func (i *Import) DecodeMappings() (Mappings, error) {
+	mappings := Mappings{}
+	if len(i.Mappings) == 0 {
+		return mappings, nil
+	}
+	if err := json.Unmarshal(i.Mappings, &mappings); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal import mappings: %w", err)
+	}
+	return mappings, nil
+}
+
+// ExerciseNames are the distinct exercise names of the sets, sorted
+func ExerciseNames(sets []Set) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, set := range sets {
+		if !seen[set.Exercise] {
+			seen[set.Exercise] = true
+			names = append(names, set.Exercise)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Unmapped are the names that are neither mapped onto an exercise nor skipped
+func (m Mappings) Unmapped(names []string) []string {
+	var unmapped []string
+	for _, name := range names {
+		if _, ok := m[name]; !ok {
+			unmapped = append(unmapped, name)
+		}
+	}
+	return unmapped
+}
+
+// Session is a workout session to be created by committing an import. The import key identifies
+// it across uploads of the same export, its logs lack the IDs of the profile and the session.
+type Session struct {
+	ImportKey   string
+	WorkoutName string
+	StartedAt   time.Time
+	CompletedAt time.Time
+	Logs        []progressmodel.ExerciseLog
+}
+
+// Snapshot is the snapshot of the workout of the session, with an exercise per exercise done in
+// the order it was first done
+func (s *Session) Snapshot(workout trainingmodel.Workout) (datatypes.JSON, error) {
+	workout.Exercises = nil
+	positions := make(map[string]int)
+	for _, log := range s.Logs {
+		position, ok := positions[log.ExerciseID]
+		if !ok {
+			workout.Exercises = append(workout.Exercises, trainingmodel.WorkoutExercise{
+				WorkoutID:  workout.ID,
+				ExerciseID: log.ExerciseID,
+				Reps:       log.Reps,
+				Position:   len(workout.Exercises) + 1,
+			})
+			position = len(workout.Exercises) - 1
+			positions[log.ExerciseID] = position
+		}
+		workout.Exercises[position].Sets++
+	}
+	snapshot, err := json.Marshal(workout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal workout snapshot: %w", err)
+	}
+	return snapshot, nil
+}
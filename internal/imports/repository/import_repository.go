@@ -0,0 +1,253 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	customerrors "github.com/VladimirKholomyanskyy/gym-api/internal/customErrors"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/imports/model"
+	progressmodel "github.com/VladimirKholomyanskyy/gym-api/internal/progress/model"
+	trainingmodel "github.com/VladimirKholomyanskyy/gym-api/internal/training/model"
+	"gorm.io/gorm"
+)
+
+// logBatchSize bounds how many exercise logs are inserted by a single statement
+const logBatchSize = 500
+
+// ImportRepository stores imports of other trackers and commits them into the history of a profile
+type ImportRepository interface {
+	Create(ctx context.Context, imp *model.Import) error
+	GetByID(ctx context.Context, id string) (*model.Import, error)
+	GetCompletedByProfileID(ctx context.Context, profileID string) ([]model.Import, error)
+	GetExercises(ctx context.Context) ([]trainingmodel.Exercise, error)
+	UpdatePartial(ctx context.Context, id string, updates map[string]any) error
+	GetWithRecordsPending(ctx context.Context, limit int) ([]model.Import, error)
+	Commit(ctx context.Context, imp *model.Import, programName string, sessions []model.Session, now time.Time) ([]model.Session, error)
+}
+
+// importRepository implements ImportRepository
+type importRepository struct {
+	db *gorm.DB
+}
+
+// NewImportRepository creates a new repository instance
+func NewImportRepository(db *gorm.DB) ImportRepository {
+	return &importRepository{db: db}
+}
+
+func (r *importRepository) Create(ctx context.Context, imp *model.Import) error {
+	if err := r.db.WithContext(ctx).Create(imp).Error; err != nil {
+		return fmt.Errorf("failed to create workout import: %w", err)
+	}
+	return nil
+}
+
+func (r *importRepository) GetByID(ctx context.Context, id string) (*model.Import, error) {
+	var imp model.Import
+	err := r.db.WithContext(ctx).First(&imp, "id = ?", id).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, customerrors.ErrEntityNotFound
+		}
+		return nil, fmt.Errorf("failed to fetch workout import by id: %w", err)
+	}
+	return &imp, nil
+}
+
+// GetCompletedByProfileID retrieves the completed imports of a profile, oldest first
+func (r *importRepository) GetCompletedByProfileID(ctx context.Context, profileID string) ([]model.Import, error) {
+	var imports []model.Import
+	err := r.db.WithContext(ctx).
+		Select("id", "profile_id", "source", "status", "mappings", "completed_at").
+		Where("profile_id = ? AND status = ?", profileID, model.StatusCompleted).
+		Order("completed_at ASC").
+		Find(&imports).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch completed workout imports: %w", err)
+	}
+	return imports, nil
+}
+
+// GetExercises retrieves all exercises, the names of an export are matched against them
+func (r *importRepository) GetExercises(ctx context.Context) ([]trainingmodel.Exercise, error) {
+	var exercises []trainingmodel.Exercise
+	if err := r.db.WithContext(ctx).Order("name ASC").Find(&exercises).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch exercises: %w", err)
+	}
+	return exercises, nil
+}
+
+func (r *importRepository) UpdatePartial(ctx context.Context, id string, updates map[string]any) error {
+	result := r.db.WithContext(ctx).Model(&model.Import{}).Where("id = ?", id).Updates(updates)
+	if result.Error != nil {
+		return fmt.Errorf("failed to update workout import: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return customerrors.ErrEntityNotFound
+	}
+	return nil
+}
+
+// GetWithRecordsPending retrieves committed imports with personal records still to be recomputed
+func (r *importRepository) GetWithRecordsPending(ctx context.Context, limit int) ([]model.Import, error) {
+	var imports []model.Import
+	err := r.db.WithContext(ctx).
+		Select("id", "profile_id", "records_pending").
+		Where("records_pending <> '{}'").
+		Order("completed_at ASC").
+		Limit(limit).
+		Find(&imports).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch workout imports with pending records: %w", err)
+	}
+	return imports, nil
+}
+
+// Commit creates the sessions of an import with their logs, in the named program with a workout
+// per workout name, and completes the import. Sessions whose import key an earlier import already
+// created are skipped. All of it happens in one transaction, so a failed commit can be retried and
+// two concurrent commits of the same import can't both succeed. The created sessions are returned.
+func (r *importRepository) Commit(ctx context.Context, imp *model.Import, programName string, sessions []model.Session, now time.Time) ([]model.Session, error) {
+	var created []model.Session
+	setsImported := 0
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.Import{}).
+			Where("id = ? AND status = ?", imp.ID, model.StatusPendingReview).
+			Update("status", model.StatusCompleted)
+		if result.Error != nil {
+			return fmt.Errorf("failed to complete workout import: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return customerrors.ErrImportCompleted
+		}
+
+		var existingKeys []string
+		err := tx.Model(&progressmodel.WorkoutSession{}).
+			Where("profile_id = ? AND import_key LIKE ?", imp.ProfileID, imp.Source+":%").
+			Pluck("import_key", &existingKeys).Error
+		if err != nil {
+			return fmt.Errorf("failed to fetch imported workout sessions: %w", err)
+		}
+		existing := make(map[string]bool, len(existingKeys))
+		for _, key := range existingKeys {
+			existing[key] = true
+		}
+		for _, session := range sessions {
+			if !existing[session.ImportKey] {
+				created = append(created, session)
+			}
+		}
+
+		if len(created) > 0 {
+			workouts, err := r.getOrCreateWorkouts(tx, imp.ProfileID, programName, created)
+			if err != nil {
+				return err
+			}
+			for i := range created {
+				if err := createSession(tx, imp.ProfileID, &created[i], workouts[created[i].WorkoutName]); err != nil {
+					return err
+				}
+				setsImported += len(created[i].Logs)
+			}
+		}
+
+		err = tx.Model(&model.Import{}).Where("id = ?", imp.ID).Updates(map[string]any{
+			"sessions_imported": len(created),
+			"sets_imported":     setsImported,
+			"sessions_skipped":  len(sessions) - len(created),
+			"completed_at":      now,
+		}).Error
+		if err != nil {
+			return fmt.Errorf("failed to update workout import: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, customerrors.ErrImportCompleted) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to commit workout import: %w", err)
+	}
+	imp.Status = model.StatusCompleted
+	imp.SessionsImported = len(created)
+	imp.SetsImported = setsImported
+	imp.SessionsSkipped = len(sessions) - len(created)
+	imp.CompletedAt = &now
+	return created, nil
+}
+
+// getOrCreateWorkouts finds the program of the profile by name and its workouts for the workout
+// names of the sessions, creating what is missing. Imports of the same tracker share the program.
+func (r *importRepository) getOrCreateWorkouts(tx *gorm.DB, profileID, programName string, sessions []model.Session) (map[string]trainingmodel.Workout, error) {
+	var program trainingmodel.TrainingProgram
+	err := tx.Where("profile_id = ? AND name = ?", profileID, programName).First(&program).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		program = trainingmodel.TrainingProgram{
+			Name:        programName,
+			ProfileID:   profileID,
+			Description: "Workouts imported from the history of another tracker",
+		}
+		err = tx.Create(&program).Error
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get training program of import: %w", err)
+	}
+
+	var existing []trainingmodel.Workout
+	if err := tx.Where("training_program_id = ?", program.ID).Order("position ASC").Find(&existing).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch workouts of import: %w", err)
+	}
+	workouts := make(map[string]trainingmodel.Workout, len(existing))
+	for _, workout := range existing {
+		if _, ok := workouts[workout.Name]; !ok {
+			workouts[workout.Name] = workout
+		}
+	}
+	position := len(existing)
+	for _, session := range sessions {
+		if _, ok := workouts[session.WorkoutName]; ok {
+			continue
+		}
+		position++
+		workout := trainingmodel.Workout{Name: session.WorkoutName, TrainingProgramID: program.ID, Position: position}
+		if err := tx.Create(&workout).Error; err != nil {
+			return nil, fmt.Errorf("failed to create workout of import: %w", err)
+		}
+		workouts[workout.Name] = workout
+	}
+	return workouts, nil
+}
+
+// createSession inserts a session with its logs, filling in the IDs of the logs
+func createSession(tx *gorm.DB, profileID string, session *model.Session, workout trainingmodel.Workout) error {
+	snapshot, err := session.Snapshot(workout)
+	if err != nil {
+		return err
+	}
+	completedAt := session.CompletedAt
+	importKey := session.ImportKey
+	workoutSession := progressmodel.WorkoutSession{
+		ProfileID:   profileID,
+		WorkoutID:   workout.ID,
+		Snapshot:    snapshot,
+		StartedAt:   session.StartedAt,
+		CompletedAt: &completedAt,
+		ImportKey:   &importKey,
+	}
+	if err := tx.Omit("Logs").Create(&workoutSession).Error; err != nil {
+		return fmt.Errorf("failed to create workout session: %w", err)
+	}
+	for i := range session.Logs {
+		session.Logs[i].ProfileID = profileID
+		session.Logs[i].SessionID = workoutSession.ID
+	}
+	if len(session.Logs) == 0 {
+		return nil
+	}
+	if err := tx.CreateInBatches(&session.Logs, logBatchSize).Error; err != nil {
+		return fmt.Errorf("failed to create exercise logs: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,387 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/VladimirKholomyanskyy/gym-api/internal/common"
+	customerrors "github.com/VladimirKholomyanskyy/gym-api/internal/customErrors"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/imports/formats"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/imports/model"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/imports/repository"
+	progressmodel "github.com/VladimirKholomyanskyy/gym-api/internal/progress/model"
+	progressusecase "github.com/VladimirKholomyanskyy/gym-api/internal/progress/usecase"
+	trainingmodel "github.com/VladimirKholomyanskyy/gym-api/internal/training/model"
+	"github.com/lib/pq"
+)
+
+// ImportUseCase brings the history of other workout trackers into the profile. An uploaded export
+// is parsed and kept with suggested exercises for its exercise names; once the profile has reviewed
+// them the import is committed into workout sessions and exercise logs with their original times.
+type ImportUseCase interface {
+	Upload(ctx context.Context, profileID string, r io.Reader, source formats.Source, units common.Units, location *time.Location) (*model.Import, error)
+	GetByID(ctx context.Context, profileID, importID string) (*model.Import, error)
+	UpdateMappings(ctx context.Context, profileID, importID string, mappings map[string]*string) (*model.Import, error)
+	Commit(ctx context.Context, profileID, importID string, now time.Time) (*model.Import, error)
+	GetExercises(ctx context.Context) (map[string]trainingmodel.Exercise, error)
+	RecomputePendingRecords(ctx context.Context) error
+}
+
+// recomputeBatchSize bounds how many imports a single run of the records retry handles
+const recomputeBatchSize = 20
+
+type importUseCase struct {
+	repo              repository.ImportRepository
+	personalRecordsUC progressusecase.PersonalRecordUseCase
+}
+
+func NewImportUseCase(repo repository.ImportRepository, personalRecordsUC progressusecase.PersonalRecordUseCase) ImportUseCase {
+	return &importUseCase{repo: repo, personalRecordsUC: personalRecordsUC}
+}
+
+// Upload parses an export and stores its sets for review. Weights are converted to kilograms from
+// the units of the export, or from the given units when the export doesn't tell. Warmup sets are
+// left out, as exercise logs can't tell them apart from working sets, and so are sets heavier than
+// a log stores. Exercise names are mapped
+// as in earlier imports of the profile, names new to the profile are matched by name.
+func (uc *importUseCase) Upload(ctx context.Context, profileID string, r io.Reader, source formats.Source, units common.Units, location *time.Location) (*model.Import, error) {
+	export, err := formats.Parse(r, source, location)
+	if err != nil {
+		return nil, err
+	}
+	if export.Units != "" {
+		units = export.Units
+	}
+	skippedRows := export.SkippedRows
+	sets := make([]model.Set, 0, len(export.Sets))
+	for _, set := range export.Sets {
+		weight := progressmodel.RoundWeight(units.ToKilograms(set.Weight))
+		// A weight a log can't store would fail the whole commit, so the row is skipped here
+		if set.Warmup || weight > progressmodel.MaxWeight {
+			skippedRows++
+			continue
+		}
+		sets = append(sets, model.Set{
+			WorkoutName: set.WorkoutName,
+			StartedAt:   set.StartedAt,
+			EndedAt:     set.EndedAt,
+			Exercise:    set.Exercise,
+			Weight:      weight,
+			Reps:        set.Reps,
+			RPE:         set.RPE,
+			ToFailure:   set.ToFailure,
+			Note:        set.Note,
+		})
+	}
+	if len(sets) == 0 {
+		return nil, customerrors.ErrEmptyImport
+	}
+
+	mappings, err := uc.suggestMappings(ctx, profileID, model.ExerciseNames(sets))
+	if err != nil {
+		return nil, err
+	}
+	setsJSON, err := json.Marshal(sets)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal import sets: %w", err)
+	}
+	mappingsJSON, err := json.Marshal(mappings)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal import mappings: %w", err)
+	}
+	imp := &model.Import{
+		ProfileID:   profileID,
+		Source:      string(export.Source),
+		Status:      model.StatusPendingReview,
+		Sets:        setsJSON,
+		Mappings:    mappingsJSON,
+		SkippedRows: skippedRows,
+	}
+	if err := uc.repo.Create(ctx, imp); err != nil {
+		return nil, err
+	}
+	return imp, nil
+}
+
+// suggestMappings maps the names as the latest completed import of the profile mapping them did,
+// and matches the others against the exercises by name. Names matching nothing stay unmapped.
+func (uc *importUseCase) suggestMappings(ctx context.Context, profileID string, names []string) (model.Mappings, error) {
+	previous := model.Mappings{}
+	imports, err := uc.repo.GetCompletedByProfileID(ctx, profileID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range imports {
+		mappings, err := imports[i].DecodeMappings()
+		if err != nil {
+			return nil, err
+		}
+		for name, exerciseID := range mappings {
+			previous[name] = exerciseID
+		}
+	}
+	exercises, err := uc.repo.GetExercises(ctx)
+	if err != nil {
+		return nil, err
+	}
+	mappings := model.Mappings{}
+	for _, name := range names {
+		if exerciseID, ok := previous[name]; ok {
+			mappings[name] = exerciseID
+		} else if exerciseID, ok := matchExercise(name, exercises); ok {
+			mappings[name] = exerciseID
+		}
+	}
+	return mappings, nil
+}
+
+// GetByID retrieves an import ensuring it belongs to the profile
+func (uc *importUseCase) GetByID(ctx context.Context, profileID, importID string) (*model.Import, error) {
+	imp, err := uc.repo.GetByID(ctx, importID)
+	if err != nil {
+		return nil, err
+	}
+	if imp.ProfileID != profileID {
+		return nil, customerrors.ErrAccessForbidden
+	}
+	return imp, nil
+}
+
+// UpdateMappings maps exercise names of an import onto exercises, a nil exercise ID skips the
+// sets of the name. Names not in the export are ignored, unknown exercises aren't found.
+func (uc *importUseCase) UpdateMappings(ctx context.Context, profileID, importID string, updates map[string]*string) (*model.Import, error) {
+	imp, err := uc.GetByID(ctx, profileID, importID)
+	if err != nil {
+		return nil, err
+	}
+	if imp.Status != model.StatusPendingReview {
+		return nil, customerrors.ErrImportCompleted
+	}
+	sets, err := imp.DecodeSets()
+	if err != nil {
+		return nil, err
+	}
+	mappings, err := imp.DecodeMappings()
+	if err != nil {
+		return nil, err
+	}
+	exercises, err := uc.GetExercises(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range model.ExerciseNames(sets) {
+		exerciseID, ok := updates[name]
+		if !ok {
+			continue
+		}
+		if exerciseID == nil {
+			mappings[name] = ""
+			continue
+		}
+		if _, ok := exercises[*exerciseID]; !ok {
+			return nil, customerrors.ErrEntityNotFound
+		}
+		mappings[name] = *exerciseID
+	}
+	mappingsJSON, err := json.Marshal(mappings)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal import mappings: %w", err)
+	}
+	if err := uc.repo.UpdatePartial(ctx, imp.ID, map[string]any{"mappings": mappingsJSON}); err != nil {
+		return nil, err
+	}
+	imp.Mappings = mappingsJSON
+	return imp, nil
+}
+
+// Commit creates the sessions and logs of an import once all its exercise names are mapped or
+// skipped. Sessions an earlier import already created are skipped, so uploading the same export
+// again only adds what is new. The personal records of the exercises are recomputed afterwards,
+// the exercises whose records failed are kept on the import and retried in the background.
+func (uc *importUseCase) Commit(ctx context.Context, profileID, importID string, now time.Time) (*model.Import, error) {
+	imp, err := uc.GetByID(ctx, profileID, importID)
+	if err != nil {
+		return nil, err
+	}
+	if imp.Status != model.StatusPendingReview {
+		return nil, customerrors.ErrImportCompleted
+	}
+	sets, err := imp.DecodeSets()
+	if err != nil {
+		return nil, err
+	}
+	mappings, err := imp.DecodeMappings()
+	if err != nil {
+		return nil, err
+	}
+	if len(mappings.Unmapped(model.ExerciseNames(sets))) > 0 {
+		return nil, customerrors.ErrUnmappedExercises
+	}
+	sessions := planSessions(formats.Source(imp.Source), sets, mappings)
+	created, err := uc.repo.Commit(ctx, imp, ProgramName(formats.Source(imp.Source)), sessions, now)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Workout import %s created %d sessions, skipped %d imported before", imp.ID, imp.SessionsImported, imp.SessionsSkipped)
+
+	exerciseIDs := make(map[string]bool)
+	for _, session := range created {
+		for _, exerciseLog := range session.Logs {
+			exerciseIDs[exerciseLog.ExerciseID] = true
+		}
+	}
+	pending := uc.recomputePersonalRecords(ctx, profileID, exerciseIDs)
+	if len(pending) > 0 {
+		if err := uc.repo.UpdatePartial(ctx, imp.ID, map[string]any{"records_pending": pending}); err != nil {
+			return nil, err
+		}
+		imp.RecordsPending = pending
+	}
+	return imp, nil
+}
+
+// RecomputePendingRecords retries the personal records that failed to be recomputed when imports
+// were committed. An import keeps the exercises that fail again for the next run.
+func (uc *importUseCase) RecomputePendingRecords(ctx context.Context) error {
+	imports, err := uc.repo.GetWithRecordsPending(ctx, recomputeBatchSize)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, imp := range imports {
+		exerciseIDs := make(map[string]bool, len(imp.RecordsPending))
+		for _, exerciseID := range imp.RecordsPending {
+			exerciseIDs[exerciseID] = true
+		}
+		pending := uc.recomputePersonalRecords(ctx, imp.ProfileID, exerciseIDs)
+		if len(pending) == len(imp.RecordsPending) {
+			continue
+		}
+		if err := uc.repo.UpdatePartial(ctx, imp.ID, map[string]any{"records_pending": pending}); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// GetExercises retrieves the exercises by ID, to name the exercises of the mappings
+func (uc *importUseCase) GetExercises(ctx context.Context) (map[string]trainingmodel.Exercise, error) {
+	exercises, err := uc.repo.GetExercises(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]trainingmodel.Exercise, len(exercises))
+	for _, exercise := range exercises {
+		byID[exercise.ID] = exercise
+	}
+	return byID, nil
+}
+
+// recomputePersonalRecords refreshes the records of imported exercises and returns the exercises
+// that failed, sorted. The sessions are already stored, so a failure doesn't undo the import.
+func (uc *importUseCase) recomputePersonalRecords(ctx context.Context, profileID string, exerciseIDs map[string]bool) pq.StringArray {
+	pending := pq.StringArray{}
+	for exerciseID := range exerciseIDs {
+		if err := uc.personalRecordsUC.Recompute(ctx, profileID, exerciseID); err != nil {
+			log.Printf("Failed to recompute personal records of exercise %s: %v", exerciseID, err)
+			pending = append(pending, exerciseID)
+		}
+	}
+	sort.Strings(pending)
+	return pending
+}
+
+// ProgramName is the name of the training program that holds the workouts imported from a source
+func ProgramName(source formats.Source) string {
+	switch source {
+	case formats.SourceStrong:
+		return "Imported from Strong"
+	case formats.SourceHevy:
+		return "Imported from Hevy"
+	case formats.SourceFitNotes:
+		return "Imported from FitNotes"
+	}
+	return "Imported"
+}
+
+// planSessions groups the sets into a session per workout and start, in the order they started.
+// Sets of skipped names are left out and so are sessions left without sets. Sets are numbered per
+// exercise in the order of the export and logged a second apart from the start of the session, so
+// their order survives. A session completes when its workout ended, or at its last set when the
+// export doesn't tell.
+func planSessions(source formats.Source, sets []model.Set, mappings model.Mappings) []model.Session {
+	var sessions []model.Session
+	index := make(map[string]int)
+	setNumbers := make(map[string]map[string]int)
+	for _, set := range sets {
+		exerciseID := mappings[set.Exercise]
+		if exerciseID == "" {
+			continue
+		}
+		key := importKey(source, set)
+		i, ok := index[key]
+		if !ok {
+			i = len(sessions)
+			index[key] = i
+			setNumbers[key] = make(map[string]int)
+			sessions = append(sessions, model.Session{
+				ImportKey:   key,
+				WorkoutName: set.WorkoutName,
+				StartedAt:   set.StartedAt,
+				CompletedAt: set.StartedAt,
+			})
+		}
+		session := &sessions[i]
+		setNumbers[key][exerciseID]++
+		exerciseLog := progressmodel.ExerciseLog{
+			ExerciseID: exerciseID,
+			SetNumber:  setNumbers[key][exerciseID],
+			Reps:       set.Reps,
+			Weight:     set.Weight,
+			LoggedAt:   session.StartedAt.Add(time.Duration(len(session.Logs)) * time.Second),
+			RPE:        roundRPE(set.RPE),
+			ToFailure:  set.ToFailure,
+		}
+		if set.Note != "" {
+			note := set.Note
+			exerciseLog.Note = &note
+		}
+		session.Logs = append(session.Logs, exerciseLog)
+		completedAt := exerciseLog.LoggedAt
+		if set.EndedAt != nil {
+			completedAt = *set.EndedAt
+		}
+		if completedAt.After(session.CompletedAt) {
+			session.CompletedAt = completedAt
+		}
+	}
+	sort.SliceStable(sessions, func(i, j int) bool {
+		return sessions[i].StartedAt.Before(sessions[j].StartedAt)
+	})
+	return sessions
+}
+
+// importKey identifies the session of a set across uploads: trackers export a workout with the
+// same name and start every time
+func importKey(source formats.Source, set model.Set) string {
+	return fmt.Sprintf("%s:%s:%s", source, set.StartedAt.UTC().Format(time.RFC3339), set.WorkoutName)
+}
+
+// roundRPE rounds an RPE to the half points exercise logs take
+func roundRPE(rpe *float64) *float64 {
+	if rpe == nil {
+		return nil
+	}
+	rounded := math.Round(*rpe*2) / 2
+	if !progressmodel.IsValidRPE(rounded) {
+		return nil
+	}
+	return &rounded
+}
@@ -0,0 +1,206 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/VladimirKholomyanskyy/gym-api/internal/common"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/imports/formats"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/imports/model"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/imports/repository"
+	progressmodel "github.com/VladimirKholomyanskyy/gym-api/internal/progress/model"
+	progressusecase "github.com/VladimirKholomyanskyy/gym-api/internal/progress/usecase"
+	trainingmodel "github.com/VladimirKholomyanskyy/gym-api/internal/training/model"
+	"github.com/lib/pq"
+)
+
+func TestMatchExercise(t *testing.T) {
+	exercises := []trainingmodel.Exercise{
+		{ID: "bench", Name: "Bench Press", Equipment: "Barbell"},
+		{ID: "db-bench", Name: "Dumbbell Bench Press", Equipment: "Dumbbell"},
+		{ID: "squat", Name: "Squat", Equipment: "Barbell"},
+		{ID: "pull-up", Name: "Pull-Up", Equipment: "Bodyweight"},
+	}
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Bench Press", "bench"},
+		{"bench press (barbell)", "bench"},
+		{"Barbell Bench Press", "bench"},
+		{"Bench Press (Dumbbell)", ""},
+		{"Dumbbell Bench Press", "db-bench"},
+		{"Squat (Barbell)", "squat"},
+		{"Pull Up", "pull-up"},
+		{"Pull Up (Weighted)", ""},
+		{"Flat Barbell Bench Press", ""},
+	}
+	for _, tt := range tests {
+		got, ok := matchExercise(tt.name, exercises)
+		if got != tt.want || ok != (tt.want != "") {
+			t.Errorf("matchExercise(%q) = %q, %v, want %q", tt.name, got, ok, tt.want)
+		}
+	}
+}
+
+func TestPlanSessions(t *testing.T) {
+	push := time.Date(2024, 3, 4, 18, 0, 0, 0, time.UTC)
+	pushEnd := push.Add(time.Hour)
+	legs := push.Add(-48 * time.Hour)
+	rpe := 8.3
+	sets := []model.Set{
+		{WorkoutName: "Push", StartedAt: push, EndedAt: &pushEnd, Exercise: "Bench Press", Weight: 80, Reps: 5, RPE: &rpe},
+		{WorkoutName: "Push", StartedAt: push, EndedAt: &pushEnd, Exercise: "Cable Fly", Weight: 10, Reps: 12},
+		{WorkoutName: "Push", StartedAt: push, EndedAt: &pushEnd, Exercise: "Bench Press", Weight: 80, Reps: 4, Note: "Grinder"},
+		{WorkoutName: "Legs", StartedAt: legs, Exercise: "Squat", Weight: 100, Reps: 5},
+		{WorkoutName: "Legs", StartedAt: legs, Exercise: "Squat", Weight: 100, Reps: 5},
+	}
+	mappings := model.Mappings{"Bench Press": "bench", "Cable Fly": "", "Squat": "squat"}
+
+	sessions := planSessions(formats.SourceStrong, sets, mappings)
+	if len(sessions) != 2 {
+		t.Fatalf("planSessions() got %d sessions, want 2", len(sessions))
+	}
+	legsSession, pushSession := sessions[0], sessions[1]
+	if legsSession.ImportKey != "strong:2024-03-02T18:00:00Z:Legs" {
+		t.Errorf("ImportKey = %q", legsSession.ImportKey)
+	}
+	if !legsSession.CompletedAt.Equal(legs.Add(time.Second)) {
+		t.Errorf("session without an end completes at %v, want at its last set", legsSession.CompletedAt)
+	}
+	if !pushSession.CompletedAt.Equal(pushEnd) {
+		t.Errorf("CompletedAt = %v, want %v", pushSession.CompletedAt, pushEnd)
+	}
+	if len(pushSession.Logs) != 2 {
+		t.Fatalf("push session has %d logs, want 2 without the skipped exercise", len(pushSession.Logs))
+	}
+	first, second := pushSession.Logs[0], pushSession.Logs[1]
+	if first.SetNumber != 1 || second.SetNumber != 2 || !second.LoggedAt.Equal(push.Add(time.Second)) {
+		t.Errorf("logs = %+v, %+v, want sets 1 and 2 a second apart", first, second)
+	}
+	if first.RPE == nil || *first.RPE != 8.5 {
+		t.Errorf("RPE = %v, want 8.5", first.RPE)
+	}
+	if second.Note == nil || *second.Note != "Grinder" {
+		t.Errorf("Note = %v, want Grinder", second.Note)
+	}
+}
+
+type fakeImportRepository struct {
+	repository.ImportRepository
+	imp     *model.Import
+	created []model.Session
+	pending []model.Import
+	updates map[string]map[string]any
+}
+
+func (r *fakeImportRepository) Create(ctx context.Context, imp *model.Import) error {
+	r.imp = imp
+	return nil
+}
+
+func (r *fakeImportRepository) GetCompletedByProfileID(ctx context.Context, profileID string) ([]model.Import, error) {
+	return nil, nil
+}
+
+func (r *fakeImportRepository) GetExercises(ctx context.Context) ([]trainingmodel.Exercise, error) {
+	return nil, nil
+}
+
+func (r *fakeImportRepository) GetByID(ctx context.Context, id string) (*model.Import, error) {
+	return r.imp, nil
+}
+
+func (r *fakeImportRepository) Commit(ctx context.Context, imp *model.Import, programName string, sessions []model.Session, now time.Time) ([]model.Session, error) {
+	imp.Status = model.StatusCompleted
+	return r.created, nil
+}
+
+func (r *fakeImportRepository) GetWithRecordsPending(ctx context.Context, limit int) ([]model.Import, error) {
+	return r.pending, nil
+}
+
+func (r *fakeImportRepository) UpdatePartial(ctx context.Context, id string, updates map[string]any) error {
+	if r.updates == nil {
+		r.updates = make(map[string]map[string]any)
+	}
+	r.updates[id] = updates
+	return nil
+}
+
+type fakePersonalRecordUseCase struct {
+	progressusecase.PersonalRecordUseCase
+	failing map[string]bool
+}
+
+func (uc *fakePersonalRecordUseCase) Recompute(ctx context.Context, profileID, exerciseID string) error {
+	if uc.failing[exerciseID] {
+		return errors.New("database is gone")
+	}
+	return nil
+}
+
+func TestUploadSkipsWeightsLogsCantStore(t *testing.T) {
+	csv := "Date,Workout Name,Duration,Exercise Name,Set Order,Weight,Reps\n" +
+		"2024-03-04 18:12:55,Pull,1h 5m,Deadlift,1,2204,1\n" +
+		// 1000.17 kg, a typo that would overflow the weight of a log
+		"2024-03-04 18:12:55,Pull,1h 5m,Deadlift,2,2205,1\n"
+	repo := &fakeImportRepository{}
+	uc := NewImportUseCase(repo, &fakePersonalRecordUseCase{})
+
+	imp, err := uc.Upload(context.Background(), "profile", strings.NewReader(csv), formats.SourceStrong, common.ImperialUnits, time.UTC)
+	if err != nil {
+		t.Fatalf("Upload() error = %v", err)
+	}
+	var sets []model.Set
+	if err := json.Unmarshal(imp.Sets, &sets); err != nil {
+		t.Fatal(err)
+	}
+	if len(sets) != 1 || sets[0].Weight != 999.72 || imp.SkippedRows != 1 {
+		t.Errorf("Upload() kept %+v and skipped %d rows, want the 999.72 kg set and one skipped", sets, imp.SkippedRows)
+	}
+}
+
+func TestCommitKeepsFailedRecords(t *testing.T) {
+	sets, _ := json.Marshal([]model.Set{
+		{WorkoutName: "Push", StartedAt: time.Date(2024, 3, 4, 18, 0, 0, 0, time.UTC), Exercise: "Bench Press", Weight: 80, Reps: 5},
+		{WorkoutName: "Push", StartedAt: time.Date(2024, 3, 4, 18, 0, 0, 0, time.UTC), Exercise: "Dips", Weight: 0, Reps: 10},
+	})
+	mappings, _ := json.Marshal(model.Mappings{"Bench Press": "bench", "Dips": "dips"})
+	repo := &fakeImportRepository{
+		imp:     &model.Import{Base: common.Base{ID: "import"}, ProfileID: "profile", Source: "strong", Status: model.StatusPendingReview, Sets: sets, Mappings: mappings},
+		created: []model.Session{{Logs: []progressmodel.ExerciseLog{{ExerciseID: "bench"}, {ExerciseID: "dips"}}}},
+	}
+	uc := NewImportUseCase(repo, &fakePersonalRecordUseCase{failing: map[string]bool{"dips": true}})
+	imp, err := uc.Commit(context.Background(), "profile", "import", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (pq.StringArray{"dips"}); !reflect.DeepEqual(imp.RecordsPending, want) || !reflect.DeepEqual(repo.updates["import"]["records_pending"], want) {
+		t.Errorf("records pending = %v, stored %v, want %v", imp.RecordsPending, repo.updates["import"], want)
+	}
+}
+
+func TestRecomputePendingRecords(t *testing.T) {
+	repo := &fakeImportRepository{pending: []model.Import{
+		{Base: common.Base{ID: "recovered"}, ProfileID: "profile", RecordsPending: pq.StringArray{"bench", "squat"}},
+		{Base: common.Base{ID: "partly"}, ProfileID: "profile", RecordsPending: pq.StringArray{"bench", "dips"}},
+		{Base: common.Base{ID: "failing"}, ProfileID: "profile", RecordsPending: pq.StringArray{"dips"}},
+	}}
+	uc := NewImportUseCase(repo, &fakePersonalRecordUseCase{failing: map[string]bool{"dips": true}})
+	if err := uc.RecomputePendingRecords(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]map[string]any{
+		"recovered": {"records_pending": pq.StringArray{}},
+		"partly":    {"records_pending": pq.StringArray{"dips"}},
+	}
+	if !reflect.DeepEqual(repo.updates, want) {
+		t.Errorf("updates = %v, want %v", repo.updates, want)
+	}
+}
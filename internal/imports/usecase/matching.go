@@ -0,0 +1,64 @@
+package usecase
+
+import (
+	"strings"
+	"unicode"
+
+	trainingmodel "github.com/VladimirKholomyanskyy/gym-api/internal/training/model"
+)
+
+// matchExercise suggests the exercise an exercise name of an export stands for. Trackers name
+// exercises like "Bench Press (Barbell)" or "Barbell Bench Press", so a name matches an exercise
+// when it is the exercise name, optionally qualified with the equipment of the exercise in
+// parentheses or as extra words. Names matching no exercise, or more than one, aren't suggested.
+func matchExercise(name string, exercises []trainingmodel.Exercise) (string, bool) {
+	base, qualifier := name, ""
+	if open := strings.LastIndex(name, "("); open > 0 && strings.HasSuffix(strings.TrimSpace(name), ")") {
+		base = name[:open]
+		qualifier = strings.TrimSuffix(strings.TrimSpace(name[open+1:]), ")")
+	}
+	full, base, qualifier := normalizeName(name), normalizeName(base), normalizeName(qualifier)
+
+	var matches []string
+	for _, exercise := range exercises {
+		exerciseName := normalizeName(exercise.Name)
+		equipment := normalizeName(exercise.Equipment)
+		switch {
+		case full == exerciseName:
+			return exercise.ID, true
+		case base == exerciseName && (qualifier == "" || qualifier == equipment):
+			matches = append(matches, exercise.ID)
+		case qualifier == "" && sameWords(full, exerciseName+" "+equipment):
+			matches = append(matches, exercise.ID)
+		}
+	}
+	if len(matches) != 1 {
+		return "", false
+	}
+	return matches[0], true
+}
+
+// normalizeName lowercases a name and turns everything but letters and digits into single spaces,
+// so "Pull-Up" and "pull up" are the same
+func normalizeName(name string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
+
+// sameWords reports whether two normalized names have the same words in any order
+func sameWords(a, b string) bool {
+	words := make(map[string]int)
+	for _, word := range strings.Fields(a) {
+		words[word]++
+	}
+	for _, word := range strings.Fields(b) {
+		words[word]--
+	}
+	for _, count := range words {
+		if count != 0 {
+			return false
+		}
+	}
+	return true
+}
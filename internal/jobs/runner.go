@@ -0,0 +1,67 @@
+// Package jobs runs periodic background work next to the API server.
+package jobs
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// Job is a unit of background work. Runs have to be idempotent: a job runs once when the runner
+// starts and then on every interval, and a run that fails is simply retried with the next one.
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) error
+}
+
+// Runner runs jobs on their intervals until its context is cancelled
+type Runner struct {
+	jobs []Job
+	wg   sync.WaitGroup
+}
+
+func NewRunner() *Runner {
+	return &Runner{}
+}
+
+// Add registers a job, jobs added after Start are not run
+func (r *Runner) Add(name string, interval time.Duration, run func(ctx context.Context) error) {
+	r.jobs = append(r.jobs, Job{Name: name, Interval: interval, Run: run})
+}
+
+// Start runs every job in its own goroutine. Runs of the same job never overlap.
+func (r *Runner) Start(ctx context.Context) {
+	for _, job := range r.jobs {
+		r.wg.Add(1)
+		go func(job Job) {
+			defer r.wg.Done()
+			ticker := time.NewTicker(job.Interval)
+			defer ticker.Stop()
+			for {
+				r.run(ctx, job)
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				}
+			}
+		}(job)
+	}
+}
+
+// Wait blocks until every job has returned after the context of Start is cancelled
+func (r *Runner) Wait() {
+	r.wg.Wait()
+}
+
+func (r *Runner) run(ctx context.Context, job Job) {
+	if ctx.Err() != nil {
+		return
+	}
+	started := time.Now()
+	if err := job.Run(ctx); err != nil {
+		log.Printf("Job %q failed after %s: %v", job.Name, time.Since(started).Round(time.Millisecond), err)
+	}
+}
@@ -0,0 +1,303 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	openapi "github.com/VladimirKholomyanskyy/gym-api/internal/api/go"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/common"
+	customerrors "github.com/VladimirKholomyanskyy/gym-api/internal/customErrors"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/measurements/model"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/measurements/usecase"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/utils"
+)
+
+const (
+	// defaultTrendDays is how far back a trend looks when no start date is given
+	defaultTrendDays = 90
+	// maxTrendDays bounds the history a single trend request reads
+	maxTrendDays = 3 * 366
+	// defaultWindowDays is the moving average window when none is given
+	defaultWindowDays = 7
+	// maxWindowDays bounds the moving average window
+	maxWindowDays = 90
+)
+
+type measurementHandler struct {
+	useCase usecase.MeasurementUseCase
+}
+
+func NewMeasurementHandler(useCase usecase.MeasurementUseCase) openapi.BodyMeasurementsAPIServicer {
+	return &measurementHandler{useCase: useCase}
+}
+
+// ListBodyMeasurements - Retrieve the body measurements of the profile, newest first
+func (h *measurementHandler) ListBodyMeasurements(ctx context.Context, measurementType, startDate, endDate string, page, pageSize int32) (openapi.ImplResponse, error) {
+	profileId, err := common.ExtractProfileID(ctx)
+	if err != nil {
+		return utils.ErrorResponse(http.StatusUnauthorized, openapi.FORBIDDEN, err.Error())
+	}
+	if !common.IsPageValid(page) {
+		return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_PAGE_NUMBER, "page must be greater than 0")
+	}
+	if !common.IsPageSizeValid(pageSize) {
+		return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_PAGE_SIZE, "pageSize must be between 1 and 100")
+	}
+	var typeFilter *model.Type
+	if measurementType != "" {
+		parsed, ok := model.ParseType(measurementType)
+		if !ok {
+			return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_REQUEST, typeError())
+		}
+		typeFilter = &parsed
+	}
+	// Without a start date the whole history is listed
+	from, to, errResponse := parseDateRange(startDate, endDate, common.ExtractLocation(ctx), 0)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+	measurements, totalCount, err := h.useCase.List(ctx, profileId, typeFilter, from, to, int(page), int(pageSize))
+	if err != nil {
+		return utils.ErrorResponse(http.StatusInternalServerError, openapi.INTERNAL_SERVER_ERROR, "Failed to fetch body measurements")
+	}
+	return openapi.Response(http.StatusOK, openapi.ListBodyMeasurements200Response{
+		TotalItems:  int32(totalCount),
+		CurrentPage: page,
+		PageSize:    pageSize,
+		TotalPages:  utils.CalculateTotalPages(totalCount, pageSize),
+		Items:       convertMeasurements(measurements, common.ExtractUnits(ctx)),
+	}), nil
+}
+
+// CreateBodyMeasurement - Record a body measurement, taken now unless a time is given
+func (h *measurementHandler) CreateBodyMeasurement(ctx context.Context, request openapi.CreateBodyMeasurementRequest) (openapi.ImplResponse, error) {
+	profileId, err := common.ExtractProfileID(ctx)
+	if err != nil {
+		return utils.ErrorResponse(http.StatusUnauthorized, openapi.FORBIDDEN, err.Error())
+	}
+	measurementType, ok := model.ParseType(request.Type)
+	if !ok {
+		return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_REQUEST, typeError())
+	}
+	units := common.ExtractUnits(ctx)
+	value := measurementType.ToMetric(request.Value, units)
+	if !measurementType.IsValidValue(value) {
+		return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_REQUEST, fmt.Sprintf("value is not a valid %s in %s", measurementType, measurementType.UnitIn(units)))
+	}
+	input := model.CreateMeasurementInput{ProfileID: profileId, Type: measurementType, Value: value, MeasuredAt: time.Now()}
+	if request.MeasuredAt != nil {
+		if request.MeasuredAt.After(time.Now()) {
+			return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_REQUEST, "measuredAt can't be in the future")
+		}
+		input.MeasuredAt = *request.MeasuredAt
+	}
+	if utils.HasText(request.Notes) {
+		notes := utils.TrimPointer(request.Notes)
+		input.Notes = &notes
+	}
+	measurement, err := h.useCase.Create(ctx, input)
+	if err != nil {
+		return utils.ErrorResponse(http.StatusInternalServerError, openapi.INTERNAL_SERVER_ERROR, "Failed to record body measurement")
+	}
+	return openapi.Response(http.StatusCreated, convertMeasurement(measurement, common.ExtractUnits(ctx))), nil
+}
+
+// GetLatestBodyMeasurements - Retrieve the latest measurement of every type the profile has measured
+func (h *measurementHandler) GetLatestBodyMeasurements(ctx context.Context) (openapi.ImplResponse, error) {
+	profileId, err := common.ExtractProfileID(ctx)
+	if err != nil {
+		return utils.ErrorResponse(http.StatusUnauthorized, openapi.FORBIDDEN, err.Error())
+	}
+	measurements, err := h.useCase.GetLatest(ctx, profileId)
+	if err != nil {
+		return utils.ErrorResponse(http.StatusInternalServerError, openapi.INTERNAL_SERVER_ERROR, "Failed to fetch latest body measurements")
+	}
+	return openapi.Response(http.StatusOK, openapi.GetLatestBodyMeasurements200Response{Items: convertMeasurements(measurements, common.ExtractUnits(ctx))}), nil
+}
+
+// GetBodyMeasurementTrend - Retrieve the daily values of a measurement type with their moving average
+func (h *measurementHandler) GetBodyMeasurementTrend(ctx context.Context, measurementType, startDate, endDate string, windowDays int32) (openapi.ImplResponse, error) {
+	profileId, err := common.ExtractProfileID(ctx)
+	if err != nil {
+		return utils.ErrorResponse(http.StatusUnauthorized, openapi.FORBIDDEN, err.Error())
+	}
+	trendType, ok := model.ParseType(measurementType)
+	if !ok {
+		return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_REQUEST, typeError())
+	}
+	if windowDays == 0 {
+		windowDays = defaultWindowDays
+	}
+	if windowDays < 1 || windowDays > maxWindowDays {
+		return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_REQUEST, fmt.Sprintf("windowDays must be between 1 and %d", maxWindowDays))
+	}
+	location := common.ExtractLocation(ctx)
+	from, to, errResponse := parseDateRange(startDate, endDate, location, defaultTrendDays)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+	if from.AddDate(0, 0, maxTrendDays).Before(to) {
+		return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_DATE_RANGE, "Date range can't be longer than 3 years")
+	}
+	trend, err := h.useCase.GetTrend(ctx, profileId, trendType, from, to, int(windowDays), location)
+	if err != nil {
+		return utils.ErrorResponse(http.StatusInternalServerError, openapi.INTERNAL_SERVER_ERROR, "Failed to compute body measurement trend")
+	}
+	return openapi.Response(http.StatusOK, convertTrend(trend, common.ExtractUnits(ctx))), nil
+}
+
+func (h *measurementHandler) GetBodyMeasurement(ctx context.Context, id string) (openapi.ImplResponse, error) {
+	profileId, err := common.ExtractProfileID(ctx)
+	if err != nil {
+		return utils.ErrorResponse(http.StatusUnauthorized, openapi.FORBIDDEN, err.Error())
+	}
+	if !common.IsUUIDValid(id) {
+		return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_ID, "Invalid body measurement id")
+	}
+	measurement, err := h.useCase.GetByID(ctx, profileId, id)
+	if err != nil {
+		return measurementErrorResponse(err, "Failed to fetch body measurement")
+	}
+	return openapi.Response(http.StatusOK, convertMeasurement(measurement, common.ExtractUnits(ctx))), nil
+}
+
+func (h *measurementHandler) UpdateBodyMeasurement(ctx context.Context, id string, request openapi.PatchBodyMeasurementRequest) (openapi.ImplResponse, error) {
+	profileId, err := common.ExtractProfileID(ctx)
+	if err != nil {
+		return utils.ErrorResponse(http.StatusUnauthorized, openapi.FORBIDDEN, err.Error())
+	}
+	if !common.IsUUIDValid(id) {
+		return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_ID, "Invalid body measurement id")
+	}
+	if request.MeasuredAt != nil && request.MeasuredAt.After(time.Now()) {
+		return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_REQUEST, "measuredAt can't be in the future")
+	}
+	input := model.UpdateMeasurementInput{ProfileID: profileId, MeasurementID: id, MeasuredAt: request.MeasuredAt}
+	if request.Value != nil {
+		// The bounds of the value depend on the type of the measurement
+		existing, err := h.useCase.GetByID(ctx, profileId, id)
+		if err != nil {
+			return measurementErrorResponse(err, "Failed to update body measurement")
+		}
+		units := common.ExtractUnits(ctx)
+		value := existing.Type.ToMetric(*request.Value, units)
+		if !existing.Type.IsValidValue(value) {
+			return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_REQUEST, fmt.Sprintf("value is not a valid %s in %s", existing.Type, existing.Type.UnitIn(units)))
+		}
+		input.Value = &value
+	}
+	if request.Notes != nil {
+		notes := utils.TrimPointer(request.Notes)
+		input.Notes = &notes
+	}
+	measurement, err := h.useCase.Update(ctx, input)
+	if err != nil {
+		return measurementErrorResponse(err, "Failed to update body measurement")
+	}
+	return openapi.Response(http.StatusOK, convertMeasurement(measurement, common.ExtractUnits(ctx))), nil
+}
+
+func (h *measurementHandler) DeleteBodyMeasurement(ctx context.Context, id string) (openapi.ImplResponse, error) {
+	profileId, err := common.ExtractProfileID(ctx)
+	if err != nil {
+		return utils.ErrorResponse(http.StatusUnauthorized, openapi.FORBIDDEN, err.Error())
+	}
+	if !common.IsUUIDValid(id) {
+		return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_ID, "Invalid body measurement id")
+	}
+	if err := h.useCase.Delete(ctx, profileId, id); err != nil {
+		return measurementErrorResponse(err, "Failed to delete body measurement")
+	}
+	return openapi.Response(http.StatusNoContent, nil), nil
+}
+
+func measurementErrorResponse(err error, message string) (openapi.ImplResponse, error) {
+	switch {
+	case errors.Is(err, customerrors.ErrAccessForbidden):
+		return utils.ErrorResponse(http.StatusForbidden, openapi.FORBIDDEN, err.Error())
+	case errors.Is(err, customerrors.ErrEntityNotFound):
+		return utils.ErrorResponse(http.StatusNotFound, openapi.RESOURCE_NOT_FOUND, "Body measurement not found")
+	}
+	return utils.ErrorResponse(http.StatusInternalServerError, openapi.INTERNAL_SERVER_ERROR, message)
+}
+
+func typeError() string {
+	names := make([]string, len(model.Types))
+	for i, t := range model.Types {
+		names[i] = string(t)
+	}
+	return "type must be one of " + strings.Join(names, ", ")
+}
+
+// parseDateRange reads an optional range of local dates into [from, to). The end defaults to today
+// and the start to defaultDays before the end, or to the beginning of time when defaultDays is zero.
+func parseDateRange(startDate, endDate string, location *time.Location, defaultDays int) (time.Time, time.Time, *openapi.ImplResponse) {
+	to := common.StartOfDay(time.Now(), location).AddDate(0, 0, 1)
+	if endDate != "" {
+		end, err := common.ParseDate(endDate, location)
+		if err != nil {
+			response, _ := utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_DATE_FORMAT, "Invalid endDate format")
+			return time.Time{}, time.Time{}, &response
+		}
+		to = end.AddDate(0, 0, 1)
+	}
+	var from time.Time
+	if defaultDays > 0 {
+		from = to.AddDate(0, 0, -defaultDays)
+	}
+	if startDate != "" {
+		start, err := common.ParseDate(startDate, location)
+		if err != nil {
+			response, _ := utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_DATE_FORMAT, "Invalid startDate format")
+			return time.Time{}, time.Time{}, &response
+		}
+		from = start
+	}
+	if !from.Before(to) {
+		response, _ := utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_DATE_RANGE, "startDate can't be after endDate")
+		return time.Time{}, time.Time{}, &response
+	}
+	return from, to, nil
+}
+
+func convertMeasurement(measurement *model.Measurement, units common.Units) openapi.BodyMeasurement {
+	return openapi.BodyMeasurement{
+		Id:         measurement.ID,
+		Type:       string(measurement.Type),
+		Value:      measurement.Type.FromMetric(measurement.Value, units),
+		Unit:       measurement.Type.UnitIn(units),
+		MeasuredAt: measurement.MeasuredAt,
+		Notes:      measurement.Notes,
+	}
+}
+
+func convertMeasurements(measurements []model.Measurement, units common.Units) []openapi.BodyMeasurement {
+	items := make([]openapi.BodyMeasurement, len(measurements))
+	for i := range measurements {
+		items[i] = convertMeasurement(&measurements[i], units)
+	}
+	return items
+}
+
+func convertTrend(trend *model.Trend, units common.Units) openapi.BodyMeasurementTrend {
+	points := make([]openapi.BodyMeasurementTrendPoint, len(trend.Points))
+	for i, p := range trend.Points {
+		points[i] = openapi.BodyMeasurementTrendPoint{
+			Date:          p.Date.Format(time.DateOnly),
+			Value:         trend.Type.FromMetric(p.Value, units),
+			MovingAverage: trend.Type.FromMetric(p.MovingAverage, units),
+			Measurements:  int32(p.Measurements),
+		}
+	}
+	return openapi.BodyMeasurementTrend{
+		Type:       string(trend.Type),
+		Unit:       trend.Type.UnitIn(units),
+		WindowDays: int32(trend.WindowDays),
+		Change:     trend.Type.FromMetric(trend.Change, units),
+		Points:     points,
+	}
+}
@@ -0,0 +1,174 @@
+package model
+
+import (
+	"math"
+	"time"
+
+	"github.com/VladimirKholomyanskyy/gym-api/internal/common"
+)
+
+// Type is what a body measurement measures. Bodyweight is stored in kg, body fat in percent and
+// the circumferences in cm.
+type Type string
+
+const (
+	TypeBodyweight Type = "bodyweight"
+	TypeBodyFat    Type = "body_fat"
+	TypeNeck       Type = "neck"
+	TypeShoulders  Type = "shoulders"
+	TypeChest      Type = "chest"
+	TypeWaist      Type = "waist"
+	TypeHips       Type = "hips"
+	TypeArm        Type = "arm"
+	TypeForearm    Type = "forearm"
+	TypeThigh      Type = "thigh"
+	TypeCalf       Type = "calf"
+)
+
+// Types lists the measurement types in the order they are presented
+var Types = []Type{
+	TypeBodyweight, TypeBodyFat, TypeNeck, TypeShoulders, TypeChest, TypeWaist,
+	TypeHips, TypeArm, TypeForearm, TypeThigh, TypeCalf,
+}
+
+// ParseType parses a measurement type name
+func ParseType(name string) (Type, bool) {
+	for _, t := range Types {
+		if string(t) == name {
+			return t, true
+		}
+	}
+	return "", false
+}
+
+// UnitIn is the unit values of the type are exchanged in with a profile using the units
+func (t Type) UnitIn(units common.Units) string {
+	switch t {
+	case TypeBodyweight:
+		return units.WeightUnit()
+	case TypeBodyFat:
+		return "%"
+	}
+	return units.LengthUnit()
+}
+
+// FromMetric converts a stored value into the units, rounded for a response
+func (t Type) FromMetric(value float64, units common.Units) float64 {
+	switch t {
+	case TypeBodyweight:
+		return units.FromKilograms(value)
+	case TypeBodyFat:
+		return RoundValue(value)
+	}
+	return units.FromCentimeters(value)
+}
+
+// ToMetric converts a value given in the units for storage
+func (t Type) ToMetric(value float64, units common.Units) float64 {
+	switch t {
+	case TypeBodyweight:
+		return units.ToKilograms(value)
+	case TypeBodyFat:
+		return value
+	}
+	return units.ToCentimeters(value)
+}
+
+// IsValidValue reports whether a value is plausible for the type. The bounds match what the
+// columns can hold, profiles only accept a bodyweight below 500 kg.
+func (t Type) IsValidValue(value float64) bool {
+	switch t {
+	case TypeBodyweight:
+		return value > 0 && value < 500
+	case TypeBodyFat:
+		return value > 0 && value < 100
+	}
+	return value > 0 && value < 1000
+}
+
+// Measurement is a dated body measurement of a profile. The latest bodyweight is kept on the
+// profile as its weight.
+type Measurement struct {
+	common.Base
+	ProfileID  string
+	Type       Type
+	Value      float64
+	MeasuredAt time.Time
+	Notes      *string
+}
+
+func (Measurement) TableName() string {
+	return "body_measurements"
+}
+
+type CreateMeasurementInput struct {
+	ProfileID  string
+	Type       Type
+	Value      float64
+	MeasuredAt time.Time
+	Notes      *string
+}
+
+type UpdateMeasurementInput struct {
+	MeasurementID string
+	ProfileID     string
+	Value         *float64
+	MeasuredAt    *time.Time
+	Notes         *string
+}
+
+// TrendPoint is a day with measurements, in the profile's time zone. Value is the average of the
+// day's measurements and MovingAverage the average of the days with measurements in the window
+// ending on it.
+type TrendPoint struct {
+	Date          time.Time
+	Value         float64
+	MovingAverage float64
+	Measurements  int
+}
+
+type Trend struct {
+	Type       Type
+	WindowDays int
+	Points     []TrendPoint
+	// Change is how much the moving average changed from the first to the last point
+	Change float64
+}
+
+// DailyTrend averages measurements ordered by time per day in the location and adds the moving
+// average over the given number of days. Days without measurements don't count towards the
+// average, so a missed weigh-in doesn't pull it down.
+func DailyTrend(measurements []Measurement, location *time.Location, windowDays int) []TrendPoint {
+	var points []TrendPoint
+	var sums []float64
+	for _, measurement := range measurements {
+		day := common.StartOfDay(measurement.MeasuredAt, location)
+		if len(points) == 0 || !points[len(points)-1].Date.Equal(day) {
+			points = append(points, TrendPoint{Date: day})
+			sums = append(sums, 0)
+		}
+		last := len(points) - 1
+		sums[last] += measurement.Value
+		points[last].Measurements++
+	}
+	for i := range points {
+		points[i].Value = RoundValue(sums[i] / float64(points[i].Measurements))
+	}
+
+	start, total := 0, 0.0
+	for i := range points {
+		total += points[i].Value
+		windowStart := points[i].Date.AddDate(0, 0, -windowDays+1)
+		for points[start].Date.Before(windowStart) {
+			total -= points[start].Value
+			start++
+		}
+		points[i].MovingAverage = RoundValue(total / float64(i-start+1))
+	}
+	return points
+}
+
+// RoundValue rounds a value to the precision it is stored with
+func RoundValue(value float64) float64 {
+	return math.Round(value*100) / 100
+}
@@ -0,0 +1,91 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/VladimirKholomyanskyy/gym-api/internal/common"
+)
+
+func TestDailyTrend(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("time zone not available: %v", err)
+	}
+	at := func(value string) time.Time {
+		instant, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return instant
+	}
+	measurements := []Measurement{
+		{Value: 80, MeasuredAt: at("2024-03-01T06:00:00Z")},
+		{Value: 81, MeasuredAt: at("2024-03-01T20:00:00Z")},
+		// Just after midnight in Berlin, the next day there
+		{Value: 79, MeasuredAt: at("2024-03-01T23:30:00Z")},
+		{Value: 78, MeasuredAt: at("2024-03-05T07:00:00Z")},
+		{Value: 77, MeasuredAt: at("2024-03-09T07:00:00Z")},
+	}
+
+	points := DailyTrend(measurements, berlin, 7)
+	want := []struct {
+		date          string
+		value         float64
+		movingAverage float64
+		measurements  int
+	}{
+		{"2024-03-01", 80.5, 80.5, 2},
+		{"2024-03-02", 79, 79.75, 1},
+		{"2024-03-05", 78, 79.17, 1},
+		// The window of seven days ending on the 9th starts on the 3rd
+		{"2024-03-09", 77, 77.5, 1},
+	}
+	if len(points) != len(want) {
+		t.Fatalf("got %d points, want %d: %+v", len(points), len(want), points)
+	}
+	for i, w := range want {
+		p := points[i]
+		if p.Date.Format(time.DateOnly) != w.date || p.Value != w.value || p.MovingAverage != w.movingAverage || p.Measurements != w.measurements {
+			t.Errorf("point %d = %s %v %v %d, want %s %v %v %d", i, p.Date.Format(time.DateOnly), p.Value, p.MovingAverage, p.Measurements,
+				w.date, w.value, w.movingAverage, w.measurements)
+		}
+	}
+}
+
+func TestIsValidValue(t *testing.T) {
+	tests := []struct {
+		measurementType Type
+		value           float64
+		want            bool
+	}{
+		{TypeBodyweight, 82.5, true},
+		{TypeBodyweight, 0, false},
+		{TypeBodyweight, 500, false},
+		{TypeBodyFat, 18, true},
+		{TypeBodyFat, 100, false},
+		{TypeWaist, 84, true},
+		{TypeWaist, -1, false},
+	}
+	for _, tt := range tests {
+		if got := tt.measurementType.IsValidValue(tt.value); got != tt.want {
+			t.Errorf("%s.IsValidValue(%v) = %v, want %v", tt.measurementType, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestTypeUnits(t *testing.T) {
+	imperial := common.ImperialUnits
+	if got := TypeBodyweight.UnitIn(imperial); got != "lb" {
+		t.Errorf("bodyweight unit = %q, want lb", got)
+	}
+	if got := TypeWaist.FromMetric(81.28, imperial); got != 32 {
+		t.Errorf("waist of 81.28 cm = %v in, want 32", got)
+	}
+	if got := TypeBodyFat.ToMetric(18.5, imperial); got != 18.5 {
+		t.Errorf("body fat = %v, want it unconverted", got)
+	}
+	if got := TypeBodyweight.ToMetric(220.5, common.MetricUnits); got != 220.5 {
+		t.Errorf("metric bodyweight = %v, want it unconverted", got)
+	}
+}
@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	customerrors "github.com/VladimirKholomyanskyy/gym-api/internal/customErrors"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/measurements/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// MeasurementRepository stores body measurements. Changes to bodyweight measurements update the
+// weight of the profile in the same transaction, so it always is the latest bodyweight.
+type MeasurementRepository interface {
+	Create(ctx context.Context, measurement *model.Measurement) error
+	GetByID(ctx context.Context, id string) (*model.Measurement, error)
+	GetAllByProfileID(ctx context.Context, profileID string, measurementType *model.Type, from, to time.Time, page, pageSize int) ([]model.Measurement, int64, error)
+	GetLatestByProfileID(ctx context.Context, profileID string) ([]model.Measurement, error)
+	GetSeries(ctx context.Context, profileID string, measurementType model.Type, from, to time.Time) ([]model.Measurement, error)
+	UpdatePartial(ctx context.Context, id string, updates map[string]any) (*model.Measurement, error)
+	Delete(ctx context.Context, id string) error
+}
+
+// measurementRepository implements MeasurementRepository
+type measurementRepository struct {
+	db *gorm.DB
+}
+
+// NewMeasurementRepository creates a new repository instance
+func NewMeasurementRepository(db *gorm.DB) MeasurementRepository {
+	return &measurementRepository{db: db}
+}
+
+func (r *measurementRepository) Create(ctx context.Context, measurement *model.Measurement) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(measurement).Error; err != nil {
+			return fmt.Errorf("failed to create body measurement: %w", err)
+		}
+		return syncProfileWeight(tx, measurement)
+	})
+}
+
+func (r *measurementRepository) GetByID(ctx context.Context, id string) (*model.Measurement, error) {
+	var measurement model.Measurement
+	err := r.db.WithContext(ctx).First(&measurement, "id = ?", id).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, customerrors.ErrEntityNotFound
+		}
+		return nil, fmt.Errorf("failed to fetch body measurement by id: %w", err)
+	}
+	return &measurement, nil
+}
+
+// GetAllByProfileID retrieves paginated measurements of a profile taken in [from, to), newest
+// first, optionally of a single type
+func (r *measurementRepository) GetAllByProfileID(ctx context.Context, profileID string, measurementType *model.Type, from, to time.Time, page, pageSize int) ([]model.Measurement, int64, error) {
+	var measurements []model.Measurement
+	var total int64
+	offset := (page - 1) * pageSize
+
+	query := r.db.WithContext(ctx).
+		Model(&model.Measurement{}).
+		Where("profile_id = ? AND measured_at >= ? AND measured_at < ?", profileID, from, to)
+	if measurementType != nil {
+		query = query.Where("type = ?", *measurementType)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count body measurements: %w", err)
+	}
+	err := query.
+		Order("measured_at DESC").
+		Limit(pageSize).
+		Offset(offset).
+		Find(&measurements).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to fetch body measurements: %w", err)
+	}
+	return measurements, total, nil
+}
+
+// GetLatestByProfileID retrieves the latest measurement of every type the profile has measured
+func (r *measurementRepository) GetLatestByProfileID(ctx context.Context, profileID string) ([]model.Measurement, error) {
+	var measurements []model.Measurement
+	err := r.db.WithContext(ctx).
+		Raw(`SELECT DISTINCT ON (type) * FROM body_measurements
+			WHERE profile_id = ?
+			ORDER BY type, measured_at DESC`, profileID).
+		Scan(&measurements).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch latest body measurements: %w", err)
+	}
+	return measurements, nil
+}
+
+// GetSeries retrieves the measurements of a type taken in [from, to), oldest first
+func (r *measurementRepository) GetSeries(ctx context.Context, profileID string, measurementType model.Type, from, to time.Time) ([]model.Measurement, error) {
+	var measurements []model.Measurement
+	err := r.db.WithContext(ctx).
+		Where("profile_id = ? AND type = ? AND measured_at >= ? AND measured_at < ?", profileID, measurementType, from, to).
+		Order("measured_at ASC").
+		Find(&measurements).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch body measurement series: %w", err)
+	}
+	return measurements, nil
+}
+
+func (r *measurementRepository) UpdatePartial(ctx context.Context, id string, updates map[string]any) (*model.Measurement, error) {
+	var measurement model.Measurement
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.Measurement{}).Where("id = ?", id).Updates(updates)
+		if result.Error != nil {
+			return fmt.Errorf("failed to update body measurement: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return customerrors.ErrEntityNotFound
+		}
+		if err := tx.First(&measurement, "id = ?", id).Error; err != nil {
+			return fmt.Errorf("failed to fetch updated body measurement: %w", err)
+		}
+		return syncProfileWeight(tx, &measurement)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &measurement, nil
+}
+
+func (r *measurementRepository) Delete(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var deleted []model.Measurement
+		result := tx.Clauses(clause.Returning{}).Where("id = ?", id).Delete(&deleted)
+		if result.Error != nil {
+			return fmt.Errorf("failed to delete body measurement: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return customerrors.ErrEntityNotFound
+		}
+		return syncProfileWeight(tx, &deleted[0])
+	})
+}
+
+// syncProfileWeight sets the weight of the profile to its latest bodyweight after a bodyweight
+// measurement changed. A profile without bodyweight measurements keeps the weight it has.
+func syncProfileWeight(tx *gorm.DB, measurement *model.Measurement) error {
+	if measurement.Type != model.TypeBodyweight {
+		return nil
+	}
+	err := tx.Exec(`UPDATE profiles SET weight = COALESCE((
+			SELECT value FROM body_measurements
+			WHERE profile_id = ? AND type = ?
+			ORDER BY measured_at DESC
+			LIMIT 1
+		), weight), updated_at = ?
+		WHERE id = ?`, measurement.ProfileID, model.TypeBodyweight, time.Now(), measurement.ProfileID).Error
+	if err != nil {
+		return fmt.Errorf("failed to update profile weight: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	customerrors "github.com/VladimirKholomyanskyy/gym-api/internal/customErrors"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/measurements/model"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/measurements/repository"
+)
+
+// MeasurementUseCase records the body measurements of profiles and computes their trends. It also
+// records the weight set on a profile, so the bodyweight history keeps every change.
+type MeasurementUseCase interface {
+	Create(ctx context.Context, input model.CreateMeasurementInput) (*model.Measurement, error)
+	GetByID(ctx context.Context, profileID, measurementID string) (*model.Measurement, error)
+	List(ctx context.Context, profileID string, measurementType *model.Type, from, to time.Time, page, pageSize int) ([]model.Measurement, int64, error)
+	GetLatest(ctx context.Context, profileID string) ([]model.Measurement, error)
+	GetTrend(ctx context.Context, profileID string, measurementType model.Type, from, to time.Time, windowDays int, location *time.Location) (*model.Trend, error)
+	Update(ctx context.Context, input model.UpdateMeasurementInput) (*model.Measurement, error)
+	Delete(ctx context.Context, profileID, measurementID string) error
+	RecordBodyweight(ctx context.Context, profileID string, weight float64, at time.Time) error
+}
+
+type measurementUseCase struct {
+	repo repository.MeasurementRepository
+}
+
+func NewMeasurementUseCase(repo repository.MeasurementRepository) MeasurementUseCase {
+	return &measurementUseCase{repo: repo}
+}
+
+func (uc *measurementUseCase) Create(ctx context.Context, input model.CreateMeasurementInput) (*model.Measurement, error) {
+	measurement := &model.Measurement{
+		ProfileID:  input.ProfileID,
+		Type:       input.Type,
+		Value:      input.Value,
+		MeasuredAt: input.MeasuredAt,
+		Notes:      input.Notes,
+	}
+	if err := uc.repo.Create(ctx, measurement); err != nil {
+		return nil, err
+	}
+	return measurement, nil
+}
+
+// GetByID retrieves a measurement ensuring it belongs to the profile
+func (uc *measurementUseCase) GetByID(ctx context.Context, profileID, measurementID string) (*model.Measurement, error) {
+	measurement, err := uc.repo.GetByID(ctx, measurementID)
+	if err != nil {
+		return nil, err
+	}
+	if measurement.ProfileID != profileID {
+		return nil, customerrors.ErrAccessForbidden
+	}
+	return measurement, nil
+}
+
+func (uc *measurementUseCase) List(ctx context.Context, profileID string, measurementType *model.Type, from, to time.Time, page, pageSize int) ([]model.Measurement, int64, error) {
+	return uc.repo.GetAllByProfileID(ctx, profileID, measurementType, from, to, page, pageSize)
+}
+
+// GetLatest returns the latest measurement of every type the profile has measured
+func (uc *measurementUseCase) GetLatest(ctx context.Context, profileID string) ([]model.Measurement, error) {
+	return uc.repo.GetLatestByProfileID(ctx, profileID)
+}
+
+// GetTrend returns the daily values of a measurement type in [from, to) with their moving average.
+// Measurements of the days before from that fall into the window of the first days are read too,
+// so the average starts out the same as it would with a longer range.
+func (uc *measurementUseCase) GetTrend(ctx context.Context, profileID string, measurementType model.Type, from, to time.Time, windowDays int, location *time.Location) (*model.Trend, error) {
+	measurements, err := uc.repo.GetSeries(ctx, profileID, measurementType, from.AddDate(0, 0, -windowDays+1), to)
+	if err != nil {
+		return nil, err
+	}
+	points := model.DailyTrend(measurements, location, windowDays)
+	first := 0
+	for first < len(points) && points[first].Date.Before(from) {
+		first++
+	}
+	trend := &model.Trend{Type: measurementType, WindowDays: windowDays, Points: points[first:]}
+	if len(trend.Points) > 0 {
+		trend.Change = model.RoundValue(trend.Points[len(trend.Points)-1].MovingAverage - trend.Points[0].MovingAverage)
+	}
+	return trend, nil
+}
+
+// Update changes the value, time or notes of a measurement, its type stays
+func (uc *measurementUseCase) Update(ctx context.Context, input model.UpdateMeasurementInput) (*model.Measurement, error) {
+	measurement, err := uc.GetByID(ctx, input.ProfileID, input.MeasurementID)
+	if err != nil {
+		return nil, err
+	}
+	updates := make(map[string]any)
+	if input.Value != nil {
+		updates["value"] = *input.Value
+	}
+	if input.MeasuredAt != nil {
+		updates["measured_at"] = *input.MeasuredAt
+	}
+	if input.Notes != nil {
+		updates["notes"] = input.Notes
+	}
+	if len(updates) == 0 {
+		return measurement, nil
+	}
+	return uc.repo.UpdatePartial(ctx, measurement.ID, updates)
+}
+
+func (uc *measurementUseCase) Delete(ctx context.Context, profileID, measurementID string) error {
+	if _, err := uc.GetByID(ctx, profileID, measurementID); err != nil {
+		return err
+	}
+	return uc.repo.Delete(ctx, measurementID)
+}
+
+// RecordBodyweight records a bodyweight measurement, which makes it the weight of the profile
+func (uc *measurementUseCase) RecordBodyweight(ctx context.Context, profileID string, weight float64, at time.Time) error {
+	_, err := uc.Create(ctx, model.CreateMeasurementInput{ProfileID: profileID, Type: model.TypeBodyweight, Value: weight, MeasuredAt: at})
+	return err
+}
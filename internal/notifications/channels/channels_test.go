@@ -0,0 +1,312 @@
+package channels
+
+import (
+	"bufio"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/VladimirKholomyanskyy/gym-api/internal/common"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/notifications/model"
+)
+
+func testNotification(channel model.Channel) *model.Notification {
+	return &model.Notification{
+		Base:    common.Base{ID: "notification-1"},
+		Channel: channel,
+		Kind:    model.KindWorkoutReminder,
+		Subject: "Leg day today",
+		Body:    "Squat — 5 × 5",
+		Data:    []byte(`{"scheduledWorkoutId":"sw-1"}`),
+	}
+}
+
+// smtpStandIn accepts a single mail the way a local catcher such as Mailpit does and returns the DATA it received
+func smtpStandIn(t *testing.T) (string, <-chan string) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	received := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+		reply("220 localhost ESMTP")
+		var data strings.Builder
+		inData := false
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			if inData {
+				if line == ".\r\n" {
+					inData = false
+					received <- data.String()
+					reply("250 OK")
+					continue
+				}
+				data.WriteString(line)
+				continue
+			}
+			switch command := strings.ToUpper(strings.Fields(line)[0]); command {
+			case "EHLO", "HELO":
+				reply("250 localhost")
+			case "DATA":
+				inData = true
+				reply("354 End data with <CR><LF>.<CR><LF>")
+			case "QUIT":
+				reply("221 Bye")
+				return
+			default:
+				reply("250 OK")
+			}
+		}
+	}()
+	return listener.Addr().String(), received
+}
+
+func TestEmailSender(t *testing.T) {
+	addr, received := smtpStandIn(t)
+	host, port, _ := net.SplitHostPort(addr)
+	portNumber, _ := strconv.Atoi(port)
+	sender := NewEmailSender(SMTPConfig{Host: host, Port: portNumber, From: "coach@example.com"})
+
+	err := sender.Send(context.Background(), testNotification(model.Channel{Type: model.ChannelEmail, Target: "athlete@example.com"}))
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	message := <-received
+	for _, want := range []string{"To: athlete@example.com", "Subject: Leg day today", "Squat — 5 × 5"} {
+		if !strings.Contains(message, want) {
+			t.Errorf("message is missing %q:\n%s", want, message)
+		}
+	}
+}
+
+func TestWebhookSender(t *testing.T) {
+	secret := "webhook-secret"
+	var gotSignature, gotEvent string
+	var gotBody []byte
+	status := http.StatusNoContent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotSignature = r.Header.Get(SignatureHeader)
+		gotEvent = r.Header.Get(EventHeader)
+		gotBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+	sender := NewWebhookSender(server.Client())
+	notification := testNotification(model.Channel{Type: model.ChannelWebhook, Target: server.URL, Secret: &secret})
+
+	if err := sender.Send(context.Background(), notification); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if gotSignature != "sha256="+Sign(secret, gotBody) {
+		t.Errorf("signature %q doesn't match the body", gotSignature)
+	}
+	if gotEvent != string(model.KindWorkoutReminder) {
+		t.Errorf("event = %q", gotEvent)
+	}
+
+	status = http.StatusGone
+	if err := sender.Send(context.Background(), notification); !errors.Is(err, ErrPermanent) {
+		t.Errorf("Send() to a gone webhook error = %v, want permanent", err)
+	}
+	status = http.StatusServiceUnavailable
+	if err := sender.Send(context.Background(), notification); err == nil || errors.Is(err, ErrPermanent) {
+		t.Errorf("Send() to an unavailable webhook error = %v, want retryable", err)
+	}
+}
+
+func TestHTTPClientBlocksInternalAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+	client := NewHTTPClient(time.Second)
+	sender := NewWebhookSender(client)
+
+	err := sender.Send(context.Background(), testNotification(model.Channel{Type: model.ChannelWebhook, Target: server.URL}))
+	if !errors.Is(err, ErrBlockedAddress) || !errors.Is(err, ErrPermanent) {
+		t.Errorf("Send() to a loopback webhook error = %v, want blocked and permanent", err)
+	}
+	if err := client.CheckRedirect(nil, nil); !errors.Is(err, ErrPermanent) {
+		t.Errorf("CheckRedirect() = %v, want redirects refused", err)
+	}
+
+	tests := map[string]bool{
+		"93.184.216.34":   true,
+		"2606:4700::1111": true,
+		"127.0.0.1":       false,
+		"10.0.0.8":        false,
+		"172.16.4.1":      false,
+		"192.168.1.1":     false,
+		"169.254.169.254": false,
+		"0.0.0.0":         false,
+		"::1":             false,
+		"fe80::1":         false,
+		"fd00::1":         false,
+		"::ffff:10.0.0.1": false,
+	}
+	for address, want := range tests {
+		if got := IsPublicIP(net.ParseIP(address)); got != want {
+			t.Errorf("IsPublicIP(%s) = %v, want %v", address, got, want)
+		}
+	}
+}
+
+func TestEmailSenderStopsAtDeadline(t *testing.T) {
+	// A server that accepts the connection and never answers
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		conn, err := listener.Accept()
+		if err == nil {
+			defer conn.Close()
+			io.Copy(io.Discard, conn)
+		}
+	}()
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	portNumber, _ := strconv.Atoi(port)
+	sender := NewEmailSender(SMTPConfig{Host: host, Port: portNumber, From: "coach@example.com"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	started := time.Now()
+	err = sender.Send(ctx, testNotification(model.Channel{Type: model.ChannelEmail, Target: "athlete@example.com"}))
+	if err == nil {
+		t.Fatal("Send() to a stuck server succeeded")
+	}
+	if elapsed := time.Since(started); elapsed > 5*time.Second {
+		t.Errorf("Send() took %v, want it to stop at the deadline", elapsed)
+	}
+}
+
+func TestWebPushSender(t *testing.T) {
+	vapid, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	browser, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	authSecret := make([]byte, 16)
+	rand.Read(authSecret)
+
+	var message pushMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := verifyVAPID(r.Header.Get("Authorization"), vapid.PublicKey().Bytes()); err != nil {
+			t.Errorf("invalid VAPID authorization: %v", err)
+		}
+		body, _ := io.ReadAll(r.Body)
+		plaintext, err := decryptPushMessage(body, browser, authSecret)
+		if err != nil {
+			t.Errorf("failed to decrypt push message: %v", err)
+		}
+		json.Unmarshal(plaintext, &message)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	sender, err := NewWebPushSender(server.Client(), VAPIDKeys{
+		PublicKey:  base64.RawURLEncoding.EncodeToString(vapid.PublicKey().Bytes()),
+		PrivateKey: base64.RawURLEncoding.EncodeToString(vapid.Bytes()),
+		Subject:    "mailto:ops@example.com",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p256dh := base64.URLEncoding.EncodeToString(browser.PublicKey().Bytes())
+	auth := base64.RawURLEncoding.EncodeToString(authSecret)
+	channel := model.Channel{Type: model.ChannelWebPush, Target: server.URL + "/push/abc", PushP256dh: &p256dh, PushAuth: &auth}
+
+	if err := sender.Send(context.Background(), testNotification(channel)); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if message.Title != "Leg day today" || message.Body != "Squat — 5 × 5" {
+		t.Errorf("decrypted message = %+v", message)
+	}
+}
+
+// verifyVAPID checks the ES256 token of an Authorization header the way a push service does
+func verifyVAPID(header string, publicKey []byte) error {
+	token, _, _ := strings.Cut(strings.TrimPrefix(header, "vapid t="), ",")
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return errors.New("malformed token")
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || len(signature) != 64 {
+		return errors.New("malformed signature")
+	}
+	key := &ecdsa.PublicKey{
+		Curve: elliptic.P256(),
+		X:     new(big.Int).SetBytes(publicKey[1:33]),
+		Y:     new(big.Int).SetBytes(publicKey[33:]),
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if !ecdsa.Verify(key, digest[:], new(big.Int).SetBytes(signature[:32]), new(big.Int).SetBytes(signature[32:])) {
+		return errors.New("signature doesn't verify")
+	}
+	return nil
+}
+
+// decryptPushMessage is the browser side of encryptPushMessage
+func decryptPushMessage(body []byte, browser *ecdh.PrivateKey, authSecret []byte) ([]byte, error) {
+	salt := body[:16]
+	if binary.BigEndian.Uint32(body[16:20]) != recordSize {
+		return nil, errors.New("unexpected record size")
+	}
+	keyLength := int(body[20])
+	serverKey := body[21 : 21+keyLength]
+	server, err := ecdh.P256().NewPublicKey(serverKey)
+	if err != nil {
+		return nil, err
+	}
+	shared, err := browser.ECDH(server)
+	if err != nil {
+		return nil, err
+	}
+	keyInfo := append(append([]byte("WebPush: info\x00"), browser.PublicKey().Bytes()...), serverKey...)
+	ikm := hkdf(authSecret, shared, keyInfo, 32)
+	block, _ := aes.NewCipher(hkdf(salt, ikm, []byte("Content-Encoding: aes128gcm\x00"), 16))
+	gcm, _ := cipher.NewGCM(block)
+	plaintext, err := gcm.Open(nil, hkdf(salt, ikm, []byte("Content-Encoding: nonce\x00"), 12), body[21+keyLength:], nil)
+	if err != nil {
+		return nil, err
+	}
+	if plaintext[len(plaintext)-1] != 0x02 {
+		return nil, errors.New("missing last record delimiter")
+	}
+	return plaintext[:len(plaintext)-1], nil
+}
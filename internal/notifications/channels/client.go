@@ -0,0 +1,50 @@
+package channels
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// ErrBlockedAddress is returned when a webhook or push endpoint resolves to an address inside the
+// network the API runs in
+var ErrBlockedAddress = errors.New("address is not publicly routable")
+
+// NewHTTPClient creates the client webhooks and web pushes are sent with. Their URLs come from the
+// profiles, so the client only connects to public addresses, checked after DNS resolution so a
+// name can't point it at loopback, private or cloud metadata addresses, and doesn't follow
+// redirects. Proxies from the environment are ignored as they'd connect on its behalf.
+func NewHTTPClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout, Control: dialPublicOnly}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return fmt.Errorf("%w: redirects are not followed", ErrPermanent)
+		},
+	}
+}
+
+// dialPublicOnly refuses connections to the resolved address unless it is public
+func dialPublicOnly(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !IsPublicIP(ip) {
+		return fmt.Errorf("%w: %w: %s", ErrPermanent, ErrBlockedAddress, host)
+	}
+	return nil
+}
+
+// IsPublicIP reports whether the address isn't loopback, private, link-local, multicast or unspecified
+func IsPublicIP(ip net.IP) bool {
+	return !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() &&
+		!ip.IsInterfaceLocalMulticast() && !ip.IsMulticast() && !ip.IsUnspecified()
+}
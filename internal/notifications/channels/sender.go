@@ -0,0 +1,43 @@
+// Package channels delivers notifications over email, web push and webhooks.
+package channels
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/VladimirKholomyanskyy/gym-api/internal/notifications/model"
+)
+
+// ErrPermanent marks delivery failures that a retry won't fix, such as an expired push subscription
+var ErrPermanent = errors.New("permanent delivery failure")
+
+// Sender delivers a notification to its channel. Errors wrapping ErrPermanent end delivery of the
+// notification, other errors are retried.
+type Sender interface {
+	Send(ctx context.Context, notification *model.Notification) error
+}
+
+// Registry picks the sender of a notification by the type of its channel. Channel types without
+// a configured sender fail permanently.
+type Registry map[model.ChannelType]Sender
+
+func (r Registry) Send(ctx context.Context, notification *model.Notification) error {
+	sender, ok := r[notification.Channel.Type]
+	if !ok {
+		return fmt.Errorf("%w: %s delivery is not configured", ErrPermanent, notification.Channel.Type)
+	}
+	return sender.Send(ctx, notification)
+}
+
+// statusError classifies an HTTP response status. Client errors other than timeouts and rate
+// limits won't go away on their own.
+func statusError(status int) error {
+	switch {
+	case status >= 200 && status < 300:
+		return nil
+	case status == 408 || status == 429 || status >= 500:
+		return fmt.Errorf("delivery failed with status %d", status)
+	}
+	return fmt.Errorf("%w: delivery rejected with status %d", ErrPermanent, status)
+}
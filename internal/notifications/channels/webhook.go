@@ -0,0 +1,74 @@
+package channels
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/VladimirKholomyanskyy/gym-api/internal/notifications/model"
+)
+
+const (
+	// SignatureHeader carries the HMAC-SHA256 of the request body keyed with the channel secret
+	SignatureHeader = "X-Gym-Signature"
+	EventHeader     = "X-Gym-Event"
+)
+
+type webhookPayload struct {
+	ID        string          `json:"id"`
+	Kind      model.Kind      `json:"kind"`
+	Subject   string          `json:"subject"`
+	Body      string          `json:"body"`
+	Data      json.RawMessage `json:"data,omitempty"`
+	CreatedAt time.Time       `json:"createdAt"`
+}
+
+type webhookSender struct {
+	client *http.Client
+}
+
+func NewWebhookSender(client *http.Client) Sender {
+	return &webhookSender{client: client}
+}
+
+func (s *webhookSender) Send(ctx context.Context, notification *model.Notification) error {
+	body, err := json.Marshal(webhookPayload{
+		ID:        notification.ID,
+		Kind:      notification.Kind,
+		Subject:   notification.Subject,
+		Body:      notification.Body,
+		Data:      json.RawMessage(notification.Data),
+		CreatedAt: notification.CreatedAt,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook payload: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, notification.Channel.Target, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("%w: invalid webhook url: %v", ErrPermanent, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, string(notification.Kind))
+	if notification.Channel.Secret != nil {
+		req.Header.Set(SignatureHeader, "sha256="+Sign(*notification.Channel.Secret, body))
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	return statusError(resp.StatusCode)
+}
+
+// Sign returns the hex encoded HMAC-SHA256 of a webhook body, receivers compare it to the signature header
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,219 @@
+package channels
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/VladimirKholomyanskyy/gym-api/internal/notifications/model"
+)
+
+const (
+	// pushTTL is how long the push service keeps a message for an offline browser
+	pushTTL = 24 * time.Hour
+	// vapidExpiry is the lifetime of the VAPID token, push services accept at most 24 hours
+	vapidExpiry = 12 * time.Hour
+	recordSize  = 4096
+)
+
+// VAPIDKeys identify the application to push services (RFC 8292). The public key is the
+// uncompressed P-256 point and the private key the raw scalar, both base64url encoded.
+type VAPIDKeys struct {
+	PublicKey  string
+	PrivateKey string
+	// Subject is a mailto: or https: URL push services can use to contact the operator
+	Subject string
+}
+
+type pushMessage struct {
+	Title string          `json:"title"`
+	Body  string          `json:"body"`
+	Kind  model.Kind      `json:"kind"`
+	Data  json.RawMessage `json:"data,omitempty"`
+}
+
+type webPushSender struct {
+	client    *http.Client
+	key       *ecdsa.PrivateKey
+	publicKey string
+	subject   string
+}
+
+func NewWebPushSender(client *http.Client, keys VAPIDKeys) (Sender, error) {
+	d, err := base64.RawURLEncoding.DecodeString(keys.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID private key: %w", err)
+	}
+	// ecdh validates the scalar and derives the public point the signing key needs
+	private, err := ecdh.P256().NewPrivateKey(d)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID private key: %w", err)
+	}
+	point := private.PublicKey().Bytes()
+	key := &ecdsa.PrivateKey{
+		PublicKey: ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(point[1:33]),
+			Y:     new(big.Int).SetBytes(point[33:]),
+		},
+		D: new(big.Int).SetBytes(d),
+	}
+	publicKey := base64.RawURLEncoding.EncodeToString(point)
+	if keys.PublicKey != publicKey {
+		return nil, errors.New("VAPID public key doesn't match the private key")
+	}
+	return &webPushSender{client: client, key: key, publicKey: publicKey, subject: keys.Subject}, nil
+}
+
+func (s *webPushSender) Send(ctx context.Context, notification *model.Notification) error {
+	channel := notification.Channel
+	if channel.PushP256dh == nil || channel.PushAuth == nil {
+		return fmt.Errorf("%w: push subscription has no keys", ErrPermanent)
+	}
+	endpoint, err := url.Parse(channel.Target)
+	if err != nil {
+		return fmt.Errorf("%w: invalid push endpoint: %v", ErrPermanent, err)
+	}
+	message, err := json.Marshal(pushMessage{
+		Title: notification.Subject,
+		Body:  notification.Body,
+		Kind:  notification.Kind,
+		Data:  json.RawMessage(notification.Data),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal push message: %w", err)
+	}
+	body, err := encryptPushMessage(message, *channel.PushP256dh, *channel.PushAuth)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrPermanent, err)
+	}
+	token, err := s.vapidToken(endpoint.Scheme+"://"+endpoint.Host, time.Now())
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.String(), bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("%w: invalid push endpoint: %v", ErrPermanent, err)
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("TTL", fmt.Sprint(int(pushTTL.Seconds())))
+	req.Header.Set("Authorization", "vapid t="+token+", k="+s.publicKey)
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call push service: %w", err)
+	}
+	defer resp.Body.Close()
+	return statusError(resp.StatusCode)
+}
+
+// vapidToken signs the ES256 JWT that proves the request comes from the holder of the VAPID key
+func (s *webPushSender) vapidToken(audience string, now time.Time) (string, error) {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"typ":"JWT","alg":"ES256"}`))
+	claims, err := json.Marshal(map[string]any{
+		"aud": audience,
+		"exp": now.Add(vapidExpiry).Unix(),
+		"sub": s.subject,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal VAPID claims: %w", err)
+	}
+	unsigned := header + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	r, sig, err := ecdsa.Sign(rand.Reader, s.key, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign VAPID token: %w", err)
+	}
+	signature := make([]byte, 64)
+	r.FillBytes(signature[:32])
+	sig.FillBytes(signature[32:])
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// encryptPushMessage encrypts a message for a push subscription as a single aes128gcm record (RFC 8291)
+func encryptPushMessage(message []byte, p256dh, auth string) ([]byte, error) {
+	subscriberKey, err := decodeBase64(p256dh)
+	if err != nil {
+		return nil, fmt.Errorf("invalid p256dh key: %w", err)
+	}
+	authSecret, err := decodeBase64(auth)
+	if err != nil {
+		return nil, fmt.Errorf("invalid auth secret: %w", err)
+	}
+	subscriber, err := ecdh.P256().NewPublicKey(subscriberKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid p256dh key: %w", err)
+	}
+	local, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate key: %w", err)
+	}
+	shared, err := local.ECDH(subscriber)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive shared secret: %w", err)
+	}
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	localKey := local.PublicKey().Bytes()
+	keyInfo := append(append([]byte("WebPush: info\x00"), subscriberKey...), localKey...)
+	ikm := hkdf(authSecret, shared, keyInfo, 32)
+	contentKey := hkdf(salt, ikm, []byte("Content-Encoding: aes128gcm\x00"), 16)
+	nonce := hkdf(salt, ikm, []byte("Content-Encoding: nonce\x00"), 12)
+
+	block, err := aes.NewCipher(contentKey)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	// A single record ends with the 0x02 delimiter and must fit the record size with its tag
+	plaintext := append(append([]byte{}, message...), 0x02)
+	if len(plaintext)+gcm.Overhead() > recordSize {
+		return nil, errors.New("push message is too large")
+	}
+
+	header := make([]byte, 0, 16+4+1+len(localKey))
+	header = append(header, salt...)
+	header = binary.BigEndian.AppendUint32(header, recordSize)
+	header = append(header, byte(len(localKey)))
+	header = append(header, localKey...)
+	return gcm.Seal(header, nonce, plaintext, nil), nil
+}
+
+// hkdf derives a key of at most 32 bytes with HKDF-SHA256 (RFC 5869), a single expand block is enough
+func hkdf(salt, secret, info []byte, length int) []byte {
+	extract := hmac.New(sha256.New, salt)
+	extract.Write(secret)
+	expand := hmac.New(sha256.New, extract.Sum(nil))
+	expand.Write(info)
+	expand.Write([]byte{1})
+	return expand.Sum(nil)[:length]
+}
+
+// decodeBase64 accepts the padded and unpadded url-safe encodings browsers use for subscription keys
+func decodeBase64(value string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+}
@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"strings"
+
+	openapi "github.com/VladimirKholomyanskyy/gym-api/internal/api/go"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/common"
+	customerrors "github.com/VladimirKholomyanskyy/gym-api/internal/customErrors"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/notifications/channels"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/notifications/model"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/notifications/usecase"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/utils"
+)
+
+type notificationHandler struct {
+	useCase usecase.NotificationUseCase
+	// vapidPublicKey is handed to browsers to subscribe to web push, empty when web push isn't configured
+	vapidPublicKey string
+}
+
+func NewNotificationHandler(useCase usecase.NotificationUseCase, vapidPublicKey string) openapi.NotificationsAPIServicer {
+	return &notificationHandler{useCase: useCase, vapidPublicKey: vapidPublicKey}
+}
+
+// ListNotificationChannels - Retrieve the notification channels of the profile
+func (h *notificationHandler) ListNotificationChannels(ctx context.Context) (openapi.ImplResponse, error) {
+	profileId, err := common.ExtractProfileID(ctx)
+	if err != nil {
+		return utils.ErrorResponse(http.StatusUnauthorized, openapi.FORBIDDEN, err.Error())
+	}
+	channels, err := h.useCase.ListChannels(ctx, profileId)
+	if err != nil {
+		return utils.ErrorResponse(http.StatusInternalServerError, openapi.INTERNAL_SERVER_ERROR, "Failed to fetch notification channels")
+	}
+	items := make([]openapi.NotificationChannel, 0, len(channels))
+	for i := range channels {
+		channel := convertChannel(&channels[i])
+		// The webhook secret is only shown when the channel is created
+		channel.Secret = nil
+		items = append(items, channel)
+	}
+	return openapi.Response(http.StatusOK, openapi.ListNotificationChannels200Response{Items: items}), nil
+}
+
+// CreateNotificationChannel - Register an email address, web push subscription or webhook for notifications
+func (h *notificationHandler) CreateNotificationChannel(ctx context.Context, request openapi.CreateNotificationChannelRequest) (openapi.ImplResponse, error) {
+	profileId, err := common.ExtractProfileID(ctx)
+	if err != nil {
+		return utils.ErrorResponse(http.StatusUnauthorized, openapi.FORBIDDEN, err.Error())
+	}
+	input := usecase.CreateChannelInput{ProfileID: profileId, Type: model.ChannelType(request.Type), Target: request.Target}
+	switch input.Type {
+	case model.ChannelEmail:
+		address, err := mail.ParseAddress(request.Target)
+		if err != nil {
+			return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_REQUEST, "target must be an email address")
+		}
+		input.Target = address.Address
+	case model.ChannelWebhook:
+		if !isHTTPSURL(request.Target) {
+			return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_REQUEST, "target must be an https URL")
+		}
+	case model.ChannelWebPush:
+		if h.vapidPublicKey == "" {
+			return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_REQUEST, "web push is not available")
+		}
+		if !isHTTPSURL(request.Target) {
+			return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_REQUEST, "target must be the https endpoint of the push subscription")
+		}
+		if request.P256dh == nil || *request.P256dh == "" || request.Auth == nil || *request.Auth == "" {
+			return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_REQUEST, "p256dh and auth keys of the push subscription are required")
+		}
+		input.P256dh, input.Auth = request.P256dh, request.Auth
+	default:
+		return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_REQUEST, "type must be one of email, web_push, webhook")
+	}
+
+	channel, err := h.useCase.CreateChannel(ctx, input)
+	if err != nil {
+		switch {
+		case errors.Is(err, customerrors.ErrTooManyChannels):
+			return utils.ErrorResponse(http.StatusUnprocessableEntity, openapi.INVALID_REQUEST, err.Error())
+		case errors.Is(err, customerrors.ErrDuplicateChannel):
+			return utils.ErrorResponse(http.StatusConflict, openapi.INVALID_REQUEST, err.Error())
+		}
+		return utils.ErrorResponse(http.StatusInternalServerError, openapi.INTERNAL_SERVER_ERROR, "Failed to create notification channel")
+	}
+	return openapi.Response(http.StatusCreated, convertChannel(channel)), nil
+}
+
+// DeleteNotificationChannel - Stop delivering notifications to a channel
+func (h *notificationHandler) DeleteNotificationChannel(ctx context.Context, id string) (openapi.ImplResponse, error) {
+	profileId, err := common.ExtractProfileID(ctx)
+	if err != nil {
+		return utils.ErrorResponse(http.StatusUnauthorized, openapi.FORBIDDEN, err.Error())
+	}
+	if !common.IsUUIDValid(id) {
+		return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_ID, "Invalid notification channel id")
+	}
+	if err := h.useCase.DeleteChannel(ctx, profileId, id); err != nil {
+		switch {
+		case errors.Is(err, customerrors.ErrEntityNotFound):
+			return utils.ErrorResponse(http.StatusNotFound, openapi.RESOURCE_NOT_FOUND, "Notification channel not found")
+		case errors.Is(err, customerrors.ErrAccessForbidden):
+			return utils.ErrorResponse(http.StatusForbidden, openapi.FORBIDDEN, err.Error())
+		}
+		return utils.ErrorResponse(http.StatusInternalServerError, openapi.INTERNAL_SERVER_ERROR, "Failed to delete notification channel")
+	}
+	return openapi.Response(http.StatusNoContent, nil), nil
+}
+
+// GetWebPushConfig - Retrieve the VAPID public key browsers subscribe to web push with
+func (h *notificationHandler) GetWebPushConfig(ctx context.Context) (openapi.ImplResponse, error) {
+	if h.vapidPublicKey == "" {
+		return utils.ErrorResponse(http.StatusNotFound, openapi.RESOURCE_NOT_FOUND, "Web push is not configured")
+	}
+	return openapi.Response(http.StatusOK, openapi.WebPushConfig{PublicKey: h.vapidPublicKey}), nil
+}
+
+func convertChannel(channel *model.Channel) openapi.NotificationChannel {
+	return openapi.NotificationChannel{
+		Id:        channel.ID,
+		Type:      string(channel.Type),
+		Target:    channel.Target,
+		Secret:    channel.Secret,
+		CreatedAt: channel.CreatedAt,
+	}
+}
+
+// isHTTPSURL checks an endpoint given by the profile. Hosts that are obviously internal are
+// refused up front, names resolving to internal addresses are refused by the client when sending.
+func isHTTPSURL(value string) bool {
+	parsed, err := url.Parse(value)
+	if err != nil || parsed.Scheme != "https" || parsed.Hostname() == "" {
+		return false
+	}
+	host := strings.ToLower(strings.TrimSuffix(parsed.Hostname(), "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return false
+	}
+	if ip := net.ParseIP(host); ip != nil && !channels.IsPublicIP(ip) {
+		return false
+	}
+	return true
+}
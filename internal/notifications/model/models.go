@@ -0,0 +1,115 @@
+package model
+
+import (
+	"time"
+
+	"github.com/VladimirKholomyanskyy/gym-api/internal/common"
+	"gorm.io/datatypes"
+)
+
+type ChannelType string
+
+const (
+	ChannelEmail   ChannelType = "email"
+	ChannelWebPush ChannelType = "web_push"
+	ChannelWebhook ChannelType = "webhook"
+)
+
+// Channel is a destination the notifications of a profile are delivered to. The target is the
+// email address, the push subscription endpoint or the webhook URL depending on the type.
+type Channel struct {
+	common.Base
+	ProfileID string
+	Type      ChannelType
+	Target    string
+	// Secret signs webhook requests
+	Secret *string
+	// PushP256dh and PushAuth are the keys of a web push subscription
+	PushP256dh *string `gorm:"column:push_p256dh"`
+	PushAuth   *string
+}
+
+func (Channel) TableName() string {
+	return "notification_channels"
+}
+
+type Kind string
+
+const (
+	KindWorkoutReminder Kind = "workout_reminder"
+	KindMissedWorkout   Kind = "missed_workout"
+)
+
+type Status string
+
+const (
+	StatusPending Status = "pending"
+	StatusSent    Status = "sent"
+	StatusFailed  Status = "failed"
+)
+
+const (
+	// MaxAttempts is how often delivery of a notification is tried before it is given up
+	MaxAttempts = 6
+	// firstRetryDelay doubles with every failed attempt
+	firstRetryDelay = time.Minute
+)
+
+// Notification is an entry of the outbox. It is written once per channel and cause, the dedup key
+// keeps repeated scheduler runs from writing it again, and it is delivered until it is sent or
+// runs out of attempts.
+type Notification struct {
+	common.Base
+	ProfileID     string
+	ChannelID     string
+	Channel       Channel
+	Kind          Kind
+	DedupKey      string
+	Subject       string
+	Body          string
+	Data          datatypes.JSON `gorm:"type:jsonb"`
+	Status        Status
+	Attempts      int
+	NextAttemptAt time.Time
+	LastError     *string
+	SentAt        *time.Time
+}
+
+// RetryDelay is how long to wait before the next attempt after the given number of failed ones
+func RetryDelay(attempts int) time.Duration {
+	if attempts < 1 {
+		return firstRetryDelay
+	}
+	return firstRetryDelay << min(attempts-1, MaxAttempts)
+}
+
+// QuietUntil tells whether t falls into the quiet hours of a profile and when they end. The quiet
+// hours are offsets from local midnight and span midnight when the end is before the start.
+func QuietUntil(t time.Time, location *time.Location, start, end time.Duration) (time.Time, bool) {
+	local := t.In(location)
+	midnight := common.StartOfDay(local, location)
+	clock := time.Duration(local.Hour())*time.Hour + time.Duration(local.Minute())*time.Minute
+	switch {
+	case start < end && clock >= start && clock < end:
+		return atClock(midnight, end, location), true
+	case start > end && clock >= start:
+		return atClock(midnight.AddDate(0, 0, 1), end, location), true
+	case start > end && clock < end:
+		return atClock(midnight, end, location), true
+	}
+	return t, false
+}
+
+// atClock returns the wall clock time at the offset from the given midnight, so a DST change
+// during the night doesn't shift it
+func atClock(midnight time.Time, clock time.Duration, location *time.Location) time.Time {
+	year, month, day := midnight.Date()
+	return time.Date(year, month, day, int(clock.Hours()), int(clock.Minutes())%60, 0, 0, location)
+}
+
+// ReminderData is stored with reminders and sent to webhooks and push subscriptions
+type ReminderData struct {
+	ScheduledWorkoutID string `json:"scheduledWorkoutId"`
+	WorkoutID          string `json:"workoutId"`
+	Date               string `json:"date"`
+}
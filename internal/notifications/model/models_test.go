@@ -0,0 +1,55 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestQuietUntil(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2025, time.March, day, hour, minute, 0, 0, berlin)
+	}
+	overnight := []time.Duration{22 * time.Hour, 7 * time.Hour}
+	daytime := []time.Duration{12 * time.Hour, 14 * time.Hour}
+
+	tests := []struct {
+		name  string
+		t     time.Time
+		hours []time.Duration
+		quiet bool
+		until time.Time
+	}{
+		{"before overnight quiet hours", at(10, 21, 59), overnight, false, time.Time{}},
+		{"evening of overnight quiet hours", at(10, 23, 0), overnight, true, at(11, 7, 0)},
+		{"morning of overnight quiet hours", at(11, 6, 30), overnight, true, at(11, 7, 0)},
+		{"end is not quiet", at(11, 7, 0), overnight, false, time.Time{}},
+		{"inside daytime quiet hours", at(10, 13, 0), daytime, true, at(10, 14, 0)},
+		{"after daytime quiet hours", at(10, 15, 0), daytime, false, time.Time{}},
+		// Clocks go forward at 02:00 on the 30th, the quiet hours still end at 07:00 wall time
+		{"across DST change", at(29, 23, 0), overnight, true, at(30, 7, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			until, quiet := QuietUntil(tt.t.UTC(), berlin, tt.hours[0], tt.hours[1])
+			if quiet != tt.quiet {
+				t.Fatalf("quiet = %v, want %v", quiet, tt.quiet)
+			}
+			if quiet && !until.Equal(tt.until) {
+				t.Errorf("until = %v, want %v", until, tt.until)
+			}
+		})
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	want := []time.Duration{time.Minute, time.Minute, 2 * time.Minute, 4 * time.Minute, 8 * time.Minute}
+	for attempts, delay := range want {
+		if got := RetryDelay(attempts); got != delay {
+			t.Errorf("RetryDelay(%d) = %v, want %v", attempts, got, delay)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/VladimirKholomyanskyy/gym-api/internal/account"
+	customerrors "github.com/VladimirKholomyanskyy/gym-api/internal/customErrors"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/notifications/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// claimLease is how long a claimed notification is hidden from other workers. A worker that dies
+// mid-delivery leaves the notification to be picked up again once the lease runs out.
+const claimLease = 5 * time.Minute
+
+// NotificationRepository stores notification channels and the notification outbox
+type NotificationRepository interface {
+	CreateChannel(ctx context.Context, channel *model.Channel) error
+	GetChannelByID(ctx context.Context, id string) (*model.Channel, error)
+	GetChannelsByProfileID(ctx context.Context, profileID string) ([]model.Channel, error)
+	DeleteChannel(ctx context.Context, id string) error
+	GetSettingsWithChannels(ctx context.Context) ([]account.Setting, error)
+	GetStartedWorkoutIDs(ctx context.Context, profileID string, from, to time.Time) ([]string, error)
+	Enqueue(ctx context.Context, notifications []model.Notification) error
+	ClaimDue(ctx context.Context, now time.Time, limit int) ([]model.Notification, error)
+	UpdatePartial(ctx context.Context, id string, updates map[string]any) error
+}
+
+// notificationRepository implements NotificationRepository
+type notificationRepository struct {
+	db *gorm.DB
+}
+
+// NewNotificationRepository creates a new repository instance
+func NewNotificationRepository(db *gorm.DB) NotificationRepository {
+	return &notificationRepository{db: db}
+}
+
+func (r *notificationRepository) CreateChannel(ctx context.Context, channel *model.Channel) error {
+	if err := r.db.WithContext(ctx).Create(channel).Error; err != nil {
+		return fmt.Errorf("failed to create notification channel: %w", err)
+	}
+	return nil
+}
+
+func (r *notificationRepository) GetChannelByID(ctx context.Context, id string) (*model.Channel, error) {
+	var channel model.Channel
+	err := r.db.WithContext(ctx).First(&channel, "id = ?", id).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, customerrors.ErrEntityNotFound
+		}
+		return nil, fmt.Errorf("failed to fetch notification channel by id: %w", err)
+	}
+	return &channel, nil
+}
+
+func (r *notificationRepository) GetChannelsByProfileID(ctx context.Context, profileID string) ([]model.Channel, error) {
+	var channels []model.Channel
+	err := r.db.WithContext(ctx).
+		Where("profile_id = ?", profileID).
+		Order("created_at ASC").
+		Find(&channels).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch notification channels: %w", err)
+	}
+	return channels, nil
+}
+
+// DeleteChannel removes a channel together with its undelivered notifications
+func (r *notificationRepository) DeleteChannel(ctx context.Context, id string) error {
+	result := r.db.WithContext(ctx).Where("id = ?", id).Delete(&model.Channel{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete notification channel: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return customerrors.ErrEntityNotFound
+	}
+	return nil
+}
+
+// GetSettingsWithChannels retrieves the settings of every profile that has notifications enabled
+// and somewhere to deliver them to
+func (r *notificationRepository) GetSettingsWithChannels(ctx context.Context) ([]account.Setting, error) {
+	var settings []account.Setting
+	err := r.db.WithContext(ctx).
+		Where("notifications_enabled").
+		Where("EXISTS (SELECT 1 FROM notification_channels WHERE notification_channels.profile_id = settings.profile_id)").
+		// Deleted accounts get no reminders while they wait to be purged
+		Where("NOT EXISTS (SELECT 1 FROM profiles WHERE profiles.id = settings.profile_id AND profiles.deleted_at IS NOT NULL)").
+		Find(&settings).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch settings of profiles with notification channels: %w", err)
+	}
+	return settings, nil
+}
+
+// GetStartedWorkoutIDs retrieves the workouts the profile started a session of in a time range, end exclusive
+func (r *notificationRepository) GetStartedWorkoutIDs(ctx context.Context, profileID string, from, to time.Time) ([]string, error) {
+	var workoutIDs []string
+	err := r.db.WithContext(ctx).
+		Table("workout_sessions").
+		Distinct("workout_id").
+		Where("profile_id = ? AND started_at >= ? AND started_at < ?", profileID, from, to).
+		Pluck("workout_id", &workoutIDs).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch started workouts: %w", err)
+	}
+	return workoutIDs, nil
+}
+
+// Enqueue writes notifications to the outbox, skipping those whose dedup key was written before
+func (r *notificationRepository) Enqueue(ctx context.Context, notifications []model.Notification) error {
+	if len(notifications) == 0 {
+		return nil
+	}
+	err := r.db.WithContext(ctx).
+		Omit("Channel").
+		Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "dedup_key"}}, DoNothing: true}).
+		Create(&notifications).Error
+	if err != nil {
+		return fmt.Errorf("failed to enqueue notifications: %w", err)
+	}
+	return nil
+}
+
+// ClaimDue locks pending notifications that are due and pushes their next attempt past the claim
+// lease, so concurrent workers never pick the same notification. The channels are preloaded.
+func (r *notificationRepository) ClaimDue(ctx context.Context, now time.Time, limit int) ([]model.Notification, error) {
+	var notifications []model.Notification
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var ids []string
+		err := tx.Model(&model.Notification{}).
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", model.StatusPending, now).
+			Order("next_attempt_at ASC").
+			Limit(limit).
+			Pluck("id", &ids).Error
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+		err = tx.Model(&model.Notification{}).
+			Where("id IN ?", ids).
+			Update("next_attempt_at", now.Add(claimLease)).Error
+		if err != nil {
+			return err
+		}
+		return tx.Preload("Channel").Where("id IN ?", ids).Order("created_at ASC").Find(&notifications).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim due notifications: %w", err)
+	}
+	return notifications, nil
+}
+
+// UpdatePartial records the outcome of a delivery attempt
+func (r *notificationRepository) UpdatePartial(ctx context.Context, id string, updates map[string]any) error {
+	result := r.db.WithContext(ctx).Model(&model.Notification{}).Where("id = ?", id).Updates(updates)
+	if result.Error != nil {
+		return fmt.Errorf("failed to update notification: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return customerrors.ErrEntityNotFound
+	}
+	return nil
+}
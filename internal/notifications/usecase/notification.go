@@ -0,0 +1,283 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/VladimirKholomyanskyy/gym-api/internal/account"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/common"
+	customerrors "github.com/VladimirKholomyanskyy/gym-api/internal/customErrors"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/notifications/channels"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/notifications/model"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/notifications/repository"
+	trainingmodel "github.com/VladimirKholomyanskyy/gym-api/internal/training/model"
+	trainingrepository "github.com/VladimirKholomyanskyy/gym-api/internal/training/repository"
+)
+
+const (
+	// MaxChannels is how many notification channels a profile may register
+	MaxChannels = 10
+	// deliveryBatchSize is how many notifications a delivery run claims at once
+	deliveryBatchSize = 100
+	// maxOccurrences bounds the scheduled workouts of two days a profile gets reminded of
+	maxOccurrences = 50
+)
+
+// CreateChannelInput registers a destination for notifications. The push keys are only used by web push.
+type CreateChannelInput struct {
+	ProfileID string
+	Type      model.ChannelType
+	Target    string
+	P256dh    *string
+	Auth      *string
+}
+
+// NotificationUseCase manages the notification channels of profiles and runs the outbox. Enqueuing
+// looks at the scheduled workouts of today and yesterday in each profile's time zone: once the
+// reminder time has passed it writes a reminder for today's workouts that weren't started yet and
+// a missed workout notice for yesterday's that never were. Delivery sends what is due, outside of
+// the profile's quiet hours, and retries failures with a growing delay.
+type NotificationUseCase interface {
+	CreateChannel(ctx context.Context, input CreateChannelInput) (*model.Channel, error)
+	ListChannels(ctx context.Context, profileID string) ([]model.Channel, error)
+	DeleteChannel(ctx context.Context, profileID, channelID string) error
+	EnqueueReminders(ctx context.Context, now time.Time) error
+	Deliver(ctx context.Context, now time.Time) error
+}
+
+type notificationUseCase struct {
+	repo                 repository.NotificationRepository
+	scheduledWorkoutRepo trainingrepository.ScheduledWorkoutRepository
+	settingsRepo         account.SettingRepository
+	sender               channels.Sender
+}
+
+func NewNotificationUseCase(repo repository.NotificationRepository, scheduledWorkoutRepo trainingrepository.ScheduledWorkoutRepository, settingsRepo account.SettingRepository, sender channels.Sender) NotificationUseCase {
+	return &notificationUseCase{repo: repo, scheduledWorkoutRepo: scheduledWorkoutRepo, settingsRepo: settingsRepo, sender: sender}
+}
+
+// CreateChannel registers a channel. Webhooks get a secret their requests are signed with, it is
+// returned with the channel.
+func (uc *notificationUseCase) CreateChannel(ctx context.Context, input CreateChannelInput) (*model.Channel, error) {
+	existing, err := uc.repo.GetChannelsByProfileID(ctx, input.ProfileID)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) >= MaxChannels {
+		return nil, customerrors.ErrTooManyChannels
+	}
+	for _, channel := range existing {
+		if channel.Type == input.Type && channel.Target == input.Target {
+			return nil, customerrors.ErrDuplicateChannel
+		}
+	}
+	channel := &model.Channel{
+		ProfileID: input.ProfileID,
+		Type:      input.Type,
+		Target:    input.Target,
+	}
+	switch input.Type {
+	case model.ChannelWebhook:
+		secret, err := newSecret()
+		if err != nil {
+			return nil, err
+		}
+		channel.Secret = &secret
+	case model.ChannelWebPush:
+		channel.PushP256dh = input.P256dh
+		channel.PushAuth = input.Auth
+	}
+	if err := uc.repo.CreateChannel(ctx, channel); err != nil {
+		return nil, err
+	}
+	return channel, nil
+}
+
+func (uc *notificationUseCase) ListChannels(ctx context.Context, profileID string) ([]model.Channel, error) {
+	return uc.repo.GetChannelsByProfileID(ctx, profileID)
+}
+
+func (uc *notificationUseCase) DeleteChannel(ctx context.Context, profileID, channelID string) error {
+	channel, err := uc.repo.GetChannelByID(ctx, channelID)
+	if err != nil {
+		return err
+	}
+	if channel.ProfileID != profileID {
+		return customerrors.ErrAccessForbidden
+	}
+	return uc.repo.DeleteChannel(ctx, channel.ID)
+}
+
+// EnqueueReminders writes the due reminders of every profile to the outbox. It runs repeatedly,
+// the dedup keys make sure every reminder is written once. A profile that fails doesn't keep the
+// others from being reminded.
+func (uc *notificationUseCase) EnqueueReminders(ctx context.Context, now time.Time) error {
+	settings, err := uc.repo.GetSettingsWithChannels(ctx)
+	if err != nil {
+		return err
+	}
+	for i := range settings {
+		if err := uc.enqueueProfileReminders(ctx, &settings[i], now); err != nil {
+			log.Printf("Failed to enqueue reminders of profile %s: %v", settings[i].ProfileID, err)
+		}
+	}
+	return nil
+}
+
+func (uc *notificationUseCase) enqueueProfileReminders(ctx context.Context, settings *account.Setting, now time.Time) error {
+	location := settings.Location()
+	local := now.In(location)
+	clock := time.Duration(local.Hour())*time.Hour + time.Duration(local.Minute())*time.Minute
+	if clock < settings.ReminderClock() {
+		return nil
+	}
+	today := common.CalendarDate(now, location)
+	yesterday := today.AddDate(0, 0, -1)
+	occurrences, _, err := uc.scheduledWorkoutRepo.GetAllByProfileIDAndRange(ctx, settings.ProfileID, yesterday, today, 1, maxOccurrences)
+	if err != nil {
+		return err
+	}
+	if len(occurrences) == 0 {
+		return nil
+	}
+	startOfToday := common.StartOfDay(now, location)
+	startedToday, err := uc.startedWorkouts(ctx, settings.ProfileID, startOfToday, now)
+	if err != nil {
+		return err
+	}
+	startedYesterday, err := uc.startedWorkouts(ctx, settings.ProfileID, startOfToday.AddDate(0, 0, -1), startOfToday)
+	if err != nil {
+		return err
+	}
+	profileChannels, err := uc.repo.GetChannelsByProfileID(ctx, settings.ProfileID)
+	if err != nil {
+		return err
+	}
+
+	var notifications []model.Notification
+	for _, occurrence := range occurrences {
+		var kind model.Kind
+		switch {
+		case occurrence.Date.Equal(today) && !startedToday[occurrence.WorkoutID]:
+			kind = model.KindWorkoutReminder
+		case occurrence.Date.Equal(yesterday) && !startedYesterday[occurrence.WorkoutID]:
+			kind = model.KindMissedWorkout
+		default:
+			continue
+		}
+		for _, channel := range profileChannels {
+			notification, err := newNotification(kind, occurrence, channel, now)
+			if err != nil {
+				return err
+			}
+			notifications = append(notifications, notification)
+		}
+	}
+	return uc.repo.Enqueue(ctx, notifications)
+}
+
+func (uc *notificationUseCase) startedWorkouts(ctx context.Context, profileID string, from, to time.Time) (map[string]bool, error) {
+	workoutIDs, err := uc.repo.GetStartedWorkoutIDs(ctx, profileID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	started := make(map[string]bool, len(workoutIDs))
+	for _, id := range workoutIDs {
+		started[id] = true
+	}
+	return started, nil
+}
+
+func newNotification(kind model.Kind, occurrence trainingmodel.ScheduledWorkout, channel model.Channel, now time.Time) (model.Notification, error) {
+	date := occurrence.Date.Format(time.DateOnly)
+	data, err := json.Marshal(model.ReminderData{ScheduledWorkoutID: occurrence.ID, WorkoutID: occurrence.WorkoutID, Date: date})
+	if err != nil {
+		return model.Notification{}, fmt.Errorf("failed to marshal reminder data: %w", err)
+	}
+	notification := model.Notification{
+		ProfileID:     occurrence.ProfileID,
+		ChannelID:     channel.ID,
+		Kind:          kind,
+		DedupKey:      fmt.Sprintf("%s:%s:%s:%s", kind, occurrence.ID, date, channel.ID),
+		Data:          data,
+		Status:        model.StatusPending,
+		NextAttemptAt: now,
+	}
+	name := occurrence.Workout.Name
+	if kind == model.KindWorkoutReminder {
+		notification.Subject = fmt.Sprintf("%s is on your plan today", name)
+		notification.Body = fmt.Sprintf("Your workout %s is scheduled for today.", name)
+	} else {
+		notification.Subject = fmt.Sprintf("You missed %s yesterday", name)
+		notification.Body = fmt.Sprintf("Your workout %s was scheduled for yesterday but wasn't started. Reschedule it to stay on track.", name)
+	}
+	if occurrence.Notes != "" {
+		notification.Body += "\n\n" + occurrence.Notes
+	}
+	return notification, nil
+}
+
+// Deliver sends the notifications that are due. Notifications of profiles in their quiet hours
+// wait until the quiet hours end and those of profiles that turned notifications off are dropped.
+func (uc *notificationUseCase) Deliver(ctx context.Context, now time.Time) error {
+	notifications, err := uc.repo.ClaimDue(ctx, now, deliveryBatchSize)
+	if err != nil {
+		return err
+	}
+	settings := make(map[string]*account.Setting)
+	var errs []error
+	for i := range notifications {
+		notification := &notifications[i]
+		setting, ok := settings[notification.ProfileID]
+		if !ok {
+			setting, err = uc.settingsRepo.GetByProfileID(ctx, notification.ProfileID)
+			if err != nil && !errors.Is(err, customerrors.ErrEntityNotFound) {
+				errs = append(errs, err)
+				continue
+			}
+			settings[notification.ProfileID] = setting
+		}
+		if err := uc.repo.UpdatePartial(ctx, notification.ID, uc.deliver(ctx, notification, setting, now)); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// deliver attempts to send a notification and returns how to update it
+func (uc *notificationUseCase) deliver(ctx context.Context, notification *model.Notification, setting *account.Setting, now time.Time) map[string]any {
+	if setting == nil || !setting.NotificationsEnabled {
+		return map[string]any{"status": model.StatusFailed, "last_error": "notifications are disabled"}
+	}
+	if start, end, ok := setting.QuietHours(); ok {
+		if until, quiet := model.QuietUntil(now, setting.Location(), start, end); quiet {
+			return map[string]any{"next_attempt_at": until}
+		}
+	}
+	err := uc.sender.Send(ctx, notification)
+	if err == nil {
+		return map[string]any{"status": model.StatusSent, "sent_at": now, "last_error": nil}
+	}
+	attempts := notification.Attempts + 1
+	updates := map[string]any{"attempts": attempts, "last_error": err.Error()}
+	if errors.Is(err, channels.ErrPermanent) || attempts >= model.MaxAttempts {
+		updates["status"] = model.StatusFailed
+	} else {
+		updates["next_attempt_at"] = now.Add(model.RetryDelay(attempts))
+	}
+	return updates
+}
+
+func newSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("failed to generate secret: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(secret), nil
+}
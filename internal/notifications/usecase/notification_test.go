@@ -0,0 +1,190 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/VladimirKholomyanskyy/gym-api/internal/account"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/common"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/notifications/channels"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/notifications/model"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/notifications/repository"
+	trainingmodel "github.com/VladimirKholomyanskyy/gym-api/internal/training/model"
+	trainingrepository "github.com/VladimirKholomyanskyy/gym-api/internal/training/repository"
+)
+
+type fakeNotificationRepository struct {
+	repository.NotificationRepository
+	settings []account.Setting
+	channels []model.Channel
+	started  map[string][]string // workout IDs by the date of the window start
+	enqueued []model.Notification
+	due      []model.Notification
+	updates  map[string]map[string]any
+}
+
+func (r *fakeNotificationRepository) GetSettingsWithChannels(ctx context.Context) ([]account.Setting, error) {
+	return r.settings, nil
+}
+
+func (r *fakeNotificationRepository) GetChannelsByProfileID(ctx context.Context, profileID string) ([]model.Channel, error) {
+	return r.channels, nil
+}
+
+func (r *fakeNotificationRepository) GetStartedWorkoutIDs(ctx context.Context, profileID string, from, to time.Time) ([]string, error) {
+	return r.started[from.Format(time.DateOnly)], nil
+}
+
+func (r *fakeNotificationRepository) Enqueue(ctx context.Context, notifications []model.Notification) error {
+	r.enqueued = append(r.enqueued, notifications...)
+	return nil
+}
+
+func (r *fakeNotificationRepository) ClaimDue(ctx context.Context, now time.Time, limit int) ([]model.Notification, error) {
+	return r.due, nil
+}
+
+func (r *fakeNotificationRepository) UpdatePartial(ctx context.Context, id string, updates map[string]any) error {
+	if r.updates == nil {
+		r.updates = make(map[string]map[string]any)
+	}
+	r.updates[id] = updates
+	return nil
+}
+
+type fakeScheduledWorkoutRepository struct {
+	trainingrepository.ScheduledWorkoutRepository
+	occurrences []trainingmodel.ScheduledWorkout
+}
+
+func (r *fakeScheduledWorkoutRepository) GetAllByProfileIDAndRange(ctx context.Context, profileID string, startDate, endDate time.Time, page, pageSize int) ([]trainingmodel.ScheduledWorkout, int64, error) {
+	return r.occurrences, int64(len(r.occurrences)), nil
+}
+
+type fakeSettingRepository struct {
+	account.SettingRepository
+	settings map[string]*account.Setting
+}
+
+func (r *fakeSettingRepository) GetByProfileID(ctx context.Context, profileID string) (*account.Setting, error) {
+	setting, ok := r.settings[profileID]
+	if !ok {
+		return nil, errors.New("settings not found")
+	}
+	return setting, nil
+}
+
+type fakeSender struct {
+	err  error
+	sent []string
+}
+
+func (s *fakeSender) Send(ctx context.Context, notification *model.Notification) error {
+	s.sent = append(s.sent, notification.ID)
+	return s.err
+}
+
+func TestEnqueueReminders(t *testing.T) {
+	today := time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)
+	yesterday := today.AddDate(0, 0, -1)
+	scheduled := func(id, workoutID string, date time.Time) trainingmodel.ScheduledWorkout {
+		return trainingmodel.ScheduledWorkout{
+			Base: common.Base{ID: id}, ProfileID: "p1", WorkoutID: workoutID, Date: date,
+			Workout: trainingmodel.Workout{Name: workoutID},
+		}
+	}
+	tests := []struct {
+		name  string
+		now   time.Time
+		want  []string
+		start map[string][]string
+	}{
+		{"before the reminder time", today.Add(7 * time.Hour), nil, nil},
+		{"after the reminder time", today.Add(9 * time.Hour), []string{
+			"workout_reminder:sw-today:2024-03-05:c1", "missed_workout:sw-yesterday:2024-03-04:c1",
+		}, nil},
+		{"workouts already started", today.Add(9 * time.Hour), nil, map[string][]string{
+			"2024-03-05": {"Push"}, "2024-03-04": {"Legs"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeNotificationRepository{
+				settings: []account.Setting{{ProfileID: "p1", Timezone: "UTC", ReminderTime: "08:00", NotificationsEnabled: true}},
+				channels: []model.Channel{{Base: common.Base{ID: "c1"}, Type: model.ChannelWebhook}},
+				started:  tt.start,
+			}
+			scheduledRepo := &fakeScheduledWorkoutRepository{occurrences: []trainingmodel.ScheduledWorkout{
+				scheduled("sw-today", "Push", today), scheduled("sw-yesterday", "Legs", yesterday),
+			}}
+			uc := NewNotificationUseCase(repo, scheduledRepo, &fakeSettingRepository{}, &fakeSender{})
+			if err := uc.EnqueueReminders(context.Background(), tt.now); err != nil {
+				t.Fatalf("EnqueueReminders() error = %v", err)
+			}
+			var got []string
+			for _, notification := range repo.enqueued {
+				got = append(got, notification.DedupKey)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("enqueued %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("enqueued %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestDeliver(t *testing.T) {
+	now := time.Date(2024, 3, 5, 12, 0, 0, 0, time.UTC)
+	quietStart, quietEnd := "11:00", "13:00"
+	settings := map[string]*account.Setting{
+		"enabled":  {ProfileID: "enabled", Timezone: "UTC", NotificationsEnabled: true},
+		"disabled": {ProfileID: "disabled", Timezone: "UTC"},
+		"quiet":    {ProfileID: "quiet", Timezone: "UTC", NotificationsEnabled: true, QuietHoursStart: &quietStart, QuietHoursEnd: &quietEnd},
+	}
+	tests := []struct {
+		name      string
+		profileID string
+		attempts  int
+		sendErr   error
+		want      map[string]any
+	}{
+		{"sent", "enabled", 0, nil, map[string]any{"status": model.StatusSent}},
+		{"disabled", "disabled", 0, nil, map[string]any{"status": model.StatusFailed}},
+		{"quiet hours", "quiet", 0, nil, map[string]any{"next_attempt_at": now.Add(time.Hour)}},
+		{"retried", "enabled", 1, errors.New("timeout"), map[string]any{"attempts": 2, "next_attempt_at": now.Add(model.RetryDelay(2))}},
+		{"permanent", "enabled", 0, channels.ErrPermanent, map[string]any{"attempts": 1, "status": model.StatusFailed}},
+		{"out of attempts", "enabled", model.MaxAttempts - 1, errors.New("timeout"), map[string]any{"status": model.StatusFailed}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeNotificationRepository{due: []model.Notification{
+				{Base: common.Base{ID: "n1"}, ProfileID: tt.profileID, Attempts: tt.attempts},
+			}}
+			sender := &fakeSender{err: tt.sendErr}
+			uc := NewNotificationUseCase(repo, &fakeScheduledWorkoutRepository{}, &fakeSettingRepository{settings: settings}, sender)
+			if err := uc.Deliver(context.Background(), now); err != nil {
+				t.Fatalf("Deliver() error = %v", err)
+			}
+			updates := repo.updates["n1"]
+			for key, want := range tt.want {
+				got := updates[key]
+				if wantTime, ok := want.(time.Time); ok {
+					if gotTime, ok := got.(time.Time); !ok || !gotTime.Equal(wantTime) {
+						t.Errorf("%s = %v, want %v", key, got, want)
+					}
+				} else if got != want {
+					t.Errorf("%s = %v, want %v", key, got, want)
+				}
+			}
+			if sent := len(sender.sent) > 0; sent != (tt.profileID == "enabled") {
+				t.Errorf("sent = %v for profile %s", sent, tt.profileID)
+			}
+		})
+	}
+}
@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	openapi "github.com/VladimirKholomyanskyy/gym-api/internal/api/go"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/common"
+	customerrors "github.com/VladimirKholomyanskyy/gym-api/internal/customErrors"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/photos/model"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/photos/usecase"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/utils"
+)
+
+// maxNoteLength bounds the note of a photo
+const maxNoteLength = 500
+
+type photoHandler struct {
+	useCase usecase.PhotoUseCase
+}
+
+func NewPhotoHandler(useCase usecase.PhotoUseCase) openapi.ProgressPhotosAPIServicer {
+	return &photoHandler{useCase: useCase}
+}
+
+// ListProgressPhotos - Retrieve the progress photos of the profile, newest first
+func (h *photoHandler) ListProgressPhotos(ctx context.Context, pose, startDate, endDate string, page, pageSize int32) (openapi.ImplResponse, error) {
+	profileId, err := common.ExtractProfileID(ctx)
+	if err != nil {
+		return utils.ErrorResponse(http.StatusUnauthorized, openapi.FORBIDDEN, err.Error())
+	}
+	if !common.IsPageValid(page) {
+		return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_PAGE_NUMBER, "page must be greater than 0")
+	}
+	if !common.IsPageSizeValid(pageSize) {
+		return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_PAGE_SIZE, "pageSize must be between 1 and 100")
+	}
+	var poseFilter *model.Pose
+	if pose != "" {
+		parsed, ok := model.ParsePose(pose)
+		if !ok {
+			return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_REQUEST, poseError)
+		}
+		poseFilter = &parsed
+	}
+	// Photos are dated, without a range the whole history is listed
+	from, to := time.Time{}, time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)
+	if startDate != "" {
+		if from, err = common.ParseDate(startDate, time.UTC); err != nil {
+			return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_DATE_FORMAT, "Invalid startDate format")
+		}
+	}
+	if endDate != "" {
+		if to, err = common.ParseDate(endDate, time.UTC); err != nil {
+			return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_DATE_FORMAT, "Invalid endDate format")
+		}
+	}
+	if from.After(to) {
+		return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_DATE_RANGE, "startDate can't be after endDate")
+	}
+	photos, totalCount, err := h.useCase.List(ctx, profileId, poseFilter, from, to, int(page), int(pageSize))
+	if err != nil {
+		return utils.ErrorResponse(http.StatusInternalServerError, openapi.INTERNAL_SERVER_ERROR, "Failed to fetch progress photos")
+	}
+	now := time.Now()
+	items := make([]openapi.ProgressPhoto, len(photos))
+	for i := range photos {
+		items[i] = convertPhoto(&photos[i], h.useCase.URLs(&photos[i], now))
+	}
+	return openapi.Response(http.StatusOK, openapi.ListProgressPhotos200Response{
+		TotalItems:  int32(totalCount),
+		CurrentPage: page,
+		PageSize:    pageSize,
+		TotalPages:  utils.CalculateTotalPages(totalCount, pageSize),
+		Items:       items,
+	}), nil
+}
+
+func (h *photoHandler) GetProgressPhoto(ctx context.Context, id string) (openapi.ImplResponse, error) {
+	profileId, err := common.ExtractProfileID(ctx)
+	if err != nil {
+		return utils.ErrorResponse(http.StatusUnauthorized, openapi.FORBIDDEN, err.Error())
+	}
+	if !common.IsUUIDValid(id) {
+		return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_ID, "Invalid progress photo id")
+	}
+	photo, err := h.useCase.GetByID(ctx, profileId, id)
+	if err != nil {
+		return photoErrorResponse(err, "Failed to fetch progress photo")
+	}
+	return openapi.Response(http.StatusOK, convertPhoto(photo, h.useCase.URLs(photo, time.Now()))), nil
+}
+
+func (h *photoHandler) UpdateProgressPhoto(ctx context.Context, id string, request openapi.PatchProgressPhotoRequest) (openapi.ImplResponse, error) {
+	profileId, err := common.ExtractProfileID(ctx)
+	if err != nil {
+		return utils.ErrorResponse(http.StatusUnauthorized, openapi.FORBIDDEN, err.Error())
+	}
+	if !common.IsUUIDValid(id) {
+		return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_ID, "Invalid progress photo id")
+	}
+	input := model.UpdatePhotoInput{PhotoID: id, ProfileID: profileId}
+	if request.TakenOn != nil {
+		takenOn, errResponse := parseTakenOn(*request.TakenOn)
+		if errResponse != nil {
+			return *errResponse, nil
+		}
+		input.TakenOn = &takenOn
+	}
+	if request.Pose != nil {
+		pose, ok := model.ParsePose(*request.Pose)
+		if !ok {
+			return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_REQUEST, poseError)
+		}
+		input.Pose = &pose
+	}
+	if request.Note != nil {
+		note := utils.TrimPointer(request.Note)
+		if len(note) > maxNoteLength {
+			return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_REQUEST, "note must not exceed 500 characters")
+		}
+		input.Note = &note
+	}
+	photo, err := h.useCase.Update(ctx, input)
+	if err != nil {
+		return photoErrorResponse(err, "Failed to update progress photo")
+	}
+	return openapi.Response(http.StatusOK, convertPhoto(photo, h.useCase.URLs(photo, time.Now()))), nil
+}
+
+func (h *photoHandler) DeleteProgressPhoto(ctx context.Context, id string) (openapi.ImplResponse, error) {
+	profileId, err := common.ExtractProfileID(ctx)
+	if err != nil {
+		return utils.ErrorResponse(http.StatusUnauthorized, openapi.FORBIDDEN, err.Error())
+	}
+	if !common.IsUUIDValid(id) {
+		return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_ID, "Invalid progress photo id")
+	}
+	if err := h.useCase.Delete(ctx, profileId, id); err != nil {
+		return photoErrorResponse(err, "Failed to delete progress photo")
+	}
+	return openapi.Response(http.StatusNoContent, nil), nil
+}
+
+const poseError = "pose must be one of front, back, left, right, other"
+
+func photoErrorResponse(err error, message string) (openapi.ImplResponse, error) {
+	switch {
+	case errors.Is(err, customerrors.ErrAccessForbidden):
+		return utils.ErrorResponse(http.StatusForbidden, openapi.FORBIDDEN, err.Error())
+	case errors.Is(err, customerrors.ErrEntityNotFound):
+		return utils.ErrorResponse(http.StatusNotFound, openapi.RESOURCE_NOT_FOUND, "Progress photo not found")
+	case errors.Is(err, customerrors.ErrInvalidImage):
+		return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_REQUEST, err.Error())
+	}
+	return utils.ErrorResponse(http.StatusInternalServerError, openapi.INTERNAL_SERVER_ERROR, message)
+}
+
+// parseTakenOn parses the calendar date a photo was taken on, which can't be in the future. The
+// date is kept as is, whatever the time zone of the profile.
+func parseTakenOn(date string) (time.Time, *openapi.ImplResponse) {
+	takenOn, err := common.ParseDate(date, time.UTC)
+	if err != nil {
+		response, _ := utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_DATE_FORMAT, "Invalid takenOn format")
+		return time.Time{}, &response
+	}
+	// A day of slack for profiles ahead of UTC
+	if takenOn.After(time.Now().UTC().AddDate(0, 0, 1)) {
+		response, _ := utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_REQUEST, "takenOn can't be in the future")
+		return time.Time{}, &response
+	}
+	return takenOn, nil
+}
+
+func convertPhoto(photo *model.Photo, urls model.PhotoURLs) openapi.ProgressPhoto {
+	return openapi.ProgressPhoto{
+		Id:           photo.ID,
+		TakenOn:      photo.TakenOn.Format(time.DateOnly),
+		Pose:         string(photo.Pose),
+		Note:         photo.Note,
+		ContentType:  photo.ContentType,
+		SizeBytes:    photo.SizeBytes,
+		Width:        int32(photo.Width),
+		Height:       int32(photo.Height),
+		Url:          urls.URL,
+		ThumbnailUrl: urls.ThumbnailURL,
+		UrlExpiresAt: urls.ExpiresAt,
+		CreatedAt:    photo.CreatedAt,
+	}
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	openapi "github.com/VladimirKholomyanskyy/gym-api/internal/api/go"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/common"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/photos/model"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/photos/usecase"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/utils"
+)
+
+// MaxUploadBytes bounds the size of an uploaded photo
+const MaxUploadBytes = 20 << 20
+
+// uploadHandler takes the image as the raw request body, so it's streamed to the storage instead
+// of being buffered like a generated JSON endpoint would. The other fields are query parameters.
+type uploadHandler struct {
+	useCase usecase.PhotoUseCase
+}
+
+func NewPhotoUploadHandler(useCase usecase.PhotoUseCase) http.Handler {
+	return &uploadHandler{useCase: useCase}
+}
+
+// ServeHTTP - Upload a progress photo, a JPEG or PNG image
+func (h *uploadHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	response, _ := h.upload(w, r)
+	openapi.EncodeJSONResponse(response.Body, &response.Code, w)
+}
+
+func (h *uploadHandler) upload(w http.ResponseWriter, r *http.Request) (openapi.ImplResponse, error) {
+	ctx := r.Context()
+	profileId, err := common.ExtractProfileID(ctx)
+	if err != nil {
+		return utils.ErrorResponse(http.StatusUnauthorized, openapi.FORBIDDEN, err.Error())
+	}
+	query := r.URL.Query()
+	input := model.UploadPhotoInput{ProfileID: profileId, Pose: model.PoseOther}
+	// Without a date the photo is taken today in the time zone of the profile
+	input.TakenOn = common.CalendarDate(time.Now(), common.ExtractLocation(ctx))
+	if takenOn := query.Get("takenOn"); takenOn != "" {
+		date, errResponse := parseTakenOn(takenOn)
+		if errResponse != nil {
+			return *errResponse, nil
+		}
+		input.TakenOn = date
+	}
+	if pose := query.Get("pose"); pose != "" {
+		parsed, ok := model.ParsePose(pose)
+		if !ok {
+			return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_REQUEST, poseError)
+		}
+		input.Pose = parsed
+	}
+	if note := query.Get("note"); utils.HasText(&note) {
+		note = utils.TrimPointer(&note)
+		if len(note) > maxNoteLength {
+			return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_REQUEST, "note must not exceed 500 characters")
+		}
+		input.Note = &note
+	}
+	if r.ContentLength > MaxUploadBytes {
+		return utils.ErrorResponse(http.StatusRequestEntityTooLarge, openapi.INVALID_REQUEST, "photo must not exceed 20 MB")
+	}
+	input.Content = http.MaxBytesReader(w, r.Body, MaxUploadBytes)
+
+	photo, err := h.useCase.Upload(ctx, input)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return utils.ErrorResponse(http.StatusRequestEntityTooLarge, openapi.INVALID_REQUEST, "photo must not exceed 20 MB")
+		}
+		response, _ := photoErrorResponse(err, "Failed to upload progress photo")
+		if response.Code == http.StatusInternalServerError {
+			log.Printf("Failed to upload progress photo: %v", err)
+		}
+		return response, nil
+	}
+	return openapi.Response(http.StatusCreated, convertPhoto(photo, h.useCase.URLs(photo, time.Now()))), nil
+}
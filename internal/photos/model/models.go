@@ -0,0 +1,71 @@
+package model
+
+import (
+	"io"
+	"time"
+
+	"github.com/VladimirKholomyanskyy/gym-api/internal/common"
+)
+
+// Pose tags which angle a progress photo is taken from, so photos of the same pose can be compared
+type Pose string
+
+const (
+	PoseFront Pose = "front"
+	PoseBack  Pose = "back"
+	PoseLeft  Pose = "left"
+	PoseRight Pose = "right"
+	PoseOther Pose = "other"
+)
+
+// ParsePose parses a pose name
+func ParsePose(name string) (Pose, bool) {
+	switch pose := Pose(name); pose {
+	case PoseFront, PoseBack, PoseLeft, PoseRight, PoseOther:
+		return pose, true
+	}
+	return "", false
+}
+
+// Photo is a progress photo of a profile. The image and its thumbnail live in the file storage
+// under their keys and are only handed out through signed URLs.
+type Photo struct {
+	common.Base
+	ProfileID    string
+	TakenOn      time.Time
+	Pose         Pose
+	Note         *string
+	ContentType  string
+	SizeBytes    int64
+	Width        int
+	Height       int
+	StorageKey   string
+	ThumbnailKey string
+}
+
+func (Photo) TableName() string {
+	return "progress_photos"
+}
+
+type UploadPhotoInput struct {
+	ProfileID string
+	TakenOn   time.Time
+	Pose      Pose
+	Note      *string
+	Content   io.Reader
+}
+
+type UpdatePhotoInput struct {
+	PhotoID   string
+	ProfileID string
+	TakenOn   *time.Time
+	Pose      *Pose
+	Note      *string
+}
+
+// PhotoURLs are the signed URLs of a photo and its thumbnail
+type PhotoURLs struct {
+	URL          string
+	ThumbnailURL string
+	ExpiresAt    time.Time
+}
@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	customerrors "github.com/VladimirKholomyanskyy/gym-api/internal/customErrors"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/photos/model"
+	"gorm.io/gorm"
+)
+
+// PhotoRepository stores the records of progress photos, the images are kept by the file storage
+type PhotoRepository interface {
+	Create(ctx context.Context, photo *model.Photo) error
+	GetByID(ctx context.Context, id string) (*model.Photo, error)
+	GetAllByProfileID(ctx context.Context, profileID string, pose *model.Pose, from, to time.Time, page, pageSize int) ([]model.Photo, int64, error)
+	UpdatePartial(ctx context.Context, id string, updates map[string]any) (*model.Photo, error)
+	Delete(ctx context.Context, id string) error
+}
+
+// photoRepository implements PhotoRepository
+type photoRepository struct {
+	db *gorm.DB
+}
+
+// NewPhotoRepository creates a new repository instance
+func NewPhotoRepository(db *gorm.DB) PhotoRepository {
+	return &photoRepository{db: db}
+}
+
+func (r *photoRepository) Create(ctx context.Context, photo *model.Photo) error {
+	if err := r.db.WithContext(ctx).Create(photo).Error; err != nil {
+		return fmt.Errorf("failed to create progress photo: %w", err)
+	}
+	return nil
+}
+
+func (r *photoRepository) GetByID(ctx context.Context, id string) (*model.Photo, error) {
+	var photo model.Photo
+	err := r.db.WithContext(ctx).First(&photo, "id = ?", id).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, customerrors.ErrEntityNotFound
+		}
+		return nil, fmt.Errorf("failed to fetch progress photo by id: %w", err)
+	}
+	return &photo, nil
+}
+
+// GetAllByProfileID retrieves paginated photos of a profile taken in a date range, both inclusive,
+// newest first and optionally of a single pose
+func (r *photoRepository) GetAllByProfileID(ctx context.Context, profileID string, pose *model.Pose, from, to time.Time, page, pageSize int) ([]model.Photo, int64, error) {
+	var photos []model.Photo
+	var total int64
+	offset := (page - 1) * pageSize
+
+	query := r.db.WithContext(ctx).
+		Model(&model.Photo{}).
+		Where("profile_id = ? AND taken_on BETWEEN ? AND ?", profileID, from.Format(time.DateOnly), to.Format(time.DateOnly))
+	if pose != nil {
+		query = query.Where("pose = ?", *pose)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count progress photos: %w", err)
+	}
+	err := query.
+		Order("taken_on DESC, created_at DESC").
+		Limit(pageSize).
+		Offset(offset).
+		Find(&photos).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to fetch progress photos: %w", err)
+	}
+	return photos, total, nil
+}
+
+func (r *photoRepository) UpdatePartial(ctx context.Context, id string, updates map[string]any) (*model.Photo, error) {
+	result := r.db.WithContext(ctx).Model(&model.Photo{}).Where("id = ?", id).Updates(updates)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to update progress photo: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, customerrors.ErrEntityNotFound
+	}
+	return r.GetByID(ctx, id)
+}
+
+func (r *photoRepository) Delete(ctx context.Context, id string) error {
+	result := r.db.WithContext(ctx).Where("id = ?", id).Delete(&model.Photo{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete progress photo: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return customerrors.ErrEntityNotFound
+	}
+	return nil
+}
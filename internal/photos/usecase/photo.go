@@ -0,0 +1,215 @@
+package usecase
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	customerrors "github.com/VladimirKholomyanskyy/gym-api/internal/customErrors"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/imaging"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/photos/model"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/photos/repository"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/storage"
+)
+
+const (
+	// URLLifetime is how long the signed URLs of a photo stay valid
+	URLLifetime = 15 * time.Minute
+	// ThumbnailSize is the longer side of thumbnails in pixels
+	ThumbnailSize = 320
+	// StoragePrefix is where photos are kept in the storage, below a directory per profile
+	StoragePrefix = "progress-photos/"
+)
+
+// extensions of the accepted content types, detected from the uploaded bytes rather than trusted from the client
+var extensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+}
+
+// PhotoUseCase manages progress photos. Every photo is checked to belong to the profile before it
+// is shown, changed or deleted, and images are only reachable through short-lived signed URLs.
+type PhotoUseCase interface {
+	Upload(ctx context.Context, input model.UploadPhotoInput) (*model.Photo, error)
+	GetByID(ctx context.Context, profileID, photoID string) (*model.Photo, error)
+	List(ctx context.Context, profileID string, pose *model.Pose, from, to time.Time, page, pageSize int) ([]model.Photo, int64, error)
+	Update(ctx context.Context, input model.UpdatePhotoInput) (*model.Photo, error)
+	Delete(ctx context.Context, profileID, photoID string) error
+	URLs(photo *model.Photo, now time.Time) model.PhotoURLs
+}
+
+type photoUseCase struct {
+	repo    repository.PhotoRepository
+	storage storage.Storage
+	signer  *storage.URLSigner
+}
+
+func NewPhotoUseCase(repo repository.PhotoRepository, storage storage.Storage, signer *storage.URLSigner) PhotoUseCase {
+	return &photoUseCase{repo: repo, storage: storage, signer: signer}
+}
+
+// Upload streams the image to the storage, then reads it back to check it and generate the
+// thumbnail. Nothing is kept when any step fails.
+func (uc *photoUseCase) Upload(ctx context.Context, input model.UploadPhotoInput) (*model.Photo, error) {
+	content := bufio.NewReaderSize(input.Content, 512)
+	head, err := content.Peek(512)
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to read upload: %w", err)
+	}
+	contentType := http.DetectContentType(head)
+	extension, ok := extensions[contentType]
+	if !ok {
+		return nil, customerrors.ErrInvalidImage
+	}
+	name, err := randomName()
+	if err != nil {
+		return nil, err
+	}
+	prefix := fmt.Sprintf("%s%s/%s", StoragePrefix, input.ProfileID, name)
+	photo := &model.Photo{
+		ProfileID:    input.ProfileID,
+		TakenOn:      input.TakenOn,
+		Pose:         input.Pose,
+		Note:         input.Note,
+		ContentType:  contentType,
+		StorageKey:   prefix + extension,
+		ThumbnailKey: prefix + "_thumb.jpg",
+	}
+
+	photo.SizeBytes, err = uc.storage.Put(ctx, photo.StorageKey, content)
+	if err != nil {
+		uc.deleteFiles(photo)
+		return nil, err
+	}
+	if err := uc.storeThumbnail(ctx, photo); err != nil {
+		uc.deleteFiles(photo)
+		if errors.Is(err, imaging.ErrUnsupportedImage) {
+			return nil, customerrors.ErrInvalidImage
+		}
+		return nil, err
+	}
+	if err := uc.repo.Create(ctx, photo); err != nil {
+		uc.deleteFiles(photo)
+		return nil, err
+	}
+	return photo, nil
+}
+
+// storeThumbnail decodes the stored image and stores its thumbnail. The dimensions are checked
+// before decoding, so an image claiming to be huge is rejected without allocating it.
+func (uc *photoUseCase) storeThumbnail(ctx context.Context, photo *model.Photo) error {
+	file, err := uc.storage.Open(ctx, photo.StorageKey)
+	if err != nil {
+		return err
+	}
+	config, _, err := imaging.DecodeConfig(file)
+	file.Close()
+	if err != nil {
+		return err
+	}
+	photo.Width, photo.Height = config.Width, config.Height
+
+	file, err = uc.storage.Open(ctx, photo.StorageKey)
+	if err != nil {
+		return err
+	}
+	img, err := imaging.Decode(file)
+	file.Close()
+	if err != nil {
+		return err
+	}
+	var thumbnail bytes.Buffer
+	if err := imaging.EncodeJPEG(&thumbnail, imaging.Thumbnail(img, ThumbnailSize)); err != nil {
+		return err
+	}
+	_, err = uc.storage.Put(ctx, photo.ThumbnailKey, &thumbnail)
+	return err
+}
+
+// GetByID retrieves a photo ensuring it belongs to the profile
+func (uc *photoUseCase) GetByID(ctx context.Context, profileID, photoID string) (*model.Photo, error) {
+	photo, err := uc.repo.GetByID(ctx, photoID)
+	if err != nil {
+		return nil, err
+	}
+	if photo.ProfileID != profileID {
+		return nil, customerrors.ErrAccessForbidden
+	}
+	return photo, nil
+}
+
+func (uc *photoUseCase) List(ctx context.Context, profileID string, pose *model.Pose, from, to time.Time, page, pageSize int) ([]model.Photo, int64, error) {
+	return uc.repo.GetAllByProfileID(ctx, profileID, pose, from, to, page, pageSize)
+}
+
+// Update changes the date, pose or note of a photo, the image can't be replaced
+func (uc *photoUseCase) Update(ctx context.Context, input model.UpdatePhotoInput) (*model.Photo, error) {
+	photo, err := uc.GetByID(ctx, input.ProfileID, input.PhotoID)
+	if err != nil {
+		return nil, err
+	}
+	updates := make(map[string]any)
+	if input.TakenOn != nil {
+		updates["taken_on"] = *input.TakenOn
+	}
+	if input.Pose != nil {
+		updates["pose"] = *input.Pose
+	}
+	if input.Note != nil {
+		updates["note"] = input.Note
+	}
+	if len(updates) == 0 {
+		return photo, nil
+	}
+	return uc.repo.UpdatePartial(ctx, photo.ID, updates)
+}
+
+// Delete removes a photo and then its files. Files that fail to be deleted are logged, the photo
+// is gone either way and its files can no longer be reached.
+func (uc *photoUseCase) Delete(ctx context.Context, profileID, photoID string) error {
+	photo, err := uc.GetByID(ctx, profileID, photoID)
+	if err != nil {
+		return err
+	}
+	if err := uc.repo.Delete(ctx, photo.ID); err != nil {
+		return err
+	}
+	uc.deleteFiles(photo)
+	return nil
+}
+
+// URLs signs the URLs of a photo and its thumbnail
+func (uc *photoUseCase) URLs(photo *model.Photo, now time.Time) model.PhotoURLs {
+	expires := now.Add(URLLifetime)
+	return model.PhotoURLs{
+		URL:          uc.signer.URL(photo.StorageKey, expires),
+		ThumbnailURL: uc.signer.URL(photo.ThumbnailKey, expires),
+		ExpiresAt:    expires,
+	}
+}
+
+// deleteFiles removes the files of a photo. It runs after the request may have been cancelled, so
+// it doesn't use the request context.
+func (uc *photoUseCase) deleteFiles(photo *model.Photo) {
+	for _, key := range []string{photo.StorageKey, photo.ThumbnailKey} {
+		if err := uc.storage.Delete(context.Background(), key); err != nil && !errors.Is(err, storage.ErrNotFound) {
+			log.Printf("Failed to delete stored file %s: %v", key, err)
+		}
+	}
+}
+
+func randomName() (string, error) {
+	name := make([]byte, 16)
+	if _, err := rand.Read(name); err != nil {
+		return "", fmt.Errorf("failed to generate file name: %w", err)
+	}
+	return hex.EncodeToString(name), nil
+}
@@ -1,27 +1,220 @@
 package handlers
 
 import (
+	"errors"
+
 	openapi "github.com/VladimirKholomyanskyy/gym-api/internal/api/go"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/common"
+	customerrors "github.com/VladimirKholomyanskyy/gym-api/internal/customErrors"
 	"github.com/VladimirKholomyanskyy/gym-api/internal/progress/model"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/utils"
 )
 
-func convertExerciseLog(gormExerciseLog *model.ExerciseLog) *openapi.ExerciseLog {
+func convertExerciseLog(gormExerciseLog *model.ExerciseLog, units common.Units) *openapi.ExerciseLog {
 	return &openapi.ExerciseLog{
 		Id:               gormExerciseLog.ID,
 		ExerciseId:       gormExerciseLog.ExerciseID,
 		WorkoutSessionId: gormExerciseLog.SessionID,
 		SetNumber:        int32(gormExerciseLog.SetNumber),
 		RepsCompleted:    int32(gormExerciseLog.Reps),
-		WeightUsed:       int32(gormExerciseLog.Weight),
-		LoggedAt:         gormExerciseLog.UpdatedAt,
+		WeightUsed:       units.FromKilograms(gormExerciseLog.Weight),
+		LoggedAt:         gormExerciseLog.LoggedAt,
+		Rpe:              gormExerciseLog.RPE,
+		Rir:              int32Pointer(gormExerciseLog.RIR),
+		RestSeconds:      int32Pointer(gormExerciseLog.RestSeconds),
+		Tempo:            gormExerciseLog.Tempo,
+		ToFailure:        gormExerciseLog.ToFailure,
+		PartialReps:      int32(gormExerciseLog.PartialReps),
+		Note:             gormExerciseLog.Note,
 	}
 
 }
 
-func convertExerciseLogs(gormExerciseLogs []model.ExerciseLog) []openapi.ExerciseLog {
+func int32Pointer(value *int) *int32 {
+	if value == nil {
+		return nil
+	}
+	v := int32(*value)
+	return &v
+}
+
+func convertExerciseLogs(gormExerciseLogs []model.ExerciseLog, units common.Units) []openapi.ExerciseLog {
 	apiExerciseLogs := make([]openapi.ExerciseLog, len(gormExerciseLogs))
 	for i, e := range gormExerciseLogs {
-		apiExerciseLogs[i] = *convertExerciseLog(&e)
+		apiExerciseLogs[i] = *convertExerciseLog(&e, units)
 	}
 	return apiExerciseLogs
 }
+
+func convertWorkoutSession(session *model.WorkoutSession) (*openapi.WorkoutSession, error) {
+	snapshot, err := utils.ConverWorkoutSnapshot(&session.Snapshot)
+	if err != nil {
+		return nil, err
+	}
+	return &openapi.WorkoutSession{
+		Id:              session.ID,
+		StartedAt:       session.StartedAt,
+		CompletedAt:     session.CompletedAt,
+		WorkoutSnapshot: *snapshot,
+	}, nil
+}
+
+func convertSyncError(err error) (*openapi.ErrorCodes, *string) {
+	if err == nil {
+		return nil, nil
+	}
+	code := openapi.INTERNAL_SERVER_ERROR
+	message := "Failed to apply item, retry later"
+	switch {
+	case errors.Is(err, customerrors.ErrEntityNotFound):
+		code, message = openapi.RESOURCE_NOT_FOUND, "Referenced workout or exercise not found"
+	case errors.Is(err, customerrors.ErrAccessForbidden):
+		code, message = openapi.FORBIDDEN, "Item belongs to another profile or session"
+	case errors.Is(err, customerrors.ErrInvalidUUID):
+		code, message = openapi.INVALID_ID, err.Error()
+	case errors.Is(err, customerrors.ErrSyncItemDeleted):
+		code, message = openapi.RESOURCE_NOT_FOUND, err.Error()
+	case errors.Is(err, customerrors.ErrSessionCompleted):
+		code, message = openapi.SESSION_COMPLETED, err.Error()
+	case errors.Is(err, customerrors.ErrSessionNotEditable):
+		code, message = openapi.SESSION_NOT_EDITABLE, err.Error()
+	case errors.Is(err, customerrors.ErrExerciseNotInWorkout):
+		code, message = openapi.EXERCISE_NOT_IN_WORKOUT, err.Error()
+	case errors.Is(err, customerrors.ErrInvalidSetNumber):
+		code, message = openapi.INVALID_SET_NUMBER, err.Error()
+	case errors.Is(err, customerrors.ErrDuplicateSet):
+		code, message = openapi.DUPLICATE_SET, err.Error()
+	case errors.Is(err, customerrors.ErrWeightTooHigh),
+		errors.Is(err, customerrors.ErrSyncWorkoutMismatch):
+		code, message = openapi.INVALID_REQUEST, err.Error()
+	case errors.Is(err, customerrors.ErrCompletedBeforeStart):
+		code, message = openapi.INVALID_DATE_RANGE, err.Error()
+	}
+	return &code, &message
+}
+
+func convertSessionSyncResult(result *model.SessionSyncResult, units common.Units) (*openapi.SyncWorkoutSessionResponse, error) {
+	errorCode, message := convertSyncError(result.Session.Err)
+	response := &openapi.SyncWorkoutSessionResponse{
+		Session: openapi.SyncWorkoutSessionResult{
+			Id:        result.Session.ID,
+			Status:    openapi.SyncItemStatus(result.Session.Status),
+			ErrorCode: errorCode,
+			Message:   message,
+		},
+		ExerciseLogs: make([]openapi.SyncExerciseLogResult, len(result.ExerciseLogs)),
+	}
+	if result.Session.Session != nil {
+		session, err := convertWorkoutSession(result.Session.Session)
+		if err != nil {
+			return nil, err
+		}
+		response.Session.WorkoutSession = session
+	}
+	for i, item := range result.ExerciseLogs {
+		errorCode, message := convertSyncError(item.Err)
+		response.ExerciseLogs[i] = openapi.SyncExerciseLogResult{
+			Id:        item.ID,
+			Status:    openapi.SyncItemStatus(item.Status),
+			ErrorCode: errorCode,
+			Message:   message,
+		}
+		if item.ExerciseLog != nil {
+			response.ExerciseLogs[i].ExerciseLog = convertExerciseLog(item.ExerciseLog, units)
+		}
+	}
+	return response, nil
+}
+
+func convertPersonalRecord(record *model.PersonalRecord, units common.Units) *openapi.PersonalRecord {
+	value, previousValue := record.Value, record.PreviousValue
+	// Reps at a weight are counted, the other records are weights or volumes
+	if record.Type != model.PersonalRecordRepsAtWeight {
+		value = units.FromKilograms(value)
+		if previousValue != nil {
+			previous := units.FromKilograms(*previousValue)
+			previousValue = &previous
+		}
+	}
+	return &openapi.PersonalRecord{
+		Id:               record.ID,
+		ExerciseId:       record.ExerciseID,
+		ExerciseLogId:    record.ExerciseLogID,
+		WorkoutSessionId: record.SessionID,
+		Type:             openapi.PersonalRecordType(record.Type),
+		Value:            value,
+		PreviousValue:    previousValue,
+		Weight:           units.FromKilograms(record.Weight),
+		Reps:             int32(record.Reps),
+		AchievedAt:       record.AchievedAt,
+	}
+}
+
+func convertPersonalRecords(records []model.PersonalRecord, units common.Units) []openapi.PersonalRecord {
+	apiRecords := make([]openapi.PersonalRecord, len(records))
+	for i, r := range records {
+		apiRecords[i] = *convertPersonalRecord(&r, units)
+	}
+	return apiRecords
+}
+
+func convertSessionComparison(comparison *model.SessionComparison, units common.Units) (*openapi.WorkoutSessionComparison, error) {
+	session, err := convertWorkoutSession(&comparison.Session)
+	if err != nil {
+		return nil, err
+	}
+	previousSessions := make([]openapi.WorkoutSession, len(comparison.PreviousSessions))
+	for i := range comparison.PreviousSessions {
+		previous, err := convertWorkoutSession(&comparison.PreviousSessions[i])
+		if err != nil {
+			return nil, err
+		}
+		previousSessions[i] = *previous
+	}
+	exercises := make([]openapi.ExerciseComparison, len(comparison.Exercises))
+	for i, e := range comparison.Exercises {
+		sets := make([]openapi.SetComparison, len(e.Sets))
+		for j, set := range e.Sets {
+			sets[j] = openapi.SetComparison{
+				SetNumber: int32(set.SetNumber),
+				Previous:  make([]*openapi.ExerciseLog, len(set.Previous)),
+			}
+			if set.Current != nil {
+				sets[j].Current = convertExerciseLog(set.Current, units)
+			}
+			for k, previous := range set.Previous {
+				if previous != nil {
+					sets[j].Previous[k] = convertExerciseLog(previous, units)
+				}
+			}
+			if set.Delta != nil {
+				sets[j].Delta = &openapi.SetDelta{
+					Weight: units.FromKilograms(set.Delta.Weight),
+					Reps:   int32(set.Delta.Reps),
+					Volume: units.FromKilograms(set.Delta.Volume),
+				}
+			}
+		}
+		previousVolumes := make([]float64, len(e.PreviousVolumes))
+		for k, volume := range e.PreviousVolumes {
+			previousVolumes[k] = units.FromKilograms(volume)
+		}
+		var volumeDelta *float64
+		if e.VolumeDelta != nil {
+			delta := units.FromKilograms(*e.VolumeDelta)
+			volumeDelta = &delta
+		}
+		exercises[i] = openapi.ExerciseComparison{
+			ExerciseId:      e.ExerciseID,
+			Volume:          units.FromKilograms(e.Volume),
+			PreviousVolumes: previousVolumes,
+			VolumeDelta:     volumeDelta,
+			Sets:            sets,
+		}
+	}
+	return &openapi.WorkoutSessionComparison{
+		Session:          *session,
+		PreviousSessions: previousSessions,
+		Exercises:        exercises,
+	}, nil
+}
@@ -2,10 +2,12 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"net/http"
 
 	openapi "github.com/VladimirKholomyanskyy/gym-api/internal/api/go"
 	"github.com/VladimirKholomyanskyy/gym-api/internal/common"
+	customerrors "github.com/VladimirKholomyanskyy/gym-api/internal/customErrors"
 	"github.com/VladimirKholomyanskyy/gym-api/internal/progress/model"
 	usecase "github.com/VladimirKholomyanskyy/gym-api/internal/progress/usecase"
 	"github.com/VladimirKholomyanskyy/gym-api/internal/utils"
@@ -26,48 +28,98 @@ func (h *exerciseLogHandler) LogExercise(ctx context.Context, logExerciseRequest
 	if err != nil {
 		return utils.ErrorResponse(http.StatusUnauthorized, openapi.FORBIDDEN, err.Error())
 	}
-	if common.IsUUIDValid(logExerciseRequest.ExerciseId) {
+	if !common.IsUUIDValid(logExerciseRequest.ExerciseId) {
 		return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_ID, "Exercise ID is not a valid UUID")
 	}
-	if common.IsUUIDValid(logExerciseRequest.WorkoutSessionId) {
+	if !common.IsUUIDValid(logExerciseRequest.WorkoutSessionId) {
 		return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_ID, "Workout session ID is not a valid UUID")
 	}
+	if logExerciseRequest.SetNumber < 1 {
+		return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_REQUEST, "Set number must be greater than 0")
+	}
+	if logExerciseRequest.RepsCompleted < 0 {
+		return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_REQUEST, "Reps can't be negative")
+	}
+	if logExerciseRequest.WeightUsed < 0 {
+		return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_REQUEST, "Weight can't be negative")
+	}
+	if message := validateSetMetrics(logExerciseRequest.Rpe, logExerciseRequest.Rir, logExerciseRequest.RestSeconds, logExerciseRequest.PartialReps, logExerciseRequest.Tempo); message != "" {
+		return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_REQUEST, message)
+	}
+	logExerciseRequest.WeightUsed = common.ExtractUnits(ctx).ToKilograms(logExerciseRequest.WeightUsed)
 	log, err := h.useCase.Create(ctx, profileId, logExerciseRequest)
 	if err != nil {
-		return utils.ErrorResponse(http.StatusInternalServerError, openapi.INTERNAL_SERVER_ERROR, "Failed to fetch exercise log")
+		switch {
+		case errors.Is(err, customerrors.ErrEntityNotFound):
+			return utils.ErrorResponse(http.StatusNotFound, openapi.RESOURCE_NOT_FOUND, err.Error())
+		case errors.Is(err, customerrors.ErrAccessForbidden):
+			return utils.ErrorResponse(http.StatusForbidden, openapi.FORBIDDEN, "Access denied to workout session")
+		case errors.Is(err, customerrors.ErrSessionCompleted):
+			return utils.ErrorResponse(http.StatusConflict, openapi.SESSION_COMPLETED, err.Error())
+		case errors.Is(err, customerrors.ErrDuplicateSet):
+			return utils.ErrorResponse(http.StatusConflict, openapi.DUPLICATE_SET, err.Error())
+		case errors.Is(err, customerrors.ErrExerciseNotInWorkout):
+			return utils.ErrorResponse(http.StatusUnprocessableEntity, openapi.EXERCISE_NOT_IN_WORKOUT, "Exercise is not part of the workout, set allowOffPlan to log it anyway")
+		case errors.Is(err, customerrors.ErrInvalidSetNumber):
+			return utils.ErrorResponse(http.StatusUnprocessableEntity, openapi.INVALID_SET_NUMBER, "Set number exceeds the planned sets, set allowOffPlan to log it anyway")
+		case errors.Is(err, customerrors.ErrWeightTooHigh):
+			return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_REQUEST, err.Error())
+		}
+		return utils.ErrorResponse(http.StatusInternalServerError, openapi.INTERNAL_SERVER_ERROR, "Failed to create exercise log")
 	}
-	return openapi.Response(http.StatusCreated, convertExerciseLog(log)), nil
+	return openapi.Response(http.StatusCreated, convertExerciseLog(log, common.ExtractUnits(ctx))), nil
 }
 
-// ListExerciseLogs - Retrieve all logged exercises for a workout session
-func (h *exerciseLogHandler) ListExerciseLogs(ctx context.Context, workoutSessionIdParam, exerciseIdParam string, page, pageSize int32) (openapi.ImplResponse, error) {
+// ListExerciseLogs - Retrieve logged sets, optionally filtered by session, exercise, failure, RPE and date range
+func (h *exerciseLogHandler) ListExerciseLogs(ctx context.Context, workoutSessionIdParam, exerciseIdParam string, toFailure *bool, minRpe, maxRpe *float64, startDate, endDate string, page, pageSize int32) (openapi.ImplResponse, error) {
 	profileId, err := common.ExtractProfileID(ctx)
 	if err != nil {
 		return utils.ErrorResponse(http.StatusUnauthorized, openapi.FORBIDDEN, err.Error())
 	}
-	var (
-		exerciseLogs []model.ExerciseLog
-		totalCount   int64
-	)
-	switch {
-	case workoutSessionIdParam != "":
-		if common.IsUUIDValid(workoutSessionIdParam) {
+	if !common.IsPageValid(page) {
+		return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_PAGE_NUMBER, "page must be greater than 0")
+	}
+	if !common.IsPageSizeValid(pageSize) {
+		return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_PAGE_SIZE, "pageSize must be between 1 and 100")
+	}
+	filter := model.ExerciseLogFilter{ToFailure: toFailure, MinRPE: minRpe, MaxRPE: maxRpe}
+	if workoutSessionIdParam != "" {
+		if !common.IsUUIDValid(workoutSessionIdParam) {
 			return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_ID, "Workout session ID is not a valid UUID")
 		}
-		exerciseLogs, totalCount, err = h.useCase.GetExerciseLogsBySessionID(ctx, profileId, workoutSessionIdParam, int(page), int(pageSize))
-
-	case exerciseIdParam != "":
-		if common.IsUUIDValid(exerciseIdParam) {
+		filter.SessionID = &workoutSessionIdParam
+	}
+	if exerciseIdParam != "" {
+		if !common.IsUUIDValid(exerciseIdParam) {
 			return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_ID, "Exercise ID is not a valid UUID")
 		}
-		exerciseLogs, totalCount, err = h.useCase.GetExerciseLogsByExerciseID(ctx, profileId, exerciseIdParam, int(page), int(pageSize))
-
-	default:
-		// Fetch all logs for the user
-		exerciseLogs, totalCount, err = h.useCase.GetExerciseLogsByProfileID(ctx, profileId, int(page), int(pageSize))
+		filter.ExerciseID = &exerciseIdParam
+	}
+	if minRpe != nil && maxRpe != nil && *minRpe > *maxRpe {
+		return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_REQUEST, "minRpe can't be greater than maxRpe")
+	}
+	location := common.ExtractLocation(ctx)
+	if startDate != "" {
+		startDateTime, err := common.ParseDate(startDate, location)
+		if err != nil {
+			return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_DATE_FORMAT, "Invalid startDate format")
+		}
+		filter.StartDate = &startDateTime
+	}
+	if endDate != "" {
+		endDateTime, err := common.ParseDate(endDate, location)
+		if err != nil {
+			return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_DATE_FORMAT, "Invalid endDate format")
+		}
+		// endDate is inclusive, the filter end is exclusive
+		endDateTime = endDateTime.AddDate(0, 0, 1)
+		filter.EndDate = &endDateTime
+	}
+	if filter.StartDate != nil && filter.EndDate != nil && !filter.StartDate.Before(*filter.EndDate) {
+		return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_DATE_RANGE, "startDate can't be after endDate")
 	}
 
-	// Handle repository error
+	exerciseLogs, totalCount, err := h.useCase.List(ctx, profileId, filter, int(page), int(pageSize))
 	if err != nil {
 		return utils.ErrorResponse(http.StatusInternalServerError, openapi.INTERNAL_SERVER_ERROR, "failed to fetch exercise logs")
 	}
@@ -79,7 +131,7 @@ func (h *exerciseLogHandler) ListExerciseLogs(ctx context.Context, workoutSessio
 			CurrentPage: page,
 			PageSize:    pageSize,
 			TotalPages:  utils.CalculateTotalPages(totalCount, pageSize),
-			Items:       convertExerciseLogs(exerciseLogs)}), nil
+			Items:       convertExerciseLogs(exerciseLogs, common.ExtractUnits(ctx))}), nil
 }
 
 // GetExerciseLog - Retrieve details of a specific exercise log
@@ -88,14 +140,95 @@ func (h *exerciseLogHandler) GetExerciseLog(ctx context.Context, exerciseLogId s
 	if err != nil {
 		return utils.ErrorResponse(http.StatusUnauthorized, openapi.FORBIDDEN, err.Error())
 	}
-	if common.IsUUIDValid(exerciseLogId) {
+	if !common.IsUUIDValid(exerciseLogId) {
 		return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_ID, "Exercise log ID is not a valid UUID")
 	}
 	log, err := h.useCase.GetExerciseLog(ctx, profileId, exerciseLogId)
 	if err != nil {
+		if errors.Is(err, customerrors.ErrAccessForbidden) {
+			return utils.ErrorResponse(http.StatusForbidden, openapi.FORBIDDEN, "Access denied to exercise log")
+		}
+		if errors.Is(err, customerrors.ErrEntityNotFound) {
+			return utils.ErrorResponse(http.StatusNotFound, openapi.RESOURCE_NOT_FOUND, "Exercise log not found")
+		}
 		return utils.ErrorResponse(http.StatusInternalServerError, openapi.INTERNAL_SERVER_ERROR, "failed to fetch exercise log")
 	}
-	return openapi.Response(http.StatusCreated, convertExerciseLog(log)), nil
+	return openapi.Response(http.StatusOK, convertExerciseLog(log, common.ExtractUnits(ctx))), nil
+}
+
+// UpdateExerciseLog - Correct a logged set
+func (h *exerciseLogHandler) UpdateExerciseLog(ctx context.Context, exerciseLogId string, request openapi.PatchExerciseLogRequest) (openapi.ImplResponse, error) {
+	profileId, err := common.ExtractProfileID(ctx)
+	if err != nil {
+		return utils.ErrorResponse(http.StatusUnauthorized, openapi.FORBIDDEN, err.Error())
+	}
+	if !common.IsUUIDValid(exerciseLogId) {
+		return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_ID, "Exercise log ID is not a valid UUID")
+	}
+	if request.SetNumber != nil && *request.SetNumber < 1 {
+		return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_REQUEST, "Set number must be greater than 0")
+	}
+	if request.RepsCompleted != nil && *request.RepsCompleted < 0 {
+		return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_REQUEST, "Reps can't be negative")
+	}
+	if request.WeightUsed != nil {
+		if *request.WeightUsed < 0 {
+			return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_REQUEST, "Weight can't be negative")
+		}
+		weight := common.ExtractUnits(ctx).ToKilograms(*request.WeightUsed)
+		request.WeightUsed = &weight
+	}
+	if message := validateSetMetrics(request.Rpe, request.Rir, request.RestSeconds, request.PartialReps, request.Tempo); message != "" {
+		return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_REQUEST, message)
+	}
+	log, err := h.useCase.Update(ctx, profileId, exerciseLogId, request)
+	if err != nil {
+		if errors.Is(err, customerrors.ErrAccessForbidden) {
+			return utils.ErrorResponse(http.StatusForbidden, openapi.FORBIDDEN, "Access denied to exercise log")
+		}
+		if errors.Is(err, customerrors.ErrEntityNotFound) {
+			return utils.ErrorResponse(http.StatusNotFound, openapi.RESOURCE_NOT_FOUND, "Exercise log not found")
+		}
+		if errors.Is(err, customerrors.ErrSessionNotEditable) {
+			return utils.ErrorResponse(http.StatusConflict, openapi.SESSION_NOT_EDITABLE, err.Error())
+		}
+		if errors.Is(err, customerrors.ErrDuplicateSet) {
+			return utils.ErrorResponse(http.StatusConflict, openapi.DUPLICATE_SET, err.Error())
+		}
+		if errors.Is(err, customerrors.ErrInvalidSetNumber) {
+			return utils.ErrorResponse(http.StatusUnprocessableEntity, openapi.INVALID_SET_NUMBER, "Set number exceeds the planned sets")
+		}
+		if errors.Is(err, customerrors.ErrWeightTooHigh) {
+			return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_REQUEST, err.Error())
+		}
+		return utils.ErrorResponse(http.StatusInternalServerError, openapi.INTERNAL_SERVER_ERROR, "Failed to update exercise log")
+	}
+	return openapi.Response(http.StatusOK, convertExerciseLog(log, common.ExtractUnits(ctx))), nil
+}
+
+// DeleteExerciseLog - Delete a logged set
+func (h *exerciseLogHandler) DeleteExerciseLog(ctx context.Context, exerciseLogId string) (openapi.ImplResponse, error) {
+	profileId, err := common.ExtractProfileID(ctx)
+	if err != nil {
+		return utils.ErrorResponse(http.StatusUnauthorized, openapi.FORBIDDEN, err.Error())
+	}
+	if !common.IsUUIDValid(exerciseLogId) {
+		return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_ID, "Exercise log ID is not a valid UUID")
+	}
+	err = h.useCase.Delete(ctx, profileId, exerciseLogId)
+	if err != nil {
+		if errors.Is(err, customerrors.ErrAccessForbidden) {
+			return utils.ErrorResponse(http.StatusForbidden, openapi.FORBIDDEN, "Access denied to exercise log")
+		}
+		if errors.Is(err, customerrors.ErrEntityNotFound) {
+			return utils.ErrorResponse(http.StatusNotFound, openapi.RESOURCE_NOT_FOUND, "Exercise log not found")
+		}
+		if errors.Is(err, customerrors.ErrSessionNotEditable) {
+			return utils.ErrorResponse(http.StatusConflict, openapi.SESSION_NOT_EDITABLE, err.Error())
+		}
+		return utils.ErrorResponse(http.StatusInternalServerError, openapi.INTERNAL_SERVER_ERROR, "Failed to delete exercise log")
+	}
+	return openapi.Response(http.StatusNoContent, nil), nil
 }
 
 func (h *exerciseLogHandler) GetWeightPerDay(ctx context.Context, exerciseId string, startDate string, endDate string) (openapi.ImplResponse, error) {
@@ -103,30 +236,34 @@ func (h *exerciseLogHandler) GetWeightPerDay(ctx context.Context, exerciseId str
 	if err != nil {
 		return utils.ErrorResponse(http.StatusUnauthorized, openapi.FORBIDDEN, err.Error())
 	}
-	if common.IsUUIDValid(exerciseId) {
+	if !common.IsUUIDValid(exerciseId) {
 		return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_ID, "Exercise ID is not a valid UUID")
 	}
-	startDateTime, err := utils.ParseTime(startDate)
+	location := common.ExtractLocation(ctx)
+	startDateTime, err := common.ParseDate(startDate, location)
 	if err != nil {
 		return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_DATE_FORMAT, "Invalid startDate format")
 	}
-	endDateTime, err := utils.ParseTime(endDate)
+	endDateTime, err := common.ParseDate(endDate, location)
 	if err != nil {
 		return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_DATE_FORMAT, "Invalid endDate format")
 	}
+	// endDate is inclusive, the query end is exclusive
+	endDateTime = endDateTime.AddDate(0, 0, 1)
 
 	// Fetch weight per day data from service
-	weightPerDayList, err := h.useCase.GetWeightPerDay(ctx, profileId, exerciseId, &startDateTime, &endDateTime)
+	weightPerDayList, err := h.useCase.GetWeightPerDay(ctx, profileId, exerciseId, &startDateTime, &endDateTime, location)
 	if err != nil {
 		return utils.ErrorResponse(http.StatusInternalServerError, openapi.INTERNAL_SERVER_ERROR, "failed to fetch weight per day")
 	}
 
 	// Convert response to OpenAPI format
 	var response []openapi.GetWeightPerDayTotalWeightPerDayInner
+	units := common.ExtractUnits(ctx)
 	for _, v := range weightPerDayList {
 		response = append(response, openapi.GetWeightPerDayTotalWeightPerDayInner{
 			Date:        utils.FormatTime(&v.Date),
-			TotalWeight: v.TotalWeight,
+			TotalWeight: units.FromKilograms(v.TotalWeight),
 		})
 	}
 
@@ -136,3 +273,23 @@ func (h *exerciseLogHandler) GetWeightPerDay(ctx context.Context, exerciseId str
 		TotalWeightPerDay: response,
 	}), nil
 }
+
+// validateSetMetrics checks the optional per-set metrics and returns a message describing the first invalid one
+func validateSetMetrics(rpe *float64, rir, restSeconds, partialReps *int32, tempo *string) string {
+	if rpe != nil && !model.IsValidRPE(*rpe) {
+		return "RPE must be between 1 and 10 in steps of 0.5"
+	}
+	if rir != nil && (*rir < 0 || *rir > 10) {
+		return "RIR must be between 0 and 10"
+	}
+	if restSeconds != nil && *restSeconds < 0 {
+		return "Rest can't be negative"
+	}
+	if partialReps != nil && *partialReps < 0 {
+		return "Partial reps can't be negative"
+	}
+	if tempo != nil && !model.IsValidTempo(*tempo) {
+		return "Tempo must look like 3-1-X-0 or 31X0"
+	}
+	return ""
+}
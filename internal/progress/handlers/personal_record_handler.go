@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	openapi "github.com/VladimirKholomyanskyy/gym-api/internal/api/go"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/common"
+	usecase "github.com/VladimirKholomyanskyy/gym-api/internal/progress/usecase"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/utils"
+)
+
+type personalRecordHandler struct {
+	useCase usecase.PersonalRecordUseCase
+}
+
+// NewPersonalRecordHandler creates a default api service
+func NewPersonalRecordHandler(useCase usecase.PersonalRecordUseCase) openapi.PersonalRecordsAPIServicer {
+	return &personalRecordHandler{useCase: useCase}
+}
+
+// ListExercisePersonalRecords - Retrieve the personal record history of an exercise
+func (h *personalRecordHandler) ListExercisePersonalRecords(ctx context.Context, exerciseId string, page, pageSize int32) (openapi.ImplResponse, error) {
+	profileId, err := common.ExtractProfileID(ctx)
+	if err != nil {
+		return utils.ErrorResponse(http.StatusUnauthorized, openapi.FORBIDDEN, err.Error())
+	}
+	if !common.IsUUIDValid(exerciseId) {
+		return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_ID, "Exercise ID is not a valid UUID")
+	}
+	if !common.IsPageValid(page) {
+		return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_PAGE_NUMBER, "page must be greater than 0")
+	}
+	if !common.IsPageSizeValid(pageSize) {
+		return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_PAGE_SIZE, "pageSize must be between 1 and 100")
+	}
+	records, totalCount, err := h.useCase.ListByExercise(ctx, profileId, exerciseId, int(page), int(pageSize))
+	if err != nil {
+		return utils.ErrorResponse(http.StatusInternalServerError, openapi.INTERNAL_SERVER_ERROR, "failed to fetch personal records")
+	}
+	return openapi.Response(
+		http.StatusOK,
+		openapi.ListPersonalRecords200Response{
+			TotalItems:  int32(totalCount),
+			CurrentPage: page,
+			PageSize:    pageSize,
+			TotalPages:  utils.CalculateTotalPages(totalCount, pageSize),
+			Items:       convertPersonalRecords(records, common.ExtractUnits(ctx))}), nil
+}
+
+// ListRecentPersonalRecords - Retrieve the latest personal records across all exercises
+func (h *personalRecordHandler) ListRecentPersonalRecords(ctx context.Context, page, pageSize int32) (openapi.ImplResponse, error) {
+	profileId, err := common.ExtractProfileID(ctx)
+	if err != nil {
+		return utils.ErrorResponse(http.StatusUnauthorized, openapi.FORBIDDEN, err.Error())
+	}
+	if !common.IsPageValid(page) {
+		return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_PAGE_NUMBER, "page must be greater than 0")
+	}
+	if !common.IsPageSizeValid(pageSize) {
+		return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_PAGE_SIZE, "pageSize must be between 1 and 100")
+	}
+	records, totalCount, err := h.useCase.ListRecent(ctx, profileId, int(page), int(pageSize))
+	if err != nil {
+		return utils.ErrorResponse(http.StatusInternalServerError, openapi.INTERNAL_SERVER_ERROR, "failed to fetch personal records")
+	}
+	return openapi.Response(
+		http.StatusOK,
+		openapi.ListPersonalRecords200Response{
+			TotalItems:  int32(totalCount),
+			CurrentPage: page,
+			PageSize:    pageSize,
+			TotalPages:  utils.CalculateTotalPages(totalCount, pageSize),
+			Items:       convertPersonalRecords(records, common.ExtractUnits(ctx))}), nil
+}
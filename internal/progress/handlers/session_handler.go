@@ -2,21 +2,33 @@ package handlers
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
 
 	openapi "github.com/VladimirKholomyanskyy/gym-api/internal/api/go"
 	"github.com/VladimirKholomyanskyy/gym-api/internal/common"
+	customerrors "github.com/VladimirKholomyanskyy/gym-api/internal/customErrors"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/progress/model"
 	usecase "github.com/VladimirKholomyanskyy/gym-api/internal/progress/usecase"
 	"github.com/VladimirKholomyanskyy/gym-api/internal/utils"
 )
 
+const (
+	// maxSyncBatchSize limits how many exercise logs a single sync request may carry
+	maxSyncBatchSize = 500
+	// maxComparedSessions limits how many previous sessions a session is compared with
+	maxComparedSessions = 5
+)
+
 type workoutSessionHandler struct {
-	useCase usecase.WorkoutSessionUseCase
+	useCase     usecase.WorkoutSessionUseCase
+	syncUseCase usecase.SessionSyncUseCase
 }
 
 // NewWorkoutSessionsAPIService creates a default api service
-func NewWorkoutSessionHandler(useCase usecase.WorkoutSessionUseCase) openapi.WorkoutSessionsAPIServicer {
-	return &workoutSessionHandler{useCase: useCase}
+func NewWorkoutSessionHandler(useCase usecase.WorkoutSessionUseCase, syncUseCase usecase.SessionSyncUseCase) openapi.WorkoutSessionsAPIServicer {
+	return &workoutSessionHandler{useCase: useCase, syncUseCase: syncUseCase}
 }
 
 func (h *workoutSessionHandler) GetWorkoutSession(ctx context.Context, workoutSessionId string) (openapi.ImplResponse, error) {
@@ -121,16 +133,113 @@ func (h *workoutSessionHandler) CompleteWorkoutSession(ctx context.Context, work
 
 	workoutSession, err := h.useCase.CompleteWorkout(ctx, profileId, workoutSessionId)
 	if err != nil {
+		if errors.Is(err, customerrors.ErrAccessForbidden) {
+			return utils.ErrorResponse(http.StatusForbidden, openapi.FORBIDDEN, "Access denied to workout session")
+		}
+		if errors.Is(err, customerrors.ErrEntityNotFound) {
+			return utils.ErrorResponse(http.StatusNotFound, openapi.RESOURCE_NOT_FOUND, "Workout session not found")
+		}
+		if errors.Is(err, customerrors.ErrSessionCompleted) {
+			return utils.ErrorResponse(http.StatusConflict, openapi.SESSION_COMPLETED, err.Error())
+		}
 		return openapi.Response(http.StatusInternalServerError, nil), nil
 	}
 
-	snapshot, err := utils.ConverWorkoutSnapshot(&workoutSession.Snapshot)
+	session, err := convertWorkoutSession(workoutSession)
 	if err != nil {
 		return openapi.Response(http.StatusInternalServerError, nil), nil
 	}
-	return openapi.Response(http.StatusCreated, openapi.WorkoutSession{
-		Id:              workoutSession.ID,
-		StartedAt:       workoutSession.StartedAt,
-		WorkoutSnapshot: *snapshot,
-	}), nil
+	return openapi.Response(http.StatusCreated, session), nil
+}
+
+// SyncWorkoutSession - Upload a session recorded offline together with its exercise logs
+func (h *workoutSessionHandler) SyncWorkoutSession(ctx context.Context, request openapi.SyncWorkoutSessionRequest) (openapi.ImplResponse, error) {
+	profileId, err := common.ExtractProfileID(ctx)
+	if err != nil {
+		return utils.ErrorResponse(http.StatusUnauthorized, openapi.FORBIDDEN, err.Error())
+	}
+	if !common.IsUUIDValid(request.Session.Id) {
+		return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_ID, "Workout session ID is not a valid UUID")
+	}
+	if !common.IsUUIDValid(request.Session.WorkoutId) {
+		return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_ID, "Workout ID is not a valid UUID")
+	}
+	if request.Session.CompletedAt != nil && request.Session.CompletedAt.Before(request.Session.StartedAt) {
+		return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_DATE_RANGE, "Session can't be completed before it started")
+	}
+	if len(request.ExerciseLogs) > maxSyncBatchSize {
+		return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_REQUEST, fmt.Sprintf("At most %d exercise logs can be synced at once", maxSyncBatchSize))
+	}
+	seen := make(map[string]bool, len(request.ExerciseLogs))
+	units := common.ExtractUnits(ctx)
+	for i, log := range request.ExerciseLogs {
+		if !common.IsUUIDValid(log.Id) || !common.IsUUIDValid(log.ExerciseId) {
+			return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_ID, "Exercise log and exercise IDs must be valid UUIDs")
+		}
+		if seen[log.Id] {
+			return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_REQUEST, "Exercise log "+log.Id+" is sent more than once")
+		}
+		seen[log.Id] = true
+		if log.SetNumber < 1 || log.RepsCompleted < 0 || log.WeightUsed < 0 {
+			return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_REQUEST, "Exercise log "+log.Id+" has invalid set number, reps or weight")
+		}
+		if message := validateSetMetrics(log.Rpe, log.Rir, log.RestSeconds, log.PartialReps, log.Tempo); message != "" {
+			return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_REQUEST, "Exercise log "+log.Id+": "+message)
+		}
+		request.ExerciseLogs[i].WeightUsed = units.ToKilograms(log.WeightUsed)
+	}
+
+	result, err := h.syncUseCase.Sync(ctx, profileId, request)
+	if err != nil {
+		return utils.ErrorResponse(http.StatusInternalServerError, openapi.INTERNAL_SERVER_ERROR, "Failed to sync workout session")
+	}
+	response, err := convertSessionSyncResult(result, units)
+	if err != nil {
+		return utils.ErrorResponse(http.StatusInternalServerError, openapi.INTERNAL_SERVER_ERROR, "Failed to unmarshall workout snapshout")
+	}
+	return openapi.Response(http.StatusOK, response), nil
+}
+
+// CompareWorkoutSessions - Compare a session, or the latest session of a workout, with the previous sessions of the same workout
+func (h *workoutSessionHandler) CompareWorkoutSessions(ctx context.Context, workoutSessionId, workoutId string, previous int32) (openapi.ImplResponse, error) {
+	profileId, err := common.ExtractProfileID(ctx)
+	if err != nil {
+		return utils.ErrorResponse(http.StatusUnauthorized, openapi.FORBIDDEN, err.Error())
+	}
+	if (workoutSessionId == "") == (workoutId == "") {
+		return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_REQUEST, "Exactly one of workoutSessionId and workoutId is required")
+	}
+	if previous == 0 {
+		previous = 1
+	}
+	if previous < 1 || previous > maxComparedSessions {
+		return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_REQUEST, fmt.Sprintf("previous must be between 1 and %d", maxComparedSessions))
+	}
+
+	var comparison *model.SessionComparison
+	if workoutSessionId != "" {
+		if !common.IsUUIDValid(workoutSessionId) {
+			return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_ID, "Workout session ID is not a valid UUID")
+		}
+		comparison, err = h.useCase.Compare(ctx, profileId, workoutSessionId, int(previous))
+	} else {
+		if !common.IsUUIDValid(workoutId) {
+			return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_ID, "Workout ID is not a valid UUID")
+		}
+		comparison, err = h.useCase.CompareLatest(ctx, profileId, workoutId, int(previous))
+	}
+	if err != nil {
+		if errors.Is(err, customerrors.ErrAccessForbidden) {
+			return utils.ErrorResponse(http.StatusForbidden, openapi.FORBIDDEN, "Access denied to workout session")
+		}
+		if errors.Is(err, customerrors.ErrEntityNotFound) {
+			return utils.ErrorResponse(http.StatusNotFound, openapi.RESOURCE_NOT_FOUND, "Workout session not found")
+		}
+		return utils.ErrorResponse(http.StatusInternalServerError, openapi.INTERNAL_SERVER_ERROR, "Failed to compare workout sessions")
+	}
+	response, err := convertSessionComparison(comparison, common.ExtractUnits(ctx))
+	if err != nil {
+		return utils.ErrorResponse(http.StatusInternalServerError, openapi.INTERNAL_SERVER_ERROR, "Failed to unmarshall workout snapshout")
+	}
+	return openapi.Response(http.StatusOK, response), nil
 }
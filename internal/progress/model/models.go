@@ -1,6 +1,8 @@
 package model
 
 import (
+	"math"
+	"regexp"
 	"time"
 
 	"github.com/VladimirKholomyanskyy/gym-api/internal/common"
@@ -14,7 +16,17 @@ type WorkoutSession struct {
 	Snapshot    datatypes.JSON `gorm:"type:jsonb;not null"` // JSONB for workout snapshot
 	StartedAt   time.Time
 	CompletedAt *time.Time
-	Logs        []ExerciseLog `gorm:"foreignKey:SessionID" json:"logs"` // Association
+	// ImportKey identifies a session imported from another tracker, nil for sessions logged here
+	ImportKey *string
+	Logs      []ExerciseLog `gorm:"foreignKey:SessionID" json:"logs"` // Association
+}
+
+// SessionEditWindow is how long after completion the logs of a session can still be corrected
+const SessionEditWindow = 48 * time.Hour
+
+// IsEditable reports whether the logs of the session may still be changed at the given time
+func (s *WorkoutSession) IsEditable(now time.Time) bool {
+	return s.CompletedAt == nil || now.Sub(*s.CompletedAt) <= SessionEditWindow
 }
 
 type ExerciseLog struct {
@@ -25,9 +37,151 @@ type ExerciseLog struct {
 	SetNumber  int
 	Reps       int
 	Weight     float64
+	LoggedAt   time.Time
+	// Optional per-set metrics
+	RPE         *float64 `gorm:"column:rpe"`
+	RIR         *int     `gorm:"column:rir"`
+	RestSeconds *int
+	Tempo       *string
+	ToFailure   bool
+	PartialReps int
+	Note        *string
+}
+
+// ExerciseLogFilter narrows down the exercise logs of a profile, nil fields are ignored
+type ExerciseLogFilter struct {
+	SessionID  *string
+	ExerciseID *string
+	ToFailure  *bool
+	MinRPE     *float64
+	MaxRPE     *float64
+	StartDate  *time.Time
+	EndDate    *time.Time // exclusive
+}
+
+var tempoPattern = regexp.MustCompile(`^[0-9X]-?[0-9X]-?[0-9X]-?[0-9X]$`)
+
+// IsValidTempo checks a lifting tempo such as "3-1-X-0" or "31X0"
+// (eccentric, bottom pause, concentric, top pause; X means explosive)
+func IsValidTempo(tempo string) bool {
+	return tempoPattern.MatchString(tempo) && (len(tempo) == 4 || len(tempo) == 7)
+}
+
+// IsValidRPE checks that an RPE lies on the 1-10 scale in half point steps
+func IsValidRPE(rpe float64) bool {
+	return rpe >= 1 && rpe <= 10 && math.Mod(rpe*2, 1) == 0
+}
+
+// MaxWeight is the heaviest weight in kilograms a log can store, weights are DECIMAL(5,2)
+const MaxWeight = 999.99
+
+// RoundWeight rounds a weight to the precision it is stored with
+func RoundWeight(weight float64) float64 {
+	return math.Round(weight*100) / 100
+}
+
+// SessionComparison lines up the sets of a session with the same sets of the previous sessions of its workout
+type SessionComparison struct {
+	Session          WorkoutSession
+	PreviousSessions []WorkoutSession // newest first
+	Exercises        []ExerciseComparison
+}
+
+// ExerciseComparison holds the sets of an exercise by set number. Volumes are aligned with the
+// previous sessions of the comparison and are zero where the exercise wasn't done.
+type ExerciseComparison struct {
+	ExerciseID      string
+	Volume          float64
+	PreviousVolumes []float64
+	VolumeDelta     *float64 // against the most recent previous session that did the exercise
+	Sets            []SetComparison
+}
+
+// SetComparison holds a set of the session and the same set of every previous session, nil where the
+// set wasn't logged. The delta is taken against the most recent previous session that logged the set.
+type SetComparison struct {
+	SetNumber int
+	Current   *ExerciseLog
+	Previous  []*ExerciseLog
+	Delta     *SetDelta
+}
+
+type SetDelta struct {
+	Weight float64
+	Reps   int
+	Volume float64
+}
+
+// Volume is the tonnage of the set
+func (l *ExerciseLog) Volume() float64 {
+	return l.Weight * float64(l.Reps)
 }
 
 type WeightPerDay struct {
 	Date        time.Time `json:"date"`
 	TotalWeight float64   `json:"total_weight"`
 }
+
+// SyncStatus describes what happened to a single item of an offline sync batch
+type SyncStatus string
+
+const (
+	SyncStatusCreated   SyncStatus = "created"
+	SyncStatusUpdated   SyncStatus = "updated"
+	SyncStatusUnchanged SyncStatus = "unchanged"
+	SyncStatusConflict  SyncStatus = "conflict"
+	SyncStatusRejected  SyncStatus = "rejected"
+	SyncStatusFailed    SyncStatus = "failed"
+)
+
+type SessionSyncItem struct {
+	ID      string
+	Status  SyncStatus
+	Err     error
+	Session *WorkoutSession // server version of the session
+}
+
+type ExerciseLogSyncItem struct {
+	ID          string
+	Status      SyncStatus
+	Err         error
+	ExerciseLog *ExerciseLog // server version of the log
+}
+
+type SessionSyncResult struct {
+	Session      SessionSyncItem
+	ExerciseLogs []ExerciseLogSyncItem
+}
+
+// PersonalRecordType is the kind of best a personal record beats
+type PersonalRecordType string
+
+const (
+	PersonalRecordHeaviestWeight PersonalRecordType = "heaviest_weight"
+	PersonalRecordRepsAtWeight   PersonalRecordType = "reps_at_weight"
+	PersonalRecordEstimatedOneRM PersonalRecordType = "estimated_1rm"
+	PersonalRecordSessionVolume  PersonalRecordType = "session_volume"
+)
+
+// PersonalRecord is the event of an exercise log beating the previous best of the profile.
+// For session volume records the log is the last set of the session.
+type PersonalRecord struct {
+	common.Base
+	ProfileID     string
+	ExerciseID    string
+	ExerciseLogID string
+	SessionID     string
+	Type          PersonalRecordType
+	Value         float64
+	PreviousValue *float64 // nil for the first record of its type
+	Weight        float64
+	Reps          int
+	AchievedAt    time.Time
+}
+
+// PendingRecordRecompute is an exercise whose personal records failed to recompute after one of its logs changed
+type PendingRecordRecompute struct {
+	ProfileID  string `gorm:"primaryKey"`
+	ExerciseID string `gorm:"primaryKey"`
+	CreatedAt  time.Time
+}
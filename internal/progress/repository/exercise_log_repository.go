@@ -14,13 +14,14 @@ import (
 type ExerciseLogRepository interface {
 	Create(ctx context.Context, exerciseLog *model.ExerciseLog) error
 	GetByID(ctx context.Context, id string) (*model.ExerciseLog, error)
-	GetAllByProfileIDAndSessionID(ctx context.Context, profileID, sessionID string, page, pageSize int) ([]model.ExerciseLog, int64, error)
-	GetAllByProfileIDAndExerciseID(ctx context.Context, profileID, exerciseID string, page, pageSize int) ([]model.ExerciseLog, int64, error)
-	GetAllByProfileID(ctx context.Context, profileID string, page, pageSize int) ([]model.ExerciseLog, int64, error)
+	ExistsBySessionIDExerciseIDAndSetNumber(ctx context.Context, sessionID, exerciseID string, setNumber int) (bool, error)
+	GetHistoryByProfileIDAndExerciseID(ctx context.Context, profileID, exerciseID string) ([]model.ExerciseLog, error)
+	GetAllByProfileIDAndFilter(ctx context.Context, profileID string, filter model.ExerciseLogFilter, page, pageSize int) ([]model.ExerciseLog, int64, error)
 	UpdatePartial(ctx context.Context, id string, updates map[string]any) (*model.ExerciseLog, error)
 	Delete(ctx context.Context, id string) error
+	IsDeleted(ctx context.Context, id string) (bool, error)
 	PermanentDelete(ctx context.Context, id string) error
-	GetWeightPerDay(ctx context.Context, profileID, exerciseID string, startDate, endDate *time.Time) ([]model.WeightPerDay, error)
+	GetWeightPerDay(ctx context.Context, profileID, exerciseID string, startDate, endDate *time.Time, location *time.Location) ([]model.WeightPerDay, error)
 }
 
 // exerciseLogRepository implements ExerciseLogRepository
@@ -45,7 +46,7 @@ func (r *exerciseLogRepository) Create(ctx context.Context, exerciseLog *model.E
 func (r *exerciseLogRepository) GetByID(ctx context.Context, id string) (*model.ExerciseLog, error) {
 	var exerciseLog model.ExerciseLog
 
-	err := r.db.WithContext(ctx).First(&exerciseLog, id).Error
+	err := r.db.WithContext(ctx).First(&exerciseLog, "id = ?", id).Error
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, customerrors.ErrEntityNotFound
@@ -56,85 +57,76 @@ func (r *exerciseLogRepository) GetByID(ctx context.Context, id string) (*model.
 	return &exerciseLog, nil
 }
 
-// GetAllByUserIDAndSessionID retrieves paginated exercise logs for a specific user and session
-func (r *exerciseLogRepository) GetAllByProfileIDAndSessionID(ctx context.Context, profileID, sessionID string, page, pageSize int) ([]model.ExerciseLog, int64, error) {
-	var exerciseLogs []model.ExerciseLog
-	var total int64
-	offset := (page - 1) * pageSize
-
-	// Count total records
-	countQuery := r.db.WithContext(ctx).
-		Model(&model.ExerciseLog{}).
-		Where("profile_id = ? AND session_id = ?", profileID, sessionID)
-	if err := countQuery.Count(&total).Error; err != nil {
-		return nil, 0, fmt.Errorf("failed to count exercise logs: %w", err)
-	}
-
-	// Fetch paginated results
+// ExistsBySessionIDExerciseIDAndSetNumber checks whether a set of an exercise is already logged in a session
+func (r *exerciseLogRepository) ExistsBySessionIDExerciseIDAndSetNumber(ctx context.Context, sessionID, exerciseID string, setNumber int) (bool, error) {
+	var count int64
 	err := r.db.WithContext(ctx).
-		Limit(pageSize).
-		Offset(offset).
-		Where("profile_id = ? AND session_id = ?", profileID, sessionID).
-		Order("logged_at DESC").
-		Find(&exerciseLogs).Error
+		Model(&model.ExerciseLog{}).
+		Where("session_id = ? AND exercise_id = ? AND set_number = ?", sessionID, exerciseID, setNumber).
+		Count(&count).Error
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to fetch exercise logs by profile and session ids: %w", err)
+		return false, fmt.Errorf("failed to count exercise logs: %w", err)
 	}
-	return exerciseLogs, total, nil
+	return count > 0, nil
 }
 
-// GetAllByUserIDAndExerciseID retrieves paginated exercise logs for a specific user and exercise
-func (r *exerciseLogRepository) GetAllByProfileIDAndExerciseID(ctx context.Context, profileID, exerciseID string, page, pageSize int) ([]model.ExerciseLog, int64, error) {
+// GetHistoryByProfileIDAndExerciseID retrieves every log of an exercise for a specific user, oldest first
+func (r *exerciseLogRepository) GetHistoryByProfileIDAndExerciseID(ctx context.Context, profileID, exerciseID string) ([]model.ExerciseLog, error) {
 	var exerciseLogs []model.ExerciseLog
-	var total int64
-	offset := (page - 1) * pageSize
-
-	// Count total records
-	countQuery := r.db.WithContext(ctx).
-		Model(&model.ExerciseLog{}).
-		Where("profile_id = ? AND exercise_id = ?", profileID, exerciseID)
-	if err := countQuery.Count(&total).Error; err != nil {
-		return nil, 0, fmt.Errorf("failed to count exercise logs: %w", err)
-	}
-
-	// Fetch paginated results
 	err := r.db.WithContext(ctx).
-		Limit(pageSize).
-		Offset(offset).
 		Where("profile_id = ? AND exercise_id = ?", profileID, exerciseID).
-		Order("logged_at DESC").
+		Order("logged_at ASC, set_number ASC").
 		Find(&exerciseLogs).Error
-
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to fetch exercise logs by profile and exercise ids: %w", err)
+		return nil, fmt.Errorf("failed to fetch exercise log history: %w", err)
 	}
-	return exerciseLogs, total, nil
+	return exerciseLogs, nil
 }
 
-// GetAllByUserID retrieves paginated exercise logs for a specific user
-func (r *exerciseLogRepository) GetAllByProfileID(ctx context.Context, profileID string, page, pageSize int) ([]model.ExerciseLog, int64, error) {
+// GetAllByProfileIDAndFilter retrieves paginated exercise logs for a specific user narrowed down by the filter
+func (r *exerciseLogRepository) GetAllByProfileIDAndFilter(ctx context.Context, profileID string, filter model.ExerciseLogFilter, page, pageSize int) ([]model.ExerciseLog, int64, error) {
 	var exerciseLogs []model.ExerciseLog
 	var total int64
 	offset := (page - 1) * pageSize
 
-	// Count total records
-	countQuery := r.db.WithContext(ctx).
+	query := r.db.WithContext(ctx).
 		Model(&model.ExerciseLog{}).
 		Where("profile_id = ?", profileID)
-	if err := countQuery.Count(&total).Error; err != nil {
+	if filter.SessionID != nil {
+		query = query.Where("session_id = ?", *filter.SessionID)
+	}
+	if filter.ExerciseID != nil {
+		query = query.Where("exercise_id = ?", *filter.ExerciseID)
+	}
+	if filter.ToFailure != nil {
+		query = query.Where("to_failure = ?", *filter.ToFailure)
+	}
+	if filter.MinRPE != nil {
+		query = query.Where("rpe >= ?", *filter.MinRPE)
+	}
+	if filter.MaxRPE != nil {
+		query = query.Where("rpe <= ?", *filter.MaxRPE)
+	}
+	if filter.StartDate != nil {
+		query = query.Where("logged_at >= ?", *filter.StartDate)
+	}
+	if filter.EndDate != nil {
+		query = query.Where("logged_at < ?", *filter.EndDate)
+	}
+
+	// Count total records
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
 		return nil, 0, fmt.Errorf("failed to count exercise logs: %w", err)
 	}
 
 	// Fetch paginated results
-	err := r.db.WithContext(ctx).
+	err := query.
 		Limit(pageSize).
 		Offset(offset).
-		Where("profile_id = ?", profileID).
 		Order("logged_at DESC").
 		Find(&exerciseLogs).Error
-
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to fetch exercise logs by profile and exercise ids: %w", err)
+		return nil, 0, fmt.Errorf("failed to fetch filtered exercise logs: %w", err)
 	}
 	return exerciseLogs, total, nil
 }
@@ -156,31 +148,40 @@ func (r *exerciseLogRepository) UpdatePartial(ctx context.Context, id string, up
 	return &updatedExerciseLog, nil
 }
 
-// SoftDelete marks an exercise log as deleted without removing it from the database
+// Delete removes an exercise log and leaves a tombstone, so syncing it again doesn't bring it back
 func (r *exerciseLogRepository) Delete(ctx context.Context, id string) error {
-	result := r.db.WithContext(ctx).
-		Delete(&model.ExerciseLog{}, id)
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := addTombstone(tx, "exercise_logs", id); err != nil {
+			return err
+		}
+		result := tx.Where("id = ?", id).Delete(&model.ExerciseLog{})
+		if result.Error != nil {
+			return result.Error
+		}
 
-	if result.Error != nil {
-		return result.Error
-	}
+		// Check if any rows were actually deleted
+		if result.RowsAffected == 0 {
+			return customerrors.ErrEntityNotFound
+		}
 
-	// Check if any rows were actually deleted
-	if result.RowsAffected == 0 {
-		return customerrors.ErrEntityNotFound
-	}
+		return nil
+	})
+}
 
-	return nil
+// IsDeleted reports whether an exercise log with the ID was deleted
+func (r *exerciseLogRepository) IsDeleted(ctx context.Context, id string) (bool, error) {
+	return isDeleted(r.db.WithContext(ctx), id)
 }
 
-// GetWeightPerDay calculates total weight per day for a specific exercise and user
-func (r *exerciseLogRepository) GetWeightPerDay(ctx context.Context, profileID, exerciseID string, startDate, endDate *time.Time) ([]model.WeightPerDay, error) {
+// GetWeightPerDay calculates total weight per day for a specific exercise and user. Days are the
+// calendar days of the given time zone and the end date is exclusive.
+func (r *exerciseLogRepository) GetWeightPerDay(ctx context.Context, profileID, exerciseID string, startDate, endDate *time.Time, location *time.Location) ([]model.WeightPerDay, error) {
 	var results []model.WeightPerDay
 	query := r.db.WithContext(ctx).
 		Table("exercise_logs").
-		Select("DATE(logged_at) AS date, SUM(weight * reps) AS total_weight").
+		Select("DATE(logged_at AT TIME ZONE ?) AS date, SUM(weight * reps) AS total_weight", location.String()).
 		Where("profile_id = ? AND exercise_id = ?", profileID, exerciseID).
-		Group("DATE(logged_at)").
+		Group("date").
 		Order("date ASC")
 
 	// Add date range filter if provided
@@ -188,7 +189,7 @@ func (r *exerciseLogRepository) GetWeightPerDay(ctx context.Context, profileID,
 		query = query.Where("logged_at >= ?", *startDate)
 	}
 	if endDate != nil {
-		query = query.Where("logged_at <= ?", *endDate)
+		query = query.Where("logged_at < ?", *endDate)
 	}
 
 	// Execute the query and populate results
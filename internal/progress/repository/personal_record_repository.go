@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/VladimirKholomyanskyy/gym-api/internal/progress/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PersonalRecordRepository defines the interface for personal record operations
+type PersonalRecordRepository interface {
+	ReplaceForExercise(ctx context.Context, profileID, exerciseID string, records []model.PersonalRecord) error
+	GetAllByProfileIDAndExerciseID(ctx context.Context, profileID, exerciseID string, page, pageSize int) ([]model.PersonalRecord, int64, error)
+	GetAllByProfileID(ctx context.Context, profileID string, page, pageSize int) ([]model.PersonalRecord, int64, error)
+	AddPending(ctx context.Context, profileID, exerciseID string) error
+	GetPending(ctx context.Context, limit int) ([]model.PendingRecordRecompute, error)
+	DeletePending(ctx context.Context, profileID, exerciseID string) error
+}
+
+// personalRecordRepository implements PersonalRecordRepository
+type personalRecordRepository struct {
+	db *gorm.DB
+}
+
+// NewPersonalRecordRepository creates a new repository instance
+func NewPersonalRecordRepository(db *gorm.DB) PersonalRecordRepository {
+	return &personalRecordRepository{db: db}
+}
+
+// ReplaceForExercise swaps the records of an exercise for freshly computed ones in a single transaction.
+// Replacements of the same exercise of a profile are serialized, so concurrent recomputes can't both
+// delete and then both insert.
+func (r *personalRecordRepository) ReplaceForExercise(ctx context.Context, profileID, exerciseID string, records []model.PersonalRecord) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// The lock is released when the transaction ends
+		err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?::text || ':' || ?::text))", profileID, exerciseID).Error
+		if err != nil {
+			return fmt.Errorf("failed to lock personal records: %w", err)
+		}
+		err = tx.Where("profile_id = ? AND exercise_id = ?", profileID, exerciseID).
+			Delete(&model.PersonalRecord{}).Error
+		if err != nil {
+			return fmt.Errorf("failed to delete personal records: %w", err)
+		}
+		if len(records) == 0 {
+			return nil
+		}
+		if err := tx.Create(&records).Error; err != nil {
+			return fmt.Errorf("failed to create personal records: %w", err)
+		}
+		return nil
+	})
+}
+
+// GetAllByProfileIDAndExerciseID retrieves the paginated record history of an exercise, newest first
+func (r *personalRecordRepository) GetAllByProfileIDAndExerciseID(ctx context.Context, profileID, exerciseID string, page, pageSize int) ([]model.PersonalRecord, int64, error) {
+	var records []model.PersonalRecord
+	var total int64
+	offset := (page - 1) * pageSize
+
+	// Count total records
+	countQuery := r.db.WithContext(ctx).
+		Model(&model.PersonalRecord{}).
+		Where("profile_id = ? AND exercise_id = ?", profileID, exerciseID)
+	if err := countQuery.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count personal records: %w", err)
+	}
+
+	// Fetch paginated results
+	err := r.db.WithContext(ctx).
+		Limit(pageSize).
+		Offset(offset).
+		Where("profile_id = ? AND exercise_id = ?", profileID, exerciseID).
+		Order("achieved_at DESC").
+		Find(&records).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to fetch personal records by profile and exercise ids: %w", err)
+	}
+	return records, total, nil
+}
+
+// GetAllByProfileID retrieves the paginated records of all exercises of a profile, newest first
+func (r *personalRecordRepository) GetAllByProfileID(ctx context.Context, profileID string, page, pageSize int) ([]model.PersonalRecord, int64, error) {
+	var records []model.PersonalRecord
+	var total int64
+	offset := (page - 1) * pageSize
+
+	// Count total records
+	countQuery := r.db.WithContext(ctx).
+		Model(&model.PersonalRecord{}).
+		Where("profile_id = ?", profileID)
+	if err := countQuery.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count personal records: %w", err)
+	}
+
+	// Fetch paginated results
+	err := r.db.WithContext(ctx).
+		Limit(pageSize).
+		Offset(offset).
+		Where("profile_id = ?", profileID).
+		Order("achieved_at DESC").
+		Find(&records).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to fetch personal records by profile id: %w", err)
+	}
+	return records, total, nil
+}
+
+// AddPending queues the records of an exercise for a later recompute, an exercise is queued once
+func (r *personalRecordRepository) AddPending(ctx context.Context, profileID, exerciseID string) error {
+	err := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&model.PendingRecordRecompute{ProfileID: profileID, ExerciseID: exerciseID}).Error
+	if err != nil {
+		return fmt.Errorf("failed to queue personal records recompute: %w", err)
+	}
+	return nil
+}
+
+// GetPending retrieves up to limit queued recomputes, oldest first
+func (r *personalRecordRepository) GetPending(ctx context.Context, limit int) ([]model.PendingRecordRecompute, error) {
+	var pending []model.PendingRecordRecompute
+	err := r.db.WithContext(ctx).
+		Order("created_at ASC").
+		Limit(limit).
+		Find(&pending).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch pending personal records recomputes: %w", err)
+	}
+	return pending, nil
+}
+
+// DeletePending removes an exercise from the recompute queue
+func (r *personalRecordRepository) DeletePending(ctx context.Context, profileID, exerciseID string) error {
+	err := r.db.WithContext(ctx).
+		Where("profile_id = ? AND exercise_id = ?", profileID, exerciseID).
+		Delete(&model.PendingRecordRecompute{}).Error
+	if err != nil {
+		return fmt.Errorf("failed to delete pending personal records recompute: %w", err)
+	}
+	return nil
+}
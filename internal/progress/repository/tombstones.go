@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// addTombstone remembers the ID of a row of the table that is about to be deleted, clients syncing
+// it again are told it was deleted. The table name comes from the repositories, never from input.
+func addTombstone(tx *gorm.DB, table, id string) error {
+	err := tx.Exec(fmt.Sprintf(`INSERT INTO tombstones (id, profile_id, deleted_at)
+		SELECT id, profile_id, NOW() FROM %s WHERE id = ?
+		ON CONFLICT (id) DO NOTHING`, table), id).Error
+	if err != nil {
+		return fmt.Errorf("failed to add tombstone: %w", err)
+	}
+	return nil
+}
+
+// isDeleted reports whether a session or log with the ID was deleted
+func isDeleted(db *gorm.DB, id string) (bool, error) {
+	var count int64
+	if err := db.Table("tombstones").Where("id = ?", id).Count(&count).Error; err != nil {
+		return false, fmt.Errorf("failed to look up tombstone: %w", err)
+	}
+	return count > 0, nil
+}
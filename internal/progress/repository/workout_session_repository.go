@@ -16,8 +16,11 @@ type WorkoutSessionRepository interface {
 	GetByID(ctx context.Context, id string) (*model.WorkoutSession, error)
 	GetAllByProfileID(ctx context.Context, profileID string, page, pageSize int) ([]model.WorkoutSession, int64, error)
 	GetAllByProfileIDAndDateRange(ctx context.Context, profileID string, startDate, endDate time.Time, page, pageSize int) ([]model.WorkoutSession, int64, error)
+	GetByIDWithLogs(ctx context.Context, id string) (*model.WorkoutSession, error)
+	GetRecentByWorkoutIDWithLogs(ctx context.Context, profileID, workoutID string, before time.Time, limit int) ([]model.WorkoutSession, error)
 	UpdatePartial(ctx context.Context, id string, updates map[string]any) error
 	Delete(ctx context.Context, id string) error
+	IsDeleted(ctx context.Context, id string) (bool, error)
 	PermanentDelete(ctx context.Context, id string) error
 }
 
@@ -118,6 +121,41 @@ func (r *workoutSessionRepository) GetAllByProfileIDAndDateRange(ctx context.Con
 	return workoutSessions, total, nil
 }
 
+// GetByIDWithLogs retrieves a workout session by ID together with its logs ordered by set
+func (r *workoutSessionRepository) GetByIDWithLogs(ctx context.Context, id string) (*model.WorkoutSession, error) {
+	var workoutSession model.WorkoutSession
+	err := r.db.WithContext(ctx).
+		Preload("Logs", orderLogs).
+		First(&workoutSession, "id = ?", id).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, customerrors.ErrEntityNotFound
+		}
+		return nil, fmt.Errorf("failed to fetch workout session: %w", err)
+	}
+	return &workoutSession, nil
+}
+
+// GetRecentByWorkoutIDWithLogs retrieves up to limit sessions of a workout started before the given
+// time together with their logs, newest first
+func (r *workoutSessionRepository) GetRecentByWorkoutIDWithLogs(ctx context.Context, profileID, workoutID string, before time.Time, limit int) ([]model.WorkoutSession, error) {
+	var workoutSessions []model.WorkoutSession
+	err := r.db.WithContext(ctx).
+		Where("profile_id = ? AND workout_id = ? AND started_at < ?", profileID, workoutID, before).
+		Preload("Logs", orderLogs).
+		Order("started_at DESC").
+		Limit(limit).
+		Find(&workoutSessions).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch recent workout sessions: %w", err)
+	}
+	return workoutSessions, nil
+}
+
+func orderLogs(db *gorm.DB) *gorm.DB {
+	return db.Order("logged_at ASC, set_number ASC")
+}
+
 // Update a workout session with optimistic locking and validation
 func (r *workoutSessionRepository) UpdatePartial(ctx context.Context, id string, updates map[string]any) error {
 	result := r.db.WithContext(ctx).Model(&model.WorkoutSession{}).Where("id = ?", id).Updates(updates)
@@ -130,21 +168,29 @@ func (r *workoutSessionRepository) UpdatePartial(ctx context.Context, id string,
 	return nil
 }
 
-// SoftDelete marks a workout session as deleted without removing it from the database
+// Delete removes a workout session and leaves a tombstone, so syncing it again doesn't bring it back
 func (r *workoutSessionRepository) Delete(ctx context.Context, id string) error {
-	result := r.db.WithContext(ctx).
-		Where("id = ?", id).
-		Delete(&model.WorkoutSession{})
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := addTombstone(tx, "workout_sessions", id); err != nil {
+			return err
+		}
+		result := tx.Where("id = ?", id).Delete(&model.WorkoutSession{})
 
-	if result.Error != nil {
-		return fmt.Errorf("failed to delete workout session: %w", result.Error)
-	}
+		if result.Error != nil {
+			return fmt.Errorf("failed to delete workout session: %w", result.Error)
+		}
 
-	if result.RowsAffected == 0 {
-		return customerrors.ErrEntityNotFound
-	}
+		if result.RowsAffected == 0 {
+			return customerrors.ErrEntityNotFound
+		}
 
-	return nil
+		return nil
+	})
+}
+
+// IsDeleted reports whether a workout session with the ID was deleted
+func (r *workoutSessionRepository) IsDeleted(ctx context.Context, id string) (bool, error) {
+	return isDeleted(r.db.WithContext(ctx), id)
 }
 func (r *workoutSessionRepository) PermanentDelete(ctx context.Context, id string) error {
 	result := r.db.WithContext(ctx).Unscoped().Where("id = ?", id).Delete(&model.WorkoutSession{})
@@ -2,51 +2,125 @@ package usecase
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"log"
 	"time"
 
 	openapi "github.com/VladimirKholomyanskyy/gym-api/internal/api/go"
 	customerrors "github.com/VladimirKholomyanskyy/gym-api/internal/customErrors"
 	"github.com/VladimirKholomyanskyy/gym-api/internal/progress/model"
 	"github.com/VladimirKholomyanskyy/gym-api/internal/progress/repository"
+	trainingmodel "github.com/VladimirKholomyanskyy/gym-api/internal/training/model"
 	usecase "github.com/VladimirKholomyanskyy/gym-api/internal/training/usecase"
 )
 
 type LogExerciseUseCase interface {
 	Create(ctx context.Context, profileID string, input openapi.CreateExerciseLogRequest) (*model.ExerciseLog, error)
 	GetExerciseLog(ctx context.Context, profileID, logID string) (*model.ExerciseLog, error)
-	GetExerciseLogsByProfileID(ctx context.Context, profileID string, page, pageSize int) ([]model.ExerciseLog, int64, error)
-	GetExerciseLogsBySessionID(ctx context.Context, profileID, sessionID string, page, pageSize int) ([]model.ExerciseLog, int64, error)
-	GetExerciseLogsByExerciseID(ctx context.Context, profileID, exerciseId string, page, pageSize int) ([]model.ExerciseLog, int64, error)
-	GetWeightPerDay(ctx context.Context, profileID string, exerciseId string, startDate *time.Time, endDate *time.Time) ([]model.WeightPerDay, error)
+	List(ctx context.Context, profileID string, filter model.ExerciseLogFilter, page, pageSize int) ([]model.ExerciseLog, int64, error)
+	GetWeightPerDay(ctx context.Context, profileID string, exerciseId string, startDate *time.Time, endDate *time.Time, location *time.Location) ([]model.WeightPerDay, error)
+	Update(ctx context.Context, profileID, logID string, input openapi.PatchExerciseLogRequest) (*model.ExerciseLog, error)
+	Delete(ctx context.Context, profileID, logID string) error
 }
 type logExerciseUseCase struct {
-	repo    repository.ExerciseLogRepository
-	useCase usecase.ExerciseUseCase
+	repo          repository.ExerciseLogRepository
+	sessionRepo   repository.WorkoutSessionRepository
+	useCase       usecase.ExerciseUseCase
+	recordUseCase PersonalRecordUseCase
 }
 
-func NewLogExerciseUseCase(repo repository.ExerciseLogRepository, useCase usecase.ExerciseUseCase) LogExerciseUseCase {
-	return &logExerciseUseCase{repo: repo, useCase: useCase}
+func NewLogExerciseUseCase(repo repository.ExerciseLogRepository, sessionRepo repository.WorkoutSessionRepository, useCase usecase.ExerciseUseCase, recordUseCase PersonalRecordUseCase) LogExerciseUseCase {
+	return &logExerciseUseCase{repo: repo, sessionRepo: sessionRepo, useCase: useCase, recordUseCase: recordUseCase}
 }
 
+// Create logs a set in a session. The session has to belong to the profile and be in progress, and unless
+// off-plan logging is requested the exercise and set number have to be part of the session's workout snapshot.
 func (uc *logExerciseUseCase) Create(ctx context.Context, profileID string, input openapi.CreateExerciseLogRequest) (*model.ExerciseLog, error) {
-	_, err := uc.useCase.GetByID(ctx, input.ExerciseId)
+	session, err := uc.sessionRepo.GetByID(ctx, input.WorkoutSessionId)
 	if err != nil {
+		return nil, fmt.Errorf("workout session not found: %w", err)
+	}
+	if session.ProfileID != profileID {
+		return nil, customerrors.ErrAccessForbidden
+	}
+	if session.CompletedAt != nil {
+		return nil, customerrors.ErrSessionCompleted
+	}
+	if model.RoundWeight(input.WeightUsed) > model.MaxWeight {
+		return nil, customerrors.ErrWeightTooHigh
+	}
+	allowOffPlan := input.AllowOffPlan != nil && *input.AllowOffPlan
+	if err := checkSet(ctx, uc.repo, uc.useCase, session, input.ExerciseId, int(input.SetNumber), allowOffPlan); err != nil {
 		return nil, err
 	}
 	exerciseLog := &model.ExerciseLog{
-		SessionID:  input.WorkoutSessionId,
-		ExerciseID: input.ExerciseId,
-		SetNumber:  int(input.SetNumber),
-		Reps:       int(input.RepsCompleted),
-		Weight:     float64(input.WeightUsed),
-		ProfileID:  profileID}
+		SessionID:   session.ID,
+		ExerciseID:  input.ExerciseId,
+		SetNumber:   int(input.SetNumber),
+		Reps:        int(input.RepsCompleted),
+		Weight:      model.RoundWeight(input.WeightUsed),
+		LoggedAt:    time.Now(),
+		ProfileID:   profileID,
+		RPE:         input.Rpe,
+		RIR:         intPointer(input.Rir),
+		RestSeconds: intPointer(input.RestSeconds),
+		Tempo:       input.Tempo,
+		ToFailure:   input.ToFailure != nil && *input.ToFailure,
+		PartialReps: intValue(input.PartialReps),
+		Note:        input.Note,
+	}
 	err = uc.repo.Create(ctx, exerciseLog)
 	if err != nil {
 		return nil, err
 	}
+	uc.recomputePersonalRecords(ctx, profileID, exerciseLog.ExerciseID)
 	return exerciseLog, nil
 }
 
+// checkSet validates the exercise and set number of a log in a session: the exercise has to exist
+// and, unless off-plan logging is allowed, be part of the session's workout snapshot with at least
+// that many sets planned. A set number is logged only once per exercise of a session.
+func checkSet(ctx context.Context, logRepo repository.ExerciseLogRepository, exerciseUseCase usecase.ExerciseUseCase, session *model.WorkoutSession, exerciseID string, setNumber int, allowOffPlan bool) error {
+	if _, err := exerciseUseCase.GetByID(ctx, exerciseID); err != nil {
+		return fmt.Errorf("exercise not found: %w", err)
+	}
+	if !allowOffPlan {
+		planned, err := plannedExercise(session, exerciseID)
+		if err != nil {
+			return err
+		}
+		if planned == nil {
+			return customerrors.ErrExerciseNotInWorkout
+		}
+		if setNumber > planned.Sets {
+			return customerrors.ErrInvalidSetNumber
+		}
+	}
+	exists, err := logRepo.ExistsBySessionIDExerciseIDAndSetNumber(ctx, session.ID, exerciseID, setNumber)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return customerrors.ErrDuplicateSet
+	}
+	return nil
+}
+
+// plannedExercise looks up an exercise in the workout snapshot taken when the session started
+func plannedExercise(session *model.WorkoutSession, exerciseID string) (*trainingmodel.WorkoutExercise, error) {
+	var workout trainingmodel.Workout
+	if err := json.Unmarshal(session.Snapshot, &workout); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal workout snapshot: %w", err)
+	}
+	for _, exercise := range workout.Exercises {
+		if exercise.ExerciseID == exerciseID {
+			return &exercise, nil
+		}
+	}
+	return nil, nil
+}
+
 func (uc *logExerciseUseCase) GetExerciseLog(ctx context.Context, profileID, logID string) (*model.ExerciseLog, error) {
 	log, err := uc.repo.GetByID(ctx, logID)
 	if err != nil {
@@ -58,22 +132,119 @@ func (uc *logExerciseUseCase) GetExerciseLog(ctx context.Context, profileID, log
 	return log, nil
 
 }
-func (uc *logExerciseUseCase) GetExerciseLogsByProfileID(ctx context.Context, profileID string, page, pageSize int) ([]model.ExerciseLog, int64, error) {
-	logs, totalCount, err := uc.repo.GetAllByProfileID(ctx, profileID, page, pageSize)
+func (uc *logExerciseUseCase) List(ctx context.Context, profileID string, filter model.ExerciseLogFilter, page, pageSize int) ([]model.ExerciseLog, int64, error) {
+	return uc.repo.GetAllByProfileIDAndFilter(ctx, profileID, filter, page, pageSize)
+}
+
+func (uc *logExerciseUseCase) GetWeightPerDay(ctx context.Context, profileID string, exerciseId string, startDate *time.Time, endDate *time.Time, location *time.Location) ([]model.WeightPerDay, error) {
+	return uc.repo.GetWeightPerDay(ctx, profileID, exerciseId, startDate, endDate, location)
+}
+
+// Update corrects a logged set, as long as the log belongs to the profile and its session is still editable.
+// A changed set number is checked against the snapshot and the sets already logged, like a new log.
+func (uc *logExerciseUseCase) Update(ctx context.Context, profileID, logID string, input openapi.PatchExerciseLogRequest) (*model.ExerciseLog, error) {
+	log, session, err := uc.getEditableExerciseLog(ctx, profileID, logID)
 	if err != nil {
-		return nil, 0, err
+		return nil, err
+	}
+	updates := make(map[string]any)
+	if input.SetNumber != nil && int(*input.SetNumber) != log.SetNumber {
+		// A set logged off-plan stays off-plan, planned sets stay within the snapshot.
+		planned, err := plannedExercise(session, log.ExerciseID)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkSet(ctx, uc.repo, uc.useCase, session, log.ExerciseID, int(*input.SetNumber), planned == nil); err != nil {
+			return nil, err
+		}
+		updates["set_number"] = int(*input.SetNumber)
+	}
+	if input.RepsCompleted != nil {
+		updates["reps"] = int(*input.RepsCompleted)
+	}
+	if input.WeightUsed != nil {
+		weight := model.RoundWeight(*input.WeightUsed)
+		if weight > model.MaxWeight {
+			return nil, customerrors.ErrWeightTooHigh
+		}
+		updates["weight"] = weight
 	}
-	return logs, totalCount, nil
+	if input.Rpe != nil {
+		updates["rpe"] = *input.Rpe
+	}
+	if input.Rir != nil {
+		updates["rir"] = int(*input.Rir)
+	}
+	if input.RestSeconds != nil {
+		updates["rest_seconds"] = int(*input.RestSeconds)
+	}
+	if input.Tempo != nil {
+		updates["tempo"] = *input.Tempo
+	}
+	if input.ToFailure != nil {
+		updates["to_failure"] = *input.ToFailure
+	}
+	if input.PartialReps != nil {
+		updates["partial_reps"] = int(*input.PartialReps)
+	}
+	if input.Note != nil {
+		updates["note"] = *input.Note
+	}
+	if len(updates) == 0 {
+		return log, nil
+	}
+	updated, err := uc.repo.UpdatePartial(ctx, log.ID, updates)
+	if err != nil {
+		return nil, err
+	}
+	uc.recomputePersonalRecords(ctx, profileID, updated.ExerciseID)
+	return updated, nil
+}
+
+// Delete removes a logged set, as long as the log belongs to the profile and its session is still editable.
+func (uc *logExerciseUseCase) Delete(ctx context.Context, profileID, logID string) error {
+	log, _, err := uc.getEditableExerciseLog(ctx, profileID, logID)
+	if err != nil {
+		return err
+	}
+	if err := uc.repo.Delete(ctx, log.ID); err != nil {
+		return err
+	}
+	uc.recomputePersonalRecords(ctx, profileID, log.ExerciseID)
+	return nil
 }
 
-func (uc *logExerciseUseCase) GetExerciseLogsBySessionID(ctx context.Context, profileID, sessionID string, page, pageSize int) ([]model.ExerciseLog, int64, error) {
-	return uc.repo.GetAllByProfileIDAndSessionID(ctx, profileID, sessionID, page, pageSize)
+// recomputePersonalRecords refreshes the records after a log change. The change itself is already
+// stored and failed recomputes are retried in the background, so an error here is only logged.
+func (uc *logExerciseUseCase) recomputePersonalRecords(ctx context.Context, profileID, exerciseID string) {
+	if err := uc.recordUseCase.Refresh(ctx, profileID, exerciseID); err != nil {
+		log.Printf("Failed to recompute personal records of exercise %s: %v", exerciseID, err)
+	}
 }
 
-func (uc *logExerciseUseCase) GetExerciseLogsByExerciseID(ctx context.Context, profileID, exerciseId string, page, pageSize int) ([]model.ExerciseLog, int64, error) {
-	return uc.repo.GetAllByProfileIDAndExerciseID(ctx, profileID, exerciseId, page, pageSize)
+// getEditableExerciseLog returns a log of the profile together with its session, as long as the session is still editable.
+func (uc *logExerciseUseCase) getEditableExerciseLog(ctx context.Context, profileID, logID string) (*model.ExerciseLog, *model.WorkoutSession, error) {
+	log, err := uc.GetExerciseLog(ctx, profileID, logID)
+	if err != nil {
+		return nil, nil, err
+	}
+	session, err := uc.sessionRepo.GetByID(ctx, log.SessionID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if session.ProfileID != profileID {
+		return nil, nil, customerrors.ErrAccessForbidden
+	}
+	if !session.IsEditable(time.Now()) {
+		return nil, nil, customerrors.ErrSessionNotEditable
+	}
+	return log, session, nil
 }
 
-func (uc *logExerciseUseCase) GetWeightPerDay(ctx context.Context, profileID string, exerciseId string, startDate *time.Time, endDate *time.Time) ([]model.WeightPerDay, error) {
-	return uc.repo.GetWeightPerDay(ctx, profileID, exerciseId, startDate, endDate)
+func intPointer(value *int32) *int {
+	if value == nil {
+		return nil
+	}
+	v := int(*value)
+	return &v
 }
@@ -0,0 +1,170 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math"
+	"sort"
+
+	"github.com/VladimirKholomyanskyy/gym-api/internal/progress/model"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/progress/repository"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/strength"
+)
+
+// PersonalRecordUseCase keeps the personal records of a profile in line with its exercise logs.
+// Records are derived data: they are recomputed from the whole history of an exercise whenever
+// one of its logs changes, so edits and deletes of old sets are reflected as well.
+type PersonalRecordUseCase interface {
+	Recompute(ctx context.Context, profileID, exerciseID string) error
+	Refresh(ctx context.Context, profileID, exerciseID string) error
+	RecomputePending(ctx context.Context) error
+	ListByExercise(ctx context.Context, profileID, exerciseID string, page, pageSize int) ([]model.PersonalRecord, int64, error)
+	ListRecent(ctx context.Context, profileID string, page, pageSize int) ([]model.PersonalRecord, int64, error)
+}
+
+// pendingRecomputeBatchSize bounds how many queued recomputes a single retry run handles
+const pendingRecomputeBatchSize = 50
+
+type personalRecordUseCase struct {
+	repo    repository.PersonalRecordRepository
+	logRepo repository.ExerciseLogRepository
+}
+
+func NewPersonalRecordUseCase(repo repository.PersonalRecordRepository, logRepo repository.ExerciseLogRepository) PersonalRecordUseCase {
+	return &personalRecordUseCase{repo: repo, logRepo: logRepo}
+}
+
+func (uc *personalRecordUseCase) Recompute(ctx context.Context, profileID, exerciseID string) error {
+	logs, err := uc.logRepo.GetHistoryByProfileIDAndExerciseID(ctx, profileID, exerciseID)
+	if err != nil {
+		return err
+	}
+	return uc.repo.ReplaceForExercise(ctx, profileID, exerciseID, detectPersonalRecords(logs))
+}
+
+// Refresh recomputes the records of an exercise after one of its logs changed. The change is already
+// stored, so a failed recompute is queued for RecomputePending instead of failing the caller; only a
+// recompute that couldn't be queued either is returned.
+func (uc *personalRecordUseCase) Refresh(ctx context.Context, profileID, exerciseID string) error {
+	err := uc.Recompute(ctx, profileID, exerciseID)
+	if err == nil {
+		return nil
+	}
+	if pendingErr := uc.repo.AddPending(ctx, profileID, exerciseID); pendingErr != nil {
+		return errors.Join(err, pendingErr)
+	}
+	log.Printf("Queued personal records of exercise %s for a retry: %v", exerciseID, err)
+	return nil
+}
+
+// RecomputePending retries the queued recomputes. Exercises that fail again stay queued for the next run.
+func (uc *personalRecordUseCase) RecomputePending(ctx context.Context) error {
+	pending, err := uc.repo.GetPending(ctx, pendingRecomputeBatchSize)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, p := range pending {
+		if err := uc.Recompute(ctx, p.ProfileID, p.ExerciseID); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err := uc.repo.DeletePending(ctx, p.ProfileID, p.ExerciseID); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (uc *personalRecordUseCase) ListByExercise(ctx context.Context, profileID, exerciseID string, page, pageSize int) ([]model.PersonalRecord, int64, error) {
+	return uc.repo.GetAllByProfileIDAndExerciseID(ctx, profileID, exerciseID, page, pageSize)
+}
+
+func (uc *personalRecordUseCase) ListRecent(ctx context.Context, profileID string, page, pageSize int) ([]model.PersonalRecord, int64, error) {
+	return uc.repo.GetAllByProfileID(ctx, profileID, page, pageSize)
+}
+
+// detectPersonalRecords replays the logs of a single exercise in the order they were logged and
+// emits a record every time a set beats the best so far. A set only counts when reps were done.
+// Reps at a weight are only a record when the weight was lifted before, otherwise every new
+// weight would be a record.
+func detectPersonalRecords(logs []model.ExerciseLog) []model.PersonalRecord {
+	var (
+		records      []model.PersonalRecord
+		heaviest     *float64
+		bestOneRM    *float64
+		bestVolume   *float64
+		repsAtWeight = make(map[float64]int)
+	)
+	sessionVolume := make(map[string]float64)
+	lastSessionLog := make(map[string]model.ExerciseLog)
+	var sessionOrder []string
+
+	for _, log := range logs {
+		if _, ok := lastSessionLog[log.SessionID]; !ok {
+			sessionOrder = append(sessionOrder, log.SessionID)
+		}
+		lastSessionLog[log.SessionID] = log
+		sessionVolume[log.SessionID] += log.Weight * float64(log.Reps)
+		if log.Reps <= 0 {
+			continue
+		}
+
+		if log.Weight > 0 && (heaviest == nil || log.Weight > *heaviest) {
+			records = append(records, newPersonalRecord(log, model.PersonalRecordHeaviestWeight, log.Weight, heaviest))
+			heaviest = &log.Weight
+		}
+		if best, ok := repsAtWeight[log.Weight]; !ok || log.Reps > best {
+			if ok {
+				previous := float64(best)
+				records = append(records, newPersonalRecord(log, model.PersonalRecordRepsAtWeight, float64(log.Reps), &previous))
+			}
+			repsAtWeight[log.Weight] = log.Reps
+		}
+		if oneRM := roundRecordValue(strength.Epley(log.Weight, log.Reps)); oneRM > 0 && (bestOneRM == nil || oneRM > *bestOneRM) {
+			records = append(records, newPersonalRecord(log, model.PersonalRecordEstimatedOneRM, oneRM, bestOneRM))
+			bestOneRM = &oneRM
+		}
+	}
+
+	// Sessions are compared once all of their sets are known, in the order they were finished.
+	sort.SliceStable(sessionOrder, func(i, j int) bool {
+		return lastSessionLog[sessionOrder[i]].LoggedAt.Before(lastSessionLog[sessionOrder[j]].LoggedAt)
+	})
+	for _, sessionID := range sessionOrder {
+		volume := roundRecordValue(sessionVolume[sessionID])
+		if volume > 0 && (bestVolume == nil || volume > *bestVolume) {
+			records = append(records, newPersonalRecord(lastSessionLog[sessionID], model.PersonalRecordSessionVolume, volume, bestVolume))
+			bestVolume = &volume
+		}
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].AchievedAt.Before(records[j].AchievedAt)
+	})
+	return records
+}
+
+func newPersonalRecord(log model.ExerciseLog, recordType model.PersonalRecordType, value float64, previous *float64) model.PersonalRecord {
+	record := model.PersonalRecord{
+		ProfileID:     log.ProfileID,
+		ExerciseID:    log.ExerciseID,
+		ExerciseLogID: log.ID,
+		SessionID:     log.SessionID,
+		Type:          recordType,
+		Value:         value,
+		Weight:        log.Weight,
+		Reps:          log.Reps,
+		AchievedAt:    log.LoggedAt,
+	}
+	if previous != nil {
+		previousValue := *previous
+		record.PreviousValue = &previousValue
+	}
+	return record
+}
+
+func roundRecordValue(value float64) float64 {
+	return math.Round(value*100) / 100
+}
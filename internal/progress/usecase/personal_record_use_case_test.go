@@ -0,0 +1,169 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/VladimirKholomyanskyy/gym-api/internal/common"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/progress/model"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/progress/repository"
+)
+
+func TestDetectPersonalRecords(t *testing.T) {
+	start := time.Date(2024, 3, 4, 18, 0, 0, 0, time.UTC)
+	set := func(sessionID string, minute, reps int, weight float64) model.ExerciseLog {
+		return model.ExerciseLog{
+			Base:       common.Base{ID: fmt.Sprintf("%s-%d", sessionID, minute)},
+			SessionID:  sessionID,
+			ExerciseID: "bench",
+			Reps:       reps,
+			Weight:     weight,
+			LoggedAt:   start.Add(time.Duration(minute) * time.Minute),
+		}
+	}
+	tests := []struct {
+		name string
+		logs []model.ExerciseLog
+		want []string
+	}{
+		{
+			name: "no logs",
+			want: nil,
+		},
+		{
+			name: "first set sets every best but reps",
+			logs: []model.ExerciseLog{set("s1", 0, 5, 100)},
+			want: []string{
+				"s1-0 heaviest_weight 100",
+				"s1-0 estimated_1rm 116.67",
+				"s1-0 session_volume 500",
+			},
+		},
+		{
+			name: "more reps at a known weight",
+			logs: []model.ExerciseLog{set("s1", 0, 5, 100), set("s2", 60, 6, 100)},
+			want: []string{
+				"s1-0 heaviest_weight 100",
+				"s1-0 estimated_1rm 116.67",
+				"s1-0 session_volume 500",
+				"s2-60 reps_at_weight 6 after 5",
+				"s2-60 estimated_1rm 120 after 116.67",
+				"s2-60 session_volume 600 after 500",
+			},
+		},
+		{
+			name: "sets without reps and lighter sets beat nothing",
+			logs: []model.ExerciseLog{set("s1", 0, 5, 100), set("s1", 1, 0, 120), set("s1", 2, 5, 80)},
+			want: []string{
+				"s1-0 heaviest_weight 100",
+				"s1-0 estimated_1rm 116.67",
+				"s1-2 session_volume 900",
+			},
+		},
+		{
+			name: "sessions compete on volume once finished",
+			logs: []model.ExerciseLog{set("s1", 0, 5, 100), set("s2", 1, 5, 50), set("s1", 2, 5, 100)},
+			want: []string{
+				"s1-0 heaviest_weight 100",
+				"s1-0 estimated_1rm 116.67",
+				"s2-1 session_volume 250",
+				"s1-2 session_volume 1000 after 250",
+			},
+		},
+		{
+			name: "a single is its own one rep max",
+			logs: []model.ExerciseLog{set("s1", 0, 3, 100), set("s1", 1, 1, 110)},
+			want: []string{
+				"s1-0 heaviest_weight 100",
+				"s1-0 estimated_1rm 110",
+				"s1-1 heaviest_weight 110 after 100",
+				"s1-1 session_volume 410",
+			},
+		},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, record := range detectPersonalRecords(tt.logs) {
+			line := fmt.Sprintf("%s %s %v", record.ExerciseLogID, record.Type, record.Value)
+			if record.PreviousValue != nil {
+				line += fmt.Sprintf(" after %v", *record.PreviousValue)
+			}
+			got = append(got, line)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: detectPersonalRecords() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+type fakePersonalRecordRepository struct {
+	repository.PersonalRecordRepository
+	failing map[string]bool
+	pending map[string]bool
+}
+
+func (r *fakePersonalRecordRepository) ReplaceForExercise(ctx context.Context, profileID, exerciseID string, records []model.PersonalRecord) error {
+	if r.failing[exerciseID] {
+		return errors.New("connection reset")
+	}
+	return nil
+}
+
+func (r *fakePersonalRecordRepository) AddPending(ctx context.Context, profileID, exerciseID string) error {
+	r.pending[exerciseID] = true
+	return nil
+}
+
+func (r *fakePersonalRecordRepository) GetPending(ctx context.Context, limit int) ([]model.PendingRecordRecompute, error) {
+	var pending []model.PendingRecordRecompute
+	for exerciseID := range r.pending {
+		pending = append(pending, model.PendingRecordRecompute{ProfileID: "profile", ExerciseID: exerciseID})
+	}
+	return pending, nil
+}
+
+func (r *fakePersonalRecordRepository) DeletePending(ctx context.Context, profileID, exerciseID string) error {
+	delete(r.pending, exerciseID)
+	return nil
+}
+
+type fakeHistoryRepository struct {
+	repository.ExerciseLogRepository
+}
+
+func (r *fakeHistoryRepository) GetHistoryByProfileIDAndExerciseID(ctx context.Context, profileID, exerciseID string) ([]model.ExerciseLog, error) {
+	return nil, nil
+}
+
+func TestRefreshQueuesFailedRecomputes(t *testing.T) {
+	repo := &fakePersonalRecordRepository{failing: map[string]bool{"squat": true}, pending: map[string]bool{}}
+	uc := NewPersonalRecordUseCase(repo, &fakeHistoryRepository{})
+
+	for _, exerciseID := range []string{"bench", "squat"} {
+		if err := uc.Refresh(context.Background(), "profile", exerciseID); err != nil {
+			t.Errorf("Refresh(%s) error = %v, want the failure queued", exerciseID, err)
+		}
+	}
+	if want := map[string]bool{"squat": true}; !reflect.DeepEqual(repo.pending, want) {
+		t.Fatalf("pending = %v, want %v", repo.pending, want)
+	}
+
+	if err := uc.RecomputePending(context.Background()); err == nil {
+		t.Error("RecomputePending() error = nil while the recompute still fails")
+	}
+	if !repo.pending["squat"] {
+		t.Error("exercise left the queue although its recompute failed again")
+	}
+
+	repo.failing["squat"] = false
+	if err := uc.RecomputePending(context.Background()); err != nil {
+		t.Fatalf("RecomputePending() error = %v", err)
+	}
+	if len(repo.pending) != 0 {
+		t.Errorf("pending = %v after a successful retry, want none", repo.pending)
+	}
+}
@@ -0,0 +1,114 @@
+package usecase
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	customerrors "github.com/VladimirKholomyanskyy/gym-api/internal/customErrors"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/progress/model"
+)
+
+// Compare lines up a session with up to previous sessions of the same workout started before it
+func (uc *workoutSessionUseCase) Compare(ctx context.Context, profileID, sessionID string, previous int) (*model.SessionComparison, error) {
+	session, err := uc.repo.GetByIDWithLogs(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if session.ProfileID != profileID {
+		return nil, customerrors.ErrAccessForbidden
+	}
+	previousSessions, err := uc.repo.GetRecentByWorkoutIDWithLogs(ctx, profileID, session.WorkoutID, session.StartedAt, previous)
+	if err != nil {
+		return nil, err
+	}
+	return compareSessions(*session, previousSessions), nil
+}
+
+// CompareLatest lines up the latest session of a workout with up to previous sessions before it
+func (uc *workoutSessionUseCase) CompareLatest(ctx context.Context, profileID, workoutID string, previous int) (*model.SessionComparison, error) {
+	sessions, err := uc.repo.GetRecentByWorkoutIDWithLogs(ctx, profileID, workoutID, time.Now().Add(time.Second), previous+1)
+	if err != nil {
+		return nil, err
+	}
+	if len(sessions) == 0 {
+		return nil, customerrors.ErrEntityNotFound
+	}
+	return compareSessions(sessions[0], sessions[1:]), nil
+}
+
+// compareSessions aligns the logs of the sessions by exercise and set number. Exercises of the
+// session keep the order they were logged in, exercises only done previously follow.
+func compareSessions(session model.WorkoutSession, previousSessions []model.WorkoutSession) *model.SessionComparison {
+	comparison := &model.SessionComparison{Session: session, PreviousSessions: previousSessions}
+	exercises := make(map[string]*model.ExerciseComparison)
+	var exerciseOrder []string
+	setFor := func(exerciseID string, setNumber int) *model.SetComparison {
+		exercise, ok := exercises[exerciseID]
+		if !ok {
+			exercise = &model.ExerciseComparison{ExerciseID: exerciseID, PreviousVolumes: make([]float64, len(previousSessions))}
+			exercises[exerciseID] = exercise
+			exerciseOrder = append(exerciseOrder, exerciseID)
+		}
+		for i := range exercise.Sets {
+			if exercise.Sets[i].SetNumber == setNumber {
+				return &exercise.Sets[i]
+			}
+		}
+		exercise.Sets = append(exercise.Sets, model.SetComparison{SetNumber: setNumber, Previous: make([]*model.ExerciseLog, len(previousSessions))})
+		return &exercise.Sets[len(exercise.Sets)-1]
+	}
+
+	for i := range session.Logs {
+		current := &session.Logs[i]
+		setFor(current.ExerciseID, current.SetNumber).Current = current
+		exercises[current.ExerciseID].Volume += current.Volume()
+	}
+	for i, previous := range previousSessions {
+		for j := range previous.Logs {
+			log := &previous.Logs[j]
+			setFor(log.ExerciseID, log.SetNumber).Previous[i] = log
+			exercises[log.ExerciseID].PreviousVolumes[i] += log.Volume()
+		}
+	}
+
+	for _, id := range exerciseOrder {
+		exercise := exercises[id]
+		sort.Slice(exercise.Sets, func(i, j int) bool { return exercise.Sets[i].SetNumber < exercise.Sets[j].SetNumber })
+		for i := range exercise.Sets {
+			exercise.Sets[i].Delta = setDelta(exercise.Sets[i])
+		}
+		exercise.Volume = model.RoundWeight(exercise.Volume)
+		for i := range exercise.PreviousVolumes {
+			exercise.PreviousVolumes[i] = model.RoundWeight(exercise.PreviousVolumes[i])
+		}
+		if exercise.Volume > 0 {
+			for _, previousVolume := range exercise.PreviousVolumes {
+				if previousVolume > 0 {
+					delta := model.RoundWeight(exercise.Volume - previousVolume)
+					exercise.VolumeDelta = &delta
+					break
+				}
+			}
+		}
+		comparison.Exercises = append(comparison.Exercises, *exercise)
+	}
+	return comparison
+}
+
+// setDelta compares a set with the most recent previous session that logged it
+func setDelta(set model.SetComparison) *model.SetDelta {
+	if set.Current == nil {
+		return nil
+	}
+	for _, previous := range set.Previous {
+		if previous != nil {
+			return &model.SetDelta{
+				Weight: model.RoundWeight(set.Current.Weight - previous.Weight),
+				Reps:   set.Current.Reps - previous.Reps,
+				Volume: model.RoundWeight(set.Current.Volume() - previous.Volume()),
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package usecase
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/VladimirKholomyanskyy/gym-api/internal/progress/model"
+)
+
+func logged(exerciseID string, setNumber, reps int, weight float64) model.ExerciseLog {
+	return model.ExerciseLog{ExerciseID: exerciseID, SetNumber: setNumber, Reps: reps, Weight: weight}
+}
+
+func TestCompareSessions(t *testing.T) {
+	session := model.WorkoutSession{ID: "current", Logs: []model.ExerciseLog{
+		logged("bench", 1, 5, 100),
+		logged("squat", 1, 5, 140),
+		logged("bench", 2, 4, 100),
+	}}
+	previous := []model.WorkoutSession{
+		{ID: "last", Logs: []model.ExerciseLog{
+			logged("bench", 1, 5, 97.5),
+		}},
+		{ID: "before", Logs: []model.ExerciseLog{
+			logged("bench", 1, 5, 95),
+			logged("bench", 2, 5, 95),
+			logged("squat", 1, 5, 135),
+			logged("row", 1, 10, 60),
+		}},
+	}
+
+	comparison := compareSessions(session, previous)
+
+	type set struct {
+		number  int
+		current bool
+		delta   *model.SetDelta
+	}
+	type exercise struct {
+		id              string
+		volume          float64
+		previousVolumes []float64
+		volumeDelta     *float64
+		sets            []set
+	}
+	delta := func(value float64) *float64 { return &value }
+	want := []exercise{
+		{"bench", 900, []float64{487.5, 950}, delta(412.5), []set{
+			{1, true, &model.SetDelta{Weight: 2.5, Reps: 0, Volume: 12.5}},
+			// set 2 wasn't logged last time, the delta falls back to the session before
+			{2, true, &model.SetDelta{Weight: 5, Reps: -1, Volume: -75}},
+		}},
+		{"squat", 700, []float64{0, 675}, delta(25), []set{
+			{1, true, &model.SetDelta{Weight: 5, Reps: 0, Volume: 25}},
+		}},
+		// Exercises only done previously follow and have nothing to compare
+		{"row", 0, []float64{0, 600}, nil, []set{
+			{1, false, nil},
+		}},
+	}
+
+	if len(comparison.Exercises) != len(want) {
+		t.Fatalf("compareSessions() got %d exercises, want %d", len(comparison.Exercises), len(want))
+	}
+	for i, w := range want {
+		got := comparison.Exercises[i]
+		if got.ExerciseID != w.id {
+			t.Errorf("exercise %d = %s, want %s", i, got.ExerciseID, w.id)
+			continue
+		}
+		if got.Volume != w.volume || !reflect.DeepEqual(got.PreviousVolumes, w.previousVolumes) {
+			t.Errorf("%s volumes = %v, %v, want %v, %v", w.id, got.Volume, got.PreviousVolumes, w.volume, w.previousVolumes)
+		}
+		if !reflect.DeepEqual(got.VolumeDelta, w.volumeDelta) {
+			t.Errorf("%s volume delta = %v, want %v", w.id, got.VolumeDelta, w.volumeDelta)
+		}
+		if len(got.Sets) != len(w.sets) {
+			t.Errorf("%s has %d sets, want %d", w.id, len(got.Sets), len(w.sets))
+			continue
+		}
+		for j, ws := range w.sets {
+			gs := got.Sets[j]
+			if gs.SetNumber != ws.number || (gs.Current != nil) != ws.current || len(gs.Previous) != len(previous) {
+				t.Errorf("%s set %d = number %d, current %v, %d previous", w.id, ws.number, gs.SetNumber, gs.Current != nil, len(gs.Previous))
+			}
+			if !reflect.DeepEqual(gs.Delta, ws.delta) {
+				t.Errorf("%s set %d delta = %+v, want %+v", w.id, ws.number, gs.Delta, ws.delta)
+			}
+		}
+	}
+}
+
+func TestSetDelta(t *testing.T) {
+	current := logged("bench", 1, 8, 62.5)
+	last := logged("bench", 1, 8, 60.05)
+	before := logged("bench", 1, 10, 50)
+	tests := []struct {
+		name string
+		set  model.SetComparison
+		want *model.SetDelta
+	}{
+		{
+			name: "not logged in the session",
+			set:  model.SetComparison{Previous: []*model.ExerciseLog{&last}},
+			want: nil,
+		},
+		{
+			name: "never logged before",
+			set:  model.SetComparison{Current: &current, Previous: []*model.ExerciseLog{nil, nil}},
+			want: nil,
+		},
+		{
+			name: "against the most recent session",
+			set:  model.SetComparison{Current: &current, Previous: []*model.ExerciseLog{&last, &before}},
+			want: &model.SetDelta{Weight: 2.45, Reps: 0, Volume: 19.6},
+		},
+		{
+			name: "skips sessions without the set",
+			set:  model.SetComparison{Current: &current, Previous: []*model.ExerciseLog{nil, &before}},
+			want: &model.SetDelta{Weight: 12.5, Reps: -2, Volume: 0},
+		},
+	}
+	for _, tt := range tests {
+		if got := setDelta(tt.set); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: setDelta() = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
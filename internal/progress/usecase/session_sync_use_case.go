@@ -0,0 +1,305 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	openapi "github.com/VladimirKholomyanskyy/gym-api/internal/api/go"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/common"
+	customerrors "github.com/VladimirKholomyanskyy/gym-api/internal/customErrors"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/progress/model"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/progress/repository"
+	training "github.com/VladimirKholomyanskyy/gym-api/internal/training/usecase"
+)
+
+// SessionSyncUseCase applies workout sessions recorded offline by the client.
+// Every item carries a client generated UUID, so a batch can be retried safely:
+// items that were already applied are reported as unchanged and items deleted
+// on the server since are reported as conflicts. Logs are validated like logs
+// recorded online.
+type SessionSyncUseCase interface {
+	Sync(ctx context.Context, profileID string, input openapi.SyncWorkoutSessionRequest) (*model.SessionSyncResult, error)
+}
+
+type sessionSyncUseCase struct {
+	sessionRepo     repository.WorkoutSessionRepository
+	logRepo         repository.ExerciseLogRepository
+	workoutUseCase  training.WorkoutUseCase
+	exerciseUseCase training.ExerciseUseCase
+	recordUseCase   PersonalRecordUseCase
+}
+
+func NewSessionSyncUseCase(sessionRepo repository.WorkoutSessionRepository,
+	logRepo repository.ExerciseLogRepository,
+	workoutUseCase training.WorkoutUseCase,
+	exerciseUseCase training.ExerciseUseCase,
+	recordUseCase PersonalRecordUseCase) SessionSyncUseCase {
+	return &sessionSyncUseCase{
+		sessionRepo:     sessionRepo,
+		logRepo:         logRepo,
+		workoutUseCase:  workoutUseCase,
+		exerciseUseCase: exerciseUseCase,
+		recordUseCase:   recordUseCase,
+	}
+}
+
+// Sync applies the session first and then each of its exercise logs. Item level
+// problems are reported in the result, only infrastructure failures on the
+// session itself abort the whole batch.
+func (uc *sessionSyncUseCase) Sync(ctx context.Context, profileID string, input openapi.SyncWorkoutSessionRequest) (*model.SessionSyncResult, error) {
+	sessionItem := uc.syncSession(ctx, profileID, input.Session)
+	if sessionItem.Status == model.SyncStatusFailed {
+		return nil, sessionItem.Err
+	}
+	result := &model.SessionSyncResult{Session: sessionItem}
+	changedExercises := make(map[string]bool)
+	for _, log := range input.ExerciseLogs {
+		// Logs of a rejected, deleted or conflicting session share its fate
+		if sessionItem.Session == nil {
+			result.ExerciseLogs = append(result.ExerciseLogs, model.ExerciseLogSyncItem{
+				ID:     log.Id,
+				Status: sessionItem.Status,
+				Err:    sessionItem.Err,
+			})
+			continue
+		}
+		result.ExerciseLogs = append(result.ExerciseLogs, uc.syncExerciseLog(ctx, profileID, sessionItem.Session, log, changedExercises))
+	}
+	// Records are recomputed once per exercise rather than once per synced set.
+	for exerciseID := range changedExercises {
+		if err := uc.recordUseCase.Refresh(ctx, profileID, exerciseID); err != nil {
+			log.Printf("Failed to recompute personal records of exercise %s: %v", exerciseID, err)
+		}
+	}
+	return result, nil
+}
+
+func (uc *sessionSyncUseCase) syncSession(ctx context.Context, profileID string, input openapi.SyncWorkoutSession) model.SessionSyncItem {
+	item := model.SessionSyncItem{ID: input.Id}
+	existing, err := uc.sessionRepo.GetByID(ctx, input.Id)
+	if err != nil && !errors.Is(err, customerrors.ErrEntityNotFound) {
+		return failSessionItem(item, err)
+	}
+	if existing == nil {
+		deleted, err := uc.sessionRepo.IsDeleted(ctx, input.Id)
+		if err != nil {
+			return failSessionItem(item, err)
+		}
+		if deleted {
+			return failSessionItem(item, customerrors.ErrSyncItemDeleted)
+		}
+		if input.CompletedAt != nil && input.CompletedAt.Before(input.StartedAt) {
+			return failSessionItem(item, customerrors.ErrCompletedBeforeStart)
+		}
+		workout, err := uc.workoutUseCase.GetByWorkoutID(ctx, profileID, input.WorkoutId)
+		if err != nil {
+			return failSessionItem(item, err)
+		}
+		snapshot, err := json.Marshal(workout)
+		if err != nil {
+			return failSessionItem(item, fmt.Errorf("failed to marshal workout: %w", err))
+		}
+		session := &model.WorkoutSession{
+			ID:          input.Id,
+			ProfileID:   profileID,
+			WorkoutID:   workout.ID,
+			Snapshot:    snapshot,
+			StartedAt:   input.StartedAt,
+			CompletedAt: input.CompletedAt,
+		}
+		if err := uc.sessionRepo.Create(ctx, session); err != nil {
+			return failSessionItem(item, err)
+		}
+		item.Status = model.SyncStatusCreated
+		item.Session = session
+		return item
+	}
+
+	if existing.ProfileID != profileID {
+		return failSessionItem(item, customerrors.ErrAccessForbidden)
+	}
+	// The logs of the batch were checked against another workout, none of them can be applied
+	if existing.WorkoutID != input.WorkoutId {
+		return failSessionItem(item, customerrors.ErrSyncWorkoutMismatch)
+	}
+	item.Session = existing
+	switch {
+	case input.CompletedAt != nil && existing.CompletedAt == nil:
+		if err := uc.sessionRepo.UpdatePartial(ctx, existing.ID, map[string]any{"completed_at": *input.CompletedAt}); err != nil {
+			return failSessionItem(item, err)
+		}
+		existing.CompletedAt = input.CompletedAt
+		item.Status = model.SyncStatusUpdated
+	case input.CompletedAt != nil && !input.CompletedAt.Equal(*existing.CompletedAt):
+		// The session was completed on the server as well, the server wins.
+		item.Status = model.SyncStatusConflict
+	default:
+		item.Status = model.SyncStatusUnchanged
+	}
+	return item
+}
+
+// syncExerciseLog applies a single log and marks the exercises whose records it affects in changedExercises.
+// A new log has to be logged before the session was completed, a changed one needs a session that is
+// still editable.
+func (uc *sessionSyncUseCase) syncExerciseLog(ctx context.Context, profileID string, session *model.WorkoutSession, input openapi.SyncExerciseLog, changedExercises map[string]bool) model.ExerciseLogSyncItem {
+	item := model.ExerciseLogSyncItem{ID: input.Id}
+	if model.RoundWeight(input.WeightUsed) > model.MaxWeight {
+		return failExerciseLogItem(item, customerrors.ErrWeightTooHigh)
+	}
+	existing, err := uc.logRepo.GetByID(ctx, input.Id)
+	if err != nil && !errors.Is(err, customerrors.ErrEntityNotFound) {
+		return failExerciseLogItem(item, err)
+	}
+	if existing == nil {
+		deleted, err := uc.logRepo.IsDeleted(ctx, input.Id)
+		if err != nil {
+			return failExerciseLogItem(item, err)
+		}
+		if deleted {
+			return failExerciseLogItem(item, customerrors.ErrSyncItemDeleted)
+		}
+		if session.CompletedAt != nil && input.LoggedAt.After(*session.CompletedAt) {
+			return failExerciseLogItem(item, customerrors.ErrSessionCompleted)
+		}
+		if err := checkSet(ctx, uc.logRepo, uc.exerciseUseCase, session, input.ExerciseId, int(input.SetNumber), false); err != nil {
+			return failExerciseLogItem(item, err)
+		}
+		exerciseLog := &model.ExerciseLog{
+			Base:        common.Base{ID: input.Id},
+			ProfileID:   profileID,
+			SessionID:   session.ID,
+			ExerciseID:  input.ExerciseId,
+			SetNumber:   int(input.SetNumber),
+			Reps:        int(input.RepsCompleted),
+			Weight:      model.RoundWeight(input.WeightUsed),
+			LoggedAt:    input.LoggedAt,
+			RPE:         input.Rpe,
+			RIR:         intPointer(input.Rir),
+			RestSeconds: intPointer(input.RestSeconds),
+			Tempo:       input.Tempo,
+			ToFailure:   input.ToFailure != nil && *input.ToFailure,
+			PartialReps: intValue(input.PartialReps),
+			Note:        input.Note,
+		}
+		if err := uc.logRepo.Create(ctx, exerciseLog); err != nil {
+			return failExerciseLogItem(item, err)
+		}
+		item.Status = model.SyncStatusCreated
+		item.ExerciseLog = exerciseLog
+		changedExercises[exerciseLog.ExerciseID] = true
+		return item
+	}
+
+	if existing.ProfileID != profileID || existing.SessionID != session.ID {
+		return failExerciseLogItem(item, customerrors.ErrAccessForbidden)
+	}
+	item.ExerciseLog = existing
+	if sameExerciseLog(existing, input) {
+		item.Status = model.SyncStatusUnchanged
+		return item
+	}
+	// The log was edited on the server after the client changed it, the server wins.
+	if existing.UpdatedAt.After(input.UpdatedAt) {
+		item.Status = model.SyncStatusConflict
+		return item
+	}
+	if !session.IsEditable(time.Now()) {
+		return failExerciseLogItem(item, customerrors.ErrSessionNotEditable)
+	}
+	if existing.ExerciseID != input.ExerciseId || existing.SetNumber != int(input.SetNumber) {
+		if err := checkSet(ctx, uc.logRepo, uc.exerciseUseCase, session, input.ExerciseId, int(input.SetNumber), false); err != nil {
+			return failExerciseLogItem(item, err)
+		}
+	}
+	// Sync sends the full state of a log, so missing optional metrics clear the stored ones.
+	updated, err := uc.logRepo.UpdatePartial(ctx, existing.ID, map[string]any{
+		"exercise_id":  input.ExerciseId,
+		"set_number":   int(input.SetNumber),
+		"reps":         int(input.RepsCompleted),
+		"weight":       model.RoundWeight(input.WeightUsed),
+		"logged_at":    input.LoggedAt,
+		"rpe":          input.Rpe,
+		"rir":          intPointer(input.Rir),
+		"rest_seconds": intPointer(input.RestSeconds),
+		"tempo":        input.Tempo,
+		"to_failure":   input.ToFailure != nil && *input.ToFailure,
+		"partial_reps": intValue(input.PartialReps),
+		"note":         input.Note,
+	})
+	if err != nil {
+		return failExerciseLogItem(item, err)
+	}
+	item.Status = model.SyncStatusUpdated
+	item.ExerciseLog = updated
+	changedExercises[existing.ExerciseID] = true
+	changedExercises[updated.ExerciseID] = true
+	return item
+}
+
+func sameExerciseLog(log *model.ExerciseLog, input openapi.SyncExerciseLog) bool {
+	return log.ExerciseID == input.ExerciseId &&
+		log.SetNumber == int(input.SetNumber) &&
+		log.Reps == int(input.RepsCompleted) &&
+		log.Weight == model.RoundWeight(input.WeightUsed) &&
+		log.LoggedAt.Equal(input.LoggedAt) &&
+		equalPointers(log.RPE, input.Rpe) &&
+		equalPointers(log.RIR, intPointer(input.Rir)) &&
+		equalPointers(log.RestSeconds, intPointer(input.RestSeconds)) &&
+		equalPointers(log.Tempo, input.Tempo) &&
+		log.ToFailure == (input.ToFailure != nil && *input.ToFailure) &&
+		log.PartialReps == intValue(input.PartialReps) &&
+		equalPointers(log.Note, input.Note)
+}
+
+func equalPointers[T comparable](a, b *T) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func intValue(value *int32) int {
+	if value == nil {
+		return 0
+	}
+	return int(*value)
+}
+
+// syncFailureStatus separates errors the client has to fix from errors worth retrying.
+// Items deleted on the server are conflicts, the server wins.
+func syncFailureStatus(err error) model.SyncStatus {
+	switch {
+	case errors.Is(err, customerrors.ErrSyncItemDeleted),
+		errors.Is(err, customerrors.ErrSyncWorkoutMismatch):
+		return model.SyncStatusConflict
+	case errors.Is(err, customerrors.ErrEntityNotFound),
+		errors.Is(err, customerrors.ErrAccessForbidden),
+		errors.Is(err, customerrors.ErrInvalidUUID),
+		errors.Is(err, customerrors.ErrSessionCompleted),
+		errors.Is(err, customerrors.ErrSessionNotEditable),
+		errors.Is(err, customerrors.ErrExerciseNotInWorkout),
+		errors.Is(err, customerrors.ErrInvalidSetNumber),
+		errors.Is(err, customerrors.ErrDuplicateSet),
+		errors.Is(err, customerrors.ErrWeightTooHigh),
+		errors.Is(err, customerrors.ErrCompletedBeforeStart):
+		return model.SyncStatusRejected
+	}
+	return model.SyncStatusFailed
+}
+
+func failSessionItem(item model.SessionSyncItem, err error) model.SessionSyncItem {
+	item.Status = syncFailureStatus(err)
+	item.Err = err
+	return item
+}
+
+func failExerciseLogItem(item model.ExerciseLogSyncItem, err error) model.ExerciseLogSyncItem {
+	item.Status = syncFailureStatus(err)
+	item.Err = err
+	return item
+}
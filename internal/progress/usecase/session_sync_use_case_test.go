@@ -0,0 +1,291 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	openapi "github.com/VladimirKholomyanskyy/gym-api/internal/api/go"
+	customerrors "github.com/VladimirKholomyanskyy/gym-api/internal/customErrors"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/progress/model"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/progress/repository"
+	trainingmodel "github.com/VladimirKholomyanskyy/gym-api/internal/training/model"
+	training "github.com/VladimirKholomyanskyy/gym-api/internal/training/usecase"
+)
+
+type fakeSessionRepository struct {
+	repository.WorkoutSessionRepository
+	sessions map[string]*model.WorkoutSession
+	deleted  map[string]bool
+}
+
+func (r *fakeSessionRepository) Create(ctx context.Context, session *model.WorkoutSession) error {
+	r.sessions[session.ID] = session
+	return nil
+}
+
+func (r *fakeSessionRepository) GetByID(ctx context.Context, id string) (*model.WorkoutSession, error) {
+	if session, ok := r.sessions[id]; ok {
+		return session, nil
+	}
+	return nil, customerrors.ErrEntityNotFound
+}
+
+func (r *fakeSessionRepository) IsDeleted(ctx context.Context, id string) (bool, error) {
+	return r.deleted[id], nil
+}
+
+type fakeExerciseLogRepository struct {
+	repository.ExerciseLogRepository
+	logs    map[string]*model.ExerciseLog
+	deleted map[string]bool
+}
+
+func (r *fakeExerciseLogRepository) Create(ctx context.Context, log *model.ExerciseLog) error {
+	if _, ok := r.logs[log.ID]; ok {
+		return errors.New("duplicate key value violates unique constraint")
+	}
+	r.logs[log.ID] = log
+	return nil
+}
+
+func (r *fakeExerciseLogRepository) GetByID(ctx context.Context, id string) (*model.ExerciseLog, error) {
+	if log, ok := r.logs[id]; ok {
+		return log, nil
+	}
+	return nil, customerrors.ErrEntityNotFound
+}
+
+func (r *fakeExerciseLogRepository) ExistsBySessionIDExerciseIDAndSetNumber(ctx context.Context, sessionID, exerciseID string, setNumber int) (bool, error) {
+	for _, log := range r.logs {
+		if log.SessionID == sessionID && log.ExerciseID == exerciseID && log.SetNumber == setNumber {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *fakeExerciseLogRepository) IsDeleted(ctx context.Context, id string) (bool, error) {
+	return r.deleted[id], nil
+}
+
+type fakeWorkoutUseCase struct {
+	training.WorkoutUseCase
+}
+
+func (uc *fakeWorkoutUseCase) GetByWorkoutID(ctx context.Context, profileID, workoutID string) (*trainingmodel.Workout, error) {
+	workout := &trainingmodel.Workout{Name: "Push", Exercises: []trainingmodel.WorkoutExercise{
+		{WorkoutID: workoutID, ExerciseID: "bench", Sets: 3, Reps: 5},
+	}}
+	workout.ID = workoutID
+	return workout, nil
+}
+
+type fakeExerciseUseCase struct {
+	training.ExerciseUseCase
+}
+
+func (uc *fakeExerciseUseCase) GetByID(ctx context.Context, id string) (*trainingmodel.Exercise, error) {
+	if id != "bench" && id != "squat" {
+		return nil, customerrors.ErrEntityNotFound
+	}
+	return &trainingmodel.Exercise{ID: id}, nil
+}
+
+type fakeRecordUseCase struct {
+	PersonalRecordUseCase
+	recomputed []string
+}
+
+func (uc *fakeRecordUseCase) Refresh(ctx context.Context, profileID, exerciseID string) error {
+	uc.recomputed = append(uc.recomputed, exerciseID)
+	return nil
+}
+
+func TestSync(t *testing.T) {
+	started := time.Date(2024, 3, 4, 18, 0, 0, 0, time.UTC)
+	completed := started.Add(time.Hour)
+	set := func(id, exerciseID string, setNumber int32, loggedAt time.Time) openapi.SyncExerciseLog {
+		return openapi.SyncExerciseLog{
+			Id:            id,
+			ExerciseId:    exerciseID,
+			SetNumber:     setNumber,
+			RepsCompleted: 5,
+			WeightUsed:    80,
+			LoggedAt:      loggedAt,
+			UpdatedAt:     loggedAt,
+		}
+	}
+	session := openapi.SyncWorkoutSession{Id: "session", WorkoutId: "push", StartedAt: started, CompletedAt: &completed}
+	heavy := set("log-1", "bench", 1, started)
+	heavy.WeightUsed = 1000
+	backwards := session
+	backwards.CompletedAt = &started
+	backwards.StartedAt = completed
+
+	tests := []struct {
+		name           string
+		session        *openapi.SyncWorkoutSession
+		logs           []openapi.SyncExerciseLog
+		deletedSession bool
+		// existingWorkout is the workout of the session already on the server
+		existingWorkout string
+		deletedLogs     map[string]bool
+		wantSession     model.SyncStatus
+		wantLogs        []model.SyncStatus
+		wantErrs        []error
+	}{
+		{
+			name:        "creates session and logs",
+			logs:        []openapi.SyncExerciseLog{set("log-1", "bench", 1, started), set("log-2", "bench", 2, started.Add(time.Minute))},
+			wantSession: model.SyncStatusCreated,
+			wantLogs:    []model.SyncStatus{model.SyncStatusCreated, model.SyncStatusCreated},
+			wantErrs:    []error{nil, nil},
+		},
+		{
+			name:        "log deleted on the server",
+			logs:        []openapi.SyncExerciseLog{set("log-1", "bench", 1, started)},
+			deletedLogs: map[string]bool{"log-1": true},
+			wantSession: model.SyncStatusCreated,
+			wantLogs:    []model.SyncStatus{model.SyncStatusConflict},
+			wantErrs:    []error{customerrors.ErrSyncItemDeleted},
+		},
+		{
+			name:           "session deleted on the server",
+			logs:           []openapi.SyncExerciseLog{set("log-1", "bench", 1, started)},
+			deletedSession: true,
+			wantSession:    model.SyncStatusConflict,
+			wantLogs:       []model.SyncStatus{model.SyncStatusConflict},
+			wantErrs:       []error{customerrors.ErrSyncItemDeleted},
+		},
+		{
+			name:            "session of another workout on the server",
+			logs:            []openapi.SyncExerciseLog{set("log-1", "bench", 1, started)},
+			existingWorkout: "pull",
+			wantSession:     model.SyncStatusConflict,
+			wantLogs:        []model.SyncStatus{model.SyncStatusConflict},
+			wantErrs:        []error{customerrors.ErrSyncWorkoutMismatch},
+		},
+		{
+			name:        "completed before it started",
+			session:     &backwards,
+			logs:        []openapi.SyncExerciseLog{set("log-1", "bench", 1, completed)},
+			wantSession: model.SyncStatusRejected,
+			wantLogs:    []model.SyncStatus{model.SyncStatusRejected},
+			wantErrs:    []error{customerrors.ErrCompletedBeforeStart},
+		},
+		{
+			name:        "exercise outside the snapshot",
+			logs:        []openapi.SyncExerciseLog{set("log-1", "squat", 1, started)},
+			wantSession: model.SyncStatusCreated,
+			wantLogs:    []model.SyncStatus{model.SyncStatusRejected},
+			wantErrs:    []error{customerrors.ErrExerciseNotInWorkout},
+		},
+		{
+			name:        "set beyond the planned sets",
+			logs:        []openapi.SyncExerciseLog{set("log-1", "bench", 4, started)},
+			wantSession: model.SyncStatusCreated,
+			wantLogs:    []model.SyncStatus{model.SyncStatusRejected},
+			wantErrs:    []error{customerrors.ErrInvalidSetNumber},
+		},
+		{
+			name:        "logged after completion",
+			logs:        []openapi.SyncExerciseLog{set("log-1", "bench", 1, completed.Add(time.Minute))},
+			wantSession: model.SyncStatusCreated,
+			wantLogs:    []model.SyncStatus{model.SyncStatusRejected},
+			wantErrs:    []error{customerrors.ErrSessionCompleted},
+		},
+		{
+			name:        "weight above what a log stores",
+			logs:        []openapi.SyncExerciseLog{heavy},
+			wantSession: model.SyncStatusCreated,
+			wantLogs:    []model.SyncStatus{model.SyncStatusRejected},
+			wantErrs:    []error{customerrors.ErrWeightTooHigh},
+		},
+		{
+			name:        "duplicate set",
+			logs:        []openapi.SyncExerciseLog{set("log-1", "bench", 1, started), set("log-2", "bench", 1, started)},
+			wantSession: model.SyncStatusCreated,
+			wantLogs:    []model.SyncStatus{model.SyncStatusCreated, model.SyncStatusRejected},
+			wantErrs:    []error{nil, customerrors.ErrDuplicateSet},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sessionRepo := &fakeSessionRepository{
+				sessions: map[string]*model.WorkoutSession{},
+				deleted:  map[string]bool{"session": tt.deletedSession},
+			}
+			if tt.existingWorkout != "" {
+				sessionRepo.sessions["session"] = &model.WorkoutSession{ID: "session", ProfileID: "profile", WorkoutID: tt.existingWorkout, StartedAt: started}
+			}
+			logRepo := &fakeExerciseLogRepository{logs: map[string]*model.ExerciseLog{}, deleted: tt.deletedLogs}
+			uc := NewSessionSyncUseCase(sessionRepo, logRepo, &fakeWorkoutUseCase{}, &fakeExerciseUseCase{}, &fakeRecordUseCase{})
+			input := session
+			if tt.session != nil {
+				input = *tt.session
+			}
+
+			result, err := uc.Sync(context.Background(), "profile", openapi.SyncWorkoutSessionRequest{Session: input, ExerciseLogs: tt.logs})
+			if err != nil {
+				t.Fatalf("Sync() error = %v", err)
+			}
+			if result.Session.Status != tt.wantSession {
+				t.Errorf("session status = %s, want %s", result.Session.Status, tt.wantSession)
+			}
+			for i, item := range result.ExerciseLogs {
+				if item.Status != tt.wantLogs[i] {
+					t.Errorf("log %s status = %s, want %s", item.ID, item.Status, tt.wantLogs[i])
+				}
+				if !errors.Is(item.Err, tt.wantErrs[i]) {
+					t.Errorf("log %s error = %v, want %v", item.ID, item.Err, tt.wantErrs[i])
+				}
+			}
+			if len(logRepo.logs) != len(tt.wantLogs)-countFailed(tt.wantErrs) {
+				t.Errorf("%d logs written, want only the accepted ones", len(logRepo.logs))
+			}
+		})
+	}
+}
+
+func countFailed(errs []error) int {
+	failed := 0
+	for _, err := range errs {
+		if err != nil {
+			failed++
+		}
+	}
+	return failed
+}
+
+func TestSyncRetry(t *testing.T) {
+	started := time.Date(2024, 3, 4, 18, 0, 0, 0, time.UTC)
+	request := openapi.SyncWorkoutSessionRequest{
+		Session: openapi.SyncWorkoutSession{Id: "session", WorkoutId: "push", StartedAt: started},
+		ExerciseLogs: []openapi.SyncExerciseLog{
+			{Id: "log-1", ExerciseId: "bench", SetNumber: 1, RepsCompleted: 5, WeightUsed: 80, LoggedAt: started, UpdatedAt: started},
+		},
+	}
+	sessionRepo := &fakeSessionRepository{sessions: map[string]*model.WorkoutSession{}}
+	logRepo := &fakeExerciseLogRepository{logs: map[string]*model.ExerciseLog{}}
+	records := &fakeRecordUseCase{}
+	uc := NewSessionSyncUseCase(sessionRepo, logRepo, &fakeWorkoutUseCase{}, &fakeExerciseUseCase{}, records)
+
+	if _, err := uc.Sync(context.Background(), "profile", request); err != nil {
+		t.Fatalf("first Sync() error = %v", err)
+	}
+	result, err := uc.Sync(context.Background(), "profile", request)
+	if err != nil {
+		t.Fatalf("retried Sync() error = %v", err)
+	}
+	if result.Session.Status != model.SyncStatusUnchanged {
+		t.Errorf("retried session status = %s, want unchanged", result.Session.Status)
+	}
+	if status := result.ExerciseLogs[0].Status; status != model.SyncStatusUnchanged {
+		t.Errorf("retried log status = %s, want unchanged", status)
+	}
+	if len(records.recomputed) != 1 {
+		t.Errorf("records recomputed %d times, want once for the first sync", len(records.recomputed))
+	}
+}
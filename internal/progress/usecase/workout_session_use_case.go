@@ -18,6 +18,8 @@ type WorkoutSessionUseCase interface {
 	CompleteWorkout(ctx context.Context, profileID, sessionID string) (*model.WorkoutSession, error)
 	List(ctx context.Context, profileID string, page, pageSize int) ([]model.WorkoutSession, int64, error)
 	GetByID(ctx context.Context, profileID, sessionID string) (*model.WorkoutSession, error)
+	Compare(ctx context.Context, profileID, sessionID string, previous int) (*model.SessionComparison, error)
+	CompareLatest(ctx context.Context, profileID, workoutID string, previous int) (*model.SessionComparison, error)
 }
 
 type workoutSessionUseCase struct {
@@ -39,7 +41,7 @@ func (uc *workoutSessionUseCase) StartWorkout(ctx context.Context, profileID str
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal workout: %w", err)
 	}
-	workoutSession := &model.WorkoutSession{ProfileID: profileID, WorkoutID: workout.ID, Snapshot: jsonData}
+	workoutSession := &model.WorkoutSession{ProfileID: profileID, WorkoutID: workout.ID, Snapshot: jsonData, StartedAt: time.Now()}
 	err = uc.repo.Create(ctx, workoutSession)
 	if err != nil {
 		return nil, err
@@ -55,13 +57,15 @@ func (uc *workoutSessionUseCase) CompleteWorkout(ctx context.Context, profileID,
 	if session.ProfileID != profileID {
 		return nil, customerrors.ErrAccessForbidden
 	}
-	if session.CompletedAt == nil {
-		return nil, customerrors.ErrAccessForbidden
+	if session.CompletedAt != nil {
+		return nil, customerrors.ErrSessionCompleted
 	}
-	err = uc.repo.UpdatePartial(ctx, session.ID, map[string]any{"completed_at": time.Now()})
+	completedAt := time.Now()
+	err = uc.repo.UpdatePartial(ctx, session.ID, map[string]any{"completed_at": completedAt})
 	if err != nil {
 		return nil, err
 	}
+	session.CompletedAt = &completedAt
 
 	return session, nil
 }
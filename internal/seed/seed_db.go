@@ -48,7 +48,7 @@ func (d *DatabaseSeed) Seed() {
 	}
 	if totalCount == 0 {
 		user := account.Profile{ExternalID: "a5ce12b2-3d4d-439c-ac8d-cd5ca5d8ea33"}
-		d.profileRepo.Create(ctx, &user)
+		d.profileRepo.CreateWithSetting(ctx, &user, account.DefaultSetting(account.LocaleHints{}))
 		// Define exercises to populate
 		exercises := []trainingmodels.Exercise{
 			{Name: "Bench Press", PrimaryMuscle: "Chest", SecondaryMuscle: []string{"Triceps", "Shoulders"}, Equipment: "Barbell", Description: "A compound chest exercise."},
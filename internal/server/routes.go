@@ -4,6 +4,7 @@ import (
 	"log"
 	"net/http"
 
+	"github.com/VladimirKholomyanskyy/gym-api/internal/account"
 	openapi "github.com/VladimirKholomyanskyy/gym-api/internal/api/go"
 	"github.com/gorilla/mux"
 	"github.com/rs/cors"
@@ -21,9 +22,18 @@ func (s *Server) RegisterRoutes() http.Handler {
 	WorkoutExercisesAPIController := openapi.NewWorkoutExercisesAPIController(s.WorkoutExercisesHandler)
 	ExercisesAPIController := openapi.NewExercisesAPIController(s.ExercisesHandler)
 	ScheduledWorkoutsAPIController := openapi.NewScheduledWorkoutsAPIController(s.ScheduledWorkoutsHandler)
+	ProgramSchedulesAPIController := openapi.NewProgramSchedulesAPIController(s.ProgramSchedulesHandler)
 
 	WorkoutSessionsAPIController := openapi.NewWorkoutSessionsAPIController(s.WorkoutSessionsHandler)
 	ExerciseLogsApiController := openapi.NewExerciseLogsAPIController(s.ExerciseLogsHandler)
+	PersonalRecordsAPIController := openapi.NewPersonalRecordsAPIController(s.PersonalRecordsHandler)
+	AnalyticsAPIController := openapi.NewAnalyticsAPIController(s.AnalyticsHandler)
+	CalendarAPIController := openapi.NewCalendarAPIController(s.CalendarHandler)
+	NotificationsAPIController := openapi.NewNotificationsAPIController(s.NotificationsHandler)
+	BodyMeasurementsAPIController := openapi.NewBodyMeasurementsAPIController(s.MeasurementsHandler)
+	ProgressPhotosAPIController := openapi.NewProgressPhotosAPIController(s.PhotosHandler)
+	DataExportsAPIController := openapi.NewDataExportsAPIController(s.ExportsHandler)
+	WorkoutImportsAPIController := openapi.NewWorkoutImportsAPIController(s.ImportsHandler)
 
 	// Create a new router
 	router := mux.NewRouter()
@@ -32,6 +42,12 @@ func (s *Server) RegisterRoutes() http.Handler {
 
 	// Register the auth config endpoint
 	publicRouter.HandleFunc("/auth/config", AuthAPIController.GetAuthConfig).Methods("GET")
+	// Calendar apps can't send bearer tokens, the feed is authenticated by the token in its path
+	publicRouter.Handle("/calendar/{token:[A-Za-z0-9_-]+}.ics", s.CalendarFeedHandler).Methods("GET")
+	// Browsers load images without bearer tokens, stored files are authenticated by their signed URL
+	publicRouter.Handle("/files/{key:.+}", s.FileHandler).Methods("GET")
+	// Avatars have random names and are shown wherever the profile is, they need no signature
+	publicRouter.Handle("/avatars/{key:.+}", s.AvatarFileHandler).Methods("GET")
 
 	// Create a subrouter for authenticated endpoints
 	// All other API endpoints
@@ -43,12 +59,29 @@ func (s *Server) RegisterRoutes() http.Handler {
 		WorkoutExercisesAPIController,
 		ExercisesAPIController,
 		ScheduledWorkoutsAPIController,
+		ProgramSchedulesAPIController,
 		WorkoutSessionsAPIController,
 		ExerciseLogsApiController,
+		PersonalRecordsAPIController,
+		AnalyticsAPIController,
+		CalendarAPIController,
+		NotificationsAPIController,
+		BodyMeasurementsAPIController,
+		ProgressPhotosAPIController,
+		DataExportsAPIController,
+		WorkoutImportsAPIController,
 	)
-
-	// Apply the authentication middleware only to the authenticated router
-	authenticatedRouter.Use(s.AuthMiddleware.Authenticate)
+	// Photos are uploaded as the raw request body and streamed to the storage
+	authenticatedRouter.Handle("/api/v1/progress-photos", s.PhotoUploadHandler).Methods("POST")
+	authenticatedRouter.Handle("/api/v1/profile/avatar", s.AvatarHandler).Methods("POST", "DELETE")
+	authenticatedRouter.Handle("/api/v1/profile", s.DeletionHandler).Methods("DELETE")
+	authenticatedRouter.Handle(account.RestorePath, s.DeletionHandler).Methods("POST")
+	// Exports of other trackers are uploaded as the CSV file the tracker wrote
+	authenticatedRouter.Handle("/api/v1/imports", s.ImportUploadHandler).Methods("POST")
+
+	// Apply the authentication middleware only to the authenticated router,
+	// the preferences middleware needs the profile ID it puts into the context
+	authenticatedRouter.Use(s.AuthMiddleware.Authenticate, s.PreferencesMiddleware)
 
 	// Mount the authenticated router to the main router
 	router.PathPrefix("/api").Handler(authenticatedRouter)
@@ -1,20 +1,48 @@
 package server
 
 import (
+	"context"
+	"crypto/rand"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/VladimirKholomyanskyy/gym-api/internal/account"
+	analyticshandlers "github.com/VladimirKholomyanskyy/gym-api/internal/analytics/handlers"
+	analyticsrepos "github.com/VladimirKholomyanskyy/gym-api/internal/analytics/repository"
+	analyticsusecase "github.com/VladimirKholomyanskyy/gym-api/internal/analytics/usecase"
 	openapi "github.com/VladimirKholomyanskyy/gym-api/internal/api/go"
 	"github.com/VladimirKholomyanskyy/gym-api/internal/auth"
+	calendarhandlers "github.com/VladimirKholomyanskyy/gym-api/internal/calendar/handlers"
+	calendarrepos "github.com/VladimirKholomyanskyy/gym-api/internal/calendar/repository"
+	calendarusecase "github.com/VladimirKholomyanskyy/gym-api/internal/calendar/usecase"
+	exporthandlers "github.com/VladimirKholomyanskyy/gym-api/internal/export/handlers"
+	exportrepos "github.com/VladimirKholomyanskyy/gym-api/internal/export/repository"
+	exportusecase "github.com/VladimirKholomyanskyy/gym-api/internal/export/usecase"
+	importhandlers "github.com/VladimirKholomyanskyy/gym-api/internal/imports/handlers"
+	importrepos "github.com/VladimirKholomyanskyy/gym-api/internal/imports/repository"
+	importusecase "github.com/VladimirKholomyanskyy/gym-api/internal/imports/usecase"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/jobs"
+	measurementhandlers "github.com/VladimirKholomyanskyy/gym-api/internal/measurements/handlers"
+	measurementrepos "github.com/VladimirKholomyanskyy/gym-api/internal/measurements/repository"
+	measurementusecase "github.com/VladimirKholomyanskyy/gym-api/internal/measurements/usecase"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/notifications/channels"
+	notificationhandlers "github.com/VladimirKholomyanskyy/gym-api/internal/notifications/handlers"
+	notificationmodel "github.com/VladimirKholomyanskyy/gym-api/internal/notifications/model"
+	notificationrepos "github.com/VladimirKholomyanskyy/gym-api/internal/notifications/repository"
+	notificationusecase "github.com/VladimirKholomyanskyy/gym-api/internal/notifications/usecase"
+	photohandlers "github.com/VladimirKholomyanskyy/gym-api/internal/photos/handlers"
+	photorepos "github.com/VladimirKholomyanskyy/gym-api/internal/photos/repository"
+	photousecase "github.com/VladimirKholomyanskyy/gym-api/internal/photos/usecase"
 	progresshandlers "github.com/VladimirKholomyanskyy/gym-api/internal/progress/handlers"
 	progressrepos "github.com/VladimirKholomyanskyy/gym-api/internal/progress/repository"
 	progressusecase "github.com/VladimirKholomyanskyy/gym-api/internal/progress/usecase"
 	"github.com/VladimirKholomyanskyy/gym-api/internal/seed"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/storage"
 	traininghandlers "github.com/VladimirKholomyanskyy/gym-api/internal/training/handlers"
 	trainingrepos "github.com/VladimirKholomyanskyy/gym-api/internal/training/repository"
 	trainingusecases "github.com/VladimirKholomyanskyy/gym-api/internal/training/usecase"
@@ -28,6 +56,7 @@ import (
 type Server struct {
 	port                     int
 	AuthMiddleware           *auth.CognitoMiddleware
+	PreferencesMiddleware    func(http.Handler) http.Handler
 	ProfilesHandler          openapi.ProfileAPIServicer
 	SettingsHandler          openapi.SettingsAPIServicer
 	TrainingProgramsHandler  openapi.TrainingProgramsAPIServicer
@@ -35,8 +64,24 @@ type Server struct {
 	WorkoutExercisesHandler  openapi.WorkoutExercisesAPIServicer
 	ExercisesHandler         openapi.ExercisesAPIServicer
 	ScheduledWorkoutsHandler openapi.ScheduledWorkoutsAPIServicer
+	ProgramSchedulesHandler  openapi.ProgramSchedulesAPIServicer
 	WorkoutSessionsHandler   openapi.WorkoutSessionsAPIServicer
 	ExerciseLogsHandler      openapi.ExerciseLogsAPIServicer
+	PersonalRecordsHandler   openapi.PersonalRecordsAPIServicer
+	AnalyticsHandler         openapi.AnalyticsAPIServicer
+	CalendarHandler          openapi.CalendarAPIServicer
+	CalendarFeedHandler      http.Handler
+	NotificationsHandler     openapi.NotificationsAPIServicer
+	MeasurementsHandler      openapi.BodyMeasurementsAPIServicer
+	PhotosHandler            openapi.ProgressPhotosAPIServicer
+	PhotoUploadHandler       http.Handler
+	FileHandler              http.Handler
+	AvatarHandler            http.Handler
+	AvatarFileHandler        http.Handler
+	DeletionHandler          http.Handler
+	ExportsHandler           openapi.DataExportsAPIServicer
+	ImportsHandler           openapi.WorkoutImportsAPIServicer
+	ImportUploadHandler      http.Handler
 	AuthHandler              openapi.AuthAPIServicer
 }
 
@@ -48,18 +93,11 @@ func NewServer() *http.Server {
 	port, _ := strconv.Atoi(os.Getenv("PORT"))
 	fmt.Println(port)
 	var (
-		database   = os.Getenv("BLUEPRINT_DB_DATABASE")
-		password   = os.Getenv("BLUEPRINT_DB_PASSWORD")
-		username   = os.Getenv("BLUEPRINT_DB_USERNAME")
-		db_port    = os.Getenv("BLUEPRINT_DB_PORT")
-		host       = os.Getenv("BLUEPRINT_DB_HOST")
-		schema     = os.Getenv("BLUEPRINT_DB_SCHEMA")
 		userPoolID = os.Getenv("AWS_COGNITO_USER_POOL_ID")
 		region     = os.Getenv("AWS_COGNITO_REGION")
 		clientID   = os.Getenv("AWS_COGNITO_CLIENT_ID")
 	)
-	dsn := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable&search_path=%s", username, password, host, db_port, database, schema)
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	db, err := OpenDatabase()
 	if err != nil {
 		panic("failed to connect database")
 	}
@@ -74,34 +112,75 @@ func NewServer() *http.Server {
 	workoutExerciseRepo := trainingrepos.NewWorkoutExerciseRepository(db)
 	exerciseRepo := trainingrepos.NewExerciseRepository(db)
 	scheduledWorkoutsRepo := trainingrepos.NewScheduledWorkoutRepository(db)
+	programSchedulesRepo := trainingrepos.NewProgramScheduleRepository(db)
 	workoutSessionRepo := progressrepos.NewWorkoutSessionRepository(db)
 	exerciseLogsRepo := progressrepos.NewExerciseLogRepository(db)
+	personalRecordsRepo := progressrepos.NewPersonalRecordRepository(db)
+	analyticsRepo := analyticsrepos.NewAnalyticsRepository(db)
+	calendarRepo := calendarrepos.NewCalendarRepository(db)
+	notificationsRepo := notificationrepos.NewNotificationRepository(db)
+	measurementsRepo := measurementrepos.NewMeasurementRepository(db)
+	photosRepo := photorepos.NewPhotoRepository(db)
+	exportsRepo := exportrepos.NewExportRepository(db)
+	importsRepo := importrepos.NewImportRepository(db)
+	fileStorage, urlSigner := NewFileStorage()
 
 	// Initializing service layer
+	onboardingService := account.NewOnboardingService(profilesRepo)
 	authorization := auth.NewAuthorization(trainingProgramRepo, workoutRepo)
 	trainingProgramUseCase := trainingusecases.NewTrainingProgramUseCase(trainingProgramRepo)
 	workoutsUseCase := trainingusecases.NewWorkoutUseCase(workoutRepo, authorization)
 	workoutExercisesUseCase := trainingusecases.NewWorkoutExerciseUseCase(workoutExerciseRepo, authorization)
 	exercisesUseCase := trainingusecases.NewExerciseUseCase(exerciseRepo)
-	scheduledWorkoutsUseCase := trainingusecases.NewScheduledWorkoutUseCase(scheduledWorkoutsRepo, authorization)
+	scheduledWorkoutsUseCase := trainingusecases.NewScheduledWorkoutUseCase(scheduledWorkoutsRepo, settingsRepo, authorization)
+	programSchedulesUseCase := trainingusecases.NewProgramScheduleUseCase(programSchedulesRepo, workoutRepo, settingsRepo, authorization)
 	workoutSessionsUseCases := progressusecase.NewWorkoutSessionUseCase(workoutSessionRepo, workoutsUseCase)
-	exerciseLogsUseCase := progressusecase.NewLogExerciseUseCase(exerciseLogsRepo, exercisesUseCase)
+	personalRecordsUseCase := progressusecase.NewPersonalRecordUseCase(personalRecordsRepo, exerciseLogsRepo)
+	exerciseLogsUseCase := progressusecase.NewLogExerciseUseCase(exerciseLogsRepo, workoutSessionRepo, exercisesUseCase, personalRecordsUseCase)
+	sessionSyncUseCase := progressusecase.NewSessionSyncUseCase(workoutSessionRepo, exerciseLogsRepo, workoutsUseCase, exercisesUseCase, personalRecordsUseCase)
+	analyticsUseCase := analyticsusecase.NewAnalyticsUseCase(analyticsRepo, settingsRepo, exercisesUseCase)
+	calendarUseCase := calendarusecase.NewCalendarUseCase(calendarRepo, scheduledWorkoutsRepo, scheduledWorkoutsUseCase, settingsRepo)
+	notificationSenders, vapidPublicKey := newNotificationSenders()
+	notificationsUseCase := notificationusecase.NewNotificationUseCase(notificationsRepo, scheduledWorkoutsRepo, settingsRepo, notificationSenders)
+	measurementsUseCase := measurementusecase.NewMeasurementUseCase(measurementsRepo)
+	photosUseCase := photousecase.NewPhotoUseCase(photosRepo, fileStorage, urlSigner)
+	exportsUseCase := exportusecase.NewExportUseCase(exportsRepo, fileStorage, urlSigner)
+	importsUseCase := importusecase.NewImportUseCase(importsRepo, personalRecordsUseCase)
+	// Files are kept below a directory per profile under these prefixes and go when it's purged
+	profileFilePrefixes := []string{account.AvatarPrefix, photousecase.StoragePrefix, exportusecase.StoragePrefix}
+	deletionService := account.NewDeletionService(profilesRepo, fileStorage, profileFilePrefixes, newIdentityProvider(region, userPoolID))
 	// Initializing application layer
-	profilesHandler := account.NewProfileHandler(profilesRepo)
+	profilesHandler := account.NewProfileHandler(profilesRepo, measurementsUseCase)
 	settingsHandler := account.NewSettingsHandler(settingsRepo)
+	avatarHandler := account.NewAvatarHandler(profilesRepo, fileStorage, os.Getenv("PUBLIC_API_URL"))
+	deletionHandler := account.NewDeletionHandler(deletionService)
 	trainingProgramsHandler := traininghandlers.NewTrainingProgramHandler(trainingProgramUseCase)
 	workoutsHandler := traininghandlers.NewWorkoutHandler(workoutsUseCase)
 	workoutExercisesHandler := traininghandlers.NewWorkoutExerciseHandler(workoutExercisesUseCase)
 	exercisesHandler := traininghandlers.NewExerciseHandler(exercisesUseCase)
 	scheduledWorkoutsHandler := traininghandlers.NewScheduledWorkoutsHandler(scheduledWorkoutsUseCase)
-	workoutSessionsHandler := progresshandlers.NewWorkoutSessionHandler(workoutSessionsUseCases)
+	programSchedulesHandler := traininghandlers.NewProgramSchedulesHandler(programSchedulesUseCase)
+	workoutSessionsHandler := progresshandlers.NewWorkoutSessionHandler(workoutSessionsUseCases, sessionSyncUseCase)
 	exerciseLogsHandler := progresshandlers.NewExerciseLogHandler(exerciseLogsUseCase)
+	personalRecordsHandler := progresshandlers.NewPersonalRecordHandler(personalRecordsUseCase)
+	analyticsHandler := analyticshandlers.NewAnalyticsHandler(analyticsUseCase)
+	calendarHandler := calendarhandlers.NewCalendarHandler(calendarUseCase, os.Getenv("PUBLIC_API_URL"))
+	calendarFeedHandler := calendarhandlers.NewCalendarFeedHandler(calendarUseCase)
+	notificationsHandler := notificationhandlers.NewNotificationHandler(notificationsUseCase, vapidPublicKey)
+	measurementsHandler := measurementhandlers.NewMeasurementHandler(measurementsUseCase)
+	photosHandler := photohandlers.NewPhotoHandler(photosUseCase)
+	photoUploadHandler := photohandlers.NewPhotoUploadHandler(photosUseCase)
+	fileHandler := storage.NewFileHandler(fileStorage, urlSigner)
+	avatarFileHandler := storage.NewPublicFileHandler(fileStorage, account.AvatarPrefix)
+	exportsHandler := exporthandlers.NewExportHandler(exportsUseCase)
+	importsHandler := importhandlers.NewImportHandler(importsUseCase)
+	importUploadHandler := importhandlers.NewImportUploadHandler(importsUseCase)
 
 	dataSeed := seed.NewDatabaseSeed(exerciseRepo, workoutRepo, trainingProgramRepo, workoutExerciseRepo, profilesRepo, settingsRepo)
 	dataSeed.Seed()
 
 	// Create Cognito middleware
-	cognitoMiddleware, err := auth.NewCognitoMiddleware(profilesRepo, userPoolID, region, clientID)
+	cognitoMiddleware, err := auth.NewCognitoMiddleware(onboardingService, userPoolID, region, clientID)
 	if err != nil {
 		log.Fatal("Failed to initialize Cognito middleware:", err)
 	}
@@ -109,6 +188,7 @@ func NewServer() *http.Server {
 	NewServer := &Server{
 		port:                     port,
 		AuthMiddleware:           cognitoMiddleware,
+		PreferencesMiddleware:    account.NewPreferencesMiddleware(settingsRepo),
 		ProfilesHandler:          profilesHandler,
 		SettingsHandler:          settingsHandler,
 		TrainingProgramsHandler:  trainingProgramsHandler,
@@ -116,19 +196,163 @@ func NewServer() *http.Server {
 		WorkoutExercisesHandler:  workoutExercisesHandler,
 		ExercisesHandler:         exercisesHandler,
 		ScheduledWorkoutsHandler: scheduledWorkoutsHandler,
+		ProgramSchedulesHandler:  programSchedulesHandler,
 		WorkoutSessionsHandler:   workoutSessionsHandler,
 		ExerciseLogsHandler:      exerciseLogsHandler,
+		PersonalRecordsHandler:   personalRecordsHandler,
+		AnalyticsHandler:         analyticsHandler,
+		CalendarHandler:          calendarHandler,
+		CalendarFeedHandler:      calendarFeedHandler,
+		NotificationsHandler:     notificationsHandler,
+		MeasurementsHandler:      measurementsHandler,
+		PhotosHandler:            photosHandler,
+		PhotoUploadHandler:       photoUploadHandler,
+		FileHandler:              fileHandler,
+		AvatarHandler:            avatarHandler,
+		AvatarFileHandler:        avatarFileHandler,
+		DeletionHandler:          deletionHandler,
+		ExportsHandler:           exportsHandler,
+		ImportsHandler:           importsHandler,
+		ImportUploadHandler:      importUploadHandler,
 		AuthHandler:              authHandler,
 	}
 
 	// Declare Server config
 	server := &http.Server{
-		Addr:         fmt.Sprintf(":%d", NewServer.port),
-		Handler:      NewServer.RegisterRoutes(),
-		IdleTimeout:  time.Minute,
-		ReadTimeout:  10 * time.Second,
+		Addr:        fmt.Sprintf(":%d", NewServer.port),
+		Handler:     NewServer.RegisterRoutes(),
+		IdleTimeout: time.Minute,
+		// Long enough for a photo upload over a mobile connection
+		ReadTimeout:  time.Minute,
 		WriteTimeout: 30 * time.Second,
 	}
 
+	// Background jobs run until the server shuts down
+	jobRunner := jobs.NewRunner()
+	jobRunner.Add("roll forward program schedules", time.Hour, func(ctx context.Context) error {
+		return programSchedulesUseCase.RollForward(ctx, time.Now())
+	})
+	jobRunner.Add("handle missed workouts", time.Hour, func(ctx context.Context) error {
+		return scheduledWorkoutsUseCase.HandleMissed(ctx, time.Now())
+	})
+	jobRunner.Add("enqueue workout reminders", 5*time.Minute, func(ctx context.Context) error {
+		return notificationsUseCase.EnqueueReminders(ctx, time.Now())
+	})
+	jobRunner.Add("deliver notifications", time.Minute, func(ctx context.Context) error {
+		return notificationsUseCase.Deliver(ctx, time.Now())
+	})
+	jobRunner.Add("generate data exports", time.Minute, func(ctx context.Context) error {
+		return exportsUseCase.Generate(ctx, time.Now())
+	})
+	jobRunner.Add("expire data exports", time.Hour, func(ctx context.Context) error {
+		return exportsUseCase.Expire(ctx, time.Now())
+	})
+	jobRunner.Add("recompute pending personal records", 10*time.Minute, func(ctx context.Context) error {
+		return personalRecordsUseCase.RecomputePending(ctx)
+	})
+	jobRunner.Add("recompute imported personal records", 10*time.Minute, func(ctx context.Context) error {
+		return importsUseCase.RecomputePendingRecords(ctx)
+	})
+	jobRunner.Add("purge deleted accounts", time.Hour, func(ctx context.Context) error {
+		return deletionService.Purge(ctx, time.Now())
+	})
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	jobRunner.Start(jobsCtx)
+	server.RegisterOnShutdown(stopJobs)
+
 	return server
 }
+
+// OpenDatabase connects to the database configured by the BLUEPRINT_DB_* variables
+func OpenDatabase() (*gorm.DB, error) {
+	var (
+		database = os.Getenv("BLUEPRINT_DB_DATABASE")
+		password = os.Getenv("BLUEPRINT_DB_PASSWORD")
+		username = os.Getenv("BLUEPRINT_DB_USERNAME")
+		db_port  = os.Getenv("BLUEPRINT_DB_PORT")
+		host     = os.Getenv("BLUEPRINT_DB_HOST")
+		schema   = os.Getenv("BLUEPRINT_DB_SCHEMA")
+	)
+	// Sessions run in UTC, dates are converted to the profile's time zone by the application
+	dsn := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable&search_path=%s&timezone=UTC", username, password, host, db_port, database, schema)
+	return gorm.Open(postgres.Open(dsn), &gorm.Config{})
+}
+
+// NewFileStorage sets up the storage of uploaded files on the local disk under STORAGE_DIR, and
+// the signer of their URLs keyed by STORAGE_SIGNING_KEY. Without a key a random one is used, the
+// URLs handed out then stop working when the server restarts.
+func NewFileStorage() (storage.Storage, *storage.URLSigner) {
+	dir := os.Getenv("STORAGE_DIR")
+	if dir == "" {
+		dir = "./data/storage"
+	}
+	fileStorage, err := storage.NewLocalStorage(dir)
+	if err != nil {
+		log.Fatal("Failed to initialize file storage:", err)
+	}
+	signingKey := []byte(os.Getenv("STORAGE_SIGNING_KEY"))
+	if len(signingKey) == 0 {
+		log.Println("STORAGE_SIGNING_KEY is not set, signed file URLs won't survive a restart")
+		signingKey = make([]byte, 32)
+		if _, err := rand.Read(signingKey); err != nil {
+			log.Fatal("Failed to generate a storage signing key:", err)
+		}
+	}
+	baseURL := strings.TrimSuffix(os.Getenv("PUBLIC_API_URL"), "/") + "/api/v1/files/"
+	return fileStorage, storage.NewURLSigner(signingKey, baseURL)
+}
+
+// newIdentityProvider sets up deleting the identities of purged profiles from the Cognito user
+// pool the server authenticates against, when AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY are set.
+// Without them identities are kept, signing in again after a purge starts a new empty profile.
+func newIdentityProvider(region, userPoolID string) account.IdentityProvider {
+	credentials := auth.AWSCredentials{
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+	if credentials.AccessKeyID == "" || credentials.SecretAccessKey == "" {
+		log.Println("AWS credentials are not set, identities of purged profiles are kept in Cognito")
+		return nil
+	}
+	client := &http.Client{Timeout: 10 * time.Second}
+	return auth.NewCognitoIdentityProvider(client, region, userPoolID, credentials)
+}
+
+// newNotificationSenders sets up the notification channels that are configured. Webhooks always
+// work, email needs SMTP_HOST and web push a VAPID key pair. For local development SMTP_HOST can
+// point at the Mailpit container of docker-compose.
+func newNotificationSenders() (channels.Registry, string) {
+	// Webhook and push endpoints are given by the profiles, the client only reaches public addresses
+	client := channels.NewHTTPClient(10 * time.Second)
+	senders := channels.Registry{
+		notificationmodel.ChannelWebhook: channels.NewWebhookSender(client),
+	}
+	if smtpHost := os.Getenv("SMTP_HOST"); smtpHost != "" {
+		smtpPort, err := strconv.Atoi(os.Getenv("SMTP_PORT"))
+		if err != nil {
+			log.Fatal("SMTP_PORT must be a port number:", err)
+		}
+		senders[notificationmodel.ChannelEmail] = channels.NewEmailSender(channels.SMTPConfig{
+			Host:     smtpHost,
+			Port:     smtpPort,
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     os.Getenv("SMTP_FROM"),
+		})
+	}
+	vapidPublicKey := os.Getenv("VAPID_PUBLIC_KEY")
+	if vapidPublicKey == "" {
+		return senders, ""
+	}
+	webPush, err := channels.NewWebPushSender(client, channels.VAPIDKeys{
+		PublicKey:  vapidPublicKey,
+		PrivateKey: os.Getenv("VAPID_PRIVATE_KEY"),
+		Subject:    os.Getenv("VAPID_SUBJECT"),
+	})
+	if err != nil {
+		log.Fatal("Failed to initialize web push:", err)
+	}
+	senders[notificationmodel.ChannelWebPush] = webPush
+	return senders, vapidPublicKey
+}
@@ -0,0 +1,92 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// fileHandler serves stored files to holders of a signed URL. Browsers load images without
+// bearer tokens, so the route is public and the signature is the only credential.
+type fileHandler struct {
+	storage Storage
+	signer  *URLSigner
+}
+
+// NewFileHandler serves the file whose key is the "key" route variable
+func NewFileHandler(storage Storage, signer *URLSigner) http.Handler {
+	return &fileHandler{storage: storage, signer: signer}
+}
+
+func (h *fileHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	key := mux.Vars(r)["key"]
+	query := r.URL.Query()
+	expires := query.Get("expires")
+	if !ValidKey(key) || !h.signer.Verify(key, expires, query.Get("signature"), time.Now()) {
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+	// Cached copies must not outlive the URL and never end up in shared caches
+	unix, _ := strconv.ParseInt(expires, 10, 64)
+	maxAge := max(0, unix-time.Now().Unix())
+	serveFile(w, r, h.storage, key, fmt.Sprintf("private, max-age=%d", maxAge))
+}
+
+// publicFileHandler serves the files below a prefix without a signature. The names of the files
+// are random and never reused, so they can be cached for good.
+type publicFileHandler struct {
+	storage Storage
+	prefix  string
+}
+
+// NewPublicFileHandler serves the file whose key is the prefix followed by the "key" route variable
+func NewPublicFileHandler(storage Storage, prefix string) http.Handler {
+	return &publicFileHandler{storage: storage, prefix: prefix}
+}
+
+func (h *publicFileHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	key := h.prefix + mux.Vars(r)["key"]
+	if !ValidKey(key) {
+		http.NotFound(w, r)
+		return
+	}
+	serveFile(w, r, h.storage, key, "public, max-age=31536000, immutable")
+}
+
+func serveFile(w http.ResponseWriter, r *http.Request, storage Storage, key, cacheControl string) {
+	file, err := storage.Open(r.Context(), key)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			http.NotFound(w, r)
+			return
+		}
+		log.Printf("Failed to open stored file %s: %v", key, err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	defer file.Close()
+
+	contentType := mime.TypeByExtension(path.Ext(key))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	// Archives are downloaded rather than opened
+	if path.Ext(key) == ".zip" {
+		contentType = "application/zip"
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", path.Base(key)))
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", cacheControl)
+	if _, err := io.Copy(w, file); err != nil {
+		log.Printf("Failed to stream stored file %s: %v", key, err)
+	}
+}
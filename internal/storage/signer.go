@@ -0,0 +1,44 @@
+package storage
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// URLSigner issues and checks time-limited URLs of stored files. The signature covers the key and
+// the expiry, so a URL can neither be pointed at another file nor extended.
+type URLSigner struct {
+	secret  []byte
+	baseURL string
+}
+
+// NewURLSigner creates a signer for URLs below baseURL, such as "https://api.example.com/api/v1/files/"
+func NewURLSigner(secret []byte, baseURL string) *URLSigner {
+	return &URLSigner{secret: secret, baseURL: baseURL}
+}
+
+// URL returns the URL of a stored file that is valid until expires
+func (s *URLSigner) URL(key string, expires time.Time) string {
+	unix := strconv.FormatInt(expires.Unix(), 10)
+	query := url.Values{"expires": {unix}, "signature": {s.signature(key, unix)}}
+	return s.baseURL + key + "?" + query.Encode()
+}
+
+// Verify checks the expiry and signature of a URL of a stored file
+func (s *URLSigner) Verify(key, expires, signature string, now time.Time) bool {
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || now.Unix() > unix {
+		return false
+	}
+	return hmac.Equal([]byte(signature), []byte(s.signature(key, expires)))
+}
+
+func (s *URLSigner) signature(key, expires string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(key + "\n" + expires))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,174 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func TestLocalStorage(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewLocalStorage(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := "progress-photos/profile-1/photo.jpg"
+	size, err := store.Put(ctx, key, strings.NewReader("image bytes"))
+	if err != nil || size != 11 {
+		t.Fatalf("Put() = %d, %v", size, err)
+	}
+	file, err := store.Open(ctx, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, _ := io.ReadAll(file)
+	file.Close()
+	if string(content) != "image bytes" {
+		t.Errorf("Open() read %q", content)
+	}
+	if err := store.Delete(ctx, key); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Open(ctx, key); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Open() of a deleted file error = %v, want not found", err)
+	}
+
+	for _, key := range []string{"../escape.jpg", "/absolute.jpg", "a/../../b.jpg", "a//b.jpg", ""} {
+		if _, err := store.Put(ctx, key, strings.NewReader("x")); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("Put(%q) error = %v, want invalid key", key, err)
+		}
+	}
+}
+
+func TestLocalStorageDeleteAll(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewLocalStorage(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := []string{"avatars/profile-1/a.jpg", "avatars/profile-1/b.jpg", "avatars/profile-10/c.jpg"}
+	for _, key := range keys {
+		if _, err := store.Put(ctx, key, strings.NewReader("x")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.DeleteAll(ctx, "avatars/profile-1/"); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range keys[:2] {
+		if _, err := store.Open(ctx, key); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Open(%q) after DeleteAll() error = %v, want not found", key, err)
+		}
+	}
+	file, err := store.Open(ctx, keys[2])
+	if err != nil {
+		t.Errorf("DeleteAll() removed %q of another prefix: %v", keys[2], err)
+	} else {
+		file.Close()
+	}
+	if err := store.DeleteAll(ctx, "avatars/profile-1"); err != nil {
+		t.Errorf("DeleteAll() of a missing prefix error = %v", err)
+	}
+	if err := store.DeleteAll(ctx, "../outside"); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("DeleteAll() of an invalid prefix error = %v, want invalid key", err)
+	}
+}
+
+func TestURLSigner(t *testing.T) {
+	signer := NewURLSigner([]byte("secret"), "https://api.example.com/api/v1/files/")
+	now := time.Unix(1_700_000_000, 0)
+	key := "progress-photos/profile-1/photo.jpg"
+	signed, err := url.Parse(signer.URL(key, now.Add(15*time.Minute)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signed.Path != "/api/v1/files/"+key {
+		t.Errorf("path = %q", signed.Path)
+	}
+	expires, signature := signed.Query().Get("expires"), signed.Query().Get("signature")
+
+	if !signer.Verify(key, expires, signature, now) {
+		t.Error("valid URL doesn't verify")
+	}
+	if signer.Verify(key, expires, signature, now.Add(16*time.Minute)) {
+		t.Error("expired URL verifies")
+	}
+	if signer.Verify("progress-photos/profile-2/photo.jpg", expires, signature, now) {
+		t.Error("signature verifies for another key")
+	}
+	if signer.Verify(key, "9999999999", signature, now) {
+		t.Error("signature verifies with an extended expiry")
+	}
+	if NewURLSigner([]byte("other"), "").Verify(key, expires, signature, now) {
+		t.Error("signature verifies with another secret")
+	}
+}
+
+func TestFileHandler(t *testing.T) {
+	store, err := NewLocalStorage(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := "progress-photos/profile-1/photo.jpg"
+	store.Put(context.Background(), key, strings.NewReader("jpeg"))
+	router := mux.NewRouter()
+	server := httptest.NewServer(router)
+	defer server.Close()
+	signer := NewURLSigner([]byte("secret"), server.URL+"/files/")
+	router.Handle("/files/{key:.+}", NewFileHandler(store, signer))
+
+	resp, err := http.Get(signer.URL(key, time.Now().Add(time.Minute)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || string(body) != "jpeg" || resp.Header.Get("Content-Type") != "image/jpeg" {
+		t.Errorf("signed URL got %d %q %q", resp.StatusCode, resp.Header.Get("Content-Type"), body)
+	}
+
+	resp, err = http.Get(server.URL + "/files/" + key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("unsigned URL got %d, want 403", resp.StatusCode)
+	}
+}
+
+func TestPublicFileHandler(t *testing.T) {
+	store, err := NewLocalStorage(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Put(context.Background(), "avatars/profile-1/avatar.jpg", strings.NewReader("avatar"))
+	store.Put(context.Background(), "progress-photos/profile-1/photo.jpg", strings.NewReader("photo"))
+	router := mux.NewRouter()
+	router.Handle("/avatars/{key:.+}", NewPublicFileHandler(store, "avatars/"))
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	for path, want := range map[string]int{
+		"/avatars/profile-1/avatar.jpg":                       http.StatusOK,
+		"/avatars/profile-1/missing.jpg":                      http.StatusNotFound,
+		"/avatars/..%2Fprogress-photos%2Fprofile-1/photo.jpg": http.StatusNotFound,
+	} {
+		resp, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Errorf("GET %s got %d, want %d", path, resp.StatusCode, want)
+		}
+	}
+}
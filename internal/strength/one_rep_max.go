@@ -0,0 +1,94 @@
+// Package strength holds the formulas used to compare sets of different weights and reps.
+package strength
+
+import "math"
+
+// Formula names a way to estimate a one rep max from a submaximal set
+type Formula string
+
+const (
+	FormulaEpley    Formula = "epley"
+	FormulaBrzycki  Formula = "brzycki"
+	FormulaLombardi Formula = "lombardi"
+	FormulaRPE      Formula = "rpe"
+)
+
+// ParseFormula returns the formula with the given name, Epley when the name is empty
+func ParseFormula(name string) (Formula, bool) {
+	switch Formula(name) {
+	case "":
+		return FormulaEpley, true
+	case FormulaEpley, FormulaBrzycki, FormulaLombardi, FormulaRPE:
+		return Formula(name), true
+	}
+	return "", false
+}
+
+// EstimateOneRepMax estimates the one rep max of a set with the given formula. The RPE table
+// needs the RPE of the set, sets without one estimate nothing. Zero means no estimate.
+func EstimateOneRepMax(formula Formula, weight float64, reps int, rpe *float64) float64 {
+	switch formula {
+	case FormulaBrzycki:
+		return Brzycki(weight, reps)
+	case FormulaLombardi:
+		return Lombardi(weight, reps)
+	case FormulaRPE:
+		if rpe == nil {
+			return 0
+		}
+		return RPETable(weight, reps, *rpe)
+	default:
+		return Epley(weight, reps)
+	}
+}
+
+// Epley estimates the one rep max of a set as weight * (1 + reps / 30).
+// A single is its own one rep max and sets without reps estimate nothing.
+func Epley(weight float64, reps int) float64 {
+	if reps <= 0 || weight <= 0 {
+		return 0
+	}
+	if reps == 1 {
+		return weight
+	}
+	return weight * (1 + float64(reps)/30)
+}
+
+// Brzycki estimates the one rep max as weight * 36 / (37 - reps). The formula breaks down
+// for very high reps, so sets of more than 36 reps estimate nothing.
+func Brzycki(weight float64, reps int) float64 {
+	if reps <= 0 || reps >= 37 || weight <= 0 {
+		return 0
+	}
+	return weight * 36 / float64(37-reps)
+}
+
+// Lombardi estimates the one rep max as weight * reps ^ 0.1
+func Lombardi(weight float64, reps int) float64 {
+	if reps <= 0 || weight <= 0 {
+		return 0
+	}
+	return weight * math.Pow(float64(reps), 0.1)
+}
+
+// rpeTenPercentages holds the percentage of the one rep max that can be lifted for 1 to 12 reps
+// at RPE 10. Lower RPEs leave reps in reserve, so a set of 5 at RPE 8 reads like 7 reps at RPE 10.
+var rpeTenPercentages = []float64{100, 95.5, 92.2, 89.2, 86.3, 83.7, 81.1, 78.6, 76.2, 73.9, 70.7, 68.0}
+
+// RPETable estimates the one rep max from the reps and RPE of a set using the common RPE
+// percentage chart. Sets beyond the chart, 12 reps including reps in reserve, estimate nothing.
+func RPETable(weight float64, reps int, rpe float64) float64 {
+	if reps <= 0 || weight <= 0 || rpe < 1 || rpe > 10 {
+		return 0
+	}
+	effectiveReps := float64(reps) + 10 - rpe
+	if effectiveReps > float64(len(rpeTenPercentages)) {
+		return 0
+	}
+	lower := int(math.Floor(effectiveReps))
+	percentage := rpeTenPercentages[lower-1]
+	if fraction := effectiveReps - float64(lower); fraction > 0 {
+		percentage -= (rpeTenPercentages[lower-1] - rpeTenPercentages[lower]) * fraction
+	}
+	return weight * 100 / percentage
+}
@@ -0,0 +1,101 @@
+package strength
+
+import (
+	"math"
+	"testing"
+)
+
+func TestOneRepMaxFormulas(t *testing.T) {
+	tests := []struct {
+		name    string
+		formula func(weight float64, reps int) float64
+		weight  float64
+		reps    int
+		want    float64
+	}{
+		{"Epley", Epley, 100, 5, 116.667},
+		{"Epley", Epley, 100, 10, 133.333},
+		{"Epley", Epley, 100, 1, 100},
+		{"Epley", Epley, 100, 0, 0},
+		{"Epley", Epley, 0, 5, 0},
+		{"Brzycki", Brzycki, 100, 5, 112.5},
+		{"Brzycki", Brzycki, 100, 10, 133.333},
+		{"Brzycki", Brzycki, 100, 1, 100},
+		{"Brzycki", Brzycki, 100, 36, 3600},
+		{"Brzycki", Brzycki, 100, 37, 0},
+		{"Brzycki", Brzycki, 100, 0, 0},
+		{"Lombardi", Lombardi, 100, 5, 117.462},
+		{"Lombardi", Lombardi, 100, 10, 125.893},
+		{"Lombardi", Lombardi, 100, 1, 100},
+		{"Lombardi", Lombardi, -100, 5, 0},
+	}
+	for _, tt := range tests {
+		if got := tt.formula(tt.weight, tt.reps); math.Abs(got-tt.want) > 0.001 {
+			t.Errorf("%s(%v, %d) = %.3f, want %.3f", tt.name, tt.weight, tt.reps, got, tt.want)
+		}
+	}
+}
+
+func TestRPETable(t *testing.T) {
+	tests := []struct {
+		weight float64
+		reps   int
+		rpe    float64
+		want   float64
+	}{
+		{100, 1, 10, 100},
+		{100, 3, 10, 108.460},   // 92.2%
+		{100, 5, 8, 123.305},    // reads like 7 reps at RPE 10, 81.1%
+		{100, 5, 8.5, 121.359},  // half way between 6 and 7 reps, 82.4%
+		{100, 12, 10, 147.059},  // the end of the chart, 68%
+		{100, 11, 9.5, 144.196}, // 11.5 reps, 69.35%
+		{100, 12, 9, 0},         // beyond the chart
+		{100, 5, 0.5, 0},
+		{100, 5, 10.5, 0},
+		{100, 0, 8, 0},
+		{0, 5, 8, 0},
+	}
+	for _, tt := range tests {
+		if got := RPETable(tt.weight, tt.reps, tt.rpe); math.Abs(got-tt.want) > 0.001 {
+			t.Errorf("RPETable(%v, %d, %v) = %.3f, want %.3f", tt.weight, tt.reps, tt.rpe, got, tt.want)
+		}
+	}
+}
+
+func TestEstimateOneRepMax(t *testing.T) {
+	rpe := 8.0
+	if got, want := EstimateOneRepMax(FormulaRPE, 100, 5, &rpe), RPETable(100, 5, 8); got != want {
+		t.Errorf("EstimateOneRepMax(rpe) = %v, want %v", got, want)
+	}
+	if got := EstimateOneRepMax(FormulaRPE, 100, 5, nil); got != 0 {
+		t.Errorf("EstimateOneRepMax(rpe) without an RPE = %v, want 0", got)
+	}
+	if got, want := EstimateOneRepMax(FormulaBrzycki, 100, 5, nil), 112.5; got != want {
+		t.Errorf("EstimateOneRepMax(brzycki) = %v, want %v", got, want)
+	}
+	if got, want := EstimateOneRepMax(Formula(""), 100, 5, nil), Epley(100, 5); got != want {
+		t.Errorf("EstimateOneRepMax() without a formula = %v, want Epley %v", got, want)
+	}
+}
+
+func TestParseFormula(t *testing.T) {
+	tests := []struct {
+		name   string
+		want   Formula
+		wantOK bool
+	}{
+		{"", FormulaEpley, true},
+		{"epley", FormulaEpley, true},
+		{"brzycki", FormulaBrzycki, true},
+		{"lombardi", FormulaLombardi, true},
+		{"rpe", FormulaRPE, true},
+		{"Epley", "", false},
+		{"wathan", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := ParseFormula(tt.name)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("ParseFormula(%q) = %q, %v, want %q, %v", tt.name, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
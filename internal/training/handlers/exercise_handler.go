@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"net/http"
 
 	openapi "github.com/VladimirKholomyanskyy/gym-api/internal/api/go"
@@ -54,7 +55,7 @@ func (h *exerciseHandler) GetExerciseById(ctx context.Context, exerciseId string
 
 	exercise, err := h.useCase.GetByID(ctx, exerciseId)
 	if err != nil {
-		if err == customerrors.ErrEntityNotFound {
+		if errors.Is(err, customerrors.ErrEntityNotFound) {
 			return utils.ErrorResponse(http.StatusNotFound, openapi.RESOURCE_NOT_FOUND, "Exercise not found")
 		}
 		return utils.ErrorResponse(http.StatusInternalServerError, openapi.INTERNAL_SERVER_ERROR, "Failed to retrieve exercise")
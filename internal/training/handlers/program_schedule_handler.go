@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/VladimirKholomyanskyy/gym-api/internal/account"
+	openapi "github.com/VladimirKholomyanskyy/gym-api/internal/api/go"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/common"
+	customerrors "github.com/VladimirKholomyanskyy/gym-api/internal/customErrors"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/training/model"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/training/usecase"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/utils"
+)
+
+// maxScheduleWeeks bounds how far ahead a program can be laid out at once
+const maxScheduleWeeks = 52
+
+type programSchedulesHandler struct {
+	useCase usecase.ProgramScheduleUseCase
+}
+
+func NewProgramSchedulesHandler(useCase usecase.ProgramScheduleUseCase) openapi.ProgramSchedulesAPIServicer {
+	return &programSchedulesHandler{useCase: useCase}
+}
+
+// ScheduleTrainingProgram - Lay the workouts of a training program out on the calendar
+func (h *programSchedulesHandler) ScheduleTrainingProgram(ctx context.Context, programId string, request openapi.ScheduleTrainingProgramRequest) (openapi.ImplResponse, error) {
+	profileId, err := common.ExtractProfileID(ctx)
+	if err != nil {
+		return utils.ErrorResponse(http.StatusUnauthorized, openapi.FORBIDDEN, err.Error())
+	}
+	if !common.IsUUIDValid(programId) {
+		return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_ID, "Training program ID is not a valid UUID")
+	}
+	startDate, err := utils.ParseTime(request.StartDate)
+	if err != nil {
+		return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_DATE_FORMAT, "Invalid startDate format")
+	}
+	if request.Weeks < 1 || request.Weeks > maxScheduleWeeks {
+		return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_REQUEST, "weeks must be between 1 and 52")
+	}
+	if len(request.Weekdays) == 0 {
+		return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_REQUEST, "At least one weekday is required")
+	}
+	input := model.ScheduleProgramInput{
+		ProfileID:         profileId,
+		TrainingProgramID: programId,
+		StartDate:         startDate,
+		Weeks:             int(request.Weeks),
+		RollForward:       request.RollForward != nil && *request.RollForward,
+	}
+	for _, name := range request.Weekdays {
+		weekday, ok := account.ParseWeekday(name)
+		if !ok {
+			return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_REQUEST, "weekdays must be lower case weekday names")
+		}
+		input.Weekdays = append(input.Weekdays, weekday)
+	}
+	if startDate.Before(common.CalendarDate(time.Now(), common.ExtractLocation(ctx))) {
+		return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_DATE_RANGE, "startDate can't be in the past")
+	}
+
+	schedule, err := h.useCase.Schedule(ctx, input)
+	if err != nil {
+		return programScheduleErrorResponse(err, "Failed to schedule training program")
+	}
+	return openapi.Response(http.StatusCreated, utils.ConvertProgramSchedule(schedule)), nil
+}
+
+// GetProgramSchedule - Retrieve a program schedule with its scheduled workouts
+func (h *programSchedulesHandler) GetProgramSchedule(ctx context.Context, id string) (openapi.ImplResponse, error) {
+	profileId, err := common.ExtractProfileID(ctx)
+	if err != nil {
+		return utils.ErrorResponse(http.StatusUnauthorized, openapi.FORBIDDEN, err.Error())
+	}
+	if !common.IsUUIDValid(id) {
+		return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_ID, "Invalid program schedule id")
+	}
+	schedule, err := h.useCase.GetByID(ctx, profileId, id)
+	if err != nil {
+		return programScheduleErrorResponse(err, "Failed to fetch program schedule")
+	}
+	return openapi.Response(http.StatusOK, utils.ConvertProgramSchedule(schedule)), nil
+}
+
+// DeleteProgramSchedule - Delete a program schedule together with the workouts it scheduled
+func (h *programSchedulesHandler) DeleteProgramSchedule(ctx context.Context, id string) (openapi.ImplResponse, error) {
+	profileId, err := common.ExtractProfileID(ctx)
+	if err != nil {
+		return utils.ErrorResponse(http.StatusUnauthorized, openapi.FORBIDDEN, err.Error())
+	}
+	if !common.IsUUIDValid(id) {
+		return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_ID, "Invalid program schedule id")
+	}
+	if err := h.useCase.Delete(ctx, profileId, id); err != nil {
+		return programScheduleErrorResponse(err, "Failed to delete program schedule")
+	}
+	return openapi.Response(http.StatusNoContent, nil), nil
+}
+
+func programScheduleErrorResponse(err error, message string) (openapi.ImplResponse, error) {
+	switch {
+	case errors.Is(err, customerrors.ErrAccessForbidden):
+		return utils.ErrorResponse(http.StatusForbidden, openapi.FORBIDDEN, err.Error())
+	case errors.Is(err, customerrors.ErrEntityNotFound):
+		return utils.ErrorResponse(http.StatusNotFound, openapi.RESOURCE_NOT_FOUND, "Training program or schedule not found")
+	case errors.Is(err, customerrors.ErrProgramHasNoWorkouts):
+		return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_REQUEST, err.Error())
+	}
+	return utils.ErrorResponse(http.StatusInternalServerError, openapi.INTERNAL_SERVER_ERROR, message)
+}
@@ -2,16 +2,23 @@ package handlers
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
+	"time"
 
 	openapi "github.com/VladimirKholomyanskyy/gym-api/internal/api/go"
 	"github.com/VladimirKholomyanskyy/gym-api/internal/common"
 	customerrors "github.com/VladimirKholomyanskyy/gym-api/internal/customErrors"
 	"github.com/VladimirKholomyanskyy/gym-api/internal/training/model"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/training/recurrence"
 	"github.com/VladimirKholomyanskyy/gym-api/internal/training/usecase"
 	"github.com/VladimirKholomyanskyy/gym-api/internal/utils"
 )
 
+// maxMoveDays bounds how far a scheduled workout can be moved at once
+const maxMoveDays = 365
+
 type scheduledWorkoutsHandler struct {
 	useCase usecase.ScheduledWorkoutUseCase
 }
@@ -37,6 +44,16 @@ func (h *scheduledWorkoutsHandler) ScheduleWorkout(ctx context.Context, request
 	if request.Notes != nil {
 		input.Notes = utils.TrimPointer(request.Notes)
 	}
+	if request.RecurrenceRule != nil {
+		rule, err := recurrence.Parse(*request.RecurrenceRule)
+		if err == nil {
+			err = rule.Validate(input.Date)
+		}
+		if err != nil {
+			return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_REQUEST, err.Error())
+		}
+		input.Recurrence = rule
+	}
 	scheduledWorkout, err := h.useCase.Create(ctx, input)
 	if err != nil {
 		return utils.ErrorResponse(http.StatusInternalServerError, openapi.INTERNAL_SERVER_ERROR, "Failed to schedule workout")
@@ -85,11 +102,20 @@ func (h *scheduledWorkoutsHandler) GetScheduledWorkout(ctx context.Context, id s
 	if err != nil {
 		return utils.ErrorResponse(http.StatusUnauthorized, openapi.FORBIDDEN, err.Error())
 	}
+	if !common.IsUUIDValid(id) {
+		return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_ID, "Invalid scheduled workout id")
+	}
 	scheduledWorkout, err := h.useCase.GetByID(ctx, profileID, id)
 	if err != nil {
+		if errors.Is(err, customerrors.ErrAccessForbidden) {
+			return utils.ErrorResponse(http.StatusForbidden, openapi.FORBIDDEN, err.Error())
+		}
+		if errors.Is(err, customerrors.ErrEntityNotFound) {
+			return utils.ErrorResponse(http.StatusNotFound, openapi.RESOURCE_NOT_FOUND, "Scheduled workout not found")
+		}
 		return utils.ErrorResponse(http.StatusInternalServerError, openapi.INTERNAL_SERVER_ERROR, "Failed to fetch scheduled workout")
 	}
-	return openapi.Response(http.StatusCreated, utils.ConvertScheduledWorkout(scheduledWorkout)), nil
+	return openapi.Response(http.StatusOK, utils.ConvertScheduledWorkout(scheduledWorkout)), nil
 }
 
 func (h *scheduledWorkoutsHandler) UpdateScheduledWorkout(ctx context.Context, id string, request openapi.PatchScheduledWorkoutRequest) (openapi.ImplResponse, error) {
@@ -100,26 +126,21 @@ func (h *scheduledWorkoutsHandler) UpdateScheduledWorkout(ctx context.Context, i
 	if !common.IsUUIDValid(id) {
 		return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_ID, "Invalid scheduled workout id")
 	}
-	input := model.UpdateScheduledWorkoutInput{ProfileID: profileId, ScheduledWorkoutID: id}
-	if request.Date != nil {
-		date, err := utils.ParseTime(*request.Date)
-		if err != nil {
-			return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_DATE_FORMAT, "Invalid date format")
-		}
-		input.Date = &date
-	}
-	if request.Notes != nil {
-		trimmedNotes := utils.TrimPointer(request.Notes)
-		input.Notes = &trimmedNotes
+	input, errResponse := parseUpdateScheduledWorkoutInput(profileId, id, request)
+	if errResponse != nil {
+		return *errResponse, nil
 	}
 	scheduledWorkout, err := h.useCase.Update(ctx, input)
 	if err != nil {
-		if err == customerrors.ErrAccessForbidden {
+		if errors.Is(err, customerrors.ErrAccessForbidden) {
 			return utils.ErrorResponse(http.StatusForbidden, openapi.FORBIDDEN, err.Error())
 		}
-		if err == customerrors.ErrEntityNotFound {
+		if errors.Is(err, customerrors.ErrEntityNotFound) {
 			return utils.ErrorResponse(http.StatusNotFound, openapi.RESOURCE_NOT_FOUND, "Scheduled workout not found")
 		}
+		if errors.Is(err, recurrence.ErrInvalidRule) {
+			return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_REQUEST, err.Error())
+		}
 		return utils.ErrorResponse(http.StatusInternalServerError, openapi.INTERNAL_SERVER_ERROR, "Failed to update scheduled workout")
 	}
 	return openapi.Response(http.StatusCreated, utils.ConvertScheduledWorkout(scheduledWorkout)), nil
@@ -147,12 +168,159 @@ func (h *scheduledWorkoutsHandler) GetNextScheduledWorkout(ctx context.Context)
 	if err != nil {
 		return utils.ErrorResponse(http.StatusUnauthorized, openapi.FORBIDDEN, err.Error())
 	}
-	scheduledWorkout, err := h.useCase.GetUpcommingScheduledWorkout(ctx, profileId)
+	today := common.CalendarDate(time.Now(), common.ExtractLocation(ctx))
+	scheduledWorkout, err := h.useCase.GetUpcommingScheduledWorkout(ctx, profileId, today)
 	if err != nil {
-		if err == customerrors.ErrEntityNotFound {
+		if errors.Is(err, customerrors.ErrEntityNotFound) {
 			return utils.ErrorResponse(http.StatusNotFound, openapi.RESOURCE_NOT_FOUND, "Scheduled workout not found")
 		}
 		return utils.ErrorResponse(http.StatusInternalServerError, openapi.INTERNAL_SERVER_ERROR, err.Error())
 	}
 	return openapi.Response(http.StatusOK, utils.ConvertScheduledWorkout(scheduledWorkout)), nil
 }
+
+// UpdateScheduledWorkoutOccurrence - Move, skip or annotate a single occurrence of a recurring scheduled workout
+func (h *scheduledWorkoutsHandler) UpdateScheduledWorkoutOccurrence(ctx context.Context, id, occurrenceDate string, request openapi.PatchScheduledWorkoutOccurrenceRequest) (openapi.ImplResponse, error) {
+	profileId, err := common.ExtractProfileID(ctx)
+	if err != nil {
+		return utils.ErrorResponse(http.StatusUnauthorized, openapi.FORBIDDEN, err.Error())
+	}
+	if !common.IsUUIDValid(id) {
+		return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_ID, "Invalid scheduled workout id")
+	}
+	occurrence, err := utils.ParseTime(occurrenceDate)
+	if err != nil {
+		return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_DATE_FORMAT, "Invalid occurrence date format")
+	}
+	input := model.UpdateOccurrenceInput{ProfileID: profileId, ScheduledWorkoutID: id, OccurrenceDate: occurrence, Skipped: request.Skipped}
+	if request.Date != nil {
+		date, err := utils.ParseTime(*request.Date)
+		if err != nil {
+			return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_DATE_FORMAT, "Invalid date format")
+		}
+		input.Date = &date
+	}
+	if request.Notes != nil {
+		trimmedNotes := utils.TrimPointer(request.Notes)
+		input.Notes = &trimmedNotes
+	}
+	scheduledWorkout, err := h.useCase.UpdateOccurrence(ctx, input)
+	if err != nil {
+		return occurrenceErrorResponse(err, "Failed to update scheduled workout occurrence")
+	}
+	return openapi.Response(http.StatusOK, utils.ConvertScheduledWorkout(scheduledWorkout)), nil
+}
+
+// ResetScheduledWorkoutOccurrence - Drop the changes made to a single occurrence of a recurring scheduled workout
+func (h *scheduledWorkoutsHandler) ResetScheduledWorkoutOccurrence(ctx context.Context, id, occurrenceDate string) (openapi.ImplResponse, error) {
+	profileId, err := common.ExtractProfileID(ctx)
+	if err != nil {
+		return utils.ErrorResponse(http.StatusUnauthorized, openapi.FORBIDDEN, err.Error())
+	}
+	if !common.IsUUIDValid(id) {
+		return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_ID, "Invalid scheduled workout id")
+	}
+	occurrence, err := utils.ParseTime(occurrenceDate)
+	if err != nil {
+		return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_DATE_FORMAT, "Invalid occurrence date format")
+	}
+	if err := h.useCase.ResetOccurrence(ctx, profileId, id, occurrence); err != nil {
+		return occurrenceErrorResponse(err, "Failed to reset scheduled workout occurrence")
+	}
+	return openapi.Response(http.StatusNoContent, nil), nil
+}
+
+// UpdateFollowingScheduledWorkouts - Change an occurrence of a recurring scheduled workout and all occurrences after it
+func (h *scheduledWorkoutsHandler) UpdateFollowingScheduledWorkouts(ctx context.Context, id, occurrenceDate string, request openapi.PatchScheduledWorkoutRequest) (openapi.ImplResponse, error) {
+	profileId, err := common.ExtractProfileID(ctx)
+	if err != nil {
+		return utils.ErrorResponse(http.StatusUnauthorized, openapi.FORBIDDEN, err.Error())
+	}
+	if !common.IsUUIDValid(id) {
+		return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_ID, "Invalid scheduled workout id")
+	}
+	occurrence, err := utils.ParseTime(occurrenceDate)
+	if err != nil {
+		return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_DATE_FORMAT, "Invalid occurrence date format")
+	}
+	input, errResponse := parseUpdateScheduledWorkoutInput(profileId, id, request)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
+	scheduledWorkout, err := h.useCase.UpdateFollowing(ctx, input, occurrence)
+	if err != nil {
+		return occurrenceErrorResponse(err, "Failed to update following scheduled workouts")
+	}
+	return openapi.Response(http.StatusOK, utils.ConvertScheduledWorkout(scheduledWorkout)), nil
+}
+
+// MoveScheduledWorkout - Move a scheduled workout, or an occurrence of a recurring one, and optionally all following scheduled workouts by a number of days
+func (h *scheduledWorkoutsHandler) MoveScheduledWorkout(ctx context.Context, id string, request openapi.MoveScheduledWorkoutRequest) (openapi.ImplResponse, error) {
+	profileId, err := common.ExtractProfileID(ctx)
+	if err != nil {
+		return utils.ErrorResponse(http.StatusUnauthorized, openapi.FORBIDDEN, err.Error())
+	}
+	if !common.IsUUIDValid(id) {
+		return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_ID, "Invalid scheduled workout id")
+	}
+	if request.Days == 0 || request.Days < -maxMoveDays || request.Days > maxMoveDays {
+		return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_REQUEST, fmt.Sprintf("Days must be between -%d and %d and not zero", maxMoveDays, maxMoveDays))
+	}
+	input := model.MoveScheduledWorkoutInput{ProfileID: profileId, ScheduledWorkoutID: id, Days: int(request.Days)}
+	if request.OccurrenceDate != nil {
+		occurrence, err := utils.ParseTime(*request.OccurrenceDate)
+		if err != nil {
+			return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_DATE_FORMAT, "Invalid occurrence date format")
+		}
+		input.OccurrenceDate = &occurrence
+	}
+	if request.Following != nil {
+		input.Following = *request.Following
+	}
+	if err := h.useCase.Move(ctx, input); err != nil {
+		return occurrenceErrorResponse(err, "Failed to move scheduled workout")
+	}
+	return openapi.Response(http.StatusNoContent, nil), nil
+}
+
+// parseUpdateScheduledWorkoutInput reads a patch request. An empty recurrence rule stops the repetition.
+func parseUpdateScheduledWorkoutInput(profileId, id string, request openapi.PatchScheduledWorkoutRequest) (model.UpdateScheduledWorkoutInput, *openapi.ImplResponse) {
+	input := model.UpdateScheduledWorkoutInput{ProfileID: profileId, ScheduledWorkoutID: id}
+	if request.Date != nil {
+		date, err := utils.ParseTime(*request.Date)
+		if err != nil {
+			response, _ := utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_DATE_FORMAT, "Invalid date format")
+			return input, &response
+		}
+		input.Date = &date
+	}
+	if request.Notes != nil {
+		trimmedNotes := utils.TrimPointer(request.Notes)
+		input.Notes = &trimmedNotes
+	}
+	if request.RecurrenceRule != nil {
+		if *request.RecurrenceRule == "" {
+			input.ClearRecurrence = true
+		} else {
+			rule, err := recurrence.Parse(*request.RecurrenceRule)
+			if err != nil {
+				response, _ := utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_REQUEST, err.Error())
+				return input, &response
+			}
+			input.Recurrence = rule
+		}
+	}
+	return input, nil
+}
+
+func occurrenceErrorResponse(err error, message string) (openapi.ImplResponse, error) {
+	switch {
+	case errors.Is(err, customerrors.ErrAccessForbidden):
+		return utils.ErrorResponse(http.StatusForbidden, openapi.FORBIDDEN, err.Error())
+	case errors.Is(err, customerrors.ErrEntityNotFound):
+		return utils.ErrorResponse(http.StatusNotFound, openapi.RESOURCE_NOT_FOUND, "Scheduled workout or occurrence override not found")
+	case errors.Is(err, customerrors.ErrNotRecurring), errors.Is(err, customerrors.ErrNotAnOccurrence), errors.Is(err, recurrence.ErrInvalidRule):
+		return utils.ErrorResponse(http.StatusBadRequest, openapi.INVALID_REQUEST, err.Error())
+	}
+	return utils.ErrorResponse(http.StatusInternalServerError, openapi.INTERNAL_SERVER_ERROR, message)
+}
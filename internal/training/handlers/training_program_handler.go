@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"net/http"
 
 	openapi "github.com/VladimirKholomyanskyy/gym-api/internal/api/go"
@@ -72,10 +73,10 @@ func (h *TrainingProgramHandler) GetTrainingProgramById(ctx context.Context, pro
 	}
 	userProgram, err := h.useCase.GetByID(ctx, profileID, programID)
 	if err != nil {
-		if err == customerrors.ErrAccessForbidden {
+		if errors.Is(err, customerrors.ErrAccessForbidden) {
 			return utils.ErrorResponse(http.StatusForbidden, openapi.FORBIDDEN, "Access denied to training program")
 		}
-		if err == customerrors.ErrEntityNotFound {
+		if errors.Is(err, customerrors.ErrEntityNotFound) {
 			return utils.ErrorResponse(http.StatusNotFound, openapi.RESOURCE_NOT_FOUND, "Scheduled workout not found")
 		}
 		return utils.ErrorResponse(http.StatusInternalServerError, openapi.INTERNAL_SERVER_ERROR, "Failed to update scheduled workout")
@@ -93,10 +94,10 @@ func (h *TrainingProgramHandler) DeleteTrainingProgram(ctx context.Context, prog
 	}
 	err = h.useCase.Delete(ctx, profileID, programID)
 	if err != nil {
-		if err == customerrors.ErrAccessForbidden {
+		if errors.Is(err, customerrors.ErrAccessForbidden) {
 			return utils.ErrorResponse(http.StatusForbidden, openapi.FORBIDDEN, "Access denied to delete training program")
 		}
-		if err == customerrors.ErrEntityNotFound {
+		if errors.Is(err, customerrors.ErrEntityNotFound) {
 			return utils.ErrorResponse(http.StatusNotFound, openapi.RESOURCE_NOT_FOUND, "Training program not found")
 		}
 		return utils.ErrorResponse(http.StatusInternalServerError, openapi.INTERNAL_SERVER_ERROR, "Failed to delete training program")
@@ -115,10 +116,10 @@ func (h *TrainingProgramHandler) UpdateTrainingProgram(ctx context.Context, prog
 	}
 	userProgram, err := h.useCase.Update(ctx, profileID, programID, request)
 	if err != nil {
-		if err == customerrors.ErrAccessForbidden {
+		if errors.Is(err, customerrors.ErrAccessForbidden) {
 			return utils.ErrorResponse(http.StatusForbidden, openapi.FORBIDDEN, "Access denied to update training program")
 		}
-		if err == customerrors.ErrEntityNotFound {
+		if errors.Is(err, customerrors.ErrEntityNotFound) {
 			return utils.ErrorResponse(http.StatusNotFound, openapi.RESOURCE_NOT_FOUND, "Training program not found")
 		}
 		return utils.ErrorResponse(http.StatusInternalServerError, openapi.INTERNAL_SERVER_ERROR, "Failed to update training program")
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"net/http"
 
 	openapi "github.com/VladimirKholomyanskyy/gym-api/internal/api/go"
@@ -36,10 +37,10 @@ func (h *workoutExerciseHandler) ListWorkoutExercises(ctx context.Context, worko
 	}
 	workoutExercises, totalCount, err := h.useCase.List(ctx, profileId, workoutId, int(page), int(pageSize))
 	if err != nil {
-		if err == customerrors.ErrAccessForbidden {
+		if errors.Is(err, customerrors.ErrAccessForbidden) {
 			return utils.ErrorResponse(http.StatusForbidden, openapi.FORBIDDEN, "Access denied to workout exercise")
 		}
-		if err == customerrors.ErrEntityNotFound {
+		if errors.Is(err, customerrors.ErrEntityNotFound) {
 			return utils.ErrorResponse(http.StatusNotFound, openapi.RESOURCE_NOT_FOUND, "Workout exercise not found")
 		}
 		return utils.ErrorResponse(http.StatusInternalServerError, openapi.INTERNAL_SERVER_ERROR, "Failed to update scheduled workout")
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"net/http"
 
 	openapi "github.com/VladimirKholomyanskyy/gym-api/internal/api/go"
@@ -37,10 +38,10 @@ func (h *WorkoutHandler) ListWorkoutsForProgram(ctx context.Context, programId s
 
 	workouts, totalCount, err := h.useCase.List(ctx, profileId, programId, int(page), int(pageSize))
 	if err != nil {
-		if err == customerrors.ErrAccessForbidden {
+		if errors.Is(err, customerrors.ErrAccessForbidden) {
 			return utils.ErrorResponse(http.StatusForbidden, openapi.FORBIDDEN, "Access denied to workout")
 		}
-		if err == customerrors.ErrEntityNotFound {
+		if errors.Is(err, customerrors.ErrEntityNotFound) {
 			return utils.ErrorResponse(http.StatusNotFound, openapi.RESOURCE_NOT_FOUND, "Workout not found")
 		}
 		return utils.ErrorResponse(http.StatusInternalServerError, openapi.INTERNAL_SERVER_ERROR, "Failed to fetch workouts")
@@ -68,10 +69,10 @@ func (h *WorkoutHandler) AddWorkoutToProgram(ctx context.Context, programId stri
 	}
 	workout, err := h.useCase.Create(ctx, profileId, programId, request)
 	if err != nil {
-		if err == customerrors.ErrAccessForbidden {
+		if errors.Is(err, customerrors.ErrAccessForbidden) {
 			return utils.ErrorResponse(http.StatusForbidden, openapi.FORBIDDEN, "Access denied to workout")
 		}
-		if err == customerrors.ErrEntityNotFound {
+		if errors.Is(err, customerrors.ErrEntityNotFound) {
 			return utils.ErrorResponse(http.StatusNotFound, openapi.RESOURCE_NOT_FOUND, "Workout not found")
 		}
 		return utils.ErrorResponse(http.StatusInternalServerError, openapi.INTERNAL_SERVER_ERROR, "Failed to create workout")
@@ -92,10 +93,10 @@ func (h *WorkoutHandler) GetWorkoutForProgram(ctx context.Context, programId str
 	}
 	workout, err := h.useCase.GetByProgramIDAndWorkoutID(ctx, profileId, programId, workoutId)
 	if err != nil {
-		if err == customerrors.ErrAccessForbidden {
+		if errors.Is(err, customerrors.ErrAccessForbidden) {
 			return utils.ErrorResponse(http.StatusForbidden, openapi.FORBIDDEN, "Access denied to workout")
 		}
-		if err == customerrors.ErrEntityNotFound {
+		if errors.Is(err, customerrors.ErrEntityNotFound) {
 			return utils.ErrorResponse(http.StatusNotFound, openapi.RESOURCE_NOT_FOUND, "Workout not found")
 		}
 		return utils.ErrorResponse(http.StatusInternalServerError, openapi.INTERNAL_SERVER_ERROR, "Failed to fetch workout")
@@ -116,10 +117,10 @@ func (h *WorkoutHandler) UpdateWorkout(ctx context.Context, programId string, wo
 	}
 	workout, err := h.useCase.Update(ctx, profileId, programId, workoutId, request)
 	if err != nil {
-		if err == customerrors.ErrAccessForbidden {
+		if errors.Is(err, customerrors.ErrAccessForbidden) {
 			return utils.ErrorResponse(http.StatusForbidden, openapi.FORBIDDEN, "Access denied to workout")
 		}
-		if err == customerrors.ErrEntityNotFound {
+		if errors.Is(err, customerrors.ErrEntityNotFound) {
 			return utils.ErrorResponse(http.StatusNotFound, openapi.RESOURCE_NOT_FOUND, "Workout not found")
 		}
 		return utils.ErrorResponse(http.StatusInternalServerError, openapi.INTERNAL_SERVER_ERROR, "Failed to update workout")
@@ -140,10 +141,10 @@ func (h *WorkoutHandler) DeleteWorkout(ctx context.Context, programId string, wo
 	}
 	err = h.useCase.Delete(ctx, profileId, programId, workoutId)
 	if err != nil {
-		if err == customerrors.ErrAccessForbidden {
+		if errors.Is(err, customerrors.ErrAccessForbidden) {
 			return utils.ErrorResponse(http.StatusForbidden, openapi.FORBIDDEN, "Access denied to workout")
 		}
-		if err == customerrors.ErrEntityNotFound {
+		if errors.Is(err, customerrors.ErrEntityNotFound) {
 			return utils.ErrorResponse(http.StatusNotFound, openapi.RESOURCE_NOT_FOUND, "Workout not found")
 		}
 		return utils.ErrorResponse(http.StatusInternalServerError, openapi.INTERNAL_SERVER_ERROR, "Failed to delete workout")
@@ -1,9 +1,11 @@
 package model
 
 import (
+	"sort"
 	"time"
 
 	"github.com/VladimirKholomyanskyy/gym-api/internal/common"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/training/recurrence"
 	"github.com/lib/pq"
 )
 
@@ -41,20 +43,127 @@ type Exercise struct {
 	Description     string
 }
 
+// ScheduledWorkout is a workout planned for a date. With a recurrence rule it is a series and the
+// date is its first occurrence; the occurrences are expanded when read and can be overridden one by one.
 type ScheduledWorkout struct {
 	common.Base
-	ProfileID string
-	WorkoutID string
-	Workout   Workout `gorm:"constraint:OnDelete:CASCADE;"`
-	Date      time.Time
-	Notes     string
+	ProfileID      string
+	WorkoutID      string
+	Workout        Workout `gorm:"constraint:OnDelete:CASCADE;"`
+	Date           time.Time
+	Notes          string
+	Status         ScheduledWorkoutStatus `gorm:"default:planned"`
+	RecurrenceRule *string
+	EndsOn         *time.Time                 // last occurrence of a series, nil when it repeats forever
+	Overrides      []ScheduledWorkoutOverride `gorm:"foreignKey:ScheduledWorkoutID"`
+	// ProgramScheduleID is set on workouts laid out by a program schedule
+	ProgramScheduleID *string
+	// OccurrenceDate is set on expanded occurrences of a series to the date the rule puts them on,
+	// which identifies the occurrence even after it is moved
+	OccurrenceDate *time.Time `gorm:"-"`
+}
+
+// ScheduledWorkoutOverride changes a single occurrence of a series: it moves it to another date,
+// skips it or gives it its own notes
+type ScheduledWorkoutOverride struct {
+	common.Base
+	ScheduledWorkoutID string
+	OccurrenceDate     time.Time
+	Date               *time.Time
+	Skipped            bool
+	Notes              *string
+	Status             *ScheduledWorkoutStatus
+}
+
+type ScheduledWorkoutStatus string
+
+const (
+	ScheduledWorkoutPlanned ScheduledWorkoutStatus = "planned"
+	// ScheduledWorkoutMissed is a workout whose date passed without a session
+	ScheduledWorkoutMissed ScheduledWorkoutStatus = "missed"
+)
+
+// Rule parses the recurrence rule of a series, nil for a single scheduled workout
+func (s *ScheduledWorkout) Rule() (*recurrence.Rule, error) {
+	if s.RecurrenceRule == nil {
+		return nil, nil
+	}
+	return recurrence.Parse(*s.RecurrenceRule)
+}
+
+// Expand returns the scheduled workout, or the occurrences of a series, that fall in [from, to] with
+// their overrides applied, ordered by date. Skipped occurrences are left out and moved ones show up
+// on their new date. Overrides of dates the rule no longer produces are ignored.
+func (s *ScheduledWorkout) Expand(from, to time.Time) []ScheduledWorkout {
+	rule, err := s.Rule()
+	if err != nil {
+		return nil
+	}
+	if rule == nil {
+		if s.Date.Before(from) || s.Date.After(to) {
+			return nil
+		}
+		return []ScheduledWorkout{*s}
+	}
+
+	overridden := make(map[string]bool, len(s.Overrides))
+	var occurrences []ScheduledWorkout
+	for i := range s.Overrides {
+		override := &s.Overrides[i]
+		overridden[override.OccurrenceDate.Format(time.DateOnly)] = true
+		if override.Skipped || !rule.Includes(s.Date, override.OccurrenceDate) {
+			continue
+		}
+		occurrence := s.Occurrence(override.OccurrenceDate, override)
+		if !occurrence.Date.Before(from) && !occurrence.Date.After(to) {
+			occurrences = append(occurrences, occurrence)
+		}
+	}
+	for _, date := range rule.Between(s.Date, from, to) {
+		if !overridden[date.Format(time.DateOnly)] {
+			occurrences = append(occurrences, s.Occurrence(date, nil))
+		}
+	}
+	sort.SliceStable(occurrences, func(i, j int) bool { return occurrences[i].Date.Before(occurrences[j].Date) })
+	return occurrences
+}
+
+// Occurrence returns the occurrence of a series on the given date with an optional override applied
+func (s *ScheduledWorkout) Occurrence(date time.Time, override *ScheduledWorkoutOverride) ScheduledWorkout {
+	occurrence := *s
+	occurrence.Overrides = nil
+	occurrence.Date = date
+	occurrence.OccurrenceDate = &date
+	if override != nil {
+		if override.Date != nil {
+			occurrence.Date = *override.Date
+		}
+		if override.Notes != nil {
+			occurrence.Notes = *override.Notes
+		}
+		if override.Status != nil {
+			occurrence.Status = *override.Status
+		}
+	}
+	return occurrence
+}
+
+// FindOverride returns the override of the occurrence on the given date, nil when it has none
+func (s *ScheduledWorkout) FindOverride(occurrenceDate time.Time) *ScheduledWorkoutOverride {
+	for i := range s.Overrides {
+		if s.Overrides[i].OccurrenceDate.Format(time.DateOnly) == occurrenceDate.Format(time.DateOnly) {
+			return &s.Overrides[i]
+		}
+	}
+	return nil
 }
 
 type CreateScheduledWorkoutInput struct {
-	ProfileID string
-	WorkoutID string
-	Date      time.Time
-	Notes     string
+	ProfileID  string
+	WorkoutID  string
+	Date       time.Time
+	Notes      string
+	Recurrence *recurrence.Rule
 }
 
 type UpdateScheduledWorkoutInput struct {
@@ -62,4 +171,95 @@ type UpdateScheduledWorkoutInput struct {
 	ProfileID          string
 	Date               *time.Time
 	Notes              *string
+	Recurrence         *recurrence.Rule
+	ClearRecurrence    bool // turns a series into a single scheduled workout on its date
+}
+
+// ProgramSchedule lays the workouts of a training program out on the calendar, cycling through
+// them by position on the chosen weekdays. Weekdays are lower case names such as "monday".
+type ProgramSchedule struct {
+	common.Base
+	ProfileID         string
+	TrainingProgramID string
+	StartDate         time.Time
+	Weekdays          pq.StringArray `gorm:"type:text[]"`
+	Weeks             int
+	// RollForward moves a missed workout and the ones after it to the next free training days
+	RollForward bool
+	// RollForwards counts the roll-forwards since a workout of the schedule was last trained
+	RollForwards      int
+	ScheduledWorkouts []ScheduledWorkout `gorm:"foreignKey:ProgramScheduleID"`
+}
+
+type ScheduleProgramInput struct {
+	ProfileID         string
+	TrainingProgramID string
+	StartDate         time.Time
+	Weekdays          []time.Weekday
+	Weeks             int
+	RollForward       bool
+}
+
+// WorkoutSessionStart is when a session of a workout was started
+type WorkoutSessionStart struct {
+	WorkoutID string
+	StartedAt time.Time
+}
+
+// MoveScheduledWorkoutInput moves a scheduled workout, or an occurrence of a series, by a number of
+// days. With Following every later scheduled workout of the profile moves along, series included.
+type MoveScheduledWorkoutInput struct {
+	ScheduledWorkoutID string
+	ProfileID          string
+	OccurrenceDate     *time.Time
+	Days               int
+	Following          bool
+}
+
+// ScheduleChanges is a set of changes to the scheduled workouts of a profile that is stored in a
+// single transaction, so a move never leaves half of the calendar shifted
+type ScheduleChanges struct {
+	// Updates holds column updates of scheduled workouts by ID
+	Updates map[string]map[string]any
+	// Overrides are created or replace the existing override of their occurrence
+	Overrides []ScheduledWorkoutOverride
+	Shifts    []SeriesShift
+}
+
+// SeriesShift moves the occurrences of a series from a date on. When the shift starts after the first
+// occurrence the series is split: Updates end it and Following takes over the shifted occurrences.
+// Otherwise Updates move the whole series and Following is nil. Overrides of the shifted
+// occurrences move along.
+type SeriesShift struct {
+	ScheduledWorkoutID string
+	From               time.Time
+	Days               int
+	Updates            map[string]any
+	Following          *ScheduledWorkout
+}
+
+// Update sets a column of a scheduled workout
+func (c *ScheduleChanges) Update(id string, column string, value any) {
+	if c.Updates == nil {
+		c.Updates = make(map[string]map[string]any)
+	}
+	if c.Updates[id] == nil {
+		c.Updates[id] = make(map[string]any)
+	}
+	c.Updates[id][column] = value
+}
+
+// IsEmpty reports whether there is nothing to store
+func (c *ScheduleChanges) IsEmpty() bool {
+	return len(c.Updates) == 0 && len(c.Overrides) == 0 && len(c.Shifts) == 0
+}
+
+// UpdateOccurrenceInput overrides a single occurrence of a series, nil fields are left unchanged
+type UpdateOccurrenceInput struct {
+	ScheduledWorkoutID string
+	ProfileID          string
+	OccurrenceDate     time.Time
+	Date               *time.Time
+	Skipped            *bool
+	Notes              *string
 }
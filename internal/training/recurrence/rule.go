@@ -0,0 +1,303 @@
+// Package recurrence implements the subset of RFC 5545 recurrence rules scheduled workouts repeat with:
+// daily and weekly frequencies with INTERVAL, BYDAY, WKST and either COUNT or UNTIL.
+// Occurrences are calendar dates, represented as midnight UTC like DATE columns.
+package recurrence
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Frequency string
+
+const (
+	Daily  Frequency = "DAILY"
+	Weekly Frequency = "WEEKLY"
+)
+
+const (
+	// MaxInterval bounds the number of days or weeks between occurrences
+	MaxInterval = 52
+	// MaxCount bounds the number of occurrences a rule with COUNT may have
+	MaxCount = 730
+)
+
+var (
+	ErrInvalidRule = errors.New("invalid recurrence rule")
+
+	weekdays = map[string]time.Weekday{
+		"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+		"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+	}
+)
+
+// Rule is a parsed recurrence rule. The first occurrence is the start date the rule is applied to.
+type Rule struct {
+	Frequency Frequency
+	Interval  int
+	ByDay     []time.Weekday // empty repeats on the weekday of the start date for weekly rules
+	WeekStart time.Weekday
+	Count     int        // zero when not limited by count
+	Until     *time.Time // inclusive, nil when not limited by date
+}
+
+// Parse reads a rule such as "FREQ=WEEKLY;INTERVAL=1;BYDAY=MO,TH;COUNT=16". An optional "RRULE:"
+// prefix is accepted and UNTIL may be a date or a UTC date time, only its date is used.
+func Parse(value string) (*Rule, error) {
+	rule := &Rule{Interval: 1, WeekStart: time.Monday}
+	value = strings.TrimPrefix(strings.TrimSpace(value), "RRULE:")
+	if value == "" {
+		return nil, fmt.Errorf("%w: empty rule", ErrInvalidRule)
+	}
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(value, ";") {
+		name, val, ok := strings.Cut(part, "=")
+		name = strings.ToUpper(name)
+		if !ok || val == "" {
+			return nil, fmt.Errorf("%w: malformed part %q", ErrInvalidRule, part)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("%w: %s is given more than once", ErrInvalidRule, name)
+		}
+		seen[name] = true
+		switch name {
+		case "FREQ":
+			switch Frequency(strings.ToUpper(val)) {
+			case Daily, Weekly:
+				rule.Frequency = Frequency(strings.ToUpper(val))
+			default:
+				return nil, fmt.Errorf("%w: only DAILY and WEEKLY frequencies are supported", ErrInvalidRule)
+			}
+		case "INTERVAL":
+			interval, err := strconv.Atoi(val)
+			if err != nil || interval < 1 || interval > MaxInterval {
+				return nil, fmt.Errorf("%w: INTERVAL must be between 1 and %d", ErrInvalidRule, MaxInterval)
+			}
+			rule.Interval = interval
+		case "BYDAY":
+			for _, day := range strings.Split(strings.ToUpper(val), ",") {
+				weekday, ok := weekdays[day]
+				if !ok {
+					return nil, fmt.Errorf("%w: unsupported BYDAY value %q", ErrInvalidRule, day)
+				}
+				if !containsWeekday(rule.ByDay, weekday) {
+					rule.ByDay = append(rule.ByDay, weekday)
+				}
+			}
+		case "WKST":
+			weekday, ok := weekdays[strings.ToUpper(val)]
+			if !ok {
+				return nil, fmt.Errorf("%w: unsupported WKST value %q", ErrInvalidRule, val)
+			}
+			rule.WeekStart = weekday
+		case "COUNT":
+			count, err := strconv.Atoi(val)
+			if err != nil || count < 1 || count > MaxCount {
+				return nil, fmt.Errorf("%w: COUNT must be between 1 and %d", ErrInvalidRule, MaxCount)
+			}
+			rule.Count = count
+		case "UNTIL":
+			if len(val) < 8 {
+				return nil, fmt.Errorf("%w: UNTIL must be a date", ErrInvalidRule)
+			}
+			until, err := time.Parse("20060102", val[:8])
+			if err != nil {
+				return nil, fmt.Errorf("%w: UNTIL must be a date", ErrInvalidRule)
+			}
+			rule.Until = &until
+		default:
+			return nil, fmt.Errorf("%w: %s is not supported", ErrInvalidRule, name)
+		}
+	}
+	if rule.Frequency == "" {
+		return nil, fmt.Errorf("%w: FREQ is required", ErrInvalidRule)
+	}
+	if rule.Count > 0 && rule.Until != nil {
+		return nil, fmt.Errorf("%w: COUNT and UNTIL can't be combined", ErrInvalidRule)
+	}
+	// Stepping by whole weeks stays on the weekday of the start, only one BYDAY value can match it
+	if rule.weekdayFixed() && len(rule.ByDay) > 1 {
+		return nil, fmt.Errorf("%w: DAILY rules with an INTERVAL of whole weeks repeat on a single weekday", ErrInvalidRule)
+	}
+	return rule, nil
+}
+
+// Validate checks the rule against the start date it is applied to: a DAILY rule with an INTERVAL
+// of whole weeks never reaches a BYDAY weekday other than that of the start
+func (r *Rule) Validate(start time.Time) error {
+	if r.weekdayFixed() && len(r.ByDay) > 0 && !containsWeekday(r.ByDay, start.Weekday()) {
+		return fmt.Errorf("%w: BYDAY of a DAILY rule with an INTERVAL of whole weeks must be the weekday of the start", ErrInvalidRule)
+	}
+	return nil
+}
+
+// weekdayFixed reports whether every occurrence falls on the weekday of the start
+func (r *Rule) weekdayFixed() bool {
+	return r.Frequency == Daily && r.Interval%7 == 0
+}
+
+// String formats the rule in its canonical form
+func (r *Rule) String() string {
+	parts := []string{"FREQ=" + string(r.Frequency)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, len(r.ByDay))
+		for i, weekday := range r.ByDay {
+			days[i] = strings.ToUpper(weekday.String()[:2])
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if r.WeekStart != time.Monday {
+		parts = append(parts, "WKST="+strings.ToUpper(r.WeekStart.String()[:2]))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if r.Until != nil {
+		parts = append(parts, "UNTIL="+r.Until.Format("20060102"))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Shift returns the rule that repeats the occurrences of this one moved by the given number of
+// days when it is started that many days later. Weekdays and the week start move along, so weeks
+// of a rule with an interval group the same occurrences as before.
+func (r *Rule) Shift(days int) *Rule {
+	shifted := *r
+	shift := func(weekday time.Weekday) time.Weekday {
+		return time.Weekday(((int(weekday)+days)%7 + 7) % 7)
+	}
+	shifted.ByDay = make([]time.Weekday, len(r.ByDay))
+	for i, weekday := range r.ByDay {
+		shifted.ByDay[i] = shift(weekday)
+	}
+	sort.Slice(shifted.ByDay, func(i, j int) bool { return shifted.ByDay[i] < shifted.ByDay[j] })
+	shifted.WeekStart = shift(r.WeekStart)
+	if r.Until != nil {
+		until := r.Until.AddDate(0, 0, days)
+		shifted.Until = &until
+	}
+	return &shifted
+}
+
+// Between returns the occurrences of the rule started at start that fall in [from, to], both inclusive
+func (r *Rule) Between(start, from, to time.Time) []time.Time {
+	var occurrences []time.Time
+	r.each(date(start), func(occurrence time.Time) bool {
+		if occurrence.After(to) {
+			return false
+		}
+		if !occurrence.Before(from) {
+			occurrences = append(occurrences, occurrence)
+		}
+		return true
+	})
+	return occurrences
+}
+
+// Includes reports whether the rule started at start has an occurrence on the given date
+func (r *Rule) Includes(start, day time.Time) bool {
+	day = date(day)
+	return len(r.Between(start, day, day)) == 1
+}
+
+// CountBefore returns the number of occurrences of the rule started at start before the given date
+func (r *Rule) CountBefore(start, day time.Time) int {
+	if !date(day).After(date(start)) {
+		return 0
+	}
+	return len(r.Between(start, date(start), date(day).AddDate(0, 0, -1)))
+}
+
+// Last returns the last occurrence of the rule started at start, false when the rule repeats forever
+func (r *Rule) Last(start time.Time) (time.Time, bool) {
+	if r.Count == 0 && r.Until == nil {
+		return time.Time{}, false
+	}
+	var last time.Time
+	r.each(date(start), func(occurrence time.Time) bool {
+		last = occurrence
+		return true
+	})
+	return last, true
+}
+
+// each calls fn with the occurrences in order until fn returns false or the rule ends
+func (r *Rule) each(start time.Time, fn func(time.Time) bool) {
+	byDay := r.ByDay
+	if r.Frequency == Weekly && len(byDay) == 0 {
+		byDay = []time.Weekday{start.Weekday()}
+	}
+	// Offsets of the weekdays from the start of the week, so a week is walked in order
+	offsets := make([]int, len(byDay))
+	for i, weekday := range byDay {
+		offsets[i] = (int(weekday) - int(r.WeekStart) + 7) % 7
+	}
+	sort.Ints(offsets)
+
+	emitted := 0
+	emit := func(occurrence time.Time) bool {
+		if occurrence.Before(start) {
+			return true
+		}
+		if r.Until != nil && occurrence.After(*r.Until) {
+			return false
+		}
+		emitted++
+		if !fn(occurrence) {
+			return false
+		}
+		return r.Count == 0 || emitted < r.Count
+	}
+
+	switch r.Frequency {
+	case Daily:
+		// Every weekday is reached within 7 steps unless the interval is whole weeks, a rule that
+		// misses BYDAY for longer never matches and would loop forever
+		misses := 0
+		for day := start; ; day = day.AddDate(0, 0, r.Interval) {
+			if len(byDay) > 0 && !containsWeekday(byDay, day.Weekday()) {
+				if r.Until != nil && day.After(*r.Until) {
+					return
+				}
+				if misses++; misses >= 7*r.Interval {
+					return
+				}
+				continue
+			}
+			misses = 0
+			if !emit(day) {
+				return
+			}
+		}
+	case Weekly:
+		weekStart := start.AddDate(0, 0, -((int(start.Weekday()) - int(r.WeekStart) + 7) % 7))
+		for week := weekStart; ; week = week.AddDate(0, 0, 7*r.Interval) {
+			for _, offset := range offsets {
+				if !emit(week.AddDate(0, 0, offset)) {
+					return
+				}
+			}
+		}
+	}
+}
+
+func containsWeekday(weekdays []time.Weekday, weekday time.Weekday) bool {
+	for _, w := range weekdays {
+		if w == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+// date drops the time of day, keeping the calendar date as midnight UTC
+func date(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
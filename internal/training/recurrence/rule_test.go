@@ -0,0 +1,195 @@
+package recurrence
+
+import (
+	"testing"
+	"time"
+)
+
+func day(value string) time.Time {
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func formatDates(dates []time.Time) []string {
+	formatted := make([]string, len(dates))
+	for i, d := range dates {
+		formatted[i] = d.Format(time.DateOnly)
+	}
+	return formatted
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestParse(t *testing.T) {
+	valid := map[string]string{
+		"FREQ=WEEKLY;BYDAY=MO,TH;COUNT=16":            "FREQ=WEEKLY;BYDAY=MO,TH;COUNT=16",
+		"RRULE:freq=weekly;interval=2;until=20250301": "FREQ=WEEKLY;INTERVAL=2;UNTIL=20250301",
+		"FREQ=DAILY;UNTIL=20250301T235959Z":           "FREQ=DAILY;UNTIL=20250301",
+		"FREQ=WEEKLY;WKST=SU;INTERVAL=1":              "FREQ=WEEKLY;WKST=SU",
+	}
+	for value, want := range valid {
+		rule, err := Parse(value)
+		if err != nil {
+			t.Errorf("Parse(%q) failed: %v", value, err)
+			continue
+		}
+		if got := rule.String(); got != want {
+			t.Errorf("Parse(%q).String() = %q, want %q", value, got, want)
+		}
+	}
+
+	invalid := []string{
+		"",
+		"BYDAY=MO",
+		"FREQ=MONTHLY",
+		"FREQ=WEEKLY;BYDAY=XX",
+		"FREQ=WEEKLY;COUNT=0",
+		"FREQ=WEEKLY;INTERVAL=100",
+		"FREQ=WEEKLY;COUNT=3;UNTIL=20250101",
+		"FREQ=WEEKLY;FREQ=DAILY",
+		"FREQ=WEEKLY;BYMONTH=1",
+	}
+	for _, value := range invalid {
+		if _, err := Parse(value); err == nil {
+			t.Errorf("Parse(%q) succeeded, want error", value)
+		}
+	}
+}
+
+func TestBetween(t *testing.T) {
+	tests := []struct {
+		rule     string
+		start    string
+		from, to string
+		want     []string
+	}{
+		{
+			// Starts on a Wednesday, so the Monday of the first week is skipped
+			rule: "FREQ=WEEKLY;BYDAY=MO,TH;COUNT=4", start: "2025-01-01", from: "2025-01-01", to: "2025-12-31",
+			want: []string{"2025-01-02", "2025-01-06", "2025-01-09", "2025-01-13"},
+		},
+		{
+			rule: "FREQ=WEEKLY;INTERVAL=2", start: "2025-01-06", from: "2025-01-15", to: "2025-02-10",
+			want: []string{"2025-01-20", "2025-02-03"},
+		},
+		{
+			rule: "FREQ=WEEKLY;BYDAY=SA;UNTIL=20250118", start: "2025-01-01", from: "2025-01-01", to: "2025-12-31",
+			want: []string{"2025-01-04", "2025-01-11", "2025-01-18"},
+		},
+		{
+			rule: "FREQ=DAILY;INTERVAL=3;COUNT=3", start: "2025-02-27", from: "2025-01-01", to: "2025-12-31",
+			want: []string{"2025-02-27", "2025-03-02", "2025-03-05"},
+		},
+		{
+			// Biweekly weeks are counted from the week of the start date
+			rule: "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR", start: "2025-01-03", from: "2025-01-01", to: "2025-01-31",
+			want: []string{"2025-01-03", "2025-01-13", "2025-01-17", "2025-01-27", "2025-01-31"},
+		},
+	}
+	for _, tt := range tests {
+		rule, err := Parse(tt.rule)
+		if err != nil {
+			t.Fatalf("Parse(%q) failed: %v", tt.rule, err)
+		}
+		got := formatDates(rule.Between(day(tt.start), day(tt.from), day(tt.to)))
+		if !equalStrings(got, tt.want) {
+			t.Errorf("%s from %s: Between(%s, %s) = %v, want %v", tt.rule, tt.start, tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestLastAndCountBefore(t *testing.T) {
+	rule, _ := Parse("FREQ=WEEKLY;BYDAY=MO,TH;COUNT=16")
+	start := day("2025-01-06")
+	last, ok := rule.Last(start)
+	if !ok || last.Format(time.DateOnly) != "2025-02-27" {
+		t.Errorf("Last = %v, %v, want 2025-02-27", last, ok)
+	}
+	if got := rule.CountBefore(start, day("2025-01-16")); got != 3 {
+		t.Errorf("CountBefore = %d, want 3", got)
+	}
+	if !rule.Includes(start, day("2025-01-16")) || rule.Includes(start, day("2025-01-17")) {
+		t.Error("Includes doesn't match the occurrences")
+	}
+
+	forever, _ := Parse("FREQ=DAILY")
+	if _, ok := forever.Last(start); ok {
+		t.Error("Last of an endless rule should report false")
+	}
+}
+
+func TestShift(t *testing.T) {
+	tests := []struct {
+		rule  string
+		start string
+		days  int
+	}{
+		{rule: "FREQ=WEEKLY;BYDAY=MO,TH;COUNT=6", start: "2025-01-01", days: 3},
+		// Saturday and Sunday move into different weeks of the original week start
+		{rule: "FREQ=WEEKLY;INTERVAL=2;BYDAY=SA,SU", start: "2025-01-04", days: 1},
+		{rule: "FREQ=WEEKLY;INTERVAL=3;BYDAY=MO,WE;UNTIL=20250401", start: "2025-01-06", days: -2},
+		{rule: "FREQ=DAILY;INTERVAL=2;BYDAY=MO,TU,WE", start: "2025-01-06", days: 5},
+	}
+	for _, tt := range tests {
+		rule, err := Parse(tt.rule)
+		if err != nil {
+			t.Fatalf("Parse(%q) failed: %v", tt.rule, err)
+		}
+		start := day(tt.start)
+		var want []time.Time
+		for _, occurrence := range rule.Between(start, start, start.AddDate(0, 6, 0)) {
+			want = append(want, occurrence.AddDate(0, 0, tt.days))
+		}
+		shiftedStart := start.AddDate(0, 0, tt.days)
+		got := rule.Shift(tt.days).Between(shiftedStart, shiftedStart, shiftedStart.AddDate(0, 6, 0))
+		if !equalStrings(formatDates(got), formatDates(want)) {
+			t.Errorf("%s shifted by %d = %v, want %v", tt.rule, tt.days, formatDates(got), formatDates(want))
+		}
+	}
+}
+
+func TestDailyWholeWeeks(t *testing.T) {
+	if _, err := Parse("FREQ=DAILY;INTERVAL=14;BYDAY=MO,TU"); err == nil {
+		t.Error("Parse of a whole-week DAILY rule with two weekdays succeeded, want error")
+	}
+	rule, err := Parse("FREQ=DAILY;INTERVAL=7;BYDAY=MO;COUNT=3")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	// 2024-01-02 is a Tuesday, stepping by weeks never reaches a Monday
+	tuesday := day("2024-01-02")
+	if err := rule.Validate(tuesday); err == nil {
+		t.Error("Validate from a Tuesday succeeded, want error")
+	}
+	if err := rule.Validate(day("2024-01-01")); err != nil {
+		t.Errorf("Validate from a Monday failed: %v", err)
+	}
+
+	done := make(chan []time.Time)
+	go func() {
+		last, _ := rule.Last(tuesday)
+		occurrences := rule.Between(tuesday, tuesday, day("2030-01-01"))
+		done <- append(occurrences, last)
+	}()
+	select {
+	case got := <-done:
+		if len(got) != 1 || !got[0].IsZero() {
+			t.Errorf("occurrences = %v, want none", formatDates(got[:len(got)-1]))
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expanding a rule that never matches doesn't end")
+	}
+}
@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	customerrors "github.com/VladimirKholomyanskyy/gym-api/internal/customErrors"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/training/model"
+	"gorm.io/gorm"
+)
+
+// ProgramScheduleRepository defines the interface for program schedule operations
+type ProgramScheduleRepository interface {
+	Create(ctx context.Context, schedule *model.ProgramSchedule) error
+	GetByID(ctx context.Context, id string) (*model.ProgramSchedule, error)
+	GetAllRollingForward(ctx context.Context, since time.Time) ([]model.ProgramSchedule, error)
+	Delete(ctx context.Context, id string) error
+	GetWorkoutSessionStarts(ctx context.Context, profileID string, workoutIDs []string, since time.Time) ([]model.WorkoutSessionStart, error)
+	UpdateRollForward(ctx context.Context, schedule *model.ProgramSchedule, dates map[string]time.Time) error
+}
+
+// programScheduleRepository implements ProgramScheduleRepository
+type programScheduleRepository struct {
+	db *gorm.DB
+}
+
+// NewProgramScheduleRepository creates a new instance of ProgramScheduleRepository
+func NewProgramScheduleRepository(db *gorm.DB) ProgramScheduleRepository {
+	return &programScheduleRepository{db: db}
+}
+
+// Create stores a program schedule together with its scheduled workouts in a single transaction
+func (r *programScheduleRepository) Create(ctx context.Context, schedule *model.ProgramSchedule) error {
+	if err := r.db.WithContext(ctx).Create(schedule).Error; err != nil {
+		return fmt.Errorf("failed to create program schedule: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves a program schedule with its scheduled workouts ordered by date
+func (r *programScheduleRepository) GetByID(ctx context.Context, id string) (*model.ProgramSchedule, error) {
+	var schedule model.ProgramSchedule
+	err := r.db.WithContext(ctx).
+		Preload("ScheduledWorkouts", orderByDate).
+		First(&schedule, "id = ?", id).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, customerrors.ErrEntityNotFound
+		}
+		return nil, fmt.Errorf("failed to fetch program schedule: %w", err)
+	}
+	return &schedule, nil
+}
+
+// GetAllRollingForward retrieves the schedules that roll missed workouts forward and still have
+// workouts scheduled on or after since, with their scheduled workouts ordered by date
+func (r *programScheduleRepository) GetAllRollingForward(ctx context.Context, since time.Time) ([]model.ProgramSchedule, error) {
+	var schedules []model.ProgramSchedule
+	err := r.db.WithContext(ctx).
+		Where("roll_forward").
+		Where("EXISTS (SELECT 1 FROM scheduled_workouts WHERE program_schedule_id = program_schedules.id AND date >= ?)", since.Format(time.DateOnly)).
+		// Schedules of deleted accounts stay as they are while they wait to be purged
+		Where("NOT EXISTS (SELECT 1 FROM profiles WHERE profiles.id = program_schedules.profile_id AND profiles.deleted_at IS NOT NULL)").
+		Preload("ScheduledWorkouts", orderByDate).
+		Find(&schedules).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch rolling program schedules: %w", err)
+	}
+	return schedules, nil
+}
+
+// Delete removes a program schedule, its scheduled workouts are removed with it
+func (r *programScheduleRepository) Delete(ctx context.Context, id string) error {
+	result := r.db.WithContext(ctx).
+		Where("id = ?", id).
+		Delete(&model.ProgramSchedule{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete program schedule: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return customerrors.ErrEntityNotFound
+	}
+	return nil
+}
+
+// GetWorkoutSessionStarts retrieves when the profile started sessions of the given workouts since a point in time
+func (r *programScheduleRepository) GetWorkoutSessionStarts(ctx context.Context, profileID string, workoutIDs []string, since time.Time) ([]model.WorkoutSessionStart, error) {
+	var starts []model.WorkoutSessionStart
+	err := r.db.WithContext(ctx).
+		Table("workout_sessions").
+		Select("workout_id, started_at").
+		Where("profile_id = ? AND workout_id IN ? AND started_at >= ?", profileID, workoutIDs, since).
+		Scan(&starts).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch workout session starts: %w", err)
+	}
+	return starts, nil
+}
+
+// UpdateRollForward saves the roll forward state of a schedule and moves its scheduled workouts,
+// keyed by ID, to new dates in a single transaction
+func (r *programScheduleRepository) UpdateRollForward(ctx context.Context, schedule *model.ProgramSchedule, dates map[string]time.Time) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(schedule).Updates(map[string]any{
+			"roll_forward":  schedule.RollForward,
+			"roll_forwards": schedule.RollForwards,
+		}).Error
+		if err != nil {
+			return fmt.Errorf("failed to update program schedule: %w", err)
+		}
+		for id, date := range dates {
+			err := tx.Model(&model.ScheduledWorkout{}).Where("id = ?", id).Update("date", date.Format(time.DateOnly)).Error
+			if err != nil {
+				return fmt.Errorf("failed to move scheduled workout: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+func orderByDate(db *gorm.DB) *gorm.DB {
+	return db.Order("date ASC")
+}
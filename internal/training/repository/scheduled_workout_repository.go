@@ -3,16 +3,19 @@ package repository
 import (
 	"context"
 	"fmt"
+	"sort"
 	"time"
 
 	customerrors "github.com/VladimirKholomyanskyy/gym-api/internal/customErrors"
 	"github.com/VladimirKholomyanskyy/gym-api/internal/training/model"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // ScheduledWorkoutRepository defines the interface for scheduled workout operations
 type ScheduledWorkoutRepository interface {
 	Create(ctx context.Context, scheduledWorkout *model.ScheduledWorkout) error
+	CreateAll(ctx context.Context, scheduledWorkouts []model.ScheduledWorkout) error
 	GetByID(ctx context.Context, id string) (*model.ScheduledWorkout, error)
 	GetAllByProfileID(ctx context.Context, profileID string, page, pageSize int) ([]model.ScheduledWorkout, int64, error)
 	GetAllByProfileIDAndDate(ctx context.Context, profileID string, date time.Time, page, pageSize int) ([]model.ScheduledWorkout, int64, error)
@@ -20,9 +23,20 @@ type ScheduledWorkoutRepository interface {
 	UpdatePartial(ctx context.Context, id string, updates map[string]any) (*model.ScheduledWorkout, error)
 	Delete(ctx context.Context, id string) error
 	PermanentDelete(ctx context.Context, id string) error
-	GetUpcomming(ctx context.Context, profileID string) (*model.ScheduledWorkout, error)
+	GetUpcomming(ctx context.Context, profileID string, today time.Time) (*model.ScheduledWorkout, error)
+	UpsertOverride(ctx context.Context, override *model.ScheduledWorkoutOverride) error
+	DeleteOverride(ctx context.Context, scheduledWorkoutID string, occurrenceDate time.Time) error
+	SplitSeries(ctx context.Context, id string, updates map[string]any, following *model.ScheduledWorkout, from time.Time) error
+	GetAllByProfileIDFrom(ctx context.Context, profileID string, from time.Time) ([]model.ScheduledWorkout, error)
+	GetProfileIDsWithPlanned(ctx context.Context, from, to time.Time) ([]string, error)
+	GetRollingForwardScheduleIDs(ctx context.Context, profileID string) ([]string, error)
+	GetWorkoutSessionStarts(ctx context.Context, profileID string, since time.Time) ([]model.WorkoutSessionStart, error)
+	ApplyChanges(ctx context.Context, changes *model.ScheduleChanges) error
 }
 
+// upcomingHorizonYears is how far ahead series are expanded when looking for the next scheduled workout
+const upcomingHorizonYears = 1
+
 // scheduledWorkoutRepository implements ScheduledWorkoutRepository
 type scheduledWorkoutRepository struct {
 	db *gorm.DB
@@ -41,11 +55,25 @@ func (r *scheduledWorkoutRepository) Create(ctx context.Context, scheduledWorkou
 	return nil
 }
 
+// CreateAll creates the scheduled workouts in one transaction, either all of them are created or none
+func (r *scheduledWorkoutRepository) CreateAll(ctx context.Context, scheduledWorkouts []model.ScheduledWorkout) error {
+	if len(scheduledWorkouts) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&scheduledWorkouts).Error; err != nil {
+			return fmt.Errorf("failed to create scheduled workouts: %w", err)
+		}
+		return nil
+	})
+}
+
 // GetScheduledWorkout retrieves a scheduled workout by ID
 func (r *scheduledWorkoutRepository) GetByID(ctx context.Context, id string) (*model.ScheduledWorkout, error) {
 	var scheduledWorkout model.ScheduledWorkout
 	err := r.db.WithContext(ctx).
 		Preload("Workout").
+		Preload("Overrides").
 		First(&scheduledWorkout, "id = ?", id).Error
 
 	if err != nil {
@@ -93,13 +121,13 @@ func (r *scheduledWorkoutRepository) GetAllByProfileIDAndDate(ctx context.Contex
 
 	countQuery := r.db.WithContext(ctx).
 		Model(&model.ScheduledWorkout{}).
-		Where("profile_id = ? AND date = ?", profileID, date)
+		Where("profile_id = ? AND date = ?", profileID, date.Format(time.DateOnly))
 	if err := countQuery.Count(&total).Error; err != nil {
 		return nil, 0, fmt.Errorf("failed to count scheduled workouts: %w", err)
 	}
 
 	err := r.db.WithContext(ctx).
-		Where("profile_id = ? AND date = ?", profileID, date).
+		Where("profile_id = ? AND date = ?", profileID, date.Format(time.DateOnly)).
 		Preload("Workout").
 		Limit(pageSize).
 		Offset(offset).
@@ -110,30 +138,34 @@ func (r *scheduledWorkoutRepository) GetAllByProfileIDAndDate(ctx context.Contex
 	return workouts, total, nil
 }
 
-// GetUserScheduledWorkoutsByRange retrieves paginated scheduled workouts for a user within a date range
+// GetAllByProfileIDAndRange retrieves paginated scheduled workouts for a user within a date range, both
+// inclusive. Series are expanded into their occurrences, so paging happens after the expansion.
 func (r *scheduledWorkoutRepository) GetAllByProfileIDAndRange(ctx context.Context, profileID string, startDate, endDate time.Time, page, pageSize int) ([]model.ScheduledWorkout, int64, error) {
 	var workouts []model.ScheduledWorkout
-	var total int64
-	offset := (page - 1) * pageSize
-
-	countQuery := r.db.WithContext(ctx).
-		Model(&model.ScheduledWorkout{}).
-		Where("profile_id = ? AND date BETWEEN ? AND ?", profileID, startDate, endDate)
-	if err := countQuery.Count(&total).Error; err != nil {
-		return nil, 0, fmt.Errorf("failed to count scheduled workouts: %w", err)
-	}
+	start, end := startDate.Format(time.DateOnly), endDate.Format(time.DateOnly)
 
 	err := r.db.WithContext(ctx).
-		Where("profile_id = ? AND date BETWEEN ? AND ?", profileID, startDate, endDate).
+		Where("profile_id = ?", profileID).
+		Where(r.db.
+			Where("recurrence_rule IS NULL AND date BETWEEN ? AND ?", start, end).
+			Or("recurrence_rule IS NOT NULL AND date <= ? AND (ends_on IS NULL OR ends_on >= ?)", end, start).
+			Or("id IN (SELECT scheduled_workout_id FROM scheduled_workout_overrides WHERE date BETWEEN ? AND ?)", start, end)).
 		Preload("Workout").
-		Order("date ASC").
-		Limit(pageSize).
-		Offset(offset).
+		Preload("Overrides").
 		Find(&workouts).Error
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to fetch scheduled workouts: %w", err)
 	}
-	return workouts, total, nil
+
+	var occurrences []model.ScheduledWorkout
+	for i := range workouts {
+		occurrences = append(occurrences, workouts[i].Expand(startDate, endDate)...)
+	}
+	sort.SliceStable(occurrences, func(i, j int) bool { return occurrences[i].Date.Before(occurrences[j].Date) })
+
+	total := int64(len(occurrences))
+	offset := min((page-1)*pageSize, len(occurrences))
+	return occurrences[offset:min(offset+pageSize, len(occurrences))], total, nil
 }
 
 // UpdateScheduledWorkout updates an existing scheduled workout
@@ -182,20 +214,29 @@ func (r *scheduledWorkoutRepository) PermanentDelete(ctx context.Context, id str
 	return nil
 }
 
-// GetClosestScheduledWorkout retrieves the scheduled workout that is closest to today's date
-func (r *scheduledWorkoutRepository) GetUpcomming(ctx context.Context, profileID string) (*model.ScheduledWorkout, error) {
-	var scheduledWorkout model.ScheduledWorkout
-	today := time.Now()
+// GetUpcomming retrieves the scheduled workout, or occurrence of a series, that is closest to today's date.
+// Today is the calendar date of the profile, dates are compared as plain dates so neither the
+// server nor the database time zone shifts them.
+func (r *scheduledWorkoutRepository) GetUpcomming(ctx context.Context, profileID string, today time.Time) (*model.ScheduledWorkout, error) {
+	occurrences, _, err := r.GetAllByProfileIDAndRange(ctx, profileID, today, today.AddDate(upcomingHorizonYears, 0, 0), 1, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(occurrences) > 0 {
+		return &occurrences[0], nil
+	}
 
+	// Nothing in the horizon, single workouts may still be scheduled further ahead
+	var scheduledWorkout model.ScheduledWorkout
 	result := r.db.WithContext(ctx).
-		Where("profile_id = ? AND date >= ?", profileID, today).
+		Where("profile_id = ? AND recurrence_rule IS NULL AND date >= ?", profileID, today.Format(time.DateOnly)).
 		Preload("Workout").
 		Order("date ASC").
 		Limit(1).
 		Find(&scheduledWorkout)
 
 	if result.Error != nil {
-		return nil, fmt.Errorf("failed to permanent delete profile: %w", result.Error)
+		return nil, fmt.Errorf("failed to fetch upcoming scheduled workout: %w", result.Error)
 	}
 	if result.RowsAffected == 0 {
 		return nil, customerrors.ErrEntityNotFound
@@ -203,3 +244,190 @@ func (r *scheduledWorkoutRepository) GetUpcomming(ctx context.Context, profileID
 
 	return &scheduledWorkout, nil
 }
+
+// UpsertOverride creates the override of an occurrence or replaces the existing one
+func (r *scheduledWorkoutRepository) UpsertOverride(ctx context.Context, override *model.ScheduledWorkoutOverride) error {
+	err := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "scheduled_workout_id"}, {Name: "occurrence_date"}},
+			DoUpdates: clause.AssignmentColumns([]string{"date", "skipped", "notes", "status", "updated_at"}),
+		}).
+		Create(override).Error
+	if err != nil {
+		return fmt.Errorf("failed to save scheduled workout override: %w", err)
+	}
+	return nil
+}
+
+// DeleteOverride removes the override of an occurrence, restoring it to the series
+func (r *scheduledWorkoutRepository) DeleteOverride(ctx context.Context, scheduledWorkoutID string, occurrenceDate time.Time) error {
+	result := r.db.WithContext(ctx).
+		Where("scheduled_workout_id = ? AND occurrence_date = ?", scheduledWorkoutID, occurrenceDate.Format(time.DateOnly)).
+		Delete(&model.ScheduledWorkoutOverride{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete scheduled workout override: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return customerrors.ErrEntityNotFound
+	}
+	return nil
+}
+
+// SplitSeries applies the updates ending a series before the occurrence on from and creates the
+// following series in a single transaction. Overrides from that occurrence on are dropped, the
+// following series starts without any.
+func (r *scheduledWorkoutRepository) SplitSeries(ctx context.Context, id string, updates map[string]any, following *model.ScheduledWorkout, from time.Time) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.ScheduledWorkout{}).Where("id = ?", id).Updates(updates).Error; err != nil {
+			return fmt.Errorf("failed to end scheduled workout series: %w", err)
+		}
+		err := tx.Where("scheduled_workout_id = ? AND occurrence_date >= ?", id, from.Format(time.DateOnly)).
+			Delete(&model.ScheduledWorkoutOverride{}).Error
+		if err != nil {
+			return fmt.Errorf("failed to delete scheduled workout overrides: %w", err)
+		}
+		if err := tx.Create(following).Error; err != nil {
+			return fmt.Errorf("failed to create following scheduled workout series: %w", err)
+		}
+		return nil
+	})
+}
+
+// GetAllByProfileIDFrom retrieves the scheduled workouts and series of a profile that have
+// occurrences on or after from, unexpanded and with their overrides
+func (r *scheduledWorkoutRepository) GetAllByProfileIDFrom(ctx context.Context, profileID string, from time.Time) ([]model.ScheduledWorkout, error) {
+	var workouts []model.ScheduledWorkout
+	start := from.Format(time.DateOnly)
+	err := r.db.WithContext(ctx).
+		Where("profile_id = ?", profileID).
+		Where(r.db.
+			Where("recurrence_rule IS NULL AND date >= ?", start).
+			Or("recurrence_rule IS NOT NULL AND (ends_on IS NULL OR ends_on >= ?)", start)).
+		Preload("Overrides").
+		Order("date ASC").
+		Find(&workouts).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch scheduled workouts: %w", err)
+	}
+	return workouts, nil
+}
+
+// GetProfileIDsWithPlanned retrieves the profiles that have workouts or series scheduled in a date
+// range, both inclusive. Single workouts count only while they are still planned.
+func (r *scheduledWorkoutRepository) GetProfileIDsWithPlanned(ctx context.Context, from, to time.Time) ([]string, error) {
+	var profileIDs []string
+	start, end := from.Format(time.DateOnly), to.Format(time.DateOnly)
+	err := r.db.WithContext(ctx).
+		Model(&model.ScheduledWorkout{}).
+		Distinct("profile_id").
+		Where(r.db.
+			Where("recurrence_rule IS NULL AND status = ? AND date BETWEEN ? AND ?", model.ScheduledWorkoutPlanned, start, end).
+			Or("recurrence_rule IS NOT NULL AND date <= ? AND (ends_on IS NULL OR ends_on >= ?)", end, start).
+			Or("id IN (SELECT scheduled_workout_id FROM scheduled_workout_overrides WHERE date BETWEEN ? AND ?)", start, end)).
+		// Missed workouts of deleted accounts aren't handled while they wait to be purged
+		Where("NOT EXISTS (SELECT 1 FROM profiles WHERE profiles.id = scheduled_workouts.profile_id AND profiles.deleted_at IS NOT NULL)").
+		Pluck("profile_id", &profileIDs).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch profiles with scheduled workouts: %w", err)
+	}
+	return profileIDs, nil
+}
+
+// GetRollingForwardScheduleIDs retrieves the program schedules of a profile that roll missed workouts forward
+func (r *scheduledWorkoutRepository) GetRollingForwardScheduleIDs(ctx context.Context, profileID string) ([]string, error) {
+	var ids []string
+	err := r.db.WithContext(ctx).
+		Model(&model.ProgramSchedule{}).
+		Where("profile_id = ? AND roll_forward", profileID).
+		Pluck("id", &ids).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch rolling program schedules: %w", err)
+	}
+	return ids, nil
+}
+
+// GetWorkoutSessionStarts retrieves when the profile started sessions since a point in time
+func (r *scheduledWorkoutRepository) GetWorkoutSessionStarts(ctx context.Context, profileID string, since time.Time) ([]model.WorkoutSessionStart, error) {
+	var starts []model.WorkoutSessionStart
+	err := r.db.WithContext(ctx).
+		Table("workout_sessions").
+		Select("workout_id, started_at").
+		Where("profile_id = ? AND started_at >= ?", profileID, since).
+		Scan(&starts).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch workout session starts: %w", err)
+	}
+	return starts, nil
+}
+
+// ApplyChanges stores a set of changes to scheduled workouts in a single transaction. Series shifts
+// take the overrides of the shifted occurrences along: they are deleted and created again on their
+// new occurrence dates, so overrides moving onto each other's dates never conflict.
+func (r *scheduledWorkoutRepository) ApplyChanges(ctx context.Context, changes *model.ScheduleChanges) error {
+	if changes.IsEmpty() {
+		return nil
+	}
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for id, updates := range changes.Updates {
+			if err := tx.Model(&model.ScheduledWorkout{}).Where("id = ?", id).Updates(updates).Error; err != nil {
+				return fmt.Errorf("failed to update scheduled workout: %w", err)
+			}
+		}
+		for i := range changes.Shifts {
+			if err := shiftSeries(tx, &changes.Shifts[i]); err != nil {
+				return err
+			}
+		}
+		for i := range changes.Overrides {
+			err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "scheduled_workout_id"}, {Name: "occurrence_date"}},
+				DoUpdates: clause.AssignmentColumns([]string{"date", "skipped", "notes", "status", "updated_at"}),
+			}).Create(&changes.Overrides[i]).Error
+			if err != nil {
+				return fmt.Errorf("failed to save scheduled workout override: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+func shiftSeries(tx *gorm.DB, shift *model.SeriesShift) error {
+	if err := tx.Model(&model.ScheduledWorkout{}).Where("id = ?", shift.ScheduledWorkoutID).Updates(shift.Updates).Error; err != nil {
+		return fmt.Errorf("failed to shift scheduled workout series: %w", err)
+	}
+	var overrides []model.ScheduledWorkoutOverride
+	err := tx.Where("scheduled_workout_id = ? AND occurrence_date >= ?", shift.ScheduledWorkoutID, shift.From.Format(time.DateOnly)).
+		Find(&overrides).Error
+	if err != nil {
+		return fmt.Errorf("failed to fetch scheduled workout overrides: %w", err)
+	}
+	seriesID := shift.ScheduledWorkoutID
+	if shift.Following != nil {
+		if err := tx.Create(shift.Following).Error; err != nil {
+			return fmt.Errorf("failed to create following scheduled workout series: %w", err)
+		}
+		seriesID = shift.Following.ID
+	}
+	if len(overrides) == 0 {
+		return nil
+	}
+	err = tx.Where("scheduled_workout_id = ? AND occurrence_date >= ?", shift.ScheduledWorkoutID, shift.From.Format(time.DateOnly)).
+		Delete(&model.ScheduledWorkoutOverride{}).Error
+	if err != nil {
+		return fmt.Errorf("failed to delete scheduled workout overrides: %w", err)
+	}
+	for i := range overrides {
+		override := &overrides[i]
+		override.ID = ""
+		override.ScheduledWorkoutID = seriesID
+		override.OccurrenceDate = override.OccurrenceDate.AddDate(0, 0, shift.Days)
+		if override.Date != nil {
+			date := override.Date.AddDate(0, 0, shift.Days)
+			override.Date = &date
+		}
+	}
+	if err := tx.Create(&overrides).Error; err != nil {
+		return fmt.Errorf("failed to move scheduled workout overrides: %w", err)
+	}
+	return nil
+}
@@ -14,6 +14,7 @@ type WorkoutRepository interface {
 	Create(ctx context.Context, workout *model.Workout) error
 	GetByID(ctx context.Context, id string) (*model.Workout, error)
 	GetAllByTrainingProgramID(ctx context.Context, id string, page, pageSize int) ([]model.Workout, int64, error)
+	ListByTrainingProgramID(ctx context.Context, programID string) ([]model.Workout, error)
 	UpdatePartial(ctx context.Context, id string, updates map[string]any) (*model.Workout, error)
 	Delete(ctx context.Context, id string) error
 	PermanentDelete(ctx context.Context, id string) error
@@ -99,6 +100,19 @@ func (r *workoutRepository) GetAllByTrainingProgramID(
 	return workouts, totalCount, nil
 }
 
+// ListByTrainingProgramID retrieves every workout of a training program ordered by position, without their exercises
+func (r *workoutRepository) ListByTrainingProgramID(ctx context.Context, programID string) ([]model.Workout, error) {
+	var workouts []model.Workout
+	err := r.db.WithContext(ctx).
+		Where("training_program_id = ?", programID).
+		Order("position ASC").
+		Find(&workouts).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch workouts: %w", err)
+	}
+	return workouts, nil
+}
+
 // Update modifies an existing workout.
 func (r *workoutRepository) UpdatePartial(ctx context.Context, id string, updates map[string]any) (*model.Workout, error) {
 	result := r.db.WithContext(ctx).Model(&model.Workout{}).Where("id = ?", id).Updates(updates)
@@ -0,0 +1,217 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/VladimirKholomyanskyy/gym-api/internal/account"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/auth"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/common"
+	customerrors "github.com/VladimirKholomyanskyy/gym-api/internal/customErrors"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/training/model"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/training/repository"
+)
+
+// rollForwardLookbackDays is how long after its last scheduled workout a schedule is still checked
+// for missed workouts
+const rollForwardLookbackDays = 7
+
+// maxRollForwards is how many days in a row a schedule rolls forward without any of its workouts
+// being trained before it stops rolling forward, its missed workouts are then handled like any other
+const maxRollForwards = 14
+
+type ProgramScheduleUseCase interface {
+	Schedule(ctx context.Context, input model.ScheduleProgramInput) (*model.ProgramSchedule, error)
+	GetByID(ctx context.Context, profileID, scheduleID string) (*model.ProgramSchedule, error)
+	Delete(ctx context.Context, profileID, scheduleID string) error
+	RollForward(ctx context.Context, now time.Time) error
+}
+
+type programScheduleUseCase struct {
+	repo          repository.ProgramScheduleRepository
+	workoutRepo   repository.WorkoutRepository
+	settingsRepo  account.SettingRepository
+	authorization *auth.Authorization
+}
+
+func NewProgramScheduleUseCase(repo repository.ProgramScheduleRepository, workoutRepo repository.WorkoutRepository, settingsRepo account.SettingRepository, authorization *auth.Authorization) ProgramScheduleUseCase {
+	return &programScheduleUseCase{repo: repo, workoutRepo: workoutRepo, settingsRepo: settingsRepo, authorization: authorization}
+}
+
+// Schedule lays the workouts of a program out on the chosen weekdays of the given number of weeks,
+// starting at the start date and cycling through the workouts by position
+func (uc *programScheduleUseCase) Schedule(ctx context.Context, input model.ScheduleProgramInput) (*model.ProgramSchedule, error) {
+	if err := uc.authorization.CanModifyTrainingProgram(ctx, input.ProfileID, input.TrainingProgramID); err != nil {
+		return nil, err
+	}
+	workouts, err := uc.workoutRepo.ListByTrainingProgramID(ctx, input.TrainingProgramID)
+	if err != nil {
+		return nil, err
+	}
+	if len(workouts) == 0 {
+		return nil, customerrors.ErrProgramHasNoWorkouts
+	}
+
+	schedule := &model.ProgramSchedule{
+		ProfileID:         input.ProfileID,
+		TrainingProgramID: input.TrainingProgramID,
+		StartDate:         input.StartDate,
+		Weeks:             input.Weeks,
+		RollForward:       input.RollForward,
+	}
+	weekdays := make(map[time.Weekday]bool, len(input.Weekdays))
+	for _, weekday := range input.Weekdays {
+		if !weekdays[weekday] {
+			weekdays[weekday] = true
+			schedule.Weekdays = append(schedule.Weekdays, strings.ToLower(weekday.String()))
+		}
+	}
+	end := input.StartDate.AddDate(0, 0, 7*input.Weeks)
+	for date := input.StartDate; date.Before(end); date = date.AddDate(0, 0, 1) {
+		if !weekdays[date.Weekday()] {
+			continue
+		}
+		workout := workouts[len(schedule.ScheduledWorkouts)%len(workouts)]
+		schedule.ScheduledWorkouts = append(schedule.ScheduledWorkouts, model.ScheduledWorkout{
+			ProfileID: input.ProfileID,
+			WorkoutID: workout.ID,
+			Date:      date,
+		})
+	}
+	if err := uc.repo.Create(ctx, schedule); err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+// GetByID retrieves a program schedule ensuring it belongs to the profile
+func (uc *programScheduleUseCase) GetByID(ctx context.Context, profileID, scheduleID string) (*model.ProgramSchedule, error) {
+	schedule, err := uc.repo.GetByID(ctx, scheduleID)
+	if err != nil {
+		return nil, err
+	}
+	if schedule.ProfileID != profileID {
+		return nil, customerrors.ErrAccessForbidden
+	}
+	return schedule, nil
+}
+
+// Delete removes a program schedule and the workouts it scheduled
+func (uc *programScheduleUseCase) Delete(ctx context.Context, profileID, scheduleID string) error {
+	if _, err := uc.GetByID(ctx, profileID, scheduleID); err != nil {
+		return err
+	}
+	return uc.repo.Delete(ctx, scheduleID)
+}
+
+// RollForward moves missed workouts of the schedules that roll forward. A failing schedule is
+// logged and skipped so it doesn't hold back the others.
+func (uc *programScheduleUseCase) RollForward(ctx context.Context, now time.Time) error {
+	schedules, err := uc.repo.GetAllRollingForward(ctx, now.AddDate(0, 0, -rollForwardLookbackDays))
+	if err != nil {
+		return err
+	}
+	for i := range schedules {
+		if err := uc.rollForward(ctx, &schedules[i], now); err != nil {
+			log.Printf("Failed to roll forward program schedule %s: %v", schedules[i].ID, err)
+		}
+	}
+	return nil
+}
+
+// rollForward finds the first workout of a schedule that is in the past of the profile and wasn't
+// trained on its date or later. That workout and every later one that isn't done yet move, in order,
+// to the schedule's weekdays from today on, skipping days already taken by trained workouts. A
+// schedule that keeps rolling forward without being trained stops rolling forward.
+func (uc *programScheduleUseCase) rollForward(ctx context.Context, schedule *model.ProgramSchedule, now time.Time) error {
+	if len(schedule.ScheduledWorkouts) == 0 {
+		return nil
+	}
+	location, err := uc.location(ctx, schedule.ProfileID)
+	if err != nil {
+		return err
+	}
+	today := common.CalendarDate(now, location)
+
+	workoutIDs := make([]string, 0, len(schedule.ScheduledWorkouts))
+	for _, scheduled := range schedule.ScheduledWorkouts {
+		workoutIDs = append(workoutIDs, scheduled.WorkoutID)
+	}
+	first := schedule.ScheduledWorkouts[0].Date
+	starts, err := uc.repo.GetWorkoutSessionStarts(ctx, schedule.ProfileID, workoutIDs, common.StartOfDay(first.AddDate(0, 0, -1), time.UTC))
+	if err != nil {
+		return err
+	}
+	trained := trainedWorkouts(schedule.ScheduledWorkouts, starts, location)
+
+	var pending []model.ScheduledWorkout
+	taken := make(map[string]bool)
+	for i, scheduled := range schedule.ScheduledWorkouts {
+		switch {
+		case trained[i]:
+			taken[scheduled.Date.Format(time.DateOnly)] = true
+		case len(pending) > 0 || scheduled.Date.Before(today):
+			pending = append(pending, scheduled)
+		}
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	weekdays := make(map[time.Weekday]bool, len(schedule.Weekdays))
+	for _, name := range schedule.Weekdays {
+		if weekday, ok := account.ParseWeekday(name); ok {
+			weekdays[weekday] = true
+		}
+	}
+	if len(weekdays) == 0 {
+		return fmt.Errorf("program schedule has no valid weekdays")
+	}
+	dates := make(map[string]time.Time)
+	date := today
+	for _, scheduled := range pending {
+		for !weekdays[date.Weekday()] || taken[date.Format(time.DateOnly)] {
+			date = date.AddDate(0, 0, 1)
+		}
+		if !date.Equal(scheduled.Date) {
+			dates[scheduled.ID] = date
+		}
+		date = date.AddDate(0, 0, 1)
+	}
+	if len(dates) == 0 {
+		return nil
+	}
+
+	// The schedule is saved on every roll-forward, so a session started after its last update
+	// means it is being trained again
+	for _, start := range starts {
+		if start.StartedAt.After(schedule.UpdatedAt) {
+			schedule.RollForwards = 0
+			break
+		}
+	}
+	if schedule.RollForwards >= maxRollForwards {
+		log.Printf("Program schedule %s rolled forward %d times without training, it stops rolling forward", schedule.ID, schedule.RollForwards)
+		schedule.RollForward = false
+		schedule.RollForwards = 0
+		return uc.repo.UpdateRollForward(ctx, schedule, nil)
+	}
+	schedule.RollForwards++
+	return uc.repo.UpdateRollForward(ctx, schedule, dates)
+}
+
+// location returns the time zone of the profile, UTC when it has no settings
+func (uc *programScheduleUseCase) location(ctx context.Context, profileID string) (*time.Location, error) {
+	settings, err := uc.settingsRepo.GetByProfileID(ctx, profileID)
+	if err != nil {
+		if errors.Is(err, customerrors.ErrEntityNotFound) {
+			return time.UTC, nil
+		}
+		return nil, err
+	}
+	return settings.Location(), nil
+}
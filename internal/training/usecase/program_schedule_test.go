@@ -0,0 +1,191 @@
+package usecase
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/VladimirKholomyanskyy/gym-api/internal/account"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/common"
+	customerrors "github.com/VladimirKholomyanskyy/gym-api/internal/customErrors"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/training/model"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/training/repository"
+)
+
+type fakeProgramScheduleRepository struct {
+	repository.ProgramScheduleRepository
+	schedules []model.ProgramSchedule
+	starts    []model.WorkoutSessionStart
+	updated   *model.ProgramSchedule
+	dates     map[string]time.Time
+}
+
+func (r *fakeProgramScheduleRepository) GetAllRollingForward(ctx context.Context, since time.Time) ([]model.ProgramSchedule, error) {
+	return r.schedules, nil
+}
+
+func (r *fakeProgramScheduleRepository) GetWorkoutSessionStarts(ctx context.Context, profileID string, workoutIDs []string, since time.Time) ([]model.WorkoutSessionStart, error) {
+	return r.starts, nil
+}
+
+func (r *fakeProgramScheduleRepository) UpdateRollForward(ctx context.Context, schedule *model.ProgramSchedule, dates map[string]time.Time) error {
+	updated := *schedule
+	r.updated = &updated
+	r.dates = dates
+	return nil
+}
+
+type fakeSettingRepository struct {
+	account.SettingRepository
+	settings map[string]*account.Setting
+}
+
+func (r *fakeSettingRepository) GetByProfileID(ctx context.Context, profileID string) (*account.Setting, error) {
+	setting, ok := r.settings[profileID]
+	if !ok {
+		return nil, customerrors.ErrEntityNotFound
+	}
+	return setting, nil
+}
+
+func date(value string) time.Time {
+	parsed, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}
+
+func started(workoutID, value string) model.WorkoutSessionStart {
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		panic(err)
+	}
+	return model.WorkoutSessionStart{WorkoutID: workoutID, StartedAt: parsed}
+}
+
+func TestTrainedWorkouts(t *testing.T) {
+	scheduled := []model.ScheduledWorkout{
+		{WorkoutID: "a", Date: date("2026-10-05")},
+		{WorkoutID: "b", Date: date("2026-10-07")},
+		{WorkoutID: "a", Date: date("2026-10-09")},
+	}
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name     string
+		starts   []model.WorkoutSessionStart
+		location *time.Location
+		want     map[int]bool
+	}{
+		{"nothing trained", nil, time.UTC, map[int]bool{}},
+		{"on the date", []model.WorkoutSessionStart{started("a", "2026-10-05T18:00:00Z"), started("b", "2026-10-07T18:00:00Z")}, time.UTC, map[int]bool{0: true, 1: true}},
+		{"a day late", []model.WorkoutSessionStart{started("b", "2026-10-08T18:00:00Z")}, time.UTC, map[int]bool{1: true}},
+		{"before the date", []model.WorkoutSessionStart{started("b", "2026-10-06T18:00:00Z")}, time.UTC, map[int]bool{}},
+		{"one session trains one date", []model.WorkoutSessionStart{started("a", "2026-10-09T18:00:00Z")}, time.UTC, map[int]bool{0: true}},
+		{"two sessions train both dates", []model.WorkoutSessionStart{started("a", "2026-10-10T18:00:00Z"), started("a", "2026-10-06T18:00:00Z")}, time.UTC, map[int]bool{0: true, 2: true}},
+		{"local date of the profile", []model.WorkoutSessionStart{started("b", "2026-10-06T22:30:00Z")}, berlin, map[int]bool{1: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := trainedWorkouts(scheduled, tt.starts, tt.location)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("trainedWorkouts() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRollForward(t *testing.T) {
+	now := time.Date(2026, 10, 8, 12, 0, 0, 0, time.UTC) // a Thursday
+	schedule := func(rollForwards int, updatedAt time.Time) model.ProgramSchedule {
+		return model.ProgramSchedule{
+			Base:         common.Base{ID: "schedule", UpdatedAt: updatedAt},
+			ProfileID:    "profile",
+			Weekdays:     []string{"monday", "wednesday", "friday"},
+			RollForward:  true,
+			RollForwards: rollForwards,
+			ScheduledWorkouts: []model.ScheduledWorkout{
+				{Base: common.Base{ID: "mon"}, WorkoutID: "a", Date: date("2026-10-05")},
+				{Base: common.Base{ID: "wed"}, WorkoutID: "b", Date: date("2026-10-07")},
+				{Base: common.Base{ID: "fri"}, WorkoutID: "a", Date: date("2026-10-09")},
+			},
+		}
+	}
+	tests := []struct {
+		name             string
+		schedule         model.ProgramSchedule
+		starts           []model.WorkoutSessionStart
+		wantUpdate       bool
+		wantRollForward  bool
+		wantRollForwards int
+		wantDates        map[string]time.Time
+	}{
+		{
+			name:     "all trained",
+			schedule: schedule(0, now.AddDate(0, 0, -7)),
+			starts:   []model.WorkoutSessionStart{started("a", "2026-10-05T18:00:00Z"), started("b", "2026-10-07T18:00:00Z")},
+		},
+		{
+			name:     "trained a day late",
+			schedule: schedule(0, now.AddDate(0, 0, -7)),
+			starts:   []model.WorkoutSessionStart{started("a", "2026-10-06T18:00:00Z"), started("b", "2026-10-08T08:00:00Z")},
+		},
+		{
+			name:             "missed workout moves the later ones",
+			schedule:         schedule(0, now.AddDate(0, 0, -7)),
+			starts:           []model.WorkoutSessionStart{started("a", "2026-10-06T18:00:00Z")},
+			wantUpdate:       true,
+			wantRollForward:  true,
+			wantRollForwards: 1,
+			wantDates:        map[string]time.Time{"wed": date("2026-10-09"), "fri": date("2026-10-12")},
+		},
+		{
+			name:             "counts roll-forwards without training",
+			schedule:         schedule(3, now.AddDate(0, 0, -1)),
+			starts:           []model.WorkoutSessionStart{started("a", "2026-10-05T18:00:00Z")},
+			wantUpdate:       true,
+			wantRollForward:  true,
+			wantRollForwards: 4,
+			wantDates:        map[string]time.Time{"wed": date("2026-10-09"), "fri": date("2026-10-12")},
+		},
+		{
+			name:             "training since the last roll-forward resets the count",
+			schedule:         schedule(maxRollForwards, now.AddDate(0, 0, -3)),
+			starts:           []model.WorkoutSessionStart{started("a", "2026-10-06T18:00:00Z")},
+			wantUpdate:       true,
+			wantRollForward:  true,
+			wantRollForwards: 1,
+			wantDates:        map[string]time.Time{"wed": date("2026-10-09"), "fri": date("2026-10-12")},
+		},
+		{
+			name:       "stops rolling forward without training",
+			schedule:   schedule(maxRollForwards, now.AddDate(0, 0, -1)),
+			wantUpdate: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeProgramScheduleRepository{schedules: []model.ProgramSchedule{tt.schedule}, starts: tt.starts}
+			uc := NewProgramScheduleUseCase(repo, nil, &fakeSettingRepository{}, nil)
+			if err := uc.RollForward(context.Background(), now); err != nil {
+				t.Fatal(err)
+			}
+			if (repo.updated != nil) != tt.wantUpdate {
+				t.Fatalf("schedule updated = %v, want %v", repo.updated != nil, tt.wantUpdate)
+			}
+			if repo.updated == nil {
+				return
+			}
+			if repo.updated.RollForward != tt.wantRollForward || repo.updated.RollForwards != tt.wantRollForwards {
+				t.Errorf("RollForward, RollForwards = %v, %d, want %v, %d", repo.updated.RollForward, repo.updated.RollForwards, tt.wantRollForward, tt.wantRollForwards)
+			}
+			if !reflect.DeepEqual(repo.dates, tt.wantDates) {
+				t.Errorf("dates = %v, want %v", repo.dates, tt.wantDates)
+			}
+		})
+	}
+}
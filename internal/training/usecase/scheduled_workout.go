@@ -2,37 +2,86 @@ package usecase
 
 import (
 	"context"
+	"errors"
+	"log"
+	"sort"
 	"time"
 
+	"github.com/VladimirKholomyanskyy/gym-api/internal/account"
 	"github.com/VladimirKholomyanskyy/gym-api/internal/auth"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/common"
 	customerrors "github.com/VladimirKholomyanskyy/gym-api/internal/customErrors"
 	"github.com/VladimirKholomyanskyy/gym-api/internal/training/model"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/training/recurrence"
 	"github.com/VladimirKholomyanskyy/gym-api/internal/training/repository"
 )
 
 type ScheduledWorkoutUseCase interface {
 	Create(ctx context.Context, input model.CreateScheduledWorkoutInput) (*model.ScheduledWorkout, error)
+	CreateAll(ctx context.Context, inputs []model.CreateScheduledWorkoutInput) ([]model.ScheduledWorkout, error)
 	GetByID(ctx context.Context, profileId, scheduledWorkoutId string) (*model.ScheduledWorkout, error)
 	List(ctx context.Context, profileID string, startDate, endDate time.Time, page, pageSize int) ([]model.ScheduledWorkout, int64, error)
 	Update(ctx context.Context, input model.UpdateScheduledWorkoutInput) (*model.ScheduledWorkout, error)
 	Delete(ctx context.Context, profileId, scheduledWorkoutId string) error
-	GetUpcommingScheduledWorkout(ctx context.Context, profileID string) (*model.ScheduledWorkout, error)
+	GetUpcommingScheduledWorkout(ctx context.Context, profileID string, today time.Time) (*model.ScheduledWorkout, error)
+	UpdateOccurrence(ctx context.Context, input model.UpdateOccurrenceInput) (*model.ScheduledWorkout, error)
+	ResetOccurrence(ctx context.Context, profileID, scheduledWorkoutID string, occurrenceDate time.Time) error
+	UpdateFollowing(ctx context.Context, input model.UpdateScheduledWorkoutInput, occurrenceDate time.Time) (*model.ScheduledWorkout, error)
+	Move(ctx context.Context, input model.MoveScheduledWorkoutInput) error
+	HandleMissed(ctx context.Context, now time.Time) error
 }
 
+const (
+	// missedLookbackDays is how far back the missed workout job looks for workouts that weren't trained
+	missedLookbackDays = 7
+	// freeDayHorizonDays is how far ahead a missed workout may be pushed to find a free day
+	freeDayHorizonDays = 14
+)
+
 type scheduledWorkoutUseCase struct {
 	repo          repository.ScheduledWorkoutRepository
+	settingsRepo  account.SettingRepository
 	authorization *auth.Authorization
 }
 
 // NewExerciseUseCase creates a new instance of ExerciseUseCase
-func NewScheduledWorkoutUseCase(repo repository.ScheduledWorkoutRepository, authorization *auth.Authorization) ScheduledWorkoutUseCase {
+func NewScheduledWorkoutUseCase(repo repository.ScheduledWorkoutRepository, settingsRepo account.SettingRepository, authorization *auth.Authorization) ScheduledWorkoutUseCase {
 	return &scheduledWorkoutUseCase{
 		repo:          repo,
+		settingsRepo:  settingsRepo,
 		authorization: authorization,
 	}
 }
 
 func (uc *scheduledWorkoutUseCase) Create(ctx context.Context, input model.CreateScheduledWorkoutInput) (*model.ScheduledWorkout, error) {
+	scheduledWorkout, err := uc.newScheduledWorkout(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.repo.Create(ctx, scheduledWorkout); err != nil {
+		return nil, err
+	}
+	return scheduledWorkout, nil
+}
+
+// CreateAll schedules several workouts at once. Every input is checked before anything is stored and
+// the workouts are stored together, so a failure leaves the schedule untouched.
+func (uc *scheduledWorkoutUseCase) CreateAll(ctx context.Context, inputs []model.CreateScheduledWorkoutInput) ([]model.ScheduledWorkout, error) {
+	scheduledWorkouts := make([]model.ScheduledWorkout, 0, len(inputs))
+	for _, input := range inputs {
+		scheduledWorkout, err := uc.newScheduledWorkout(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+		scheduledWorkouts = append(scheduledWorkouts, *scheduledWorkout)
+	}
+	if err := uc.repo.CreateAll(ctx, scheduledWorkouts); err != nil {
+		return nil, err
+	}
+	return scheduledWorkouts, nil
+}
+
+func (uc *scheduledWorkoutUseCase) newScheduledWorkout(ctx context.Context, input model.CreateScheduledWorkoutInput) (*model.ScheduledWorkout, error) {
 	if err := uc.authorization.CanModifyWorkout(ctx, input.ProfileID, input.WorkoutID); err != nil {
 		return nil, customerrors.ErrAccessForbidden
 	}
@@ -42,8 +91,13 @@ func (uc *scheduledWorkoutUseCase) Create(ctx context.Context, input model.Creat
 		Date:      input.Date,
 		Notes:     input.Notes,
 	}
-	if err := uc.repo.Create(ctx, scheduledWorkout); err != nil {
-		return nil, err
+	if input.Recurrence != nil {
+		if err := input.Recurrence.Validate(input.Date); err != nil {
+			return nil, err
+		}
+		rule := input.Recurrence.String()
+		scheduledWorkout.RecurrenceRule = &rule
+		scheduledWorkout.EndsOn = endsOn(input.Recurrence, input.Date)
 	}
 	return scheduledWorkout, nil
 }
@@ -65,19 +119,40 @@ func (uc *scheduledWorkoutUseCase) GetByID(ctx context.Context, profileID, sched
 	return scheduledWorkout, nil
 }
 
-// UpdateScheduledWorkout updates a scheduled workout.
+// UpdateScheduledWorkout updates a scheduled workout. For a series the changes apply to all of its
+// occurrences, changing the date moves the start of the series.
 func (uc *scheduledWorkoutUseCase) Update(ctx context.Context, input model.UpdateScheduledWorkoutInput) (*model.ScheduledWorkout, error) {
 	scheduledWorkout, err := uc.GetByID(ctx, input.ProfileID, input.ScheduledWorkoutID)
 	if err != nil {
 		return nil, err
 	}
 	updates := make(map[string]any)
+	date := scheduledWorkout.Date
 	if input.Date != nil {
-		updates["date"] = input.Date
+		updates["date"] = *input.Date
+		date = *input.Date
 	}
 	if input.Notes != nil {
 		updates["notes"] = *input.Notes
 	}
+	rule, err := scheduledWorkout.Rule()
+	if err != nil {
+		return nil, err
+	}
+	if input.Recurrence != nil {
+		rule = input.Recurrence
+	}
+	switch {
+	case input.ClearRecurrence:
+		updates["recurrence_rule"] = nil
+		updates["ends_on"] = nil
+	case rule != nil && (input.Recurrence != nil || input.Date != nil):
+		if err := rule.Validate(date); err != nil {
+			return nil, err
+		}
+		updates["recurrence_rule"] = rule.String()
+		updates["ends_on"] = endsOn(rule, date)
+	}
 	if len(updates) == 0 {
 		return scheduledWorkout, nil
 	}
@@ -93,7 +168,408 @@ func (uc *scheduledWorkoutUseCase) Delete(ctx context.Context, profileID, schedu
 	return uc.repo.Delete(ctx, scheduledWorkoutID)
 }
 
-// GetUpcommingScheduledWorkout implements ScheduledWorkoutUseCase.
-func (uc *scheduledWorkoutUseCase) GetUpcommingScheduledWorkout(ctx context.Context, profileID string) (*model.ScheduledWorkout, error) {
-	return uc.repo.GetUpcomming(ctx, profileID)
+// GetUpcommingScheduledWorkout returns the first workout scheduled on or after today, the calendar date of the profile.
+func (uc *scheduledWorkoutUseCase) GetUpcommingScheduledWorkout(ctx context.Context, profileID string, today time.Time) (*model.ScheduledWorkout, error) {
+	return uc.repo.GetUpcomming(ctx, profileID, today)
+}
+
+// UpdateOccurrence moves, skips or annotates a single occurrence of a series. Changes are merged
+// into the existing override of the occurrence.
+func (uc *scheduledWorkoutUseCase) UpdateOccurrence(ctx context.Context, input model.UpdateOccurrenceInput) (*model.ScheduledWorkout, error) {
+	scheduledWorkout, err := uc.getOccurrenceSeries(ctx, input.ProfileID, input.ScheduledWorkoutID, input.OccurrenceDate)
+	if err != nil {
+		return nil, err
+	}
+	override := scheduledWorkout.FindOverride(input.OccurrenceDate)
+	if override == nil {
+		override = &model.ScheduledWorkoutOverride{ScheduledWorkoutID: scheduledWorkout.ID, OccurrenceDate: input.OccurrenceDate}
+	}
+	if input.Date != nil {
+		override.Date = input.Date
+	}
+	if input.Skipped != nil {
+		override.Skipped = *input.Skipped
+	}
+	if input.Notes != nil {
+		override.Notes = input.Notes
+	}
+	if err := uc.repo.UpsertOverride(ctx, override); err != nil {
+		return nil, err
+	}
+	occurrence := scheduledWorkout.Occurrence(input.OccurrenceDate, override)
+	return &occurrence, nil
+}
+
+// ResetOccurrence drops the override of an occurrence, so it follows the series again
+func (uc *scheduledWorkoutUseCase) ResetOccurrence(ctx context.Context, profileID, scheduledWorkoutID string, occurrenceDate time.Time) error {
+	if _, err := uc.getOccurrenceSeries(ctx, profileID, scheduledWorkoutID, occurrenceDate); err != nil {
+		return err
+	}
+	return uc.repo.DeleteOverride(ctx, scheduledWorkoutID, occurrenceDate)
+}
+
+// UpdateFollowing changes the occurrence on occurrenceDate and all occurrences after it. The series
+// is ended the day before and a new series with the changes applied takes over from that occurrence,
+// keeping the remaining count of a counted rule. Editing from the first occurrence updates the whole series.
+func (uc *scheduledWorkoutUseCase) UpdateFollowing(ctx context.Context, input model.UpdateScheduledWorkoutInput, occurrenceDate time.Time) (*model.ScheduledWorkout, error) {
+	scheduledWorkout, err := uc.getOccurrenceSeries(ctx, input.ProfileID, input.ScheduledWorkoutID, occurrenceDate)
+	if err != nil {
+		return nil, err
+	}
+	if !occurrenceDate.After(scheduledWorkout.Date) {
+		return uc.Update(ctx, input)
+	}
+	rule, err := scheduledWorkout.Rule()
+	if err != nil {
+		return nil, err
+	}
+
+	until := occurrenceDate.AddDate(0, 0, -1)
+	ended := *rule
+	ended.Count = 0
+	ended.Until = &until
+	updates := map[string]any{"recurrence_rule": ended.String(), "ends_on": endsOn(&ended, scheduledWorkout.Date)}
+
+	following := &model.ScheduledWorkout{
+		ProfileID: scheduledWorkout.ProfileID,
+		WorkoutID: scheduledWorkout.WorkoutID,
+		Date:      occurrenceDate,
+		Notes:     scheduledWorkout.Notes,
+	}
+	if input.Date != nil {
+		following.Date = *input.Date
+	}
+	if input.Notes != nil {
+		following.Notes = *input.Notes
+	}
+	if !input.ClearRecurrence {
+		followingRule := *rule
+		if input.Recurrence != nil {
+			followingRule = *input.Recurrence
+		} else if rule.Count > 0 {
+			followingRule.Count = rule.Count - rule.CountBefore(scheduledWorkout.Date, occurrenceDate)
+		}
+		if err := followingRule.Validate(following.Date); err != nil {
+			return nil, err
+		}
+		recurrenceRule := followingRule.String()
+		following.RecurrenceRule = &recurrenceRule
+		following.EndsOn = endsOn(&followingRule, following.Date)
+	}
+	if err := uc.repo.SplitSeries(ctx, scheduledWorkout.ID, updates, following, occurrenceDate); err != nil {
+		return nil, err
+	}
+	return following, nil
+}
+
+// Move moves a scheduled workout, or an occurrence of a series, by a number of days. A series
+// without an occurrence date moves from its first occurrence. With Following every later workout
+// of the profile moves along: single workouts on or after the moved date, series starting after it
+// as a whole and series running through it are split so their following occurrences move. All
+// changes are stored together.
+func (uc *scheduledWorkoutUseCase) Move(ctx context.Context, input model.MoveScheduledWorkoutInput) error {
+	scheduledWorkout, err := uc.GetByID(ctx, input.ProfileID, input.ScheduledWorkoutID)
+	if err != nil {
+		return err
+	}
+	if input.Days == 0 {
+		return nil
+	}
+	if scheduledWorkout.RecurrenceRule != nil && input.OccurrenceDate == nil {
+		input.OccurrenceDate = &scheduledWorkout.Date
+	}
+	if input.OccurrenceDate != nil {
+		scheduledWorkout, err = uc.getOccurrenceSeries(ctx, input.ProfileID, input.ScheduledWorkoutID, *input.OccurrenceDate)
+		if err != nil {
+			return err
+		}
+	}
+
+	changes := &model.ScheduleChanges{}
+	if !input.Following {
+		if input.OccurrenceDate == nil {
+			moveOccurrence(changes, scheduledWorkout, scheduledWorkout, scheduledWorkout.Date.AddDate(0, 0, input.Days))
+		} else {
+			occurrence := scheduledWorkout.Occurrence(*input.OccurrenceDate, scheduledWorkout.FindOverride(*input.OccurrenceDate))
+			moveOccurrence(changes, scheduledWorkout, &occurrence, occurrence.Date.AddDate(0, 0, input.Days))
+		}
+		return uc.repo.ApplyChanges(ctx, changes)
+	}
+
+	from := scheduledWorkout.Date
+	if input.OccurrenceDate != nil {
+		from = scheduledWorkout.Occurrence(*input.OccurrenceDate, scheduledWorkout.FindOverride(*input.OccurrenceDate)).Date
+	}
+	workouts, err := uc.repo.GetAllByProfileIDFrom(ctx, input.ProfileID, from)
+	if err != nil {
+		return err
+	}
+	for i := range workouts {
+		workout := &workouts[i]
+		seriesFrom := from
+		if workout.ID == scheduledWorkout.ID && input.OccurrenceDate != nil {
+			seriesFrom = *input.OccurrenceDate
+		}
+		if err := shiftFollowing(changes, workout, seriesFrom, input.Days); err != nil {
+			return err
+		}
+	}
+	return uc.repo.ApplyChanges(ctx, changes)
+}
+
+// shiftFollowing adds the changes moving the occurrences of a scheduled workout on or after from by days
+func shiftFollowing(changes *model.ScheduleChanges, scheduledWorkout *model.ScheduledWorkout, from time.Time, days int) error {
+	rule, err := scheduledWorkout.Rule()
+	if err != nil {
+		return err
+	}
+	if rule == nil {
+		if !scheduledWorkout.Date.Before(from) {
+			changes.Update(scheduledWorkout.ID, "date", scheduledWorkout.Date.AddDate(0, 0, days))
+		}
+		return nil
+	}
+
+	shifted := rule.Shift(days)
+	if !from.After(scheduledWorkout.Date) {
+		start := scheduledWorkout.Date.AddDate(0, 0, days)
+		changes.Shifts = append(changes.Shifts, model.SeriesShift{
+			ScheduledWorkoutID: scheduledWorkout.ID,
+			From:               scheduledWorkout.Date,
+			Days:               days,
+			Updates:            map[string]any{"date": start, "recurrence_rule": shifted.String(), "ends_on": endsOn(shifted, start)},
+		})
+		return nil
+	}
+
+	// The series runs through from, it ends before its next occurrence and a shifted copy takes over
+	next, ok := nextOccurrence(rule, scheduledWorkout.Date, from)
+	if !ok {
+		return nil
+	}
+	until := next.AddDate(0, 0, -1)
+	ended := *rule
+	ended.Count = 0
+	ended.Until = &until
+	if rule.Count > 0 {
+		shifted.Count = rule.Count - rule.CountBefore(scheduledWorkout.Date, next)
+	}
+	start := next.AddDate(0, 0, days)
+	recurrenceRule := shifted.String()
+	changes.Shifts = append(changes.Shifts, model.SeriesShift{
+		ScheduledWorkoutID: scheduledWorkout.ID,
+		From:               next,
+		Days:               days,
+		Updates:            map[string]any{"recurrence_rule": ended.String(), "ends_on": endsOn(&ended, scheduledWorkout.Date)},
+		Following: &model.ScheduledWorkout{
+			ProfileID:         scheduledWorkout.ProfileID,
+			WorkoutID:         scheduledWorkout.WorkoutID,
+			Date:              start,
+			Notes:             scheduledWorkout.Notes,
+			RecurrenceRule:    &recurrenceRule,
+			EndsOn:            endsOn(shifted, start),
+			ProgramScheduleID: scheduledWorkout.ProgramScheduleID,
+		},
+	})
+	return nil
+}
+
+// nextOccurrence returns the first occurrence of the rule started at start on or after from. Rules
+// repeat at least every MaxInterval weeks, so looking a little further ahead than that is enough.
+func nextOccurrence(rule *recurrence.Rule, start, from time.Time) (time.Time, bool) {
+	occurrences := rule.Between(start, from, from.AddDate(0, 0, 7*(recurrence.MaxInterval+1)))
+	if len(occurrences) == 0 {
+		return time.Time{}, false
+	}
+	return occurrences[0], true
+}
+
+// moveOccurrence adds the change moving a scheduled workout or an occurrence of a series to a date,
+// where it is planned again. Occurrences are moved by their override, keeping what it already changes.
+func moveOccurrence(changes *model.ScheduleChanges, series *model.ScheduledWorkout, occurrence *model.ScheduledWorkout, date time.Time) {
+	if occurrence.OccurrenceDate == nil {
+		changes.Update(occurrence.ID, "date", date)
+		changes.Update(occurrence.ID, "status", model.ScheduledWorkoutPlanned)
+		return
+	}
+	changes.Overrides = append(changes.Overrides, occurrenceOverride(series, *occurrence.OccurrenceDate, func(override *model.ScheduledWorkoutOverride) {
+		override.Date = &date
+		override.Status = nil
+	}))
+}
+
+// markMissed adds the change marking a scheduled workout or an occurrence of a series missed
+func markMissed(changes *model.ScheduleChanges, series *model.ScheduledWorkout, occurrence *model.ScheduledWorkout) {
+	if occurrence.OccurrenceDate == nil {
+		changes.Update(occurrence.ID, "status", model.ScheduledWorkoutMissed)
+		return
+	}
+	changes.Overrides = append(changes.Overrides, occurrenceOverride(series, *occurrence.OccurrenceDate, func(override *model.ScheduledWorkoutOverride) {
+		status := model.ScheduledWorkoutMissed
+		override.Status = &status
+	}))
+}
+
+// occurrenceOverride returns a copy of the override of an occurrence, a new one when it has none, with a change applied
+func occurrenceOverride(series *model.ScheduledWorkout, occurrenceDate time.Time, change func(*model.ScheduledWorkoutOverride)) model.ScheduledWorkoutOverride {
+	override := model.ScheduledWorkoutOverride{ScheduledWorkoutID: series.ID, OccurrenceDate: occurrenceDate}
+	if existing := series.FindOverride(occurrenceDate); existing != nil {
+		override = *existing
+	}
+	change(&override)
+	return override
+}
+
+// HandleMissed applies the missed workout policy of every profile to the workouts of the past days
+// that weren't trained. It runs repeatedly: handled workouts are either marked missed or moved to
+// today or later, so they aren't handled again. Workouts of program schedules that roll forward
+// are left to the schedule. A failing profile is logged and skipped.
+func (uc *scheduledWorkoutUseCase) HandleMissed(ctx context.Context, now time.Time) error {
+	from := common.CalendarDate(now, time.UTC).AddDate(0, 0, -missedLookbackDays-1)
+	profileIDs, err := uc.repo.GetProfileIDsWithPlanned(ctx, from, common.CalendarDate(now, time.UTC))
+	if err != nil {
+		return err
+	}
+	for _, profileID := range profileIDs {
+		if err := uc.handleMissed(ctx, profileID, now); err != nil {
+			log.Printf("Failed to handle missed workouts of profile %s: %v", profileID, err)
+		}
+	}
+	return nil
+}
+
+func (uc *scheduledWorkoutUseCase) handleMissed(ctx context.Context, profileID string, now time.Time) error {
+	settings, err := uc.settingsRepo.GetByProfileID(ctx, profileID)
+	if err != nil {
+		if !errors.Is(err, customerrors.ErrEntityNotFound) {
+			return err
+		}
+		settings = &account.Setting{ProfileID: profileID}
+	}
+	location := settings.Location()
+	today := common.CalendarDate(now, location)
+	from := today.AddDate(0, 0, -missedLookbackDays)
+
+	occurrences, series, err := uc.occurrences(ctx, profileID, from, today.AddDate(0, 0, freeDayHorizonDays))
+	if err != nil {
+		return err
+	}
+	starts, err := uc.repo.GetWorkoutSessionStarts(ctx, profileID, common.StartOfDay(from.AddDate(0, 0, -1), time.UTC))
+	if err != nil {
+		return err
+	}
+	trained := trainedWorkouts(occurrences, starts, location)
+	var missed []*model.ScheduledWorkout
+	isMissed := make(map[*model.ScheduledWorkout]bool)
+	for i := range occurrences {
+		occurrence := &occurrences[i]
+		if occurrence.Date.Before(today) && occurrence.Status == model.ScheduledWorkoutPlanned && !trained[i] {
+			missed = append(missed, occurrence)
+			isMissed[occurrence] = true
+		}
+	}
+	if len(missed) == 0 {
+		return nil
+	}
+
+	changes := &model.ScheduleChanges{}
+	switch policy, _ := account.ParseMissedWorkoutPolicy(string(settings.MissedWorkoutPolicy)); policy {
+	case account.MissedWorkoutNextFreeDay:
+		taken := make(map[string]bool, len(occurrences))
+		for _, occurrence := range occurrences {
+			taken[occurrence.Date.Format(time.DateOnly)] = true
+		}
+		for _, occurrence := range missed {
+			date, ok := freeDay(taken, today, today.AddDate(0, 0, freeDayHorizonDays))
+			if !ok {
+				markMissed(changes, series[occurrence.ID], occurrence)
+				continue
+			}
+			taken[date.Format(time.DateOnly)] = true
+			moveOccurrence(changes, series[occurrence.ID], occurrence, date)
+		}
+	case account.MissedWorkoutShiftWeek:
+		days := int(today.Sub(missed[0].Date).Hours() / 24)
+		weekEnd := today.AddDate(0, 0, 6-(int(today.Weekday())-int(settings.WeekStartDay())+7)%7)
+		for i := range occurrences {
+			occurrence := &occurrences[i]
+			if occurrence.Date.After(weekEnd) || occurrence.Status != model.ScheduledWorkoutPlanned ||
+				(occurrence.Date.Before(today) && !isMissed[occurrence]) {
+				continue
+			}
+			moveOccurrence(changes, series[occurrence.ID], occurrence, occurrence.Date.AddDate(0, 0, days))
+		}
+	default:
+		for _, occurrence := range missed {
+			markMissed(changes, series[occurrence.ID], occurrence)
+		}
+	}
+	return uc.repo.ApplyChanges(ctx, changes)
+}
+
+// occurrences returns the occurrences of a profile in [from, to] ordered by date, leaving out
+// workouts of program schedules that roll forward, together with the series they belong to by ID
+func (uc *scheduledWorkoutUseCase) occurrences(ctx context.Context, profileID string, from, to time.Time) ([]model.ScheduledWorkout, map[string]*model.ScheduledWorkout, error) {
+	workouts, err := uc.repo.GetAllByProfileIDFrom(ctx, profileID, from)
+	if err != nil {
+		return nil, nil, err
+	}
+	rollingForward, err := uc.repo.GetRollingForwardScheduleIDs(ctx, profileID)
+	if err != nil {
+		return nil, nil, err
+	}
+	skip := make(map[string]bool, len(rollingForward))
+	for _, id := range rollingForward {
+		skip[id] = true
+	}
+	var occurrences []model.ScheduledWorkout
+	series := make(map[string]*model.ScheduledWorkout, len(workouts))
+	for i := range workouts {
+		workout := &workouts[i]
+		if workout.ProgramScheduleID != nil && skip[*workout.ProgramScheduleID] {
+			continue
+		}
+		series[workout.ID] = workout
+		occurrences = append(occurrences, workout.Expand(from, to)...)
+	}
+	sort.SliceStable(occurrences, func(i, j int) bool { return occurrences[i].Date.Before(occurrences[j].Date) })
+	return occurrences, series, nil
+}
+
+// freeDay returns the first date in [from, to] that has no scheduled workout
+func freeDay(taken map[string]bool, from, to time.Time) (time.Time, bool) {
+	for date := from; !date.After(to); date = date.AddDate(0, 0, 1) {
+		if !taken[date.Format(time.DateOnly)] {
+			return date, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// getOccurrenceSeries retrieves a series of the profile and checks that the rule produces an occurrence on the date
+func (uc *scheduledWorkoutUseCase) getOccurrenceSeries(ctx context.Context, profileID, scheduledWorkoutID string, occurrenceDate time.Time) (*model.ScheduledWorkout, error) {
+	scheduledWorkout, err := uc.GetByID(ctx, profileID, scheduledWorkoutID)
+	if err != nil {
+		return nil, err
+	}
+	rule, err := scheduledWorkout.Rule()
+	if err != nil {
+		return nil, err
+	}
+	if rule == nil {
+		return nil, customerrors.ErrNotRecurring
+	}
+	if !rule.Includes(scheduledWorkout.Date, occurrenceDate) {
+		return nil, customerrors.ErrNotAnOccurrence
+	}
+	return scheduledWorkout, nil
+}
+
+// endsOn returns the last occurrence of a series starting on the given date, nil when it repeats forever
+func endsOn(rule *recurrence.Rule, start time.Time) *time.Time {
+	last, ok := rule.Last(start)
+	if !ok {
+		return nil
+	}
+	return &last
 }
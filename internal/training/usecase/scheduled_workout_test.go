@@ -0,0 +1,306 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/VladimirKholomyanskyy/gym-api/internal/account"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/common"
+	customerrors "github.com/VladimirKholomyanskyy/gym-api/internal/customErrors"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/training/model"
+	"github.com/VladimirKholomyanskyy/gym-api/internal/training/repository"
+)
+
+type fakeScheduledWorkoutRepository struct {
+	repository.ScheduledWorkoutRepository
+	workouts       []model.ScheduledWorkout
+	rollingForward []string
+	starts         []model.WorkoutSessionStart
+	changes        *model.ScheduleChanges
+}
+
+func (r *fakeScheduledWorkoutRepository) GetByID(ctx context.Context, id string) (*model.ScheduledWorkout, error) {
+	for _, workout := range r.workouts {
+		if workout.ID == id {
+			return &workout, nil
+		}
+	}
+	return nil, customerrors.ErrEntityNotFound
+}
+
+func (r *fakeScheduledWorkoutRepository) GetAllByProfileIDFrom(ctx context.Context, profileID string, from time.Time) ([]model.ScheduledWorkout, error) {
+	return append([]model.ScheduledWorkout(nil), r.workouts...), nil
+}
+
+func (r *fakeScheduledWorkoutRepository) GetProfileIDsWithPlanned(ctx context.Context, from, to time.Time) ([]string, error) {
+	return []string{"profile"}, nil
+}
+
+func (r *fakeScheduledWorkoutRepository) GetRollingForwardScheduleIDs(ctx context.Context, profileID string) ([]string, error) {
+	return r.rollingForward, nil
+}
+
+func (r *fakeScheduledWorkoutRepository) GetWorkoutSessionStarts(ctx context.Context, profileID string, since time.Time) ([]model.WorkoutSessionStart, error) {
+	return r.starts, nil
+}
+
+func (r *fakeScheduledWorkoutRepository) ApplyChanges(ctx context.Context, changes *model.ScheduleChanges) error {
+	r.changes = changes
+	return nil
+}
+
+func single(id, workoutID, value string) model.ScheduledWorkout {
+	return model.ScheduledWorkout{Base: common.Base{ID: id}, ProfileID: "profile", WorkoutID: workoutID, Date: date(value), Status: model.ScheduledWorkoutPlanned}
+}
+
+func series(id, workoutID, value, rule string) model.ScheduledWorkout {
+	workout := single(id, workoutID, value)
+	workout.RecurrenceRule = &rule
+	return workout
+}
+
+// describe lists schedule changes as sorted lines, so tests compare them regardless of map order
+func describe(changes *model.ScheduleChanges) []string {
+	if changes == nil {
+		return nil
+	}
+	format := func(value any) string {
+		switch value := value.(type) {
+		case time.Time:
+			return value.Format(time.DateOnly)
+		case *time.Time:
+			if value == nil {
+				return "nil"
+			}
+			return value.Format(time.DateOnly)
+		case *model.ScheduledWorkoutStatus:
+			if value == nil {
+				return "nil"
+			}
+			return string(*value)
+		}
+		return fmt.Sprint(value)
+	}
+	var lines []string
+	for id, updates := range changes.Updates {
+		for column, value := range updates {
+			lines = append(lines, fmt.Sprintf("%s %s=%s", id, column, format(value)))
+		}
+	}
+	for _, override := range changes.Overrides {
+		lines = append(lines, fmt.Sprintf("%s@%s date=%s status=%s", override.ScheduledWorkoutID, format(override.OccurrenceDate), format(override.Date), format(override.Status)))
+	}
+	for _, shift := range changes.Shifts {
+		for column, value := range shift.Updates {
+			lines = append(lines, fmt.Sprintf("%s from %s by %d %s=%s", shift.ScheduledWorkoutID, format(shift.From), shift.Days, column, format(value)))
+		}
+		if shift.Following != nil {
+			lines = append(lines, fmt.Sprintf("%s following %s %s", shift.ScheduledWorkoutID, format(shift.Following.Date), *shift.Following.RecurrenceRule))
+		}
+	}
+	sort.Strings(lines)
+	return lines
+}
+
+func TestHandleMissed(t *testing.T) {
+	now := time.Date(2026, 10, 8, 12, 0, 0, 0, time.UTC) // a Thursday
+	scheduleID := "schedule"
+	rolling := single("rolling", "e", "2026-10-07")
+	rolling.ProgramScheduleID = &scheduleID
+	workouts := []model.ScheduledWorkout{
+		single("mon", "a", "2026-10-05"),
+		single("tue", "b", "2026-10-06"),
+		single("fri", "c", "2026-10-09"),
+		series("sat", "d", "2026-10-10", "FREQ=WEEKLY;BYDAY=SA"),
+		rolling,
+	}
+	trainedLate := []model.WorkoutSessionStart{started("a", "2026-10-06T18:00:00Z")}
+	tests := []struct {
+		name   string
+		policy account.MissedWorkoutPolicy
+		starts []model.WorkoutSessionStart
+		want   []string
+	}{
+		{
+			name:   "everything trained",
+			policy: account.MissedWorkoutMarkMissed,
+			starts: []model.WorkoutSessionStart{started("a", "2026-10-05T18:00:00Z"), started("b", "2026-10-07T07:00:00Z")},
+		},
+		{
+			name:   "mark missed",
+			policy: account.MissedWorkoutMarkMissed,
+			starts: trainedLate,
+			want:   []string{"tue status=missed"},
+		},
+		{
+			name:   "default policy marks missed",
+			starts: trainedLate,
+			want:   []string{"tue status=missed"},
+		},
+		{
+			name:   "next free day",
+			policy: account.MissedWorkoutNextFreeDay,
+			starts: trainedLate,
+			want:   []string{"tue date=2026-10-08", "tue status=planned"},
+		},
+		{
+			name:   "next free day skips taken days",
+			policy: account.MissedWorkoutNextFreeDay,
+			want: []string{
+				"mon date=2026-10-08", "mon status=planned",
+				"tue date=2026-10-11", "tue status=planned",
+			},
+		},
+		{
+			name:   "shift week",
+			policy: account.MissedWorkoutShiftWeek,
+			starts: trainedLate,
+			want: []string{
+				"fri date=2026-10-11", "fri status=planned",
+				"sat@2026-10-10 date=2026-10-12 status=nil",
+				"tue date=2026-10-08", "tue status=planned",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeScheduledWorkoutRepository{workouts: workouts, rollingForward: []string{scheduleID}, starts: tt.starts}
+			settings := &fakeSettingRepository{settings: map[string]*account.Setting{
+				"profile": {ProfileID: "profile", MissedWorkoutPolicy: tt.policy},
+			}}
+			uc := NewScheduledWorkoutUseCase(repo, settings, nil)
+			if err := uc.HandleMissed(context.Background(), now); err != nil {
+				t.Fatal(err)
+			}
+			if got := describe(repo.changes); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("changes = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMove(t *testing.T) {
+	workouts := []model.ScheduledWorkout{
+		single("mon", "a", "2026-10-05"),
+		single("wed", "b", "2026-10-07"),
+		series("weekly", "c", "2026-10-02", "FREQ=WEEKLY;BYDAY=FR"),
+		series("later", "d", "2026-10-10", "FREQ=DAILY;INTERVAL=7;COUNT=3"),
+	}
+	occurrence := date("2026-10-09")
+	tests := []struct {
+		name  string
+		input model.MoveScheduledWorkoutInput
+		want  []string
+	}{
+		{
+			name:  "single workout",
+			input: model.MoveScheduledWorkoutInput{ScheduledWorkoutID: "wed", Days: 2},
+			want:  []string{"wed date=2026-10-09", "wed status=planned"},
+		},
+		{
+			name:  "occurrence of a series",
+			input: model.MoveScheduledWorkoutInput{ScheduledWorkoutID: "weekly", OccurrenceDate: &occurrence, Days: -1},
+			want:  []string{"weekly@2026-10-09 date=2026-10-08 status=nil"},
+		},
+		{
+			name:  "single workout and the following ones",
+			input: model.MoveScheduledWorkoutInput{ScheduledWorkoutID: "wed", Days: 1, Following: true},
+			want: []string{
+				"later from 2026-10-10 by 1 date=2026-10-11",
+				"later from 2026-10-10 by 1 ends_on=2026-10-25",
+				"later from 2026-10-10 by 1 recurrence_rule=FREQ=DAILY;INTERVAL=7;WKST=TU;COUNT=3",
+				"wed date=2026-10-08",
+				"weekly following 2026-10-10 FREQ=WEEKLY;BYDAY=SA;WKST=TU",
+				"weekly from 2026-10-09 by 1 ends_on=2026-10-02",
+				"weekly from 2026-10-09 by 1 recurrence_rule=FREQ=WEEKLY;BYDAY=FR;UNTIL=20261008",
+			},
+		},
+		{
+			name:  "nothing to move",
+			input: model.MoveScheduledWorkoutInput{ScheduledWorkoutID: "wed"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeScheduledWorkoutRepository{workouts: workouts}
+			uc := NewScheduledWorkoutUseCase(repo, &fakeSettingRepository{}, nil)
+			tt.input.ProfileID = "profile"
+			if err := uc.Move(context.Background(), tt.input); err != nil {
+				t.Fatal(err)
+			}
+			if got := describe(repo.changes); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("changes = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("other profile", func(t *testing.T) {
+		uc := NewScheduledWorkoutUseCase(&fakeScheduledWorkoutRepository{workouts: workouts}, &fakeSettingRepository{}, nil)
+		err := uc.Move(context.Background(), model.MoveScheduledWorkoutInput{ProfileID: "other", ScheduledWorkoutID: "wed", Days: 1})
+		if err != customerrors.ErrAccessForbidden {
+			t.Errorf("Move() error = %v, want %v", err, customerrors.ErrAccessForbidden)
+		}
+	})
+}
+
+func TestShiftFollowing(t *testing.T) {
+	from := date("2026-10-07")
+	tests := []struct {
+		name    string
+		workout model.ScheduledWorkout
+		days    int
+		want    []string
+	}{
+		{
+			name:    "single workout before",
+			workout: single("single", "a", "2026-10-06"),
+			days:    2,
+		},
+		{
+			name:    "single workout on the date",
+			workout: single("single", "a", "2026-10-07"),
+			days:    2,
+			want:    []string{"single date=2026-10-09"},
+		},
+		{
+			name:    "series starting after",
+			workout: series("series", "a", "2026-10-08", "FREQ=WEEKLY;BYDAY=TH;COUNT=4"),
+			days:    -1,
+			want: []string{
+				"series from 2026-10-08 by -1 date=2026-10-07",
+				"series from 2026-10-08 by -1 ends_on=2026-10-28",
+				"series from 2026-10-08 by -1 recurrence_rule=FREQ=WEEKLY;BYDAY=WE;WKST=SU;COUNT=4",
+			},
+		},
+		{
+			name:    "series running through",
+			workout: series("series", "a", "2026-09-30", "FREQ=WEEKLY;BYDAY=WE;COUNT=4"),
+			days:    1,
+			want: []string{
+				"series following 2026-10-08 FREQ=WEEKLY;BYDAY=TH;WKST=TU;COUNT=3",
+				"series from 2026-10-07 by 1 ends_on=2026-09-30",
+				"series from 2026-10-07 by 1 recurrence_rule=FREQ=WEEKLY;BYDAY=WE;UNTIL=20261006",
+			},
+		},
+		{
+			name:    "series ended before",
+			workout: series("series", "a", "2026-09-16", "FREQ=WEEKLY;BYDAY=WE;COUNT=2"),
+			days:    1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes := &model.ScheduleChanges{}
+			if err := shiftFollowing(changes, &tt.workout, from, tt.days); err != nil {
+				t.Fatal(err)
+			}
+			if got := describe(changes); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("changes = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package usecase
+
+import (
+	"sort"
+	"time"
+
+	"github.com/VladimirKholomyanskyy/gym-api/internal/training/model"
+)
+
+// trainedWorkouts tells which scheduled workouts were trained: a scheduled workout is trained by a
+// session of its workout started on its date or later, in the time zone of the profile, so a
+// workout done a day late counts. Every session trains a single scheduled workout, the earliest
+// one it can, so one session doesn't mark every later date of the same workout trained. The
+// scheduled workouts are expected in date order, the result holds their indexes.
+func trainedWorkouts(scheduled []model.ScheduledWorkout, starts []model.WorkoutSessionStart, location *time.Location) map[int]bool {
+	sessionDates := make(map[string][]time.Time)
+	for _, start := range starts {
+		local := start.StartedAt.In(location)
+		sessionDates[start.WorkoutID] = append(sessionDates[start.WorkoutID], time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC))
+	}
+	for _, dates := range sessionDates {
+		sort.Slice(dates, func(i, j int) bool { return dates[i].Before(dates[j]) })
+	}
+	trained := make(map[int]bool)
+	for i, workout := range scheduled {
+		dates := sessionDates[workout.WorkoutID]
+		for j, date := range dates {
+			if !date.Before(workout.Date) {
+				trained[i] = true
+				sessionDates[workout.WorkoutID] = append(dates[:j:j], dates[j+1:]...)
+				break
+			}
+		}
+	}
+	return trained
+}
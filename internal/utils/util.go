@@ -32,6 +32,8 @@ func FormatTime(t *time.Time) string {
 	return t.Format("2006-01-02")
 }
 
+// ParseTime parses a YYYY-MM-DD calendar date as midnight UTC, the way DATE columns are read and
+// written. Dates bounding timestamps have to be parsed in the profile's time zone with common.ParseDate.
 func ParseTime(dateStr string) (time.Time, error) {
 	t, err := time.Parse("2006-01-02", dateStr)
 	if err != nil {
@@ -132,12 +134,19 @@ func ConvertWorkoutExercises(gormWorkoutExercise []model.WorkoutExercise) []open
 }
 
 func ConvertScheduledWorkout(gormScheduledWorkout *model.ScheduledWorkout) *openapi.ScheduledWorkout {
-	return &openapi.ScheduledWorkout{
-		Id:        gormScheduledWorkout.ID,
-		WorkoutId: gormScheduledWorkout.WorkoutID,
-		Date:      gormScheduledWorkout.Date.Format("2006-01-02"),
-		Notes:     gormScheduledWorkout.Notes,
+	scheduledWorkout := &openapi.ScheduledWorkout{
+		Id:             gormScheduledWorkout.ID,
+		WorkoutId:      gormScheduledWorkout.WorkoutID,
+		Date:           gormScheduledWorkout.Date.Format("2006-01-02"),
+		Notes:          gormScheduledWorkout.Notes,
+		RecurrenceRule: gormScheduledWorkout.RecurrenceRule,
+		Status:         string(gormScheduledWorkout.Status),
 	}
+	if gormScheduledWorkout.OccurrenceDate != nil {
+		occurrenceDate := gormScheduledWorkout.OccurrenceDate.Format("2006-01-02")
+		scheduledWorkout.OccurrenceDate = &occurrenceDate
+	}
+	return scheduledWorkout
 }
 
 func ConvertScheduledWorkouts(gormScheduledWorkouts []model.ScheduledWorkout) []openapi.ScheduledWorkout {
@@ -148,6 +157,18 @@ func ConvertScheduledWorkouts(gormScheduledWorkouts []model.ScheduledWorkout) []
 	return apiScheduledWorkouts
 }
 
+func ConvertProgramSchedule(gormProgramSchedule *model.ProgramSchedule) *openapi.ProgramSchedule {
+	return &openapi.ProgramSchedule{
+		Id:                gormProgramSchedule.ID,
+		TrainingProgramId: gormProgramSchedule.TrainingProgramID,
+		StartDate:         gormProgramSchedule.StartDate.Format("2006-01-02"),
+		Weekdays:          gormProgramSchedule.Weekdays,
+		Weeks:             int32(gormProgramSchedule.Weeks),
+		RollForward:       gormProgramSchedule.RollForward,
+		ScheduledWorkouts: ConvertScheduledWorkouts(gormProgramSchedule.ScheduledWorkouts),
+	}
+}
+
 func ConverWorkoutSnapshot(workoutJson *datatypes.JSON) (*openapi.WorkoutSessionWorkoutSnapshot, error) {
 	var workout model.Workout
 	err := json.Unmarshal(*workoutJson, &workout)
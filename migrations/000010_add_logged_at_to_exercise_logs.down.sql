@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_exercise_logs_session_id;
+ALTER TABLE exercise_logs DROP COLUMN IF EXISTS logged_at;
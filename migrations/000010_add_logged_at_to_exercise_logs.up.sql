@@ -0,0 +1,6 @@
+ALTER TABLE exercise_logs ADD COLUMN logged_at TIMESTAMP WITH TIME ZONE;
+UPDATE exercise_logs SET logged_at = created_at WHERE logged_at IS NULL;
+ALTER TABLE exercise_logs ALTER COLUMN logged_at SET NOT NULL;
+ALTER TABLE exercise_logs ALTER COLUMN logged_at SET DEFAULT NOW();
+
+CREATE INDEX idx_exercise_logs_session_id ON exercise_logs(session_id);
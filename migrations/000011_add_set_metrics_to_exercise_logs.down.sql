@@ -0,0 +1,8 @@
+ALTER TABLE exercise_logs
+    DROP COLUMN IF EXISTS rpe,
+    DROP COLUMN IF EXISTS rir,
+    DROP COLUMN IF EXISTS rest_seconds,
+    DROP COLUMN IF EXISTS tempo,
+    DROP COLUMN IF EXISTS to_failure,
+    DROP COLUMN IF EXISTS partial_reps,
+    DROP COLUMN IF EXISTS note;
@@ -0,0 +1,8 @@
+ALTER TABLE exercise_logs
+    ADD COLUMN rpe DECIMAL(3, 1) CHECK (rpe >= 1 AND rpe <= 10),
+    ADD COLUMN rir INT CHECK (rir >= 0 AND rir <= 10),
+    ADD COLUMN rest_seconds INT CHECK (rest_seconds >= 0),
+    ADD COLUMN tempo VARCHAR(7),
+    ADD COLUMN to_failure BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN partial_reps INT NOT NULL DEFAULT 0 CHECK (partial_reps >= 0),
+    ADD COLUMN note TEXT;
@@ -0,0 +1,19 @@
+CREATE TABLE personal_records (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    profile_id UUID NOT NULL REFERENCES profiles(id) ON DELETE CASCADE,
+    exercise_id UUID NOT NULL REFERENCES exercises(id),
+    exercise_log_id UUID NOT NULL REFERENCES exercise_logs(id) ON DELETE CASCADE,
+    session_id UUID NOT NULL REFERENCES workout_sessions(id) ON DELETE CASCADE,
+    type VARCHAR(32) NOT NULL CHECK (type IN ('heaviest_weight', 'reps_at_weight', 'estimated_1rm', 'session_volume')),
+    value DECIMAL(10, 2) NOT NULL,
+    previous_value DECIMAL(10, 2),
+    weight DECIMAL(5, 2) NOT NULL,
+    reps INT NOT NULL,
+    achieved_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_personal_records_profile_exercise ON personal_records (profile_id, exercise_id, achieved_at DESC);
+CREATE INDEX idx_personal_records_profile_achieved_at ON personal_records (profile_id, achieved_at DESC);
@@ -0,0 +1,3 @@
+ALTER TABLE settings
+    ADD COLUMN week_start VARCHAR(10) NOT NULL DEFAULT 'monday'
+        CHECK (week_start IN ('monday', 'tuesday', 'wednesday', 'thursday', 'friday', 'saturday', 'sunday'));
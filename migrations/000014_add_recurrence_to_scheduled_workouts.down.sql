@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS scheduled_workout_overrides;
+DROP INDEX IF EXISTS idx_scheduled_workouts_profile_recurring;
+ALTER TABLE scheduled_workouts
+    DROP COLUMN IF EXISTS ends_on,
+    DROP COLUMN IF EXISTS recurrence_rule;
@@ -0,0 +1,18 @@
+ALTER TABLE scheduled_workouts
+    ADD COLUMN recurrence_rule TEXT,
+    ADD COLUMN ends_on DATE;
+
+CREATE TABLE scheduled_workout_overrides (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    scheduled_workout_id UUID NOT NULL REFERENCES scheduled_workouts(id) ON DELETE CASCADE,
+    occurrence_date DATE NOT NULL,
+    date DATE,
+    skipped BOOLEAN NOT NULL DEFAULT FALSE,
+    notes TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    UNIQUE (scheduled_workout_id, occurrence_date)
+);
+
+CREATE INDEX idx_scheduled_workouts_profile_recurring ON scheduled_workouts (profile_id, date) WHERE recurrence_rule IS NOT NULL;
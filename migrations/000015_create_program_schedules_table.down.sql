@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_scheduled_workouts_program_schedule;
+ALTER TABLE scheduled_workouts DROP COLUMN IF EXISTS program_schedule_id;
+DROP TABLE IF EXISTS program_schedules;
@@ -0,0 +1,19 @@
+CREATE TABLE program_schedules (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    profile_id UUID NOT NULL REFERENCES profiles(id) ON DELETE CASCADE,
+    training_program_id UUID NOT NULL REFERENCES training_programs(id) ON DELETE CASCADE,
+    start_date DATE NOT NULL,
+    weekdays TEXT[] NOT NULL,
+    weeks INT NOT NULL CHECK (weeks BETWEEN 1 AND 52),
+    roll_forward BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_program_schedules_roll_forward ON program_schedules (roll_forward) WHERE roll_forward;
+
+ALTER TABLE scheduled_workouts
+    ADD COLUMN program_schedule_id UUID REFERENCES program_schedules(id) ON DELETE CASCADE;
+
+CREATE INDEX idx_scheduled_workouts_program_schedule ON scheduled_workouts (program_schedule_id, date);
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_notifications_due;
+DROP TABLE IF EXISTS notifications;
+DROP TABLE IF EXISTS notification_channels;
+ALTER TABLE settings
+    DROP COLUMN IF EXISTS quiet_hours_end,
+    DROP COLUMN IF EXISTS quiet_hours_start,
+    DROP COLUMN IF EXISTS reminder_time;
@@ -0,0 +1,3 @@
+ALTER TABLE settings DROP COLUMN IF EXISTS missed_workout_policy;
+ALTER TABLE scheduled_workout_overrides DROP COLUMN IF EXISTS status;
+ALTER TABLE scheduled_workouts DROP COLUMN IF EXISTS status;
@@ -0,0 +1,9 @@
+ALTER TABLE scheduled_workouts
+    ADD COLUMN status VARCHAR(16) NOT NULL DEFAULT 'planned' CHECK (status IN ('planned', 'missed'));
+
+ALTER TABLE scheduled_workout_overrides
+    ADD COLUMN status VARCHAR(16) CHECK (status IN ('planned', 'missed'));
+
+ALTER TABLE settings
+    ADD COLUMN missed_workout_policy VARCHAR(16) NOT NULL DEFAULT 'mark_missed'
+        CHECK (missed_workout_policy IN ('mark_missed', 'next_free_day', 'shift_week'));
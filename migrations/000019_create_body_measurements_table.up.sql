@@ -0,0 +1,19 @@
+CREATE TABLE body_measurements (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    profile_id UUID NOT NULL REFERENCES profiles(id) ON DELETE CASCADE,
+    type VARCHAR(16) NOT NULL CHECK (type IN ('bodyweight', 'body_fat', 'neck', 'shoulders', 'chest', 'waist', 'hips', 'arm', 'forearm', 'thigh', 'calf')),
+    value DECIMAL(6, 2) NOT NULL CHECK (value > 0), -- kg for bodyweight, percent for body fat, cm otherwise
+    measured_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    notes TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_body_measurements_profile_type_measured_at ON body_measurements (profile_id, type, measured_at);
+
+-- The weight stored on profiles so far is the first point of their bodyweight history
+INSERT INTO body_measurements (profile_id, type, value, measured_at, created_at, updated_at)
+SELECT id, 'bodyweight', weight, updated_at, NOW(), NOW()
+FROM profiles
+WHERE weight IS NOT NULL;
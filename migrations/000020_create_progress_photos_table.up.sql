@@ -0,0 +1,18 @@
+CREATE TABLE progress_photos (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    profile_id UUID NOT NULL REFERENCES profiles(id) ON DELETE CASCADE,
+    taken_on DATE NOT NULL,
+    pose VARCHAR(16) NOT NULL CHECK (pose IN ('front', 'back', 'left', 'right', 'other')),
+    note TEXT,
+    content_type VARCHAR(32) NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    width INTEGER NOT NULL,
+    height INTEGER NOT NULL,
+    storage_key TEXT NOT NULL UNIQUE,
+    thumbnail_key TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_progress_photos_profile_taken_on ON progress_photos (profile_id, taken_on);
@@ -0,0 +1 @@
+ALTER TABLE profiles ALTER COLUMN height TYPE DECIMAL(5, 2);
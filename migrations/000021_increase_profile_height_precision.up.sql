@@ -0,0 +1,2 @@
+-- Heights entered in inches need sub-centimeter precision to come back as entered
+ALTER TABLE profiles ALTER COLUMN height TYPE DECIMAL(5, 4);
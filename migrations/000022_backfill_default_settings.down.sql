@@ -0,0 +1,2 @@
+-- The backfilled settings can't be told apart from settings created since, they are kept
+SELECT 1;
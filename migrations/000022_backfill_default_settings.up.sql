@@ -0,0 +1,5 @@
+-- Profiles created before settings were provisioned with them get the default settings
+INSERT INTO settings (profile_id, language, measurement_units, timezone, notifications_enabled, created_at, updated_at)
+SELECT p.id, 'en', 'metric', 'UTC', TRUE, NOW(), NOW()
+FROM profiles p
+WHERE NOT EXISTS (SELECT 1 FROM settings s WHERE s.profile_id = p.id);
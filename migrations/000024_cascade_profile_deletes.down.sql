@@ -0,0 +1,17 @@
+DROP INDEX IF EXISTS idx_profiles_deleted_at_pending;
+
+ALTER TABLE exercise_logs
+    DROP CONSTRAINT IF EXISTS exercise_logs_profile_id_fkey,
+    ADD CONSTRAINT exercise_logs_profile_id_fkey FOREIGN KEY (profile_id) REFERENCES profiles(id);
+
+ALTER TABLE workout_sessions
+    DROP CONSTRAINT IF EXISTS workout_sessions_profile_id_fkey,
+    ADD CONSTRAINT workout_sessions_profile_id_fkey FOREIGN KEY (profile_id) REFERENCES profiles(id);
+
+ALTER TABLE workouts
+    DROP CONSTRAINT IF EXISTS workouts_training_program_id_fkey,
+    ADD CONSTRAINT workouts_training_program_id_fkey FOREIGN KEY (training_program_id) REFERENCES training_programs(id);
+
+ALTER TABLE training_programs
+    DROP CONSTRAINT IF EXISTS training_programs_profile_id_fkey,
+    ADD CONSTRAINT training_programs_profile_id_fkey FOREIGN KEY (profile_id) REFERENCES profiles(id);
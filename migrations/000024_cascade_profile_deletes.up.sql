@@ -0,0 +1,18 @@
+-- Everything a profile owns goes with it when the profile is purged
+ALTER TABLE training_programs
+    DROP CONSTRAINT IF EXISTS training_programs_profile_id_fkey,
+    ADD CONSTRAINT training_programs_profile_id_fkey FOREIGN KEY (profile_id) REFERENCES profiles(id) ON DELETE CASCADE;
+
+ALTER TABLE workouts
+    DROP CONSTRAINT IF EXISTS workouts_training_program_id_fkey,
+    ADD CONSTRAINT workouts_training_program_id_fkey FOREIGN KEY (training_program_id) REFERENCES training_programs(id) ON DELETE CASCADE;
+
+ALTER TABLE workout_sessions
+    DROP CONSTRAINT IF EXISTS workout_sessions_profile_id_fkey,
+    ADD CONSTRAINT workout_sessions_profile_id_fkey FOREIGN KEY (profile_id) REFERENCES profiles(id) ON DELETE CASCADE;
+
+ALTER TABLE exercise_logs
+    DROP CONSTRAINT IF EXISTS exercise_logs_profile_id_fkey,
+    ADD CONSTRAINT exercise_logs_profile_id_fkey FOREIGN KEY (profile_id) REFERENCES profiles(id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_profiles_deleted_at_pending ON profiles (deleted_at) WHERE deleted_at IS NOT NULL;
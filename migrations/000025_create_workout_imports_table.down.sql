@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_workout_sessions_profile_import_key;
+
+ALTER TABLE workout_sessions DROP COLUMN IF EXISTS import_key;
+
+DROP TABLE IF EXISTS workout_imports;
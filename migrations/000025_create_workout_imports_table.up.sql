@@ -0,0 +1,23 @@
+CREATE TABLE workout_imports (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    profile_id UUID NOT NULL REFERENCES profiles(id) ON DELETE CASCADE,
+    source VARCHAR(16) NOT NULL CHECK (source IN ('strong', 'hevy', 'fitnotes')),
+    status VARCHAR(16) NOT NULL DEFAULT 'pending_review' CHECK (status IN ('pending_review', 'completed')),
+    sets JSONB NOT NULL,
+    mappings JSONB NOT NULL DEFAULT '{}',
+    skipped_rows INT NOT NULL DEFAULT 0,
+    sessions_imported INT NOT NULL DEFAULT 0,
+    sets_imported INT NOT NULL DEFAULT 0,
+    sessions_skipped INT NOT NULL DEFAULT 0,
+    completed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_workout_imports_profile_status ON workout_imports (profile_id, status);
+
+-- Imported sessions are keyed by their source and start, so uploading an export again skips them
+ALTER TABLE workout_sessions ADD COLUMN import_key TEXT;
+
+CREATE UNIQUE INDEX idx_workout_sessions_profile_import_key ON workout_sessions (profile_id, import_key) WHERE import_key IS NOT NULL;
@@ -0,0 +1 @@
+ALTER TABLE program_schedules DROP COLUMN IF EXISTS roll_forwards;
@@ -0,0 +1,2 @@
+-- Counts the roll-forwards since a workout of the schedule was last trained
+ALTER TABLE program_schedules ADD COLUMN roll_forwards INT NOT NULL DEFAULT 0;
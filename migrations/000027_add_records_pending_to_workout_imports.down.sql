@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_workout_imports_records_pending;
+
+ALTER TABLE workout_imports DROP COLUMN IF EXISTS records_pending;
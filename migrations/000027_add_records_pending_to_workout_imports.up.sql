@@ -0,0 +1,4 @@
+-- Exercises of a committed import whose personal records still have to be recomputed
+ALTER TABLE workout_imports ADD COLUMN records_pending TEXT[] NOT NULL DEFAULT '{}';
+
+CREATE INDEX idx_workout_imports_records_pending ON workout_imports (id) WHERE records_pending <> '{}';
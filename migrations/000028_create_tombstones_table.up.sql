@@ -0,0 +1,7 @@
+-- IDs of deleted workout sessions and exercise logs, so an offline client syncing them again gets a
+-- conflict instead of bringing them back
+CREATE TABLE tombstones (
+    id UUID PRIMARY KEY,
+    profile_id UUID NOT NULL REFERENCES profiles(id) ON DELETE CASCADE,
+    deleted_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
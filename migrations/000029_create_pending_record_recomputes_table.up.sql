@@ -0,0 +1,7 @@
+-- Exercises whose personal records failed to recompute after a log change, retried in the background
+CREATE TABLE pending_record_recomputes (
+    profile_id UUID NOT NULL REFERENCES profiles(id) ON DELETE CASCADE,
+    exercise_id UUID NOT NULL REFERENCES exercises(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (profile_id, exercise_id)
+);